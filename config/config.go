@@ -1,5 +1,8 @@
-// Package config collects together all configuration settings
-// NOTE: Subject to change, do not rely on this package from outside git-lfs source
+// Package config collects together all configuration settings of Git LFS in a
+// repository, from its Git configuration and the environment.
+//
+// New and NewIn, and the Configuration they return, are part of the stable Go
+// API of Git LFS described in docs/library.md.
 package config
 
 import (
@@ -27,6 +30,8 @@ var (
 	gitConfigWarningPrefix = "lfs."
 )
 
+// Configuration gives access to the settings of Git LFS in a repository, from
+// Git configuration and the environment, and to its directories.
 type Configuration struct {
 	// Os provides a `*Environment` used to access to the system's
 	// environment through os.Getenv. It is the point of entry for all
@@ -59,10 +64,13 @@ type Configuration struct {
 	timestamp  time.Time
 }
 
+// New returns the configuration of the repository in the current directory.
 func New() *Configuration {
 	return NewIn("", "")
 }
 
+// NewIn returns the configuration of the repository with the given working
+// and Git directories.
 func NewIn(workdir, gitdir string) *Configuration {
 	gitConf := git.NewConfig(workdir, gitdir)
 	c := &Configuration{
@@ -191,16 +199,19 @@ func (c *Configuration) TusTransfersAllowed() bool {
 	return c.Git.Bool("lfs.tustransfers", false)
 }
 
+// FetchIncludePaths returns the patterns of lfs.fetchinclude.
 func (c *Configuration) FetchIncludePaths() []string {
 	patterns, _ := c.Git.Get("lfs.fetchinclude")
 	return tools.CleanPaths(patterns, ",")
 }
 
+// FetchExcludePaths returns the patterns of lfs.fetchexclude.
 func (c *Configuration) FetchExcludePaths() []string {
 	patterns, _ := c.Git.Get("lfs.fetchexclude")
 	return tools.CleanPaths(patterns, ",")
 }
 
+// CurrentRef returns the ref which is checked out.
 func (c *Configuration) CurrentRef() *git.Ref {
 	c.loading.Lock()
 	defer c.loading.Unlock()
@@ -216,14 +227,17 @@ func (c *Configuration) CurrentRef() *git.Ref {
 	return c.ref
 }
 
+// IsDefaultRemote returns whether the remote is "origin".
 func (c *Configuration) IsDefaultRemote() bool {
 	return c.Remote() == defaultRemote
 }
 
+// AutoDetectRemoteEnabled returns the value of lfs.remote.autodetect.
 func (c *Configuration) AutoDetectRemoteEnabled() bool {
 	return c.Git.Bool("lfs.remote.autodetect", false)
 }
 
+// SearchAllRemotesEnabled returns the value of lfs.remote.searchall.
 func (c *Configuration) SearchAllRemotesEnabled() bool {
 	return c.Git.Bool("lfs.remote.searchall", false)
 }
@@ -258,6 +272,11 @@ func (c *Configuration) Remote() string {
 	return *c.currentRemote
 }
 
+// PushRemote returns the remote to push to, based on:
+// 1. The value of branch.<name>.pushRemote for the current branch.
+// 2. The value of remote.lfspushdefault.
+// 3. The value of remote.pushDefault.
+// 4. The remote returned by Remote.
 func (c *Configuration) PushRemote() string {
 	ref := c.CurrentRef()
 	c.loading.Lock()
@@ -282,6 +301,8 @@ func (c *Configuration) PushRemote() string {
 	return *c.pushRemote
 }
 
+// SetValidRemote sets the remote to "name", which may be a remote's name, a
+// URL, or a local path, returning an error if it is none of those.
 func (c *Configuration) SetValidRemote(name string) error {
 	if err := git.ValidateRemote(name); err != nil {
 		name := git.RewriteLocalPathAsURL(name)
@@ -293,6 +314,7 @@ func (c *Configuration) SetValidRemote(name string) error {
 	return nil
 }
 
+// SetValidPushRemote is like SetValidRemote, but sets the remote to push to.
 func (c *Configuration) SetValidPushRemote(name string) error {
 	if err := git.ValidateRemote(name); err != nil {
 		name := git.RewriteLocalPathAsURL(name)
@@ -304,19 +326,24 @@ func (c *Configuration) SetValidPushRemote(name string) error {
 	return nil
 }
 
+// SetRemote sets the remote to "name", without validating it.
 func (c *Configuration) SetRemote(name string) {
 	c.currentRemote = &name
 }
 
+// SetPushRemote sets the remote to push to to "name", without validating it.
 func (c *Configuration) SetPushRemote(name string) {
 	c.pushRemote = &name
 }
 
+// Remotes returns the names of the repository's remotes.
 func (c *Configuration) Remotes() []string {
 	c.loadGitConfig()
 	return c.remotes
 }
 
+// Extensions returns the configured lfs.extension.<name> extensions, keyed by
+// name.
 func (c *Configuration) Extensions() map[string]Extension {
 	c.loadGitConfig()
 	return c.extensions
@@ -327,14 +354,20 @@ func (c *Configuration) SortedExtensions() ([]Extension, error) {
 	return SortExtensions(c.Extensions())
 }
 
+// SkipDownloadErrors returns whether objects which fail to download should be
+// left as pointers, rather than failing the checkout.
 func (c *Configuration) SkipDownloadErrors() bool {
 	return c.Os.Bool("GIT_LFS_SKIP_DOWNLOAD_ERRORS", false) || c.Git.Bool("lfs.skipdownloaderrors", false)
 }
 
+// SetLockableFilesReadOnly returns whether lockable files which aren't locked
+// should be made read-only.
 func (c *Configuration) SetLockableFilesReadOnly() bool {
 	return c.Os.Bool("GIT_LFS_SET_LOCKABLE_READONLY", true) && c.Git.Bool("lfs.setlockablereadonly", true)
 }
 
+// ForceProgress returns whether progress should be shown even when standard
+// error is not a terminal.
 func (c *Configuration) ForceProgress() bool {
 	return c.Os.Bool("GIT_LFS_FORCE_PROGRESS", false) || c.Git.Bool("lfs.forceprogress", false)
 }
@@ -359,15 +392,18 @@ func (c *Configuration) HookDir() (string, error) {
 	return filepath.Join(c.LocalGitStorageDir(), "hooks"), nil
 }
 
+// InRepo returns whether the configuration is of a Git repository.
 func (c *Configuration) InRepo() bool {
 	return len(c.LocalGitDir()) > 0
 }
 
+// LocalWorkingDir returns the repository's working tree, if it has one.
 func (c *Configuration) LocalWorkingDir() string {
 	c.loadGitDirs()
 	return c.workDir
 }
 
+// LocalGitDir returns the repository's Git directory.
 func (c *Configuration) LocalGitDir() string {
 	c.loadGitDirs()
 	return *c.gitDir
@@ -396,38 +432,55 @@ func (c *Configuration) loadGitDirs() {
 	c.workDir = tools.ResolveSymlinks(workdir)
 }
 
+// LocalGitStorageDir returns the Git directory which holds the repository's
+// objects, which differs from LocalGitDir in a linked worktree.
 func (c *Configuration) LocalGitStorageDir() string {
 	return c.Filesystem().GitStorageDir
 }
 
+// LocalReferenceDirs returns the object directories of the repositories which
+// this one borrows objects from.
 func (c *Configuration) LocalReferenceDirs() []string {
 	return c.Filesystem().ReferenceDirs
 }
 
+// LFSStorageDir returns the directory of Git LFS's objects and other files.
 func (c *Configuration) LFSStorageDir() string {
 	return c.Filesystem().LFSStorageDir
 }
 
+// LFSObjectDir returns the local object directory.
 func (c *Configuration) LFSObjectDir() string {
 	return c.Filesystem().LFSObjectDir()
 }
 
+// LFSObjectExists returns whether the object "oid" is stored locally with the
+// given size.
 func (c *Configuration) LFSObjectExists(oid string, size int64) bool {
-	return c.Filesystem().ObjectExists(oid, size)
+	store, err := c.Filesystem().Store()
+	if err != nil {
+		return false
+	}
+	return store.Exists(oid, size)
 }
 
+// EachLFSObject calls "fn" with each object in the local object directory.
 func (c *Configuration) EachLFSObject(fn func(fs.Object) error) error {
 	return c.Filesystem().EachObject(fn)
 }
 
+// LocalLogDir returns the directory of Git LFS's error logs.
 func (c *Configuration) LocalLogDir() string {
 	return c.Filesystem().LogDir()
 }
 
+// TempDir returns the directory of Git LFS's temporary files.
 func (c *Configuration) TempDir() string {
 	return c.Filesystem().TempDir()
 }
 
+// Filesystem returns the directories in which the repository's objects and
+// other files are stored.
 func (c *Configuration) Filesystem() *fs.Filesystem {
 	c.loadGitDirs()
 	c.loading.Lock()
@@ -442,11 +495,13 @@ func (c *Configuration) Filesystem() *fs.Filesystem {
 			lfsdir,
 			c.RepositoryPermissions(false),
 		)
+		c.fs.StoreBackend, _ = c.Git.Get("lfs.storebackend")
 	}
 
 	return c.fs
 }
 
+// Cleanup removes temporary files which are no longer needed.
 func (c *Configuration) Cleanup() error {
 	if c == nil {
 		return nil
@@ -456,66 +511,86 @@ func (c *Configuration) Cleanup() error {
 	return c.fs.Cleanup()
 }
 
+// OSEnv returns the environment variables.
 func (c *Configuration) OSEnv() Environment {
 	return c.Os
 }
 
+// GitEnv returns the Git configuration.
 func (c *Configuration) GitEnv() Environment {
 	return c.Git
 }
 
+// GitConfig returns the Git configuration, with which it may be changed.
 func (c *Configuration) GitConfig() *git.Configuration {
 	return c.gitConfig
 }
 
+// FindGitGlobalKey returns the value of "key" in the global Git configuration.
 func (c *Configuration) FindGitGlobalKey(key string) string {
 	return c.gitConfig.FindGlobal(key)
 }
 
+// FindGitSystemKey returns the value of "key" in the system Git configuration.
 func (c *Configuration) FindGitSystemKey(key string) string {
 	return c.gitConfig.FindSystem(key)
 }
 
+// FindGitLocalKey returns the value of "key" in the local Git configuration.
 func (c *Configuration) FindGitLocalKey(key string) string {
 	return c.gitConfig.FindLocal(key)
 }
 
+// FindGitWorktreeKey returns the value of "key" in the worktree Git configuration.
 func (c *Configuration) FindGitWorktreeKey(key string) string {
 	return c.gitConfig.FindWorktree(key)
 }
 
+// SetGitGlobalKey sets "key" to "val" in the global Git configuration.
 func (c *Configuration) SetGitGlobalKey(key, val string) (string, error) {
 	return c.gitConfig.SetGlobal(key, val)
 }
 
+// SetGitSystemKey sets "key" to "val" in the system Git configuration.
 func (c *Configuration) SetGitSystemKey(key, val string) (string, error) {
 	return c.gitConfig.SetSystem(key, val)
 }
 
+// SetGitLocalKey sets "key" to "val" in the local Git configuration.
 func (c *Configuration) SetGitLocalKey(key, val string) (string, error) {
 	return c.gitConfig.SetLocal(key, val)
 }
 
+// SetGitWorktreeKey sets "key" to "val" in the worktree Git configuration.
 func (c *Configuration) SetGitWorktreeKey(key, val string) (string, error) {
 	return c.gitConfig.SetWorktree(key, val)
 }
 
+// UnsetGitGlobalSection removes the section "key" from the global Git
+// configuration.
 func (c *Configuration) UnsetGitGlobalSection(key string) (string, error) {
 	return c.gitConfig.UnsetGlobalSection(key)
 }
 
+// UnsetGitSystemSection removes the section "key" from the system Git
+// configuration.
 func (c *Configuration) UnsetGitSystemSection(key string) (string, error) {
 	return c.gitConfig.UnsetSystemSection(key)
 }
 
+// UnsetGitLocalSection removes the section "key" from the local Git
+// configuration.
 func (c *Configuration) UnsetGitLocalSection(key string) (string, error) {
 	return c.gitConfig.UnsetLocalSection(key)
 }
 
+// UnsetGitWorktreeSection removes the section "key" from the worktree Git
+// configuration.
 func (c *Configuration) UnsetGitWorktreeSection(key string) (string, error) {
 	return c.gitConfig.UnsetWorktreeSection(key)
 }
 
+// UnsetGitLocalKey removes "key" from the local Git configuration.
 func (c *Configuration) UnsetGitLocalKey(key string) (string, error) {
 	return c.gitConfig.UnsetLocalKey(key)
 }
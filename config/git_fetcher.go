@@ -11,6 +11,7 @@ import (
 	"github.com/git-lfs/git-lfs/v3/tr"
 )
 
+// GitFetcher is a Fetcher of Git configuration values.
 type GitFetcher struct {
 	vmu  sync.RWMutex
 	vals map[string][]string
@@ -88,6 +89,9 @@ func readGitConfig(configs ...*git.ConfigurationSource) (gf *GitFetcher, extensi
 				allowed = true
 				remote := strings.Join(parts[1:len(parts)-1], ".")
 				uniqRemotes[remote] = remote == "origin"
+			} else if len(parts) > 3 && parts[0] == "lfs" && parts[1] == "route" {
+				// prop: lfs.route.<path>.<prop>
+				allowed = true
 			} else if len(parts) > 2 && parts[len(parts)-1] == "access" {
 				allowed = true
 			}
@@ -131,6 +135,7 @@ func (g *GitFetcher) Get(key string) (val string, ok bool) {
 	return all[len(all)-1], true
 }
 
+// GetAll is like Get, but returns every value of the key.
 func (g *GitFetcher) GetAll(key string) []string {
 	g.vmu.RLock()
 	defer g.vmu.RUnlock()
@@ -138,6 +143,7 @@ func (g *GitFetcher) GetAll(key string) []string {
 	return g.vals[g.caseFoldKey(key)]
 }
 
+// All returns every key and its values.
 func (g *GitFetcher) All() map[string][]string {
 	newmap := make(map[string][]string)
 
@@ -189,6 +195,7 @@ var safeKeys = []string{
 	"lfs.fetchinclude",
 	"lfs.gitprotocol",
 	"lfs.locksverify",
+	"lfs.mirror",
 	"lfs.pushurl",
 	"lfs.skipdownloaderrors",
 	"lfs.url",
@@ -0,0 +1,66 @@
+package config
+
+import "strings"
+
+// legacySections are the sections in which Git LFS read its options before it
+// was given its current name, and in which git-media read them before that.
+// Their options are now read from the "lfs" section.
+var legacySections = []string{"hawser", "media"}
+
+// legacyRemoteKeys maps keys in a "remote.<name>" section which named a
+// remote's LFS endpoint under those earlier names to the key now read.
+var legacyRemoteKeys = map[string]string{
+	"hawser": "lfsurl",
+	"media":  "lfsurl",
+}
+
+// obsoleteKeys are keys which Git LFS once read, but which no longer have any
+// effect and have no replacement.
+var obsoleteKeys = map[string]bool{
+	// The batch API is always used.
+	"lfs.batch": true,
+}
+
+// MigrateKey returns the key which replaces the configuration key "key", if it
+// is one which an earlier version of Git LFS, or git-media, read. If "key" is
+// obsolete and has no replacement, the returned key is empty. Keys are
+// compared as Git compares them, ignoring the case of the section and name,
+// but not of any subsection.
+func MigrateKey(key string) (string, bool) {
+	section, rest := key, ""
+	if i := strings.Index(key, "."); i >= 0 {
+		section, rest = key[:i], key[i:]
+	}
+	section = strings.ToLower(section)
+
+	lastDot := strings.LastIndex(key, ".")
+	if lastDot < 0 {
+		return "", false
+	}
+	name := strings.ToLower(key[lastDot+1:])
+	hasSubsection := lastDot > len(section)
+
+	var legacy bool
+	for _, s := range legacySections {
+		if section == s {
+			section, legacy = "lfs", true
+			break
+		}
+	}
+
+	// A legacy key may have been replaced by one which is itself now
+	// obsolete.
+	if obsoleteKeys[section+"."+name] && !hasSubsection {
+		return "", true
+	}
+	if legacy {
+		return "lfs" + rest, true
+	}
+
+	if section == "remote" && hasSubsection {
+		if current, ok := legacyRemoteKeys[name]; ok {
+			return key[:lastDot+1] + current, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,38 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMigrateKey(t *testing.T) {
+	for key, expected := range map[string]string{
+		"hawser.url":                        "lfs.url",
+		"media.url":                         "lfs.url",
+		"Hawser.ConcurrentTransfers":        "lfs.ConcurrentTransfers",
+		"hawser.https://Example.com.access": "lfs.https://Example.com.access",
+		"remote.origin.media":               "remote.origin.lfsurl",
+		"remote.Origin.hawser":              "remote.Origin.lfsurl",
+		"lfs.batch":                         "",
+		"hawser.batch":                      "",
+		"Media.Batch":                       "",
+		"hawser.https://example.com.batch":  "lfs.https://example.com.batch",
+	} {
+		current, ok := MigrateKey(key)
+		assert.True(t, ok, key)
+		assert.Equal(t, expected, current, key)
+	}
+
+	for _, key := range []string{
+		"lfs.url",
+		"lfs.https://example.com.batch",
+		"remote.origin.url",
+		"remote.media",
+		"core.hawser",
+		"hawser",
+	} {
+		_, ok := MigrateKey(key)
+		assert.False(t, ok, key)
+	}
+}
@@ -4,6 +4,7 @@ package config
 // the `map[string]string` type.
 type mapFetcher map[string][]string
 
+// UniqMapFetcher returns a Fetcher of the single values in "m".
 func UniqMapFetcher(m map[string]string) Fetcher {
 	multi := make(map[string][]string, len(m))
 	for k, v := range m {
@@ -13,6 +14,7 @@ func UniqMapFetcher(m map[string]string) Fetcher {
 	return MapFetcher(multi)
 }
 
+// MapFetcher returns a Fetcher of the values in "m".
 func MapFetcher(m map[string][]string) Fetcher {
 	return mapFetcher(m)
 }
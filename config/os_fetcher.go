@@ -63,6 +63,7 @@ func (o *OsFetcher) GetAll(key string) []string {
 	return make([]string, 0)
 }
 
+// All returns nil, since environment variables are not listed.
 func (o *OsFetcher) All() map[string][]string {
 	return nil
 }
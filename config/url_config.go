@@ -7,10 +7,13 @@ import (
 	"strings"
 )
 
+// URLConfig reads settings which may be configured per URL, such as
+// `http.<url>.<key>`.
 type URLConfig struct {
 	git Environment
 }
 
+// NewURLConfig returns a URLConfig of the Git configuration "git".
 func NewURLConfig(git Environment) *URLConfig {
 	if git == nil {
 		git = EnvironmentOf(make(mapFetcher))
@@ -38,6 +41,7 @@ func (c *URLConfig) Get(prefix, rawurl, key string) (string, bool) {
 	return c.git.Get(strings.Join([]string{prefix, key}, "."))
 }
 
+// GetAll is like Get, but returns every value of the key.
 func (c *URLConfig) GetAll(prefix, rawurl, key string) []string {
 	if c == nil {
 		return nil
@@ -51,6 +55,8 @@ func (c *URLConfig) GetAll(prefix, rawurl, key string) []string {
 	return c.git.GetAll(strings.Join([]string{prefix, key}, "."))
 }
 
+// Bool is like Get, but parses the value as a boolean, returning "def" if it
+// is unset or invalid.
 func (c *URLConfig) Bool(prefix, rawurl, key string, def bool) bool {
 	s, _ := c.Get(prefix, rawurl, key)
 	return Bool(s, def)
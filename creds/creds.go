@@ -70,10 +70,19 @@ func bufferCreds(c Creds) *bytes.Buffer {
 
 type CredentialHelperContext struct {
 	netrcCredHelper   *netrcCredentialHelper
+	nativeCredHelper  *nativeCredentialHelper
 	commandCredHelper *commandCredentialHelper
 	askpassCredHelper *AskPassCredentialHelper
 	cachingCredHelper *credentialCacher
 
+	// repromptCredHelper is used only with a configured credential
+	// helper, since otherwise the askpass helper already prompts.
+	repromptCredHelper *repromptCredentialHelper
+
+	// promptCreds is false when "lfs.promptcredentials" is disabled, in
+	// which case no helper is permitted to prompt the user.
+	promptCreds bool
+
 	urlConfig      *config.URLConfig
 	wwwAuthHeaders []string
 }
@@ -82,6 +91,11 @@ func NewCredentialHelperContext(gitEnv config.Environment, osEnv config.Environm
 	c := &CredentialHelperContext{urlConfig: config.NewURLConfig(gitEnv)}
 
 	c.netrcCredHelper = newNetrcCredentialHelper(osEnv)
+	c.promptCreds = gitEnv.Bool("lfs.promptcredentials", true)
+
+	if native, _ := gitEnv.Get("lfs.defaultcredentialhelper"); len(native) > 0 {
+		c.nativeCredHelper = &nativeCredentialHelper{Helper: native}
+	}
 
 	askpass, ok := osEnv.Get("GIT_ASKPASS")
 	if !ok {
@@ -107,8 +121,13 @@ func NewCredentialHelperContext(gitEnv config.Environment, osEnv config.Environm
 		c.cachingCredHelper = NewCredentialCacher()
 	}
 
+	if c.cachingCredHelper != nil && c.askpassCredHelper != nil && c.promptCreds {
+		c.repromptCredHelper = newRepromptCredentialHelper(c.cachingCredHelper, c.askpassCredHelper)
+	}
+
 	c.commandCredHelper = &commandCredentialHelper{
 		SkipPrompt: osEnv.Bool("GIT_TERMINAL_PROMPT", false),
+		NoPrompt:   !c.promptCreds,
 	}
 
 	return c
@@ -147,13 +166,24 @@ func (ctxt *CredentialHelperContext) GetCredentialHelper(helper CredentialHelper
 	if ctxt.cachingCredHelper != nil {
 		helpers = append(helpers, ctxt.cachingCredHelper)
 	}
-	if ctxt.askpassCredHelper != nil {
-		helper, _ := ctxt.urlConfig.Get("credential", rawurl, "helper")
-		if len(helper) == 0 {
+
+	// When no credential helper is configured, look in the platform's
+	// native credential store first, since doing so never prompts, and
+	// only then fall back to asking the user with GIT_ASKPASS.
+	configured, _ := ctxt.urlConfig.Get("credential", rawurl, "helper")
+	if len(configured) == 0 {
+		if ctxt.nativeCredHelper != nil {
+			helpers = append(helpers, ctxt.nativeCredHelper)
+		}
+		if ctxt.askpassCredHelper != nil && ctxt.promptCreds {
 			helpers = append(helpers, ctxt.askpassCredHelper)
 		}
 	}
-	return CredentialHelperWrapper{CredentialHelper: NewCredentialHelpers(append(helpers, ctxt.commandCredHelper)), Input: input, Url: u}
+	helpers = append(helpers, ctxt.commandCredHelper)
+	if len(configured) > 0 && ctxt.repromptCredHelper != nil {
+		helpers = append(helpers, ctxt.repromptCredHelper)
+	}
+	return CredentialHelperWrapper{CredentialHelper: NewCredentialHelpers(helpers), Input: input, Url: u}
 }
 
 // AskPassCredentialHelper implements the CredentialHelper type for GIT_ASKPASS
@@ -293,6 +323,10 @@ func (a *AskPassCredentialHelper) args(prompt string) []string {
 
 type commandCredentialHelper struct {
 	SkipPrompt bool
+	// NoPrompt prevents `git credential` from prompting on the terminal
+	// or with an askpass program, so that it fails instead of waiting
+	// for input which will never arrive, as in CI environments.
+	NoPrompt bool
 }
 
 func (h *commandCredentialHelper) Fill(creds Creds) (Creds, error) {
@@ -325,6 +359,9 @@ func (h *commandCredentialHelper) exec(subcommand string, input Creds) (Creds, e
 	}
 	cmd.Stdin = bufferCreds(input)
 	cmd.Stdout = output
+	if h.NoPrompt {
+		cmd.Env = append(cmd.Env, "GIT_TERMINAL_PROMPT=0", "GIT_ASKPASS=", "GCM_INTERACTIVE=never")
+	}
 	/*
 	   There is a reason we don't read from stderr here:
 	   Git's credential cache daemon helper does not close its stderr, so if this
@@ -359,8 +396,13 @@ func (h *commandCredentialHelper) exec(subcommand string, input Creds) (Creds, e
 		return nil, errors.New(tr.Tr.Get("`git credential %s` error: %s", subcommand, err.Error()))
 	}
 
+	return parseCreds(output.String()), nil
+}
+
+// parseCreds parses the "key=value" lines written by a credential helper.
+func parseCreds(output string) Creds {
 	creds := make(Creds)
-	for _, line := range strings.Split(output.String(), "\n") {
+	for _, line := range strings.Split(output, "\n") {
 		pieces := strings.SplitN(line, "=", 2)
 		if len(pieces) < 2 || len(pieces[1]) < 1 {
 			continue
@@ -371,17 +413,79 @@ func (h *commandCredentialHelper) exec(subcommand string, input Creds) (Creds, e
 			creds[pieces[0]] = []string{pieces[1]}
 		}
 	}
+	return creds
+}
 
-	return creds, nil
+// nativeCredentialHelper implements the CredentialHelper type by running a
+// platform credential helper, such as "git credential-osxkeychain" or "git
+// credential-wincred", directly. Unlike `git credential`, it never prompts the
+// user, so it is safe to consult before any helper which might.
+type nativeCredentialHelper struct {
+	Helper string
+}
+
+func (h *nativeCredentialHelper) Fill(creds Creds) (Creds, error) {
+	tracerx.Printf("creds: git credential-%s get (%q, %q, %q)", h.Helper,
+		firstEntryForKey(creds, "protocol"),
+		firstEntryForKey(creds, "host"),
+		firstEntryForKey(creds, "path"))
+
+	filled, err := h.exec("get", creds)
+	if err != nil {
+		return nil, err
+	}
+	if len(firstEntryForKey(filled, "password")) == 0 {
+		return nil, nil
+	}
+
+	// Helpers only reply with the values they know, so fill in the
+	// rest of the request to make a complete set of credentials.
+	for k, v := range creds {
+		if _, ok := filled[k]; !ok {
+			filled[k] = v
+		}
+	}
+	return filled, nil
+}
+
+func (h *nativeCredentialHelper) Approve(creds Creds) error {
+	_, err := h.exec("store", creds)
+	return err
+}
+
+func (h *nativeCredentialHelper) Reject(creds Creds) error {
+	_, err := h.exec("erase", creds)
+	return err
+}
+
+func (h *nativeCredentialHelper) exec(subcommand string, input Creds) (Creds, error) {
+	output := new(bytes.Buffer)
+	cmd, err := subprocess.ExecCommand("git", "credential-"+h.Helper, subcommand)
+	if err != nil {
+		return nil, errors.New(tr.Tr.Get("failed to find `git credential-%s %s`: %v", h.Helper, subcommand, err))
+	}
+	cmd.Stdin = bufferCreds(input)
+	cmd.Stdout = output
+
+	if err := cmd.Run(); err != nil {
+		return nil, errors.New(tr.Tr.Get("`git credential-%s %s` error: %s", h.Helper, subcommand, err.Error()))
+	}
+	return parseCreds(output.String()), nil
 }
 
 type credentialCacher struct {
 	creds map[string]Creds
-	mu    sync.Mutex
+	// rejected holds the credentials which were cached, and so had
+	// worked, before being rejected.
+	rejected map[string]Creds
+	mu       sync.Mutex
 }
 
 func NewCredentialCacher() *credentialCacher {
-	return &credentialCacher{creds: make(map[string]Creds)}
+	return &credentialCacher{
+		creds:    make(map[string]Creds),
+		rejected: make(map[string]Creds),
+	}
 }
 
 func credCacheKey(creds Creds) string {
@@ -427,11 +531,90 @@ func (c *credentialCacher) Approve(what Creds) error {
 func (c *credentialCacher) Reject(what Creds) error {
 	key := credCacheKey(what)
 	c.mu.Lock()
-	delete(c.creds, key)
+	if cached, ok := c.creds[key]; ok {
+		c.rejected[key] = cached
+		delete(c.creds, key)
+	}
 	c.mu.Unlock()
 	return credHelperNoOp
 }
 
+// lastRejected returns the most recently rejected credentials which had
+// previously been cached for the same URL as "what", if any.
+func (c *credentialCacher) lastRejected(what Creds) (Creds, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	rejected, ok := c.rejected[credCacheKey(what)]
+	return rejected, ok
+}
+
+// repromptCredentialHelper prompts the user with GIT_ASKPASS, at most once per
+// URL, for credentials which worked earlier in the current operation but were
+// then rejected, and which no other helper can fill any more. This happens
+// when a credential-cache daemon's entry expires in the middle of a long
+// transfer: rejecting the stale credentials leaves the daemon empty, and
+// without a terminal, `git credential fill` has no way to ask for new ones.
+//
+// Concurrent fills wait for a prompt in progress and share its answer, so
+// that the user is asked only once rather than by every queued transfer.
+type repromptCredentialHelper struct {
+	cacher  *credentialCacher
+	askpass CredentialHelper
+
+	prompted map[string]Creds
+	mu       sync.Mutex
+}
+
+func newRepromptCredentialHelper(cacher *credentialCacher, askpass CredentialHelper) *repromptCredentialHelper {
+	return &repromptCredentialHelper{
+		cacher:   cacher,
+		askpass:  askpass,
+		prompted: make(map[string]Creds),
+	}
+}
+
+func (h *repromptCredentialHelper) Fill(what Creds) (Creds, error) {
+	rejected, ok := h.cacher.lastRejected(what)
+	if !ok {
+		return nil, credHelperNoOp
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	key := credCacheKey(what)
+	if prompted, ok := h.prompted[key]; ok {
+		if prompted == nil || firstEntryForKey(prompted, "password") == firstEntryForKey(rejected, "password") {
+			// We've already asked once, and either got
+			// nothing or credentials which were rejected
+			// too.
+			return nil, credHelperNoOp
+		}
+		return prompted, nil
+	}
+
+	tracerx.Printf("creds: credentials for %s://%s were rejected and could not be refilled; prompting again",
+		firstEntryForKey(what, "protocol"),
+		firstEntryForKey(what, "host"))
+
+	creds, err := h.askpass.Fill(what)
+	h.prompted[key] = creds
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range what {
+		if _, ok := creds[k]; !ok {
+			creds[k] = v
+		}
+	}
+	return creds, nil
+}
+
+func (h *repromptCredentialHelper) Approve(_ Creds) error { return credHelperNoOp }
+
+func (h *repromptCredentialHelper) Reject(_ Creds) error { return credHelperNoOp }
+
 // CredentialHelpers iterates through a slice of CredentialHelper objects
 // CredentialHelpers is a []CredentialHelper that iterates through each
 // credential helper to fill, reject, or approve credentials. Typically, the
@@ -2,9 +2,12 @@ package creds
 
 import (
 	"errors"
+	"net/url"
 	"testing"
 
+	"github.com/git-lfs/git-lfs/v3/config"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 type testCredHelper struct {
@@ -269,3 +272,122 @@ func TestCredHelperSetAllFillErrors(t *testing.T) {
 	assert.Equal(t, 0, len(helper1.reject))
 	assert.Equal(t, 0, len(helper2.reject))
 }
+
+func TestCredentialHelperContextNativeHelper(t *testing.T) {
+	ctxt := NewCredentialHelperContext(config.EnvironmentOf(config.MapFetcher(map[string][]string{
+		"lfs.defaultcredentialhelper": []string{"test"},
+	})), config.EnvironmentOf(config.MapFetcher(map[string][]string{
+		"GIT_ASKPASS": []string{"askpass"},
+	})))
+
+	u, _ := url.Parse("https://example.com/repo.git")
+	helpers := ctxt.GetCredentialHelper(nil, u).CredentialHelper.(*CredentialHelpers).helpers
+
+	require.Len(t, helpers, 5)
+	assert.Equal(t, &nativeCredentialHelper{Helper: "test"}, helpers[2])
+	assert.Equal(t, ctxt.askpassCredHelper, helpers[3])
+	assert.Equal(t, ctxt.commandCredHelper, helpers[4])
+}
+
+func TestCredentialHelperContextNoNativeHelperByDefault(t *testing.T) {
+	ctxt := NewCredentialHelperContext(config.EnvironmentOf(config.MapFetcher(nil)),
+		config.EnvironmentOf(config.MapFetcher(nil)))
+
+	assert.Nil(t, ctxt.nativeCredHelper)
+}
+
+func TestCredentialHelperContextNoNativeHelperWhenConfigured(t *testing.T) {
+	ctxt := NewCredentialHelperContext(config.EnvironmentOf(config.MapFetcher(map[string][]string{
+		"lfs.defaultcredentialhelper": []string{"test"},
+		"credential.helper":           []string{"store"},
+	})), config.EnvironmentOf(config.MapFetcher(nil)))
+
+	u, _ := url.Parse("https://example.com/repo.git")
+	helpers := ctxt.GetCredentialHelper(nil, u).CredentialHelper.(*CredentialHelpers).helpers
+
+	for _, h := range helpers {
+		_, ok := h.(*nativeCredentialHelper)
+		assert.False(t, ok)
+	}
+}
+
+func TestCredentialHelperContextNoPrompt(t *testing.T) {
+	ctxt := NewCredentialHelperContext(config.EnvironmentOf(config.MapFetcher(map[string][]string{
+		"lfs.defaultcredentialhelper": []string{""},
+		"lfs.promptcredentials":       []string{"false"},
+	})), config.EnvironmentOf(config.MapFetcher(map[string][]string{
+		"GIT_ASKPASS": []string{"askpass"},
+	})))
+
+	u, _ := url.Parse("https://example.com/repo.git")
+	helpers := ctxt.GetCredentialHelper(nil, u).CredentialHelper.(*CredentialHelpers).helpers
+
+	assert.Nil(t, ctxt.nativeCredHelper)
+	assert.True(t, ctxt.commandCredHelper.NoPrompt)
+	for _, h := range helpers {
+		_, ok := h.(*AskPassCredentialHelper)
+		assert.False(t, ok)
+	}
+}
+
+func TestCredentialHelperContextReprompt(t *testing.T) {
+	ctxt := NewCredentialHelperContext(config.EnvironmentOf(config.MapFetcher(map[string][]string{
+		"credential.helper": []string{"cache"},
+	})), config.EnvironmentOf(config.MapFetcher(map[string][]string{
+		"GIT_ASKPASS": []string{"askpass"},
+	})))
+
+	u, _ := url.Parse("https://example.com/repo.git")
+	helpers := ctxt.GetCredentialHelper(nil, u).CredentialHelper.(*CredentialHelpers).helpers
+
+	require.NotNil(t, ctxt.repromptCredHelper)
+	assert.Equal(t, ctxt.commandCredHelper, helpers[len(helpers)-2])
+	assert.Equal(t, ctxt.repromptCredHelper, helpers[len(helpers)-1])
+}
+
+func TestRepromptCredentialHelper(t *testing.T) {
+	cache := NewCredentialCacher()
+	prompt := newTestCredHelper()
+	reprompt := newRepromptCredentialHelper(cache, prompt)
+
+	input := Creds{"protocol": []string{"https"}, "host": []string{"example.com"}}
+	oldCreds := Creds{
+		"protocol": []string{"https"},
+		"host":     []string{"example.com"},
+		"username": []string{"foo"},
+		"password": []string{"old"},
+	}
+	newCreds := Creds{
+		"protocol": []string{"https"},
+		"host":     []string{"example.com"},
+		"username": []string{"foo"},
+		"password": []string{"new"},
+	}
+
+	// Credentials which never worked are not prompted for again.
+	_, err := reprompt.Fill(input)
+	assert.Equal(t, credHelperNoOp, err)
+	assert.Empty(t, prompt.fill)
+
+	cache.Approve(oldCreds)
+	cache.Reject(input)
+
+	out, err := reprompt.Fill(newCreds)
+	assert.Nil(t, err)
+	assert.Equal(t, newCreds, out)
+	assert.Len(t, prompt.fill, 1)
+
+	// Later fills share the answer to the first prompt.
+	out, err = reprompt.Fill(input)
+	assert.Nil(t, err)
+	assert.Equal(t, newCreds, out)
+	assert.Len(t, prompt.fill, 1)
+
+	// If the new credentials are rejected too, we give up.
+	cache.Approve(out)
+	cache.Reject(out)
+
+	_, err = reprompt.Fill(input)
+	assert.Equal(t, credHelperNoOp, err)
+	assert.Len(t, prompt.fill, 1)
+}
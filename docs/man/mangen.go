@@ -25,7 +25,7 @@ func warnf(w io.Writer, format string, a ...interface{}) {
 
 func readManDir() (string, []os.FileInfo) {
 	rootDirs := []string{
-		"..",
+		"../..",
 		"/tmp/docker_run/git-lfs",
 	}
 
@@ -61,14 +61,14 @@ func main() {
 	infof(os.Stderr, "Converting man pages into code...\n")
 	rootDir, fs := readManDir()
 	manDir := filepath.Join(rootDir, "docs", "man")
-	out, err := os.Create(filepath.Join(rootDir, "commands", "mancontent_gen.go"))
+	out, err := os.Create(filepath.Join(rootDir, "internal", "commands", "mancontent_gen.go"))
 	if err != nil {
 		warnf(os.Stderr, "Failed to create go file: %v\n", err)
 		os.Exit(2)
 	}
 	out.WriteString("package commands\n\nfunc init() {\n")
 	out.WriteString("\t// THIS FILE IS GENERATED, DO NOT EDIT\n")
-	out.WriteString("\t// Use 'go generate ./commands' to update\n")
+	out.WriteString("\t// Use 'go generate ./internal/commands' to update\n")
 	fileregex := regexp.MustCompile(`git-lfs(?:-([A-Za-z\-]+))?.adoc`)
 	headerregex := regexp.MustCompile(`^==\s+([A-Za-z0-9 ]+)`)
 	// cross-references
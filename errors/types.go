@@ -191,6 +191,37 @@ func IsRetriableError(err error) bool {
 	return false
 }
 
+// IsQuotaExceededError indicates that the server refused the operation
+// because a storage or bandwidth quota has been used up, so that retrying it
+// is pointless until the quota is raised.
+func IsQuotaExceededError(err error) bool {
+	if e, ok := err.(interface {
+		QuotaExceededError() bool
+	}); ok {
+		return e.QuotaExceededError()
+	}
+	if parent := parentOf(err); parent != nil {
+		return IsQuotaExceededError(parent)
+	}
+	return false
+}
+
+// IsLockConflictError indicates that the server refused the operation because
+// a file it involves is locked by another user, such as when a lock cannot be
+// created because one already exists, or an object cannot be uploaded because
+// it is for a file which someone else has locked.
+func IsLockConflictError(err error) bool {
+	if e, ok := err.(interface {
+		LockConflictError() bool
+	}); ok {
+		return e.LockConflictError()
+	}
+	if parent := parentOf(err); parent != nil {
+		return IsLockConflictError(parent)
+	}
+	return false
+}
+
 func IsRetriableLaterError(err error) (time.Time, bool) {
 	if e, ok := err.(interface {
 		RetriableLaterError() (time.Time, bool)
@@ -412,30 +443,66 @@ type retriableLaterError struct {
 }
 
 func NewRetriableLaterError(err error, header string) error {
-	secs, err := strconv.Atoi(header)
-	if err == nil {
-		return retriableLaterError{
-			wrappedError:  newWrappedError(err, ""),
-			timeAvailable: time.Now().Add(time.Duration(secs) * time.Second),
-		}
+	timeAvailable, ok := ParseRetryAfter(header)
+	if !ok {
+		// We could not return a successful error from the Retry-After
+		// header.
+		return nil
 	}
 
-	time, err := time.Parse(time.RFC1123, header)
-	if err == nil {
-		return retriableLaterError{
-			wrappedError:  newWrappedError(err, ""),
-			timeAvailable: time,
-		}
+	return retriableLaterError{
+		wrappedError:  newWrappedError(err, ""),
+		timeAvailable: timeAvailable,
 	}
+}
 
-	// We could not return a successful error from the Retry-After header.
-	return nil
+// ParseRetryAfter returns the time given by the value of a Retry-After HTTP
+// header, which is either a number of seconds from now or an HTTP date, or
+// false if it is neither.
+func ParseRetryAfter(header string) (time.Time, bool) {
+	if secs, err := strconv.Atoi(header); err == nil {
+		return time.Now().Add(time.Duration(secs) * time.Second), true
+	}
+
+	if t, err := time.Parse(time.RFC1123, header); err == nil {
+		return t, true
+	}
+
+	return time.Time{}, false
 }
 
 func (e retriableLaterError) RetriableLaterError() (time.Time, bool) {
 	return e.timeAvailable, true
 }
 
+// Definitions for IsQuotaExceededError()
+
+type quotaExceededError struct {
+	*wrappedError
+}
+
+func (e quotaExceededError) QuotaExceededError() bool {
+	return true
+}
+
+func NewQuotaExceededError(err error) error {
+	return quotaExceededError{newWrappedError(err, tr.Tr.Get("Server quota exceeded"))}
+}
+
+// Definitions for IsLockConflictError()
+
+type lockConflictError struct {
+	*wrappedError
+}
+
+func (e lockConflictError) LockConflictError() bool {
+	return true
+}
+
+func NewLockConflictError(err error) error {
+	return lockConflictError{newWrappedError(err, tr.Tr.Get("Locked by another user"))}
+}
+
 // Definitions for IsUnprocessableEntityError()
 
 type unprocessableEntityError struct {
@@ -3,6 +3,7 @@ package errors_test
 import (
 	"net/url"
 	"testing"
+	"time"
 
 	"github.com/git-lfs/git-lfs/v3/errors"
 	"github.com/stretchr/testify/assert"
@@ -44,3 +45,36 @@ func TestCannotRetryOnGenericUrlError(t *testing.T) {
 	err := &url.Error{Err: errors.New("")}
 	assert.False(t, errors.IsRetriableError(err))
 }
+
+func TestRetriableLaterErrorKeepsMessage(t *testing.T) {
+	err := errors.NewRetriableLaterError(errors.New("slow down"), "30")
+
+	readyAt, ok := errors.IsRetriableLaterError(err)
+	assert.True(t, ok)
+	assert.WithinDuration(t, time.Now().Add(30*time.Second), readyAt, 5*time.Second)
+	assert.Contains(t, err.Error(), "slow down")
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	at, ok := errors.ParseRetryAfter("Wed, 21 Oct 2015 07:28:00 GMT")
+	assert.True(t, ok)
+	assert.Equal(t, time.Date(2015, 10, 21, 7, 28, 0, 0, time.UTC), at.UTC())
+
+	_, ok = errors.ParseRetryAfter("soon")
+	assert.False(t, ok)
+}
+
+func TestQuotaExceededError(t *testing.T) {
+	err := errors.Wrap(errors.NewQuotaExceededError(errors.New("out of space")), "upload")
+
+	assert.True(t, errors.IsQuotaExceededError(err))
+	assert.False(t, errors.IsQuotaExceededError(errors.New("out of space")))
+}
+
+func TestLockConflictError(t *testing.T) {
+	err := errors.Wrap(errors.NewLockConflictError(errors.New("a.psd is locked")), "upload")
+
+	assert.True(t, errors.IsLockConflictError(err))
+	assert.False(t, errors.IsLockConflictError(errors.New("a.psd is locked")))
+	assert.Contains(t, err.Error(), "a.psd is locked")
+}
@@ -37,6 +37,7 @@ func (p PatternType) String() string {
 
 type options struct {
 	defaultValue bool
+	caseFold     bool
 }
 
 type option func(*options)
@@ -49,18 +50,51 @@ func DefaultValue(val bool) option {
 	}
 }
 
-func NewFromPatterns(include, exclude []Pattern, setters ...option) *Filter {
+// CaseFold is an option which makes every pattern given to New match
+// case-insensitively, as if each had the "icase" pathspec magic.
+func CaseFold(val bool) option {
+	return func(args *options) {
+		args.caseFold = val
+	}
+}
+
+func newOptions(setters []option) *options {
 	args := &options{defaultValue: true}
 	for _, setter := range setters {
 		setter(args)
 	}
+	return args
+}
+
+func NewFromPatterns(include, exclude []Pattern, setters ...option) *Filter {
+	args := newOptions(setters)
 	return &Filter{include: include, exclude: exclude, defaultValue: args.defaultValue}
 }
 
+// New returns a filter including the paths matched by any of the include
+// patterns and not matched by any of the exclude patterns.
+//
+// Each pattern may begin with git pathspec magic, such as ":(icase)*.psd" or
+// ":(literal)file[1].dat". An include pattern with the "exclude" magic (also
+// written ":!" or ":^") is treated as an exclude pattern.
 func New(include, exclude []string, ptype PatternType, setters ...option) *Filter {
-	return NewFromPatterns(
-		convertToWildmatch(include, ptype),
-		convertToWildmatch(exclude, ptype), setters...)
+	args := newOptions(setters)
+
+	var inc, exc []Pattern
+	for _, raw := range include {
+		p, magic := parsePathspec(raw)
+		if magic.exclude {
+			exc = append(exc, newPathspecPattern(raw, p, magic, ptype, args.caseFold))
+		} else {
+			inc = append(inc, newPathspecPattern(raw, p, magic, ptype, args.caseFold))
+		}
+	}
+	for _, raw := range exclude {
+		p, magic := parsePathspec(raw)
+		exc = append(exc, newPathspecPattern(raw, p, magic, ptype, args.caseFold))
+	}
+
+	return &Filter{include: inc, exclude: exc, defaultValue: args.defaultValue}
 }
 
 // Include returns the result of calling String() on each Pattern in the
@@ -133,29 +167,75 @@ func (w *wm) String() string {
 	return w.p
 }
 
+// literal is a Pattern which matches a single path, and any path beneath it,
+// without interpreting any wildcard characters.
+type literal struct {
+	p string
+}
+
+func (l *literal) Match(filename string) bool {
+	if l.p == "." {
+		// The root of the repository contains every path.
+		return true
+	}
+	return filename == l.p || strings.HasPrefix(filename, join(l.p, ""))
+}
+
+func (l *literal) String() string {
+	return l.p
+}
+
+// NewLiteralPattern returns a Pattern matching the given path exactly, as well
+// as any path beneath it if it names a directory. Unlike the patterns returned
+// by NewPattern, characters such as "*" and "?" have no special meaning, which
+// makes it suitable for path lists generated by other programs.
+func NewLiteralPattern(p string) Pattern {
+	tracerx.Printf("filepathfilter: creating literal pattern %q", p)
+
+	return &literal{p: strings.TrimSuffix(p, string(sep))}
+}
+
 const (
 	sep byte = '/'
 )
 
 func NewPattern(p string, ptype PatternType) Pattern {
-	tracerx.Printf("filepathfilter: creating pattern %q of type %v", p, ptype)
+	return newPattern(p, p, ptype, false)
+}
+
+// newPathspecPattern returns a Pattern matching "p", honoring the given
+// pathspec magic, which reports the original pattern "raw" from String().
+func newPathspecPattern(raw, p string, magic pathspecMagic, ptype PatternType, caseFold bool) Pattern {
+	if magic.literal {
+		return NewLiteralPattern(p)
+	}
+	return newPattern(raw, p, ptype, caseFold || magic.icase)
+}
+
+func newPattern(raw, p string, ptype PatternType, caseFold bool) Pattern {
+	tracerx.Printf("filepathfilter: creating pattern %q of type %v", raw, ptype)
+
+	caseOpt := wildmatch.SystemCase
+	if caseFold {
+		caseOpt = wildmatch.CaseFold
+	}
 
 	switch ptype {
 	case GitIgnore:
 		return &wm{
-			p: p,
+			p: raw,
 			w: wildmatch.NewWildmatch(
 				p,
-				wildmatch.SystemCase,
+				caseOpt,
 				wildmatch.Contents,
 			),
 		}
 	case GitAttributes:
 		return &wm{
-			p: p,
+			p: raw,
 			w: wildmatch.NewWildmatch(
 				p,
-				wildmatch.SystemCase,
+				caseOpt,
 				wildmatch.Basename,
 				wildmatch.GitAttributes,
 			),
@@ -180,11 +260,3 @@ func join(paths ...string) string {
 
 	return joined
 }
-
-func convertToWildmatch(rawpatterns []string, ptype PatternType) []Pattern {
-	patterns := make([]Pattern, len(rawpatterns))
-	for i, raw := range rawpatterns {
-		patterns[i] = NewPattern(raw, ptype)
-	}
-	return patterns
-}
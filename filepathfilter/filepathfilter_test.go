@@ -152,3 +152,77 @@ func TestFilterReportsExcludePatterns(t *testing.T) {
 
 	assert.Equal(t, []string{"*.baz", "*.quux"}, filter.Exclude())
 }
+
+func TestLiteralPatternMatch(t *testing.T) {
+	p := NewLiteralPattern("dir/file[1]*.dat")
+	assert.True(t, p.Match("dir/file[1]*.dat"))
+	assert.False(t, p.Match("dir/file1x.dat"))
+	assert.False(t, p.Match("other/dir/file[1]*.dat"))
+
+	d := NewLiteralPattern("dir/sub/")
+	assert.Equal(t, "dir/sub", d.String())
+	assert.True(t, d.Match("dir/sub"))
+	assert.True(t, d.Match("dir/sub/a.dat"))
+	assert.False(t, d.Match("dir/subway.dat"))
+
+	assert.True(t, NewLiteralPattern(".").Match("dir/a.dat"))
+}
+
+func TestParsePathspec(t *testing.T) {
+	for raw, expected := range map[string]struct {
+		pattern string
+		magic   pathspecMagic
+	}{
+		"*.dat":                   {"*.dat", pathspecMagic{}},
+		":!*.dat":                 {"*.dat", pathspecMagic{exclude: true}},
+		":^*.dat":                 {"*.dat", pathspecMagic{exclude: true}},
+		":/*.dat":                 {"*.dat", pathspecMagic{}},
+		":/!:*.dat":               {"*.dat", pathspecMagic{exclude: true}},
+		":(exclude)*.dat":         {"*.dat", pathspecMagic{exclude: true}},
+		":(icase,literal)a[1].b":  {"a[1].b", pathspecMagic{icase: true, literal: true}},
+		":(top,glob)dir/**/*.dat": {"dir/**/*.dat", pathspecMagic{}},
+		":(unknown)*.dat":         {":(unknown)*.dat", pathspecMagic{}},
+		":(exclude*.dat":          {":(exclude*.dat", pathspecMagic{}},
+	} {
+		pattern, magic := parsePathspec(raw)
+		assert.Equal(t, expected.pattern, pattern, "pattern for %q", raw)
+		assert.Equal(t, expected.magic, magic, "magic for %q", raw)
+	}
+}
+
+func TestFilterPathspecExcludeMagic(t *testing.T) {
+	filter := New([]string{"*.dat", ":!secret/*.dat"}, nil, GitIgnore)
+
+	assert.Equal(t, []string{"*.dat"}, filter.Include())
+	assert.Equal(t, []string{":!secret/*.dat"}, filter.Exclude())
+	assert.True(t, filter.Allows("public/a.dat"))
+	assert.False(t, filter.Allows("secret/a.dat"))
+	assert.False(t, filter.Allows("public/a.txt"))
+}
+
+func TestFilterPathspecExcludeMagicOnly(t *testing.T) {
+	filter := New([]string{":(exclude)*.dat"}, nil, GitIgnore)
+
+	assert.True(t, filter.Allows("a.txt"))
+	assert.False(t, filter.Allows("a.dat"))
+}
+
+func TestFilterPathspecIcaseMagic(t *testing.T) {
+	filter := New([]string{":(icase)*.psd"}, nil, GitIgnore)
+
+	assert.True(t, filter.Allows("images/a.PSD"))
+	assert.True(t, filter.Allows("images/a.psd"))
+}
+
+func TestFilterPathspecLiteralMagic(t *testing.T) {
+	filter := New([]string{":(literal)file[1].dat"}, nil, GitIgnore)
+
+	assert.True(t, filter.Allows("file[1].dat"))
+	assert.False(t, filter.Allows("file1.dat"))
+}
+
+func TestFilterCaseFold(t *testing.T) {
+	filter := New([]string{"*.psd"}, nil, GitIgnore, CaseFold(true))
+
+	assert.True(t, filter.Allows("a.PSD"))
+}
@@ -0,0 +1,76 @@
+package filepathfilter
+
+import (
+	"strings"
+)
+
+// pathspecMagic holds the git pathspec magic given at the start of a pattern.
+//
+// See: https://git-scm.com/docs/gitglossary#Documentation/gitglossary.txt-aiddefpathspecapathspec
+type pathspecMagic struct {
+	// exclude indicates that paths matching the pattern should be
+	// excluded, given as ":(exclude)", ":!", or ":^".
+	exclude bool
+	// icase indicates that the pattern should match case-insensitively,
+	// given as ":(icase)".
+	icase bool
+	// literal indicates that wildcard characters in the pattern have no
+	// special meaning, given as ":(literal)".
+	literal bool
+}
+
+// parsePathspec separates any git pathspec magic at the start of the given
+// pattern from the pattern itself. Both the long form, such as
+// ":(icase,exclude)*.dat", and the short form, such as ":!*.dat" or
+// ":/*.dat", are recognized.
+//
+// The "top" and "glob" magic words are accepted but have no effect, since
+// patterns are always matched from the root of the repository and "*" never
+// matches a directory separator.
+//
+// Patterns which do not begin with a colon, or which use magic that is not
+// understood, are returned unchanged.
+func parsePathspec(p string) (string, pathspecMagic) {
+	var magic pathspecMagic
+
+	if !strings.HasPrefix(p, ":") {
+		return p, magic
+	}
+
+	rest := p[1:]
+	if strings.HasPrefix(rest, "(") {
+		end := strings.IndexByte(rest, ')')
+		if end < 0 {
+			return p, pathspecMagic{}
+		}
+
+		for _, word := range strings.Split(rest[1:end], ",") {
+			switch strings.TrimSpace(word) {
+			case "exclude":
+				magic.exclude = true
+			case "icase":
+				magic.icase = true
+			case "literal":
+				magic.literal = true
+			case "top", "glob", "":
+			default:
+				return p, pathspecMagic{}
+			}
+		}
+		return rest[end+1:], magic
+	}
+
+	for len(rest) > 0 {
+		switch rest[0] {
+		case '!', '^':
+			magic.exclude = true
+		case '/':
+		case ':':
+			return rest[1:], magic
+		default:
+			return rest, magic
+		}
+		rest = rest[1:]
+	}
+	return rest, magic
+}
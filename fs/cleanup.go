@@ -40,7 +40,11 @@ func (f *Filesystem) cleanupTmp() error {
 		parts := strings.SplitN(info.Name(), "-", 2)
 		oid := parts[0]
 		if len(parts) == 2 && len(oid) == 64 {
-			fi, err := os.Stat(f.ObjectPathname(oid))
+			var fi os.FileInfo
+			objPath, err := f.ObjectPathname(oid)
+			if err == nil {
+				fi, err = os.Stat(objPath)
+			}
 			if err == nil && !fi.IsDir() {
 				tracerx.Printf("Removing existing tmp object file: %s", path)
 				os.RemoveAll(path)
@@ -1,3 +1,9 @@
+// Package fs describes the directories in which Git LFS stores a repository's
+// objects, temporary files and logs, and the LocalStore through which its
+// objects are read and written.
+//
+// Filesystem, LocalStore and RegisterLocalStore are part of the stable Go API
+// of Git LFS described in docs/library.md.
 package fs
 
 import (
@@ -21,6 +27,7 @@ import (
 
 var (
 	oidRE             = regexp.MustCompile(`\A[[:alnum:]]{64}`)
+	validOidRE        = regexp.MustCompile(`\A[0-9a-f]*\z`)
 	EmptyObjectSHA256 = hex.EncodeToString(sha256.New().Sum(nil))
 )
 
@@ -38,17 +45,23 @@ type Object struct {
 	Size int64
 }
 
+// Filesystem describes the directories in which a repository's Git LFS objects,
+// temporary files and logs are stored.
 type Filesystem struct {
 	GitStorageDir string   // parent of objects/lfs (may be same as GitDir but may not)
 	LFSStorageDir string   // parent of lfs objects and tmp dirs. Default: ".git/lfs"
 	ReferenceDirs []string // alternative local media dirs (relative to clone reference repo)
+	StoreBackend  string   // name of the LocalStore holding objects. Default: "file"
 	lfsobjdir     string
 	tmpdir        string
 	logdir        string
 	repoPerms     os.FileMode
 	mu            sync.Mutex
+	store         LocalStore
+	storeMu       sync.Mutex
 }
 
+// EachObject calls "fn" with each object in the local object directory.
 func (f *Filesystem) EachObject(fn func(Object) error) error {
 	var eachErr error
 	tools.FastWalkDir(f.LFSObjectDir(), func(parentDir string, info os.FileInfo, err error) {
@@ -66,16 +79,40 @@ func (f *Filesystem) EachObject(fn func(Object) error) error {
 	return eachErr
 }
 
+// ObjectExists returns whether the object "oid" is in the local object
+// directory with the given size. No object exists with an invalid OID.
 func (f *Filesystem) ObjectExists(oid string, size int64) bool {
+	path, err := f.ObjectPathname(oid)
+	if err != nil {
+		return false
+	}
 	if size == 0 {
 		return true
 	}
-	return tools.FileExistsOfSize(f.ObjectPathname(oid), size)
+	return tools.FileExistsOfSize(path, size)
+}
+
+// ValidateOid returns an error if "oid" is not a well-formed SHA-256 object
+// ID, that is, 64 lowercase hexadecimal digits. Object IDs are used to build
+// paths within the object store, so any which come from outside Git LFS must
+// be validated before they are used.
+func ValidateOid(oid string) error {
+	switch {
+	case len(oid) < sha256.Size*2:
+		return errors.New(tr.Tr.Get("too short object ID: %q", oid))
+	case len(oid) > sha256.Size*2:
+		return errors.New(tr.Tr.Get("too long object ID: %q", oid))
+	case !validOidRE.MatchString(oid):
+		return errors.New(tr.Tr.Get("invalid object ID: %q is not lowercase hexadecimal", oid))
+	}
+	return nil
 }
 
+// ObjectPath returns the path of the object "oid" in the local object
+// directory, creating the directory which holds it if need be.
 func (f *Filesystem) ObjectPath(oid string) (string, error) {
-	if len(oid) < 4 {
-		return "", errors.New(tr.Tr.Get("too short object ID: %q", oid))
+	if err := ValidateOid(oid); err != nil {
+		return "", err
 	}
 	if oid == EmptyObjectSHA256 {
 		return os.DevNull, nil
@@ -87,17 +124,27 @@ func (f *Filesystem) ObjectPath(oid string) (string, error) {
 	return filepath.Join(dir, oid), nil
 }
 
-func (f *Filesystem) ObjectPathname(oid string) string {
+// ObjectPathname returns the path of the object "oid" in the local object
+// directory, without checking that it exists or creating any directories. It
+// returns an error if "oid" is not a valid object ID.
+func (f *Filesystem) ObjectPathname(oid string) (string, error) {
+	if err := ValidateOid(oid); err != nil {
+		return "", err
+	}
 	if oid == EmptyObjectSHA256 {
-		return os.DevNull
+		return os.DevNull, nil
 	}
-	return filepath.Join(f.localObjectDir(oid), oid)
+	return filepath.Join(f.localObjectDir(oid), oid), nil
 }
 
+// DecodePathname reverts the escaping of the path "path" by Git. See
+// DecodePathBytes.
 func (f *Filesystem) DecodePathname(path string) string {
 	return string(DecodePathBytes([]byte(path)))
 }
 
+// RepositoryPermissions returns the permissions with which files are created in
+// the repository, according to its core.sharedRepository setting.
 func (f *Filesystem) RepositoryPermissions(executable bool) os.FileMode {
 	if executable {
 		return tools.ExecutablePermissions(f.repoPerms)
@@ -141,6 +188,8 @@ func (f *Filesystem) localObjectDir(oid string) string {
 	return filepath.Join(f.LFSObjectDir(), oid[0:2], oid[2:4])
 }
 
+// ObjectReferencePaths returns the paths at which the object "oid" would be
+// stored in each of the repository's reference directories.
 func (f *Filesystem) ObjectReferencePaths(oid string) []string {
 	if len(f.ReferenceDirs) == 0 {
 		return nil
@@ -153,6 +202,7 @@ func (f *Filesystem) ObjectReferencePaths(oid string) []string {
 	return paths
 }
 
+// LFSObjectDir returns the local object directory, creating it if need be.
 func (f *Filesystem) LFSObjectDir() string {
 	f.mu.Lock()
 	defer f.mu.Unlock()
@@ -165,6 +215,7 @@ func (f *Filesystem) LFSObjectDir() string {
 	return f.lfsobjdir
 }
 
+// LogDir returns the directory of Git LFS's error logs, creating it if need be.
 func (f *Filesystem) LogDir() string {
 	f.mu.Lock()
 	defer f.mu.Unlock()
@@ -177,6 +228,8 @@ func (f *Filesystem) LogDir() string {
 	return f.logdir
 }
 
+// TempDir returns the directory of Git LFS's temporary files, creating it if
+// need be.
 func (f *Filesystem) TempDir() string {
 	f.mu.Lock()
 	defer f.mu.Unlock()
@@ -189,7 +242,9 @@ func (f *Filesystem) TempDir() string {
 	return f.tmpdir
 }
 
+// Cleanup removes temporary files which are no longer needed.
 func (f *Filesystem) Cleanup() error {
+	tools.RemoveTempFiles()
 	if f == nil {
 		return nil
 	}
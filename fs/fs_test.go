@@ -42,3 +42,31 @@ func TestRepositoryPermissions(t *testing.T) {
 		assert.Equal(t, v, fs.RepositoryPermissions(false))
 	}
 }
+
+func TestValidateOid(t *testing.T) {
+	assert.Nil(t, ValidateOid(EmptyObjectSHA256))
+
+	for desc, oid := range map[string]string{
+		"empty":     "",
+		"too short": EmptyObjectSHA256[:63],
+		"too long":  EmptyObjectSHA256 + "0",
+		"uppercase": "E3B0C44298FC1C149AFBF4C8996FB92427AE41E4649B934CA495991B7852B855",
+		"traversal": "../../../../../../etc/passwd/" + EmptyObjectSHA256[:35],
+	} {
+		assert.NotNil(t, ValidateOid(oid), desc)
+	}
+}
+
+func TestObjectPathRejectsInvalidOid(t *testing.T) {
+	f := &Filesystem{}
+	_, err := f.ObjectPath("../../config/" + EmptyObjectSHA256[:51])
+	assert.NotNil(t, err)
+
+	_, err = f.ObjectPathname("../../config/" + EmptyObjectSHA256[:51])
+	assert.NotNil(t, err)
+	_, err = f.ObjectPathname("ab")
+	assert.NotNil(t, err)
+
+	assert.False(t, f.ObjectExists("../../config/"+EmptyObjectSHA256[:51], 0))
+	assert.False(t, NewFileStore(f).Exists("ab", 1))
+}
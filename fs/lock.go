@@ -0,0 +1,136 @@
+package fs
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/git-lfs/git-lfs/v3/tools"
+	"github.com/rubyist/tracerx"
+)
+
+var (
+	// objectLockRefresh is how often the holder of an object lock updates
+	// its lock file's modification time, to show that it is still alive.
+	objectLockRefresh = 10 * time.Second
+	// objectLockStale is how long a lock file may go without being
+	// updated before it is taken to have been left behind by a process
+	// which died, and is removed.
+	objectLockStale = time.Minute
+	// objectLockPoll is how often a process waiting for an object lock
+	// checks whether it has been released.
+	objectLockPoll = 100 * time.Millisecond
+)
+
+// ObjectLock is held by one process at a time while it writes an object into
+// the local object store, so that processes sharing the store, such as a
+// fetch in one terminal and a checkout in another, do not download the same
+// object at once. Objects are always moved into place whole, so the lock only
+// spares a process work; it is not needed to keep the store consistent.
+type ObjectLock struct {
+	path string
+
+	done chan struct{}
+	wg   sync.WaitGroup
+}
+
+// LockObject waits until it can lock the object "oid" for writing, or until
+// "ctx" is done. A lock whose holder has not updated it within objectLockStale
+// is taken over.
+func (f *Filesystem) LockObject(ctx context.Context, oid string) (*ObjectLock, error) {
+	if err := ValidateOid(oid); err != nil {
+		return nil, err
+	}
+
+	dir := filepath.Join(f.TempDir(), "locks")
+	if err := tools.MkdirAll(dir, f); err != nil {
+		return nil, err
+	}
+	path := filepath.Join(dir, oid+".lock")
+
+	for {
+		file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if err == nil {
+			host, _ := os.Hostname()
+			fmt.Fprintf(file, "%d %s\n", os.Getpid(), host)
+			file.Close()
+
+			l := &ObjectLock{path: path, done: make(chan struct{})}
+			l.wg.Add(1)
+			go l.refresh()
+			return l, nil
+		}
+		if !os.IsExist(err) {
+			return nil, err
+		}
+
+		if stat, err := os.Stat(path); err == nil && time.Since(stat.ModTime()) > objectLockStale {
+			removeStaleLock(path, stat)
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(objectLockPoll):
+		}
+	}
+}
+
+// removeStaleLock removes the lock file "path", which was found to be stale
+// with the state "stat".
+//
+// The lock is moved aside before it is removed, so that of several processes
+// finding it stale, only one removes it. Another may have removed it and taken
+// a new lock since it was found to be stale, though, in which case the file
+// which was moved is that new lock, and is put back.
+func removeStaleLock(path string, stat os.FileInfo) {
+	stale := fmt.Sprintf("%s.%d.stale", path, os.Getpid())
+	if os.Rename(path, stale) != nil {
+		return
+	}
+
+	moved, err := os.Stat(stale)
+	if err == nil && (!os.SameFile(stat, moved) || time.Since(moved.ModTime()) <= objectLockStale) {
+		// Link rather than rename it back, so as not to replace
+		// a lock taken in the meantime by yet another process.
+		if err := os.Link(stale, path); err != nil {
+			tracerx.Printf("fs: unable to restore lock %s: %s", path, err)
+		}
+	} else {
+		tracerx.Printf("fs: removing stale lock %s, last updated %s", path, stat.ModTime())
+	}
+	os.Remove(stale)
+}
+
+// refresh updates the lock file's modification time until the lock is
+// released.
+func (l *ObjectLock) refresh() {
+	defer l.wg.Done()
+
+	ticker := time.NewTicker(objectLockRefresh)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-l.done:
+			return
+		case <-ticker.C:
+			now := time.Now()
+			if err := os.Chtimes(l.path, now, now); err != nil {
+				tracerx.Printf("fs: unable to refresh lock %s: %s", l.path, err)
+			}
+		}
+	}
+}
+
+// Unlock releases the lock.
+func (l *ObjectLock) Unlock() error {
+	close(l.done)
+	l.wg.Wait()
+
+	return os.Remove(l.path)
+}
@@ -0,0 +1,103 @@
+package fs
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLockObject(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fs-lock")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	f := &Filesystem{LFSStorageDir: dir, repoPerms: 0644}
+
+	lock, err := f.LockObject(context.Background(), helloOid)
+	require.Nil(t, err)
+
+	// Another process must wait until the lock is released.
+	ctx, cancel := context.WithTimeout(context.Background(), 3*objectLockPoll)
+	defer cancel()
+	_, err = f.LockObject(ctx, helloOid)
+	assert.Equal(t, context.DeadlineExceeded, err)
+
+	require.Nil(t, lock.Unlock())
+
+	lock, err = f.LockObject(context.Background(), helloOid)
+	require.Nil(t, err)
+	assert.Nil(t, lock.Unlock())
+}
+
+func TestLockObjectTakesOverStaleLock(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fs-lock")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	f := &Filesystem{LFSStorageDir: dir, repoPerms: 0644}
+
+	// A process which died while holding the lock left it behind.
+	_, err = f.LockObject(context.Background(), helloOid)
+	require.Nil(t, err)
+
+	path := filepath.Join(f.TempDir(), "locks", helloOid+".lock")
+	old := time.Now().Add(-2 * objectLockStale)
+	require.Nil(t, os.Chtimes(path, old, old))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	lock, err := f.LockObject(ctx, helloOid)
+	require.Nil(t, err)
+	assert.Nil(t, lock.Unlock())
+
+	_, err = os.Stat(path)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestRemoveStaleLockKeepsNewLock(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, helloOid+".lock")
+
+	require.Nil(t, ioutil.WriteFile(path, []byte("1 old\n"), 0644))
+	old := time.Now().Add(-2 * objectLockStale)
+	require.Nil(t, os.Chtimes(path, old, old))
+	stat, err := os.Stat(path)
+	require.Nil(t, err)
+
+	// Another process removed the stale lock and took a new one after
+	// this one found it stale.
+	require.Nil(t, os.Remove(path))
+	require.Nil(t, ioutil.WriteFile(path, []byte("2 new\n"), 0644))
+
+	removeStaleLock(path, stat)
+
+	data, err := ioutil.ReadFile(path)
+	require.Nil(t, err)
+	assert.Equal(t, "2 new\n", string(data))
+
+	// Once that lock is stale too, it is removed.
+	require.Nil(t, os.Chtimes(path, old, old))
+	stat, err = os.Stat(path)
+	require.Nil(t, err)
+
+	removeStaleLock(path, stat)
+	_, err = os.Stat(path)
+	assert.True(t, os.IsNotExist(err))
+
+	entries, err := ioutil.ReadDir(dir)
+	require.Nil(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestLockObjectRejectsInvalidOid(t *testing.T) {
+	f := &Filesystem{LFSStorageDir: t.TempDir(), repoPerms: 0644}
+
+	_, err := f.LockObject(context.Background(), "../../oops")
+	assert.NotNil(t, err)
+}
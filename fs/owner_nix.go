@@ -0,0 +1,16 @@
+//go:build !windows
+// +build !windows
+
+package fs
+
+import (
+	"os"
+	"syscall"
+)
+
+// ownedByCurrentUser returns whether the file described by "fi" belongs to the
+// user running this process.
+func ownedByCurrentUser(fi os.FileInfo) bool {
+	stat, ok := fi.Sys().(*syscall.Stat_t)
+	return ok && int(stat.Uid) == os.Getuid()
+}
@@ -0,0 +1,14 @@
+//go:build windows
+// +build windows
+
+package fs
+
+import "os"
+
+// ownedByCurrentUser returns whether the file described by "fi" belongs to the
+// user running this process. Windows reports no owner in a file's metadata,
+// so every file is assumed to belong to the current user, and the access
+// control lists of the object store must keep other users out of it.
+func ownedByCurrentUser(fi os.FileInfo) bool {
+	return true
+}
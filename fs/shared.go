@@ -0,0 +1,166 @@
+package fs
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/git-lfs/git-lfs/v3/tools"
+	"github.com/rubyist/tracerx"
+)
+
+// SharedRepository is a repository which has been registered as using a
+// shared object store.
+type SharedRepository struct {
+	GitDir  string `json:"git_dir"`
+	WorkDir string `json:"work_dir,omitempty"`
+
+	path string
+}
+
+// Unregister removes the repository from the object store's registry.
+func (r *SharedRepository) Unregister() error {
+	return os.Remove(r.path)
+}
+
+// UsesStore returns whether the repository, whose `lfs.storage` setting is
+// "lfsdir", keeps its objects in the object store of "f". A repository which
+// registered itself may since have been configured to use another store.
+func (r *SharedRepository) UsesStore(f *Filesystem, lfsdir string) bool {
+	if len(lfsdir) == 0 {
+		lfsdir = "lfs"
+	}
+	if !filepath.IsAbs(lfsdir) {
+		lfsdir = filepath.Join(r.GitDir, lfsdir)
+	}
+
+	rel, err := filepath.Rel(canonicalPath(f.LFSStorageDir), canonicalPath(lfsdir))
+	return err == nil && rel == "."
+}
+
+// IsSharedStore returns whether the object store lies outside the repository's
+// Git directory, in which case it may be shared with other repositories which
+// set `lfs.storage` to the same directory.
+func (f *Filesystem) IsSharedStore() bool {
+	rel, err := filepath.Rel(f.GitStorageDir, f.LFSStorageDir)
+	return err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// RegisterRepository records that the repository, whose working tree is
+// "workDir" (or blank if it is bare), uses the object store, if the store is
+// shared, so that pruning in the other repositories which share it retains
+// the objects this one needs.
+func (f *Filesystem) RegisterRepository(workDir string) error {
+	if !f.IsSharedStore() {
+		return nil
+	}
+
+	gitDir, err := filepath.Abs(f.GitStorageDir)
+	if err != nil {
+		return err
+	}
+
+	path := f.sharedRepositoryPath(gitDir)
+	if tools.FileExists(path) {
+		return nil
+	}
+
+	dir := filepath.Dir(path)
+	if err := tools.MkdirAll(dir, f); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(&SharedRepository{GitDir: gitDir, WorkDir: workDir})
+	if err != nil {
+		return err
+	}
+
+	tmp, err := tools.TempFile(dir, "repo", f)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// SharedRepositories returns the other repositories which have registered
+// themselves as using the object store. Since pruning runs Git LFS in each of
+// them, registrations which are not owned by the current user, or which were
+// not written by RegisterRepository for the Git directory they name, are
+// ignored.
+func (f *Filesystem) SharedRepositories() ([]*SharedRepository, error) {
+	dir := filepath.Join(f.LFSStorageDir, "repos")
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	self := f.sharedRepositoryPath(f.GitStorageDir)
+
+	var repos []*SharedRepository
+	for _, entry := range entries {
+		path := filepath.Join(dir, entry.Name())
+		if entry.IsDir() || !strings.HasSuffix(path, ".json") || path == self {
+			continue
+		}
+
+		if !ownedByCurrentUser(entry) {
+			tracerx.Printf("fs: ignoring shared repository %s owned by another user", path)
+			continue
+		}
+
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		repo := &SharedRepository{path: path}
+		if err := json.Unmarshal(data, repo); err != nil {
+			tracerx.Printf("fs: ignoring malformed shared repository %s: %s", path, err)
+			continue
+		}
+		if !filepath.IsAbs(repo.GitDir) || f.sharedRepositoryPath(repo.GitDir) != path {
+			tracerx.Printf("fs: ignoring shared repository %s, which does not match its Git directory %q", path, repo.GitDir)
+			continue
+		}
+		repos = append(repos, repo)
+	}
+	return repos, nil
+}
+
+// canonicalPath returns "path" made absolute and with any symbolic links
+// resolved, or as it is if that is not possible.
+func canonicalPath(path string) string {
+	if canonical, err := tools.CanonicalizeSystemPath(path); err == nil {
+		return canonical
+	}
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return path
+}
+
+// sharedRepositoryPath returns the path of the file which registers the
+// repository whose Git directory is "gitDir".
+func (f *Filesystem) sharedRepositoryPath(gitDir string) string {
+	if abs, err := filepath.Abs(gitDir); err == nil {
+		gitDir = abs
+	}
+	sum := sha256.Sum256([]byte(gitDir))
+	return filepath.Join(f.LFSStorageDir, "repos", hex.EncodeToString(sum[:])+".json")
+}
@@ -0,0 +1,51 @@
+package fs
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSharedRepositories(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fs-shared")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	store := filepath.Join(dir, "store")
+	a := &Filesystem{GitStorageDir: filepath.Join(dir, "a", ".git"), LFSStorageDir: store, repoPerms: 0644}
+	b := &Filesystem{GitStorageDir: filepath.Join(dir, "b", ".git"), LFSStorageDir: store, repoPerms: 0644}
+	require.True(t, a.IsSharedStore())
+
+	require.Nil(t, a.RegisterRepository(filepath.Join(dir, "a")))
+	require.Nil(t, b.RegisterRepository(filepath.Join(dir, "b")))
+
+	// A registration which names a Git directory other than the one
+	// it was written for is ignored.
+	forged := filepath.Join(store, "repos", "forged.json")
+	require.Nil(t, ioutil.WriteFile(forged, []byte(`{"git_dir":"/tmp/elsewhere/.git"}`), 0644))
+
+	repos, err := a.SharedRepositories()
+	require.Nil(t, err)
+	require.Len(t, repos, 1)
+	assert.Equal(t, b.GitStorageDir, repos[0].GitDir)
+	assert.Equal(t, filepath.Join(dir, "b"), repos[0].WorkDir)
+}
+
+func TestSharedRepositoryUsesStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fs-shared")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	gitDir := filepath.Join(dir, "repo", ".git")
+	f := &Filesystem{LFSStorageDir: filepath.Join(dir, "store")}
+	repo := &SharedRepository{GitDir: gitDir}
+
+	assert.True(t, repo.UsesStore(f, filepath.Join(dir, "store")))
+	assert.True(t, repo.UsesStore(f, filepath.Join("..", "..", "store")))
+	assert.False(t, repo.UsesStore(f, filepath.Join(dir, "other")))
+	assert.False(t, repo.UsesStore(f, ""))
+}
@@ -0,0 +1,272 @@
+package fs
+
+import (
+	"encoding/hex"
+	"errors"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/git-lfs/git-lfs/v3/tools"
+	"github.com/git-lfs/git-lfs/v3/tr"
+)
+
+// DefaultLocalStore is the name of the LocalStore used when none is
+// configured, which keeps each object in a file named by its OID.
+const DefaultLocalStore = "file"
+
+// LocalStore is a content-addressable store of a repository's LFS objects,
+// keyed by OID. Code which reads or writes objects as a whole, such as the
+// transfer adapters, should do so through a LocalStore, so that stores other
+// than the default may be used, such as ones with an index in a database, or
+// which fetch objects lazily.
+type LocalStore interface {
+	// Get opens the object "oid" for reading.
+	Get(oid string) (io.ReadCloser, error)
+	// Put stores the contents of "r" as the object "oid", which they
+	// must hash to.
+	Put(oid string, r io.Reader) error
+	// Exists returns whether the object "oid" is stored with the given
+	// size.
+	Exists(oid string, size int64) bool
+	// Size returns the size of the stored object "oid", or an error for
+	// which os.IsNotExist is true if it is not stored.
+	Size(oid string) (int64, error)
+}
+
+// FileImporter is implemented by a LocalStore which can take ownership of a
+// complete file more cheaply than by copying its contents with Put.
+type FileImporter interface {
+	// Import moves the file at "path", whose contents have already been
+	// verified to hash to "oid", into the store as the object "oid".
+	Import(oid, path string) error
+}
+
+// ImportFile moves the file at "path", whose contents have already been
+// verified to hash to "oid", into "store" as the object "oid". The file is
+// removed afterwards, unless the store took it over.
+func ImportFile(store LocalStore, oid, path string) error {
+	if importer, ok := store.(FileImporter); ok {
+		return importer.Import(oid, path)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(path)
+	defer f.Close()
+
+	return store.Put(oid, f)
+}
+
+// FileBacked is implemented by a LocalStore which keeps each object in the file
+// named by Filesystem.ObjectPathname, as FileStore does. Only the few features
+// which need an object's own file, such as deduplicating working tree files by
+// cloning them from the store, or using objects as the bases of delta
+// transfers, require it; the rest read and write objects through the store, or
+// a temporary copy of the object, as ObjectFile gives.
+type FileBacked interface {
+	// ObjectPathname returns the path of the file holding the object
+	// "oid", or an error if "oid" is not a valid object ID.
+	ObjectPathname(oid string) (string, error)
+}
+
+// NewLocalStoreFunc returns a LocalStore for the repository whose storage
+// directories are described by "f".
+type NewLocalStoreFunc func(f *Filesystem) (LocalStore, error)
+
+var (
+	localStoresMu sync.Mutex
+	localStores   = map[string]NewLocalStoreFunc{
+		DefaultLocalStore: func(f *Filesystem) (LocalStore, error) {
+			return NewFileStore(f), nil
+		},
+	}
+)
+
+// RegisterLocalStore makes a LocalStore available under "name", which may then
+// be chosen with the lfs.storebackend configuration option.
+func RegisterLocalStore(name string, fn NewLocalStoreFunc) {
+	localStoresMu.Lock()
+	defer localStoresMu.Unlock()
+
+	localStores[name] = fn
+}
+
+// Store returns the repository's LocalStore, of the kind named by
+// StoreBackend.
+func (f *Filesystem) Store() (LocalStore, error) {
+	f.storeMu.Lock()
+	defer f.storeMu.Unlock()
+
+	if f.store != nil {
+		return f.store, nil
+	}
+
+	name := f.StoreBackend
+	if len(name) == 0 {
+		name = DefaultLocalStore
+	}
+
+	localStoresMu.Lock()
+	fn, ok := localStores[name]
+	names := make([]string, 0, len(localStores))
+	for n := range localStores {
+		names = append(names, n)
+	}
+	localStoresMu.Unlock()
+
+	if !ok {
+		sort.Strings(names)
+		return nil, errors.New(tr.Tr.Get("unknown local object store %q (expected one of: %s)", name, strings.Join(names, ", ")))
+	}
+
+	store, err := fn(f)
+	if err != nil {
+		return nil, err
+	}
+	f.store = store
+	return store, nil
+}
+
+// StoredObjectPath returns the path of the file in which the repository's
+// LocalStore keeps the object "oid", for features which need the object's own
+// file, or an error if the store does not keep objects in files.
+func (f *Filesystem) StoredObjectPath(oid string) (string, error) {
+	store, err := f.Store()
+	if err != nil {
+		return "", err
+	}
+
+	fb, ok := store.(FileBacked)
+	if !ok {
+		return "", errors.New(tr.Tr.Get("local object store %q does not keep objects in files", f.StoreBackend))
+	}
+	return fb.ObjectPathname(oid)
+}
+
+// ObjectFile returns the path of a file holding the object "oid", for callers
+// which need to read it from a file, such as to upload it. If the repository's
+// LocalStore keeps objects in files, that is the object's own file; otherwise
+// the object is copied to a temporary file. The returned function removes any
+// temporary file, and must be called once the file is no longer needed.
+func (f *Filesystem) ObjectFile(oid string) (string, func(), error) {
+	store, err := f.Store()
+	if err != nil {
+		return "", func() {}, err
+	}
+
+	if fb, ok := store.(FileBacked); ok {
+		path, err := fb.ObjectPathname(oid)
+		return path, func() {}, err
+	}
+
+	r, err := store.Get(oid)
+	if err != nil {
+		return "", func() {}, err
+	}
+	defer r.Close()
+
+	tmp, err := tools.TempFile(f.TempDir(), oid, f)
+	if err != nil {
+		return "", func() {}, err
+	}
+	remove := func() { os.Remove(tmp.Name()) }
+
+	_, err = io.Copy(tmp, r)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		remove()
+		return "", func() {}, err
+	}
+	return tmp.Name(), remove, nil
+}
+
+// FileStore is the default LocalStore, which keeps each object in a file
+// named by its OID beneath the LFS object directory, in subdirectories named
+// by the first two pairs of characters of the OID.
+type FileStore struct {
+	fs *Filesystem
+}
+
+// NewFileStore returns a FileStore for the storage directories described by
+// "f".
+func NewFileStore(f *Filesystem) *FileStore {
+	return &FileStore{fs: f}
+}
+
+func (s *FileStore) Get(oid string) (io.ReadCloser, error) {
+	path, err := s.fs.ObjectPathname(oid)
+	if err != nil {
+		return nil, err
+	}
+	return os.Open(path)
+}
+
+func (s *FileStore) Put(oid string, r io.Reader) error {
+	if err := ValidateOid(oid); err != nil {
+		return err
+	}
+
+	tmp, err := tools.TempFile(s.fs.TempDir(), oid, s.fs)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	hash := tools.NewLfsContentHash()
+	_, err = io.Copy(io.MultiWriter(tmp, hash), r)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+
+	if actual := hex.EncodeToString(hash.Sum(nil)); actual != oid {
+		return errors.New(tr.Tr.Get("expected OID %s, got %s", oid, actual))
+	}
+	return s.Import(oid, tmp.Name())
+}
+
+// Import renames the file at "path" into place as the object "oid".
+func (s *FileStore) Import(oid, path string) error {
+	dest, err := s.fs.ObjectPath(oid)
+	if err != nil {
+		return err
+	}
+
+	err = tools.RenameFileCopyPermissions(path, dest)
+	if _, serr := os.Stat(dest); serr == nil {
+		// The object is in place, even if another process put it
+		// there first.
+		return nil
+	}
+	return err
+}
+
+func (s *FileStore) ObjectPathname(oid string) (string, error) {
+	return s.fs.ObjectPathname(oid)
+}
+
+func (s *FileStore) Exists(oid string, size int64) bool {
+	return s.fs.ObjectExists(oid, size)
+}
+
+func (s *FileStore) Size(oid string) (int64, error) {
+	path, err := s.fs.ObjectPathname(oid)
+	if err != nil {
+		return 0, err
+	}
+
+	fi, err := os.Stat(path)
+	if err != nil {
+		return 0, err
+	}
+	return fi.Size(), nil
+}
@@ -0,0 +1,212 @@
+package fs
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// helloOid is the OID of the contents "hello".
+const helloOid = "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"
+
+func TestFileStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fs-store")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	f := &Filesystem{LFSStorageDir: dir, repoPerms: 0644}
+	store, err := f.Store()
+	require.Nil(t, err)
+	assert.IsType(t, &FileStore{}, store)
+
+	assert.False(t, store.Exists(helloOid, 5))
+	assert.NotNil(t, store.Put(helloOid, strings.NewReader("goodbye")))
+	assert.False(t, store.Exists(helloOid, 5))
+
+	require.Nil(t, store.Put(helloOid, strings.NewReader("hello")))
+	assert.True(t, store.Exists(helloOid, 5))
+	assert.FileExists(t, filepath.Join(dir, "objects", "2c", "f2", helloOid))
+
+	size, err := store.Size(helloOid)
+	require.Nil(t, err)
+	assert.Equal(t, int64(5), size)
+
+	r, err := store.Get(helloOid)
+	require.Nil(t, err)
+	data, err := ioutil.ReadAll(r)
+	r.Close()
+	require.Nil(t, err)
+	assert.Equal(t, "hello", string(data))
+
+	_, err = store.Get("../../config")
+	assert.NotNil(t, err)
+}
+
+func TestImportFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fs-store")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	f := &Filesystem{LFSStorageDir: dir, repoPerms: 0644}
+	store, err := f.Store()
+	require.Nil(t, err)
+
+	path := filepath.Join(dir, "download")
+	require.Nil(t, ioutil.WriteFile(path, []byte("hello"), 0644))
+	require.Nil(t, ImportFile(store, helloOid, path))
+
+	assert.True(t, store.Exists(helloOid, 5))
+	_, err = os.Stat(path)
+	assert.True(t, os.IsNotExist(err))
+}
+
+// recordingStore is a FileStore which records the objects Put to it.
+type recordingStore struct {
+	FileStore
+	puts []string
+}
+
+func (s *recordingStore) Put(oid string, r io.Reader) error {
+	s.puts = append(s.puts, oid)
+	return s.FileStore.Put(oid, r)
+}
+
+func TestRegisterLocalStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fs-store")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	_, err = (&Filesystem{StoreBackend: "missing"}).Store()
+	assert.NotNil(t, err)
+
+	var rs *recordingStore
+	RegisterLocalStore("recording", func(f *Filesystem) (LocalStore, error) {
+		rs = &recordingStore{FileStore: FileStore{fs: f}}
+		// Hide its Import method, so that ImportFile must Put.
+		return struct {
+			LocalStore
+			FileBacked
+		}{rs, rs}, nil
+	})
+	defer func() {
+		localStoresMu.Lock()
+		delete(localStores, "recording")
+		localStoresMu.Unlock()
+	}()
+
+	f := &Filesystem{LFSStorageDir: dir, StoreBackend: "recording", repoPerms: 0644}
+	store, err := f.Store()
+	require.Nil(t, err)
+
+	path := filepath.Join(dir, "download")
+	require.Nil(t, ioutil.WriteFile(path, []byte("hello"), 0644))
+	require.Nil(t, ImportFile(store, helloOid, path))
+
+	assert.Equal(t, []string{helloOid}, rs.puts)
+	assert.True(t, store.Exists(helloOid, 5))
+	_, err = os.Stat(path)
+	assert.True(t, os.IsNotExist(err))
+}
+
+// memoryStore is a LocalStore which keeps objects in memory rather than in
+// files.
+type memoryStore struct {
+	objects map[string][]byte
+}
+
+func (s *memoryStore) Get(oid string) (io.ReadCloser, error) {
+	data, ok := s.objects[oid]
+	if !ok {
+		return nil, os.ErrNotExist
+	}
+	return ioutil.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (s *memoryStore) Put(oid string, r io.Reader) error {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	s.objects[oid] = data
+	return nil
+}
+
+func (s *memoryStore) Exists(oid string, size int64) bool {
+	data, ok := s.objects[oid]
+	return ok && int64(len(data)) == size
+}
+
+func (s *memoryStore) Size(oid string) (int64, error) {
+	data, ok := s.objects[oid]
+	if !ok {
+		return 0, os.ErrNotExist
+	}
+	return int64(len(data)), nil
+}
+
+func TestObjectFileFromStoreWithoutFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fs-store")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	RegisterLocalStore("memory", func(f *Filesystem) (LocalStore, error) {
+		return &memoryStore{objects: map[string][]byte{helloOid: []byte("hello")}}, nil
+	})
+	defer func() {
+		localStoresMu.Lock()
+		delete(localStores, "memory")
+		localStoresMu.Unlock()
+	}()
+
+	f := &Filesystem{LFSStorageDir: dir, StoreBackend: "memory", repoPerms: 0644}
+
+	_, err = f.StoredObjectPath(helloOid)
+	assert.NotNil(t, err)
+
+	path, done, err := f.ObjectFile(helloOid)
+	require.Nil(t, err)
+	data, err := ioutil.ReadFile(path)
+	require.Nil(t, err)
+	assert.Equal(t, "hello", string(data))
+
+	done()
+	_, err = os.Stat(path)
+	assert.True(t, os.IsNotExist(err))
+
+	_, _, err = f.ObjectFile(strings.Repeat("0", 64))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestObjectFileFromFileStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fs-store")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	f := &Filesystem{LFSStorageDir: dir, repoPerms: 0644}
+	store, err := f.Store()
+	require.Nil(t, err)
+	require.Nil(t, store.Put(helloOid, strings.NewReader("hello")))
+
+	expected, err := f.ObjectPathname(helloOid)
+	require.Nil(t, err)
+
+	path, err := f.StoredObjectPath(helloOid)
+	require.Nil(t, err)
+	assert.Equal(t, expected, path)
+
+	path, done, err := f.ObjectFile(helloOid)
+	require.Nil(t, err)
+	assert.Equal(t, expected, path)
+
+	// The object's own file is not removed.
+	done()
+	_, err = os.Stat(path)
+	assert.Nil(t, err)
+}
@@ -7,13 +7,13 @@ import (
 	"sync"
 	"syscall"
 
-	"github.com/git-lfs/git-lfs/v3/commands"
+	"github.com/git-lfs/git-lfs/v3/internal/commands"
 	"github.com/git-lfs/git-lfs/v3/tr"
 )
 
 func main() {
 	c := make(chan os.Signal)
-	signal.Notify(c, os.Interrupt, os.Kill)
+	signal.Notify(c, os.Interrupt, os.Kill, syscall.SIGTERM)
 
 	var once sync.Once
 
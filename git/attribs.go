@@ -1,6 +1,7 @@
 package git
 
 import (
+	"bytes"
 	"io"
 	"os"
 	"path"
@@ -176,6 +177,70 @@ func GetAttributeFilter(workingDir, gitDir string) *filepathfilter.Filter {
 	return filepathfilter.NewFromPatterns(patterns, nil)
 }
 
+// GetLineEndingConvertedPaths returns the paths of the files in the index which
+// are tracked by Git LFS, but to which Git would also apply end-of-line
+// conversion, either because they have the "text" or "eol" attribute set or,
+// when autocrlf is true, because the "text" attribute is unspecified.
+// Converting the line endings of a file's pointer corrupts it.
+func GetLineEndingConvertedPaths(autocrlf bool) ([]string, error) {
+	lsFiles, err := gitNoLFS("ls-files", "-z", "--cached")
+	if err != nil {
+		return nil, err
+	}
+	files, err := lsFiles.Output()
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, nil
+	}
+
+	checkAttr, err := gitNoLFS("check-attr", "-z", "--stdin", FilterAttrib, "text", "eol")
+	if err != nil {
+		return nil, err
+	}
+	checkAttr.Stdin = bytes.NewReader(files)
+	out, err := checkAttr.Output()
+	if err != nil {
+		return nil, err
+	}
+
+	// The output is a sequence of NUL-terminated path, attribute, and
+	// value triples, with the attributes for each path given in the order
+	// in which they were requested.
+	fields := bytes.Split(out, []byte{0})
+	values := make(map[string]string, 3)
+
+	var paths []string
+	for i := 0; i+2 < len(fields); i += 3 {
+		path, attr, value := string(fields[i]), string(fields[i+1]), string(fields[i+2])
+		values[attr] = value
+		if attr != "eol" {
+			continue
+		}
+
+		if values[FilterAttrib] == "lfs" && convertsLineEndings(values["text"], values["eol"], autocrlf) {
+			paths = append(paths, path)
+		}
+		values = make(map[string]string, 3)
+	}
+	return paths, nil
+}
+
+// convertsLineEndings returns whether Git performs end-of-line conversion on a
+// file with the given values of the "text" and "eol" attributes, as reported
+// by `git check-attr`.
+func convertsLineEndings(text, eol string, autocrlf bool) bool {
+	switch text {
+	case "unset":
+		return false
+	case "unspecified":
+		return autocrlf || eol == "lf" || eol == "crlf"
+	default:
+		return true
+	}
+}
+
 func findAttributeFiles(workingDir, gitDir string) []attrFile {
 	var paths []attrFile
 
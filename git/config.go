@@ -152,6 +152,32 @@ func (c *Configuration) UnsetLocalKey(key string) (string, error) {
 	return c.gitConfigWrite("--unset", key)
 }
 
+// ListScope returns the git config in the given scope, such as "global",
+// without following any includes, as it is listed by "git config --null". Each
+// value is given as its key, a newline and the value, and ends with a NUL.
+func (c *Configuration) ListScope(scope string) (string, error) {
+	cmd, err := subprocess.ExecCommand("git", "config", "--"+scope, "--null", "--list")
+	if err != nil {
+		return "", err
+	}
+	if len(c.GitDir) > 0 {
+		cmd.Dir = c.GitDir
+	}
+	return subprocess.Output(cmd)
+}
+
+// AddScope adds a value for the key in the given scope, such as "global",
+// keeping any values it already has
+func (c *Configuration) AddScope(scope, key, val string) (string, error) {
+	return c.gitConfigWrite("--"+scope, "--add", key, val)
+}
+
+// UnsetScopeKey removes all values of the key from the given scope, such as
+// "global"
+func (c *Configuration) UnsetScopeKey(scope, key string) (string, error) {
+	return c.gitConfigWrite("--"+scope, "--unset-all", key)
+}
+
 func (c *Configuration) Sources(dir string, optionalFilename string) ([]*ConfigurationSource, error) {
 	gitconfig, err := c.Source()
 	if err != nil {
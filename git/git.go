@@ -22,6 +22,7 @@ import (
 	"time"
 
 	lfserrors "github.com/git-lfs/git-lfs/v3/errors"
+	"github.com/git-lfs/git-lfs/v3/filepathfilter"
 	"github.com/git-lfs/git-lfs/v3/subprocess"
 	"github.com/git-lfs/git-lfs/v3/tools"
 	"github.com/git-lfs/git-lfs/v3/tr"
@@ -238,7 +239,11 @@ func DiffIndex(ref string, cached bool, refresh bool) (*bufio.Scanner, error) {
 		}
 	}
 
-	args := []string{"diff-index", "-M"}
+	args := []string{
+		"diff-index",
+		"-M",
+		"-z", // Use a NUL separator. This also disables the quoting of special characters.
+	}
 	if cached {
 		args = append(args, "--cached")
 	}
@@ -252,7 +257,9 @@ func DiffIndex(ref string, cached bool, refresh bool) (*bufio.Scanner, error) {
 		return nil, err
 	}
 
-	return bufio.NewScanner(cmd.Stdout), nil
+	scanner := bufio.NewScanner(cmd.Stdout)
+	scanner.Split(tools.SplitOnNul)
+	return scanner, nil
 }
 
 func HashObject(r io.Reader) (string, error) {
@@ -600,6 +607,39 @@ func UpdateIndexFromStdin() (*subprocess.Cmd, error) {
 	return git("update-index", "-q", "--refresh", "--stdin")
 }
 
+// UpdateIndexEntry sets the index entry for the given path to the blob "sha"
+// with the given mode, without reading the working tree.
+func UpdateIndexEntry(mode, sha, path string) error {
+	_, err := gitNoLFSSimple("update-index", "--cacheinfo", fmt.Sprintf("%s,%s,%s", mode, sha, path))
+	return err
+}
+
+// PathBlobHistory returns the blobs which the given path has held in the
+// history of "ref", most recent first.
+func PathBlobHistory(ref, path string) ([]string, error) {
+	cmd, err := gitNoLFS("log", "--format=", "--raw", "--no-abbrev", "--no-renames", "-z", ref, "--", path)
+	if err != nil {
+		return nil, errors.New(tr.Tr.Get("failed to find `git log`: %v", err))
+	}
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, errors.New(tr.Tr.Get("failed to call `git log`: %v", err))
+	}
+
+	// Each change is given as ":<old mode> <new mode> <old sha> <new sha>
+	// <status>", followed by the path, each terminated by a NUL.
+	var blobs []string
+	fields := strings.Split(string(out), "\x00")
+	for i := 0; i+1 < len(fields); i += 2 {
+		change := strings.Fields(strings.TrimLeft(fields[i], "\n"))
+		if len(change) < 5 || strings.Trim(change[3], "0") == "" {
+			continue
+		}
+		blobs = append(blobs, change[3])
+	}
+	return blobs, nil
+}
+
 // RecentBranches returns branches with commit dates on or after the given date/time
 // Return full Ref type for easier detection of duplicate SHAs etc
 // since: refs with commits on or after this date will be included
@@ -1304,20 +1344,19 @@ func AllRefsIn(wd string) ([]*Ref, error) {
 }
 
 // GetTrackedFiles returns a list of files which are tracked in Git which match
-// the pattern specified (standard wildcard form)
+// the pattern specified, using the same matching rules as a pattern in a
+// .gitattributes file.
 // Both pattern and the results are relative to the current working directory, not
 // the root of the repository
 func GetTrackedFiles(pattern string) ([]string, error) {
-	safePattern := sanitizePattern(pattern)
-	rootWildcard := len(safePattern) < len(pattern) && strings.ContainsRune(safePattern, '*')
+	matcher := filepathfilter.NewPattern(pattern, filepathfilter.GitAttributes)
 
 	var ret []string
 	cmd, err := gitNoLFS(
-		"-c", "core.quotepath=false", // handle special chars in filenames
 		"ls-files",
+		"-z",       // handle special chars in filenames
 		"--cached", // include things which are staged but not committed right now
-		"--",       // no ambiguous patterns
-		safePattern)
+	)
 	if err != nil {
 		return nil, errors.New(tr.Tr.Get("failed to find `git ls-files`: %v", err))
 	}
@@ -1328,31 +1367,23 @@ func GetTrackedFiles(pattern string) ([]string, error) {
 	}
 	cmd.Start()
 	scanner := bufio.NewScanner(outp)
+	scanner.Split(tools.SplitOnNul)
 	for scanner.Scan() {
 		line := scanner.Text()
 
-		// If the given pattern is a root wildcard, skip all files which
-		// are not direct descendants of the repository's root.
-		//
-		// This matches the behavior of how .gitattributes performs
-		// filename matches.
-		if rootWildcard && filepath.Dir(line) != "." {
+		// Match each file the same way a .gitattributes pattern
+		// would, rather than relying on `git ls-files` pathspecs,
+		// whose rules differ, so that the files reported are exactly
+		// those which the new attributes will apply to.
+		if !matcher.Match(line) {
 			continue
 		}
 
-		ret = append(ret, strings.TrimSpace(line))
+		ret = append(ret, line)
 	}
 	return ret, cmd.Wait()
 }
 
-func sanitizePattern(pattern string) string {
-	if strings.HasPrefix(pattern, "/") {
-		return pattern[1:]
-	}
-
-	return pattern
-}
-
 // GetFilesChanged returns a list of files which were changed, either between 2
 // commits, or at a single commit if you only supply one argument and a blank
 // string for the other
@@ -496,12 +496,23 @@ func TestGetTrackedFiles(t *testing.T) {
 	sublist = []string{"folder1/anotherfile.txt", "folder1/file10.txt"}
 	assert.Equal(t, sublist, tracked)
 
+	// as in .gitattributes, "*" does not match across directories
 	tracked, err = GetTrackedFiles("folder2/*")
 	assert.Nil(t, err)
 	sort.Strings(tracked)
+	sublist = []string{"folder2/something.txt"}
+	assert.Equal(t, sublist, tracked)
+
+	tracked, err = GetTrackedFiles("folder2/**")
+	assert.Nil(t, err)
+	sort.Strings(tracked)
 	sublist = []string{"folder2/folder3/deep.txt", "folder2/something.txt"}
 	assert.Equal(t, sublist, tracked)
 
+	tracked, err = GetTrackedFiles("**/deep.txt")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"folder2/folder3/deep.txt"}, tracked)
+
 	// relative dir
 	os.Chdir("folder1")
 	tracked, err = GetTrackedFiles("*.txt")
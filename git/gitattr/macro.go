@@ -20,6 +20,13 @@ func NewMacroProcessor() *MacroProcessor {
 	}
 }
 
+// Macro returns the attributes to which the named macro expands, and whether
+// such a macro has been defined.
+func (mp *MacroProcessor) Macro(name string) ([]*Attr, bool) {
+	attrs, ok := mp.macros[name]
+	return attrs, ok
+}
+
 // ProcessLines reads the specified lines, returning a new set of lines which
 // all have a valid pattern.  If readMacros is true, it additionally loads any
 // macro lines as it reads them.
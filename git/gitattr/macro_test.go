@@ -183,3 +183,23 @@ func TestProcessLinesIsStateful(t *testing.T) {
 	assert.Equal(t, patternLines2[0].Attrs()[3], &Attr{K: "text", V: "false"})
 	assert.Equal(t, patternLines2[0].Attrs()[4], &Attr{K: "lfs", V: "true"})
 }
+
+func TestMacroReportsDefinedMacros(t *testing.T) {
+	lines, _, err := ParseLines(strings.NewReader(
+		"[attr]lfs filter=lfs diff=lfs merge=lfs -text\n"))
+	assert.NoError(t, err)
+
+	mp := NewMacroProcessor()
+	_, ok := mp.Macro("lfs")
+	assert.False(t, ok)
+
+	mp.ProcessLines(lines, true)
+
+	attrs, ok := mp.Macro("lfs")
+	assert.True(t, ok)
+	assert.Len(t, attrs, 4)
+	assert.Equal(t, &Attr{K: "filter", V: "lfs"}, attrs[0])
+
+	_, ok = mp.Macro("binary")
+	assert.True(t, ok)
+}
@@ -4,6 +4,7 @@ import (
 	"bufio"
 	"io/ioutil"
 	"path"
+	"strconv"
 	"strings"
 
 	"github.com/git-lfs/git-lfs/v3/errors"
@@ -98,3 +99,47 @@ func NewLsFiles(workingDir string, standardExclude bool, untracked bool) (*LsFil
 
 	return rv, nil
 }
+
+// IndexEntry is an entry in the index, as listed by `git ls-files --stage`.
+type IndexEntry struct {
+	Mode  string
+	Sha   string
+	Stage int
+	Path  string
+}
+
+// IndexEntries returns all of the entries in the index.
+func IndexEntries() ([]*IndexEntry, error) {
+	cmd, err := gitNoLFS("ls-files", "--stage", "-z")
+	if err != nil {
+		return nil, errors.New(tr.Tr.Get("failed to find `git ls-files`: %v", err))
+	}
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, errors.New(tr.Tr.Get("failed to call `git ls-files`: %v", err))
+	}
+
+	var entries []*IndexEntry
+	for _, line := range strings.Split(string(out), "\x00") {
+		// Each entry is given as "<mode> <sha> <stage>\t<path>".
+		parts := strings.SplitN(line, "\t", 2)
+		if len(parts) < 2 {
+			continue
+		}
+		fields := strings.Fields(parts[0])
+		if len(fields) < 3 {
+			continue
+		}
+		stage, err := strconv.Atoi(fields[2])
+		if err != nil {
+			return nil, errors.New(tr.Tr.Get("invalid `git ls-files` stage: %q", fields[2]))
+		}
+		entries = append(entries, &IndexEntry{
+			Mode:  fields[0],
+			Sha:   fields[1],
+			Stage: stage,
+			Path:  parts[1],
+		})
+	}
+	return entries, nil
+}
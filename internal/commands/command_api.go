@@ -0,0 +1,97 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/git-lfs/git-lfs/v3/tr"
+	"github.com/spf13/cobra"
+)
+
+var (
+	apiRemote    string
+	apiData      string
+	apiOperation string
+)
+
+func apiCommand(cmd *cobra.Command, args []string) {
+	if len(args) != 2 {
+		Exit(tr.Tr.Get("Usage: git lfs api <method> <path> [--data=<json>]"))
+	}
+
+	if len(apiRemote) > 0 {
+		cfg.SetRemote(apiRemote)
+	}
+
+	if apiOperation != "download" && apiOperation != "upload" {
+		Exit(tr.Tr.Get("Invalid operation %q: must be \"download\" or \"upload\"", apiOperation))
+	}
+
+	var body interface{}
+	if len(apiData) > 0 {
+		if !json.Valid([]byte(apiData)) {
+			Exit(tr.Tr.Get("Invalid JSON in --data: %s", apiData))
+		}
+		body = json.RawMessage(apiData)
+	}
+
+	apiClient := getAPIClient()
+	endpoint := apiClient.Endpoints.Endpoint(apiOperation, cfg.Remote())
+	req, err := apiClient.NewRequest(strings.ToUpper(args[0]), endpoint, strings.TrimPrefix(args[1], "/"), body)
+	if err != nil {
+		ExitWithError(err)
+	}
+
+	req = apiClient.LogRequest(req, "lfs.api")
+	res, err := apiClient.DoAPIRequestWithAuth(cfg.Remote(), req)
+	if res != nil {
+		printAPIResponse(res)
+	}
+	if err != nil {
+		Exit(tr.Tr.Get("API request failed: %s", err))
+	}
+}
+
+// printAPIResponse prints the status line and headers of "res", followed by
+// its body, which is indented if it is JSON.
+func printAPIResponse(res *http.Response) {
+	Print("%s %s", res.Proto, res.Status)
+
+	keys := make([]string, 0, len(res.Header))
+	for key := range res.Header {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		for _, value := range res.Header[key] {
+			Print("%s: %s", key, value)
+		}
+	}
+	Print("")
+
+	// The body of an error response may already have been read, in order
+	// to decode its message, so any failure to read it here is ignored.
+	by, _ := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+
+	var indented bytes.Buffer
+	if json.Indent(&indented, by, "", "  ") == nil {
+		by = indented.Bytes()
+	}
+	if len(by) > 0 {
+		fmt.Fprintln(OutputWriter, strings.TrimRight(string(by), "\n"))
+	}
+}
+
+func init() {
+	RegisterCommand("api", apiCommand, func(cmd *cobra.Command) {
+		cmd.Flags().StringVarP(&apiRemote, "remote", "r", "", "specify which remote's endpoint to use")
+		cmd.Flags().StringVarP(&apiData, "data", "d", "", "JSON request body")
+		cmd.Flags().StringVarP(&apiOperation, "operation", "o", "download", "use the endpoint for \"download\" or \"upload\"")
+	})
+}
@@ -39,6 +39,11 @@ func checkoutCommand(cmd *cobra.Command, args []string) {
 		Exit(tr.Tr.Get("--to and exactly one of --theirs, --ours, and --base must be used together"))
 	}
 
+	paths, usePaths := pathsFromList()
+	if usePaths && len(paths) == 0 && len(args) == 0 {
+		return
+	}
+
 	ref, err := git.CurrentRef()
 	if err != nil {
 		Panic(err, tr.Tr.Get("Could not checkout"))
@@ -46,13 +51,13 @@ func checkoutCommand(cmd *cobra.Command, args []string) {
 
 	singleCheckout := newSingleCheckout(cfg.Git, "")
 	if singleCheckout.Skip() {
-		fmt.Println(tr.Tr.Get("Cannot checkout LFS objects, Git LFS is not installed."))
+		Error(tr.Tr.Get("Cannot checkout LFS objects, Git LFS is not installed."))
 		return
 	}
 
 	var totalBytes int64
 	var pointers []*lfs.WrappedPointer
-	logger := tasklog.NewLogger(os.Stdout,
+	logger := tasklog.NewLogger(os.Stderr,
 		tasklog.ForceProgress(cfg.ForceProgress()),
 	)
 	meter := tq.NewMeter(cfg)
@@ -71,7 +76,15 @@ func checkoutCommand(cmd *cobra.Command, args []string) {
 		pointers = append(pointers, p)
 	})
 
-	chgitscanner.Filter = filepathfilter.New(rootedPaths(args), nil, filepathfilter.GitIgnore)
+	if usePaths {
+		patterns := literalPathPatterns(paths)
+		for _, p := range rootedPaths(args) {
+			patterns = append(patterns, filepathfilter.NewPattern(p, filepathfilter.GitIgnore))
+		}
+		chgitscanner.Filter = filepathfilter.NewFromPatterns(patterns, nil)
+	} else {
+		chgitscanner.Filter = filepathfilter.New(rootedPaths(args), nil, filepathfilter.GitIgnore)
+	}
 
 	if err := chgitscanner.ScanTree(ref.Sha, nil); err != nil {
 		ExitWithError(err)
@@ -94,7 +107,7 @@ func checkoutCommand(cmd *cobra.Command, args []string) {
 func checkoutConflict(file string, stage git.IndexStage) {
 	singleCheckout := newSingleCheckout(cfg.Git, "")
 	if singleCheckout.Skip() {
-		fmt.Println(tr.Tr.Get("Cannot checkout LFS objects, Git LFS is not installed."))
+		Error(tr.Tr.Get("Cannot checkout LFS objects, Git LFS is not installed."))
 		return
 	}
 
@@ -170,5 +183,6 @@ func init() {
 		cmd.Flags().BoolVar(&checkoutOurs, "ours", false, "Checkout our version of a conflicted file")
 		cmd.Flags().BoolVar(&checkoutTheirs, "theirs", false, "Checkout their version of a conflicted file")
 		cmd.Flags().BoolVar(&checkoutBase, "base", false, "Checkout the base version of a conflicted file")
+		addPathsFromFlags(cmd)
 	})
 }
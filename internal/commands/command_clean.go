@@ -44,49 +44,30 @@ func clean(gf *lfs.GitFilter, to io.Writer, from io.Reader, fileName string, fil
 		}
 	}
 
-	cleaned, err := gf.Clean(from, fileName, fileSize, cb)
+	ptr, err := gf.CleanTo(to, from, fileName, fileSize, cb)
 	if file != nil {
 		file.Close()
 	}
 
-	if cleaned != nil {
-		defer cleaned.Teardown()
-	}
-
 	if errors.IsCleanPointerError(err) {
 		// If the contents read from the working directory was _already_
 		// a pointer, we'll get a `CleanPointerError`, with the context
 		// containing the bytes that we should write back out to Git.
+		//
+		// A pointer whose line endings were converted to CRLF when it
+		// was checked out is repaired here, so that re-adding the file
+		// restores its canonical form.
 
-		_, err = to.Write(errors.GetContext(err, "bytes").([]byte))
+		_, err = to.Write(lfs.RepairPointerLineEndings(errors.GetContext(err, "bytes").([]byte)))
 		return nil, err
 	}
 
-	if err != nil {
+	// An error writing the pointer is returned along with it.
+	if err != nil && ptr == nil {
 		ExitWithError(errors.Wrap(err, tr.Tr.Get("Error cleaning Git LFS object")))
 	}
 
-	tmpfile := cleaned.Filename
-	mediafile, err := gf.ObjectPath(cleaned.Oid)
-	if err != nil {
-		Panic(err, tr.Tr.Get("Unable to get local media path."))
-	}
-
-	if stat, _ := os.Stat(mediafile); stat != nil {
-		if stat.Size() != cleaned.Size && len(cleaned.Pointer.Extensions) == 0 {
-			Exit("%s\n%s\n%s", tr.Tr.Get("Files don't match:"), mediafile, tmpfile)
-		}
-		Debug("%s exists", mediafile)
-	} else {
-		if err := os.Rename(tmpfile, mediafile); err != nil {
-			Panic(err, tr.Tr.Get("Unable to move %s to %s", tmpfile, mediafile))
-		}
-
-		Debug(tr.Tr.Get("Writing %s", mediafile))
-	}
-
-	_, err = lfs.EncodePointer(to, cleaned.Pointer)
-	return cleaned.Pointer, err
+	return ptr, err
 }
 
 func cleanCommand(cmd *cobra.Command, args []string) {
@@ -118,7 +118,10 @@ func dedup(p *lfs.WrappedPointer) (success bool, err error) {
 	}
 
 	// Do clone
-	srcFile := cfg.Filesystem().ObjectPathname(p.Oid)
+	srcFile, err := cfg.Filesystem().StoredObjectPath(p.Oid)
+	if err != nil {
+		return false, err
+	}
 	if srcFile == os.DevNull {
 		return true, nil
 	}
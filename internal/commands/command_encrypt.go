@@ -0,0 +1,77 @@
+package commands
+
+import (
+	"bufio"
+	"io"
+	"os"
+
+	"github.com/git-lfs/git-lfs/v3/errors"
+	"github.com/git-lfs/git-lfs/v3/lfs"
+	"github.com/git-lfs/git-lfs/v3/tr"
+	"github.com/spf13/cobra"
+)
+
+func encryptCommand(cmd *cobra.Command, args []string) {
+	cmd.Usage()
+}
+
+func encryptCleanCommand(cmd *cobra.Command, args []string) {
+	requireStdin(tr.Tr.Get("This command should be run by a Git LFS extension's clean command"))
+	setupRepository()
+
+	key, err := lfs.EncryptionKey(cfg)
+	if err != nil {
+		ExitWithError(err)
+	}
+
+	// The plaintext is read twice, once to derive the nonce and once to
+	// encrypt it, so it has to be buffered somewhere seekable first.
+	tmp, err := lfs.TempFile(cfg, "encrypt")
+	if err != nil {
+		ExitWithError(err)
+	}
+	defer func() {
+		tmp.Close()
+		os.Remove(tmp.Name())
+	}()
+
+	if _, err := io.Copy(tmp, os.Stdin); err != nil {
+		ExitWithError(errors.Wrap(err, tr.Tr.Get("could not read object to encrypt")))
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		ExitWithError(err)
+	}
+
+	w := bufio.NewWriter(os.Stdout)
+	if err := lfs.EncryptObject(key, tmp, w); err != nil {
+		ExitWithError(errors.Wrap(err, tr.Tr.Get("could not encrypt object")))
+	}
+	if err := w.Flush(); err != nil {
+		ExitWithError(err)
+	}
+}
+
+func encryptSmudgeCommand(cmd *cobra.Command, args []string) {
+	requireStdin(tr.Tr.Get("This command should be run by a Git LFS extension's smudge command"))
+	setupRepository()
+
+	key, err := lfs.EncryptionKey(cfg)
+	if err != nil {
+		ExitWithError(err)
+	}
+
+	w := bufio.NewWriter(os.Stdout)
+	if err := lfs.DecryptObject(key, bufio.NewReader(os.Stdin), w); err != nil {
+		ExitWithError(errors.Wrap(err, tr.Tr.Get("could not decrypt object")))
+	}
+	if err := w.Flush(); err != nil {
+		ExitWithError(err)
+	}
+}
+
+func init() {
+	RegisterCommand("encrypt", encryptCommand, func(cmd *cobra.Command) {
+		cmd.AddCommand(NewCommand("clean", encryptCleanCommand))
+		cmd.AddCommand(NewCommand("smudge", encryptSmudgeCommand))
+	})
+}
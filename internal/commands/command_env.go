@@ -1,9 +1,12 @@
 package commands
 
 import (
+	"fmt"
+
 	"github.com/git-lfs/git-lfs/v3/config"
 	"github.com/git-lfs/git-lfs/v3/git"
 	"github.com/git-lfs/git-lfs/v3/lfs"
+	"github.com/git-lfs/git-lfs/v3/lfshttp"
 	"github.com/git-lfs/git-lfs/v3/tr"
 	"github.com/spf13/cobra"
 )
@@ -25,11 +28,8 @@ func envCommand(cmd *cobra.Command, args []string) {
 		defaultRemote = cfg.Remote()
 		endpoint := getAPIClient().Endpoints.Endpoint("download", defaultRemote)
 		if len(endpoint.Url) > 0 {
-			access := getAPIClient().Endpoints.AccessFor(endpoint.Url)
-			Print("Endpoint=%s (auth=%s)", endpoint.Url, access.Mode())
-			if len(endpoint.SSHMetadata.UserAndHost) > 0 {
-				Print("  SSH=%s:%s", endpoint.SSHMetadata.UserAndHost, endpoint.SSHMetadata.Path)
-			}
+			printEndpoint("Endpoint", endpoint)
+			printPushEndpoint("PushEndpoint", defaultRemote, endpoint)
 		}
 	}
 
@@ -38,11 +38,8 @@ func envCommand(cmd *cobra.Command, args []string) {
 			continue
 		}
 		remoteEndpoint := getAPIClient().Endpoints.Endpoint("download", remote)
-		remoteAccess := getAPIClient().Endpoints.AccessFor(remoteEndpoint.Url)
-		Print("Endpoint (%s)=%s (auth=%s)", remote, remoteEndpoint.Url, remoteAccess.Mode())
-		if len(remoteEndpoint.SSHMetadata.UserAndHost) > 0 {
-			Print("  SSH=%s:%s", remoteEndpoint.SSHMetadata.UserAndHost, remoteEndpoint.SSHMetadata.Path)
-		}
+		printEndpoint(fmt.Sprintf("Endpoint (%s)", remote), remoteEndpoint)
+		printPushEndpoint(fmt.Sprintf("PushEndpoint (%s)", remote), remote, remoteEndpoint)
 	}
 
 	for _, env := range lfs.Environ(cfg, getTransferManifest(), oldEnv) {
@@ -55,6 +52,24 @@ func envCommand(cmd *cobra.Command, args []string) {
 	}
 }
 
+func printEndpoint(label string, endpoint lfshttp.Endpoint) {
+	access := getAPIClient().Endpoints.AccessFor(endpoint.Url)
+	Print("%s=%s (auth=%s)", label, endpoint.Url, access.Mode())
+	if len(endpoint.SSHMetadata.UserAndHost) > 0 {
+		Print("  SSH=%s:%s", endpoint.SSHMetadata.UserAndHost, endpoint.SSHMetadata.Path)
+	}
+}
+
+// printPushEndpoint prints the endpoint used to push to the given remote, if
+// it differs from the endpoint "download" used to fetch from it, such as when
+// "lfs.pushurl" or "remote.<name>.lfspushurl" is set.
+func printPushEndpoint(label, remote string, download lfshttp.Endpoint) {
+	upload := getAPIClient().Endpoints.Endpoint("upload", remote)
+	if len(upload.Url) > 0 && upload.Url != download.Url {
+		printEndpoint(label, upload)
+	}
+}
+
 func init() {
 	RegisterCommand("env", envCommand, nil)
 }
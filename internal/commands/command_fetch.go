@@ -19,6 +19,11 @@ var (
 	fetchRecentArg bool
 	fetchAllArg    bool
 	fetchPruneArg  bool
+	fetchDryRunArg bool
+
+	// fetchDryRun reports the objects which would be fetched, if
+	// --dry-run was given.
+	fetchDryRun *dryRunReport
 )
 
 func getIncludeExcludeArgs(cmd *cobra.Command) (include, exclude *string) {
@@ -60,10 +65,27 @@ func fetchCommand(cmd *cobra.Command, args []string) {
 		refs = []*git.Ref{ref}
 	}
 
+	if fetchDryRunArg {
+		fetchDryRun = newDryRunReport(tr.Tr.Get("fetch"))
+	}
+
 	success := true
 	include, exclude := getIncludeExcludeArgs(cmd)
 	fetchPruneCfg := lfs.NewFetchPruneConfig(cfg.Git)
 
+	paths, usePaths := pathsFromList()
+	if usePaths {
+		if fetchAllArg {
+			Exit(tr.Tr.Get("Cannot combine --all with --paths-from"))
+		}
+		if include != nil {
+			Exit(tr.Tr.Get("Cannot combine --include with --paths-from"))
+		}
+		if len(paths) == 0 {
+			return
+		}
+	}
+
 	if fetchAllArg {
 		if fetchRecentArg {
 			Exit(tr.Tr.Get("Cannot combine --all with --recent"))
@@ -72,7 +94,7 @@ func fetchCommand(cmd *cobra.Command, args []string) {
 			Exit(tr.Tr.Get("Cannot combine --all with --include or --exclude"))
 		}
 		if len(cfg.FetchIncludePaths()) > 0 || len(cfg.FetchExcludePaths()) > 0 {
-			Print(tr.Tr.Get("Ignoring global include / exclude paths to fulfil --all"))
+			Error(tr.Tr.Get("Ignoring global include / exclude paths to fulfil --all"))
 		}
 
 		if len(args) > 1 {
@@ -86,11 +108,16 @@ func fetchCommand(cmd *cobra.Command, args []string) {
 		}
 
 	} else { // !all
-		filter := buildFilepathFilter(cfg, include, exclude, true)
+		var filter *filepathfilter.Filter
+		if usePaths {
+			filter = buildPathsFromFilter(literalPathPatterns(paths), exclude, true)
+		} else {
+			filter = buildFilepathFilter(cfg, include, exclude, true)
+		}
 
 		// Fetch refs sequentially per arg order; duplicates in later refs will be ignored
 		for _, ref := range refs {
-			Print("fetch: %s", tr.Tr.Get("Fetching reference %s", ref.Refspec()))
+			Error("fetch: %s", tr.Tr.Get("Fetching reference %s", ref.Refspec()))
 			s := fetchRef(ref.Sha, filter)
 			success = success && s
 		}
@@ -101,10 +128,12 @@ func fetchCommand(cmd *cobra.Command, args []string) {
 		}
 	}
 
+	fetchDryRun.Summarize()
+
 	if fetchPruneArg {
 		verify := fetchPruneCfg.PruneVerifyRemoteAlways
-		// no dry-run or verbose options in fetch, assume false
-		prune(fetchPruneCfg, verify, false, false)
+		// no verbose option in fetch, assume false
+		prune(fetchPruneCfg, verify, fetchDryRunArg, false)
 	}
 
 	if !success {
@@ -150,7 +179,7 @@ func fetchRef(ref string, filter *filepathfilter.Filter) bool {
 
 func pointersToFetchForRefs(refs []string) ([]*lfs.WrappedPointer, error) {
 	// This could be a long process so use the chan version & report progress
-	logger := tasklog.NewLogger(OutputWriter,
+	logger := tasklog.NewLogger(os.Stderr,
 		tasklog.ForceProgress(cfg.ForceProgress()),
 	)
 	task := logger.Simple()
@@ -227,7 +256,7 @@ func fetchRecent(fetchconf lfs.FetchPruneConfig, alreadyFetchedRefs []*git.Ref,
 	}
 	// First find any other recent refs
 	if fetchconf.FetchRecentRefsDays > 0 {
-		Print("fetch: %s", tr.Tr.GetN(
+		Error("fetch: %s", tr.Tr.GetN(
 			"Fetching recent branches within %v day",
 			"Fetching recent branches within %v days",
 			fetchconf.FetchRecentRefsDays,
@@ -246,7 +275,7 @@ func fetchRecent(fetchconf lfs.FetchPruneConfig, alreadyFetchedRefs []*git.Ref,
 				}
 			} else {
 				uniqueRefShas[ref.Sha] = ref.Name
-				Print("fetch: %s", tr.Tr.Get("Fetching reference %s", ref.Name))
+				Error("fetch: %s", tr.Tr.Get("Fetching reference %s", ref.Name))
 				k := fetchRef(ref.Sha, filter)
 				ok = ok && k
 			}
@@ -261,7 +290,7 @@ func fetchRecent(fetchconf lfs.FetchPruneConfig, alreadyFetchedRefs []*git.Ref,
 				Error(tr.Tr.Get("Couldn't scan commits at %v: %v", refName, err))
 				continue
 			}
-			Print("fetch: %s", tr.Tr.GetN(
+			Error("fetch: %s", tr.Tr.GetN(
 				"Fetching changes within %v day of %v",
 				"Fetching changes within %v days of %v",
 				fetchconf.FetchRecentCommitsDays,
@@ -279,13 +308,13 @@ func fetchRecent(fetchconf lfs.FetchPruneConfig, alreadyFetchedRefs []*git.Ref,
 
 func fetchAll() bool {
 	pointers := scanAll()
-	Print("fetch: %s", tr.Tr.Get("Fetching all references..."))
+	Error("fetch: %s", tr.Tr.Get("Fetching all references..."))
 	return fetchAndReportToChan(pointers, nil, nil)
 }
 
 func scanAll() []*lfs.WrappedPointer {
 	// This could be a long process so use the chan version & report progress
-	logger := tasklog.NewLogger(OutputWriter,
+	logger := tasklog.NewLogger(os.Stderr,
 		tasklog.ForceProgress(cfg.ForceProgress()),
 	)
 	task := logger.Simple()
@@ -327,8 +356,9 @@ func fetchAndReportToChan(allpointers []*lfs.WrappedPointer, filter *filepathfil
 	ready, pointers, meter := readyAndMissingPointers(allpointers, filter)
 	q := newDownloadQueue(
 		getTransferManifestOperationRemote("download", cfg.Remote()),
-		cfg.Remote(), tq.WithProgress(meter),
+		cfg.Remote(), tq.WithProgress(meter), tq.DryRun(fetchDryRun != nil),
 	)
+	fetchDryRun.Watch(q)
 
 	if out != nil {
 		// If we already have it, or it won't be fetched
@@ -380,10 +410,10 @@ func fetchAndReportToChan(allpointers []*lfs.WrappedPointer, filter *filepathfil
 }
 
 func readyAndMissingPointers(allpointers []*lfs.WrappedPointer, filter *filepathfilter.Filter) ([]*lfs.WrappedPointer, []*lfs.WrappedPointer, *tq.Meter) {
-	logger := tasklog.NewLogger(os.Stdout,
+	logger := tasklog.NewLogger(os.Stderr,
 		tasklog.ForceProgress(cfg.ForceProgress()),
 	)
-	meter := buildProgressMeter(false, tq.Download)
+	meter := buildProgressMeter(fetchDryRun != nil, tq.Download)
 	logger.Enqueue(meter)
 
 	seen := make(map[string]bool, len(allpointers))
@@ -419,5 +449,7 @@ func init() {
 		cmd.Flags().BoolVarP(&fetchRecentArg, "recent", "r", false, "Fetch recent refs & commits")
 		cmd.Flags().BoolVarP(&fetchAllArg, "all", "a", false, "Fetch all LFS files ever referenced")
 		cmd.Flags().BoolVarP(&fetchPruneArg, "prune", "p", false, "After fetching, prune old data")
+		cmd.Flags().BoolVarP(&fetchDryRunArg, "dry-run", "d", false, "Don't fetch anything, just report what would be fetched")
+		addPathsFromFlags(cmd)
 	})
 }
@@ -65,12 +65,26 @@ func filterCommand(cmd *cobra.Command, args []string) {
 
 	ptrs := make(map[string]*lfs.Pointer)
 
+	// Git asks for files in working tree order, so give each object it
+	// delays a lower priority than the last, and have the queue download
+	// the files nearest the start of the tree first.
+	var priority int
+
 	var q *tq.TransferQueue
 	var malformed []string
 	var malformedOnWindows []string
 	var closeOnce *sync.Once
 	var available chan *tq.Transfer
 	gitfilter := lfs.NewGitFilter(cfg)
+
+	// Objects which Git does not let us delay are each downloaded by a
+	// queue of their own. Have those queues record their transfers
+	// alongside the delayed ones, so that this process reports on the
+	// whole checkout at once.
+	gitfilter.SetTransferOptions(
+		tq.WithStats(getTransferStats()),
+		tq.WithProgressDir(transferProgressDir()),
+	)
 	for s.Scan() {
 		var n int64
 		var err error
@@ -108,6 +122,8 @@ func filterCommand(cmd *cobra.Command, args []string) {
 					getTransferManifestOperationRemote("download", cfg.Remote()),
 					cfg.Remote(),
 					tq.RemoteRef(currentRemoteRef()),
+					tq.WithStats(getTransferStats()),
+					tq.WithProgressDir(transferProgressDir()),
 				)
 				go infiniteTransferBuffer(q, available)
 			}
@@ -116,10 +132,11 @@ func filterCommand(cmd *cobra.Command, args []string) {
 			if req.Header["can-delay"] == "1" {
 				var ptr *lfs.Pointer
 
-				n, delayed, ptr, err = delayedSmudge(gitfilter, s, w, req.Payload, q, req.Header["pathname"], skip, filter)
+				n, delayed, ptr, err = delayedSmudge(gitfilter, s, w, req.Payload, q, req.Header["pathname"], priority, skip, filter)
 
 				if delayed {
 					ptrs[req.Header["pathname"]] = ptr
+					priority--
 				}
 			} else {
 				s.WriteStatus(statusFromErr(nil))
@@ -11,6 +11,7 @@ import (
 
 	"github.com/git-lfs/git-lfs/v3/errors"
 	"github.com/git-lfs/git-lfs/v3/filepathfilter"
+	"github.com/git-lfs/git-lfs/v3/fs"
 	"github.com/git-lfs/git-lfs/v3/git"
 	"github.com/git-lfs/git-lfs/v3/lfs"
 	"github.com/git-lfs/git-lfs/v3/tools"
@@ -99,6 +100,16 @@ func fsckCommand(cmd *cobra.Command, args []string) {
 		os.Exit(1)
 	}
 
+	// Corrupt objects are moved aside as files, so they can only be
+	// repaired in stores which keep objects in files.
+	store, err := cfg.Filesystem().Store()
+	if err != nil {
+		ExitWithError(err)
+	}
+	if _, ok := store.(fs.FileBacked); !ok {
+		Exit(tr.Tr.Get("objects: repair: local object store %q does not keep objects in files", cfg.Filesystem().StoreBackend))
+	}
+
 	badDir := filepath.Join(cfg.LFSStorageDir(), "bad")
 	Print("objects: repair: %s", tr.Tr.Get("moving corrupt objects to %s", badDir))
 
@@ -108,8 +119,8 @@ func fsckCommand(cmd *cobra.Command, args []string) {
 
 	for _, oid := range corruptOids {
 		badFile := filepath.Join(badDir, oid)
-		srcFile := cfg.Filesystem().ObjectPathname(oid)
-		if srcFile == os.DevNull {
+		srcFile, err := cfg.Filesystem().ObjectPathname(oid)
+		if err != nil || srcFile == os.DevNull {
 			continue
 		}
 		if err := os.Rename(srcFile, badFile); err != nil {
@@ -212,17 +223,28 @@ func doFsckPointers(include, exclude string) []corruptPointer {
 }
 
 func fsckPointer(name, oid string, size int64) (bool, error) {
-	path := cfg.Filesystem().ObjectPathname(oid)
+	path, err := cfg.Filesystem().ObjectPathname(oid)
+	if err != nil {
+		return false, err
+	}
 
 	Debug(tr.Tr.Get("Examining %v (%v)", name, path))
 
-	f, err := os.Open(path)
-	if pErr, pOk := err.(*os.PathError); pOk {
+	store, err := cfg.Filesystem().Store()
+	if err != nil {
+		return false, err
+	}
+
+	f, err := store.Get(oid)
+	if pErr, pOk := err.(*os.PathError); pOk || os.IsNotExist(err) {
 		// This is an empty file.  No problem here.
 		if size == 0 {
 			return true, nil
 		}
-		Print("objects: openError: %s", tr.Tr.Get("%s (%s) could not be checked: %s", name, oid, pErr.Err))
+		if pOk {
+			err = pErr.Err
+		}
+		Print("objects: openError: %s", tr.Tr.Get("%s (%s) could not be checked: %s", name, oid, err))
 		return false, nil
 	}
 
@@ -2,9 +2,12 @@ package commands
 
 import (
 	"os"
+	"sort"
 
+	"github.com/git-lfs/git-lfs/v3/errors"
 	"github.com/git-lfs/git-lfs/v3/git"
 	"github.com/git-lfs/git-lfs/v3/lfs"
+	"github.com/git-lfs/git-lfs/v3/tools/humanize"
 	"github.com/git-lfs/git-lfs/v3/tr"
 	"github.com/spf13/cobra"
 )
@@ -29,6 +32,7 @@ func installCommand(cmd *cobra.Command, args []string) {
 
 	if !skipRepoInstall && (localInstall || worktreeInstall || cfg.InRepo()) {
 		installHooksCommand(cmd, args)
+		adoptGitMedia()
 	}
 
 	Print(tr.Tr.Get("Git LFS initialized."))
@@ -77,6 +81,56 @@ func cmdInstallOptions() *lfs.FilterOptions {
 	}
 }
 
+// adoptGitMedia converts what git-media left in the current repository, if it
+// was used there, for use by Git LFS, and reports what it did. Once the
+// attributes have been converted and the objects imported, it does nothing.
+func adoptGitMedia() {
+	r := lfs.DetectGitMedia(cfg)
+	if r == nil || (len(r.Attributes) == 0 && (len(r.ObjectDir) == 0 || r.Imported)) {
+		return
+	}
+
+	Print(tr.Tr.Get("This repository was set up for git-media; migrating it to Git LFS:"))
+
+	if len(r.Attributes) > 0 {
+		if err := r.ConvertAttributes(); err != nil {
+			ExitWithError(errors.Wrap(err, tr.Tr.Get("Unable to convert git-media attributes")))
+		}
+		for _, attrs := range r.Attributes {
+			for _, line := range attrs.Lines {
+				Print("  %s: %q -> %q", attrs.Path, line, lfs.ConvertGitMediaAttribute(line))
+			}
+		}
+	}
+
+	if len(r.ObjectDir) > 0 && !r.Imported {
+		report, err := r.ImportObjects(cfg)
+		if err != nil {
+			ExitWithError(errors.Wrap(err, tr.Tr.Get("Unable to import git-media objects")))
+		}
+		Print("  %s", tr.Tr.GetN(
+			"imported %d object (%s) from %s, %d already present",
+			"imported %d objects (%s) from %s, %d already present",
+			report.Imported,
+			report.Imported,
+			humanize.FormatBytes(uint64(report.Size)),
+			r.ObjectDir,
+			report.Present,
+		))
+	}
+
+	keys := make([]string, 0, len(r.Filters))
+	for key := range r.Filters {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		Print("  %s", tr.Tr.Get("left %s = %q in place, for commits which still use git-media", key, r.Filters[key]))
+	}
+
+	Print(tr.Tr.Get("Review and commit the changes to the attributes files, then run `git add --renormalize .` to store the files with Git LFS."))
+}
+
 func installHooksCommand(cmd *cobra.Command, args []string) {
 	updateForce = forceInstall
 
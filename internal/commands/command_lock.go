@@ -28,6 +28,10 @@ func lockCommand(cmd *cobra.Command, args []string) {
 	lockClient.RemoteRef = refUpdate.RemoteRef()
 	defer lockClient.Close()
 
+	if paths, ok := pathsFromList(); ok {
+		args = append(args, paths...)
+	}
+
 	success := true
 	locks := make([]locking.Lock, 0, len(args))
 	for _, path := range args {
@@ -130,5 +134,6 @@ func init() {
 	RegisterCommand("lock", lockCommand, func(cmd *cobra.Command) {
 		cmd.Flags().StringVarP(&lockRemote, "remote", "r", "", "specify which remote to use when interacting with locks")
 		cmd.Flags().BoolVarP(&locksCmdFlags.JSON, "json", "", false, "print output in json")
+		addPathsFromFlags(cmd)
 	})
 }
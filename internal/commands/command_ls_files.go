@@ -2,6 +2,7 @@ package commands
 
 import (
 	"encoding/json"
+	"fmt"
 	"os"
 	"strings"
 
@@ -20,6 +21,7 @@ var (
 	lsFilesShowSize     = false
 	lsFilesShowNameOnly = false
 	lsFilesJSON         = false
+	lsFilesNullTerm     = false
 	debug               = false
 )
 
@@ -73,6 +75,10 @@ func lsFilesCommand(cmd *cobra.Command, args []string) {
 		}
 	}
 
+	if lsFilesNullTerm && (debug || lsFilesJSON) {
+		Exit(tr.Tr.Get("Cannot combine -z with --debug or --json"))
+	}
+
 	showOidLen := 10
 	if longOIDs {
 		showOidLen = 64
@@ -129,7 +135,11 @@ func lsFilesCommand(cmd *cobra.Command, args []string) {
 				msg = append(msg, "("+size+")")
 			}
 
-			Print(strings.Join(msg, " "))
+			if lsFilesNullTerm {
+				fmt.Fprintf(os.Stdout, "%s\x00", strings.Join(msg, " "))
+			} else {
+				Print(strings.Join(msg, " "))
+			}
 		}
 
 		seen[p.Name] = struct{}{}
@@ -203,5 +213,6 @@ func init() {
 		cmd.Flags().StringVarP(&includeArg, "include", "I", "", "Include a list of paths")
 		cmd.Flags().StringVarP(&excludeArg, "exclude", "X", "", "Exclude a list of paths")
 		cmd.Flags().BoolVarP(&lsFilesJSON, "json", "", false, "print output in JSON")
+		cmd.Flags().BoolVarP(&lsFilesNullTerm, "null", "z", false, "terminate each line with NUL instead of newline")
 	})
 }
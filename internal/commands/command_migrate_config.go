@@ -0,0 +1,163 @@
+package commands
+
+import (
+	"strings"
+
+	"github.com/git-lfs/git-lfs/v3/config"
+	"github.com/git-lfs/git-lfs/v3/git"
+	"github.com/git-lfs/git-lfs/v3/tr"
+	"github.com/rubyist/tracerx"
+	"github.com/spf13/cobra"
+)
+
+var (
+	migrateConfigDryRun bool
+	migrateConfigSystem bool
+	migrateConfigGlobal bool
+	migrateConfigLocal  bool
+)
+
+// configEntry is a key set in a scope of the Git configuration, with each of
+// its values in order.
+type configEntry struct {
+	key    string
+	values []string
+}
+
+func migrateConfigCommand(cmd *cobra.Command, args []string) {
+	requireGitVersion()
+
+	var scopes []string
+	if migrateConfigSystem {
+		scopes = append(scopes, "system")
+	}
+	if migrateConfigGlobal {
+		scopes = append(scopes, "global")
+	}
+	if migrateConfigLocal {
+		setupRepository()
+		scopes = append(scopes, "local")
+	}
+	if len(scopes) == 0 {
+		scopes = []string{"system", "global"}
+		if cfg.InRepo() {
+			scopes = append(scopes, "local")
+		}
+	}
+
+	var migrated int
+	var failed bool
+	for _, scope := range scopes {
+		n, ok := migrateConfigScope(cfg.GitConfig(), scope)
+		migrated += n
+		failed = failed || !ok
+	}
+
+	if migrated == 0 {
+		Print(tr.Tr.Get("No outdated configuration found."))
+	}
+	if failed {
+		Exit(tr.Tr.Get("Not all configuration could be migrated."))
+	}
+}
+
+// migrateConfigScope rewrites each outdated key in the given scope, returning
+// the number of keys it rewrote, or would have in a dry run, and whether it
+// could rewrite them all.
+func migrateConfigScope(gitConfig *git.Configuration, scope string) (int, bool) {
+	out, err := gitConfig.ListScope(scope)
+	if err != nil {
+		// Git fails to list a scope whose file does not exist.
+		tracerx.Printf("migrate-config: unable to read %s configuration: %s", scope, err)
+		return 0, true
+	}
+	entries := parseConfigList(out)
+
+	set := make(map[string]bool, len(entries))
+	for _, e := range entries {
+		set[strings.ToLower(e.key)] = true
+	}
+
+	var migrated int
+	ok := true
+	for _, e := range entries {
+		current, outdated := config.MigrateKey(e.key)
+		if !outdated {
+			continue
+		}
+		migrated++
+
+		var dest string
+		switch {
+		case len(current) == 0:
+			Print(tr.Tr.Get("%s: remove %s, which is no longer used", scope, e.key))
+		case set[strings.ToLower(current)]:
+			Print(tr.Tr.Get("%s: remove %s, since %s is already set", scope, e.key, current))
+		default:
+			Print(tr.Tr.Get("%s: rename %s to %s", scope, e.key, current))
+			dest = current
+			set[strings.ToLower(current)] = true
+		}
+		if migrateConfigDryRun {
+			continue
+		}
+
+		if err := migrateConfigEntry(gitConfig, scope, e, dest); err != nil {
+			Error(tr.Tr.Get("%s: could not migrate %s: %s", scope, e.key, err))
+			ok = false
+		}
+	}
+	return migrated, ok
+}
+
+// migrateConfigEntry copies the values of "e" to the key "dest" in the given
+// scope, unless it is empty, and then removes "e".
+func migrateConfigEntry(gitConfig *git.Configuration, scope string, e *configEntry, dest string) error {
+	if len(dest) > 0 {
+		for _, value := range e.values {
+			if _, err := gitConfig.AddScope(scope, dest, value); err != nil {
+				return err
+			}
+		}
+	}
+
+	_, err := gitConfig.UnsetScopeKey(scope, e.key)
+	return err
+}
+
+// parseConfigList parses the output of git.Configuration.ListScope into the
+// keys it lists, in the order in which they are first set.
+func parseConfigList(out string) []*configEntry {
+	var entries []*configEntry
+	byKey := make(map[string]*configEntry)
+
+	for _, item := range strings.Split(out, "\x00") {
+		if len(item) == 0 {
+			continue
+		}
+
+		// A key without a value is a boolean which is true.
+		key, value := item, "true"
+		if i := strings.Index(item, "\n"); i >= 0 {
+			key, value = item[:i], item[i+1:]
+		}
+
+		e, ok := byKey[key]
+		if !ok {
+			e = &configEntry{key: key}
+			byKey[key] = e
+			entries = append(entries, e)
+		}
+		e.values = append(e.values, value)
+	}
+	return entries
+}
+
+func init() {
+	RegisterCommand("migrate-config", migrateConfigCommand, func(cmd *cobra.Command) {
+		cmd.Flags().BoolVarP(&migrateConfigDryRun, "dry-run", "d", false, "report what would be migrated without changing anything")
+		cmd.Flags().BoolVarP(&migrateConfigSystem, "system", "", false, "migrate the system configuration")
+		cmd.Flags().BoolVarP(&migrateConfigGlobal, "global", "", false, "migrate the global configuration")
+		cmd.Flags().BoolVarP(&migrateConfigLocal, "local", "", false, "migrate the repository's configuration")
+	})
+}
@@ -171,9 +171,8 @@ func migrateImportCommand(cmd *cobra.Command, args []string) {
 				}
 			}
 
-			var buf bytes.Buffer
-
-			if _, err := clean(gitfilter, &buf, b.Contents, path, b.Size); err != nil {
+			blob, err := gitfilter.ImportBlob(path, b)
+			if err != nil {
 				return nil, err
 			}
 
@@ -183,9 +182,7 @@ func migrateImportCommand(cmd *cobra.Command, args []string) {
 				exts.Add(fmt.Sprintf("/%s filter=lfs diff=lfs merge=lfs -text", escapeGlobCharacters(path)))
 			}
 
-			return &gitobj.Blob{
-				Contents: &buf, Size: int64(buf.Len()),
-			}, nil
+			return blob, nil
 		},
 
 		TreePreCallbackFn: func(path string, t *gitobj.Tree) error {
@@ -404,17 +401,12 @@ func rewriteTree(gf *lfs.GitFilter, db *gitobj.ObjectDatabase, root []byte, path
 			return nil, err
 		}
 
-		var buf bytes.Buffer
-
-		if _, err := clean(gf, &buf, blob.Contents, blobEntry.Name, blob.Size); err != nil {
+		pointer, err := gf.ImportBlob(blobEntry.Name, blob)
+		if err != nil {
 			return nil, err
 		}
 
-		newOid, err := db.WriteBlob(&gitobj.Blob{
-			Contents: &buf,
-			Size:     int64(buf.Len()),
-		})
-
+		newOid, err := db.WriteBlob(pointer)
 		if err != nil {
 			return nil, err
 		}
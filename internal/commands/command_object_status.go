@@ -0,0 +1,167 @@
+package commands
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+
+	"github.com/git-lfs/git-lfs/v3/errors"
+	"github.com/git-lfs/git-lfs/v3/filepathfilter"
+	"github.com/git-lfs/git-lfs/v3/fs"
+	"github.com/git-lfs/git-lfs/v3/git"
+	"github.com/git-lfs/git-lfs/v3/lfs"
+	"github.com/git-lfs/git-lfs/v3/tr"
+	"github.com/spf13/cobra"
+)
+
+var (
+	objectStatusJSON    = false
+	objectStatusOffline = false
+)
+
+func objectStatusCommand(cmd *cobra.Command, args []string) {
+	setupWorkingCopy()
+
+	if list, ok := pathsFromList(); ok {
+		args = append(args, list...)
+	}
+
+	// An argument is taken to be an OID unless there is a file by that
+	// name.
+	var paths, oids []string
+	for _, arg := range args {
+		if _, err := os.Stat(arg); err != nil && fs.ValidateOid(arg) == nil {
+			oids = append(oids, arg)
+		} else {
+			paths = append(paths, arg)
+		}
+	}
+
+	var pointers []*lfs.WrappedPointer
+	if len(args) == 0 || len(paths) > 0 {
+		pointers = objectStatusPointers(paths)
+	}
+	for _, oid := range oids {
+		pointers = append(pointers, &lfs.WrappedPointer{Pointer: lfs.NewPointer(oid, 0, nil)})
+	}
+
+	var statuses []*lfs.ObjectStatus
+	if objectStatusOffline {
+		gf := lfs.NewGitFilter(cfg)
+		for _, p := range pointers {
+			status, err := gf.ObjectStatus(p)
+			if err != nil {
+				ExitWithError(err)
+			}
+			statuses = append(statuses, status)
+		}
+	} else if len(pointers) > 0 {
+		client, err := lfs.NewClient(cfg, "")
+		if err != nil {
+			ExitWithError(err)
+		}
+		statuses, err = client.ObjectStatus(pointers...)
+		if err != nil {
+			ExitWithError(errors.Wrap(err, tr.Tr.Get("Could not get object status")))
+		}
+	}
+
+	if objectStatusJSON {
+		if statuses == nil {
+			statuses = []*lfs.ObjectStatus{}
+		}
+		data := struct {
+			Objects []*lfs.ObjectStatus `json:"objects"`
+		}{Objects: statuses}
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", " ")
+		if err := encoder.Encode(data); err != nil {
+			ExitWithError(err)
+		}
+		return
+	}
+
+	for _, status := range statuses {
+		name := status.Name
+		if len(name) == 0 {
+			name = status.Oid
+		}
+		Print("%s %s", objectStatusStates(status), name)
+	}
+}
+
+// objectStatusPointers returns the pointer of each Git LFS file in the index
+// whose path is one of "paths", or of every Git LFS file in the index if none
+// are given. A file which is staged is described by its staged pointer rather
+// than the one in HEAD.
+func objectStatusPointers(paths []string) []*lfs.WrappedPointer {
+	ref := "HEAD"
+	if _, err := git.CurrentRef(); err != nil {
+		ref, err = git.EmptyTree()
+		if err != nil {
+			ExitWithError(errors.Wrap(
+				err, tr.Tr.Get("Could not read empty Git tree object")))
+		}
+	}
+
+	seen := make(map[string]struct{})
+	var pointers []*lfs.WrappedPointer
+
+	gitscanner := lfs.NewGitScanner(cfg, func(p *lfs.WrappedPointer, err error) {
+		if err != nil {
+			Exit(tr.Tr.Get("Could not scan for Git LFS files: %s", err))
+			return
+		}
+		if _, ok := seen[p.Name]; ok {
+			return
+		}
+		seen[p.Name] = struct{}{}
+		pointers = append(pointers, p)
+	})
+	if len(paths) > 0 {
+		gitscanner.Filter = filepathfilter.NewFromPatterns(literalPathPatterns(paths), nil)
+	}
+
+	if err := gitscanner.ScanIndex(ref, nil); err != nil {
+		Exit(tr.Tr.Get("Could not scan for Git LFS index: %s", err))
+	}
+	if err := gitscanner.ScanTree(ref, nil); err != nil {
+		Exit(tr.Tr.Get("Could not scan for Git LFS tree: %s", err))
+	}
+	return pointers
+}
+
+// objectStatusStates returns the comma-separated states of the object
+// described by "status", or "-" if it is in none of them.
+func objectStatusStates(status *lfs.ObjectStatus) string {
+	var states []string
+	if status.Pointer {
+		states = append(states, "pointer")
+	}
+	if status.Modified {
+		states = append(states, "modified")
+	}
+	if status.Local {
+		states = append(states, "local")
+	}
+	if status.Pushed != nil {
+		if *status.Pushed {
+			states = append(states, "pushed")
+		} else {
+			states = append(states, "unpushed")
+		}
+	}
+
+	if len(states) == 0 {
+		return "-"
+	}
+	return strings.Join(states, ",")
+}
+
+func init() {
+	RegisterCommand("object-status", objectStatusCommand, func(cmd *cobra.Command) {
+		cmd.Flags().BoolVarP(&objectStatusJSON, "json", "", false, "print output in JSON")
+		cmd.Flags().BoolVarP(&objectStatusOffline, "offline", "", false, "don't ask the remote which objects it has")
+		addPathsFromFlags(cmd)
+	})
+}
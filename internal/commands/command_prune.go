@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"os"
 	"runtime"
+	"strings"
 	"sync"
 	"time"
 
@@ -14,6 +15,7 @@ import (
 	"github.com/git-lfs/git-lfs/v3/fs"
 	"github.com/git-lfs/git-lfs/v3/git"
 	"github.com/git-lfs/git-lfs/v3/lfs"
+	"github.com/git-lfs/git-lfs/v3/subprocess"
 	"github.com/git-lfs/git-lfs/v3/tasklog"
 	"github.com/git-lfs/git-lfs/v3/tools"
 	"github.com/git-lfs/git-lfs/v3/tools/humanize"
@@ -31,6 +33,11 @@ var (
 	pruneRecentArg      bool
 	pruneForceArg       bool
 	pruneDoNotVerifyArg bool
+
+	// pruneRetainedArg makes prune print the objects it would retain
+	// rather than pruning, for another repository sharing the object
+	// store.
+	pruneRetainedArg bool
 )
 
 func pruneCommand(cmd *cobra.Command, args []string) {
@@ -66,7 +73,7 @@ func prune(fetchPruneConfig lfs.FetchPruneConfig, verifyRemote, dryRun, verbose
 	localObjects := make([]fs.Object, 0, 100)
 	retainedObjects := tools.NewStringSetWithCapacity(100)
 
-	logger := tasklog.NewLogger(OutputWriter,
+	logger := tasklog.NewLogger(os.Stderr,
 		tasklog.ForceProgress(cfg.ForceProgress()),
 	)
 	defer logger.Close()
@@ -129,6 +136,19 @@ func prune(fetchPruneConfig lfs.FetchPruneConfig, verifyRemote, dryRun, verbose
 	errorwait.Wait() // make sure all errors have been processed
 	pruneCheckErrors(taskErrors)
 
+	if pruneRetainedArg {
+		close(progressChan)
+		progresswait.Wait()
+		for oid := range retainedObjects.Iter() {
+			Print("%s", oid)
+		}
+		return
+	}
+
+	if err := retainSharedObjects(retainedObjects); err != nil {
+		ExitWithError(err)
+	}
+
 	prunableObjects := make([]string, 0, len(localObjects)/2)
 
 	// Build list of prunables (also queue for verify at same time if applicable)
@@ -541,6 +561,83 @@ func pruneTaskGetReachableObjects(gitscanner *lfs.GitScanner, outObjectSet *tool
 	}
 }
 
+// retainSharedObjects adds to "retained" the objects needed by each of the
+// other repositories which share the object store, by asking Git LFS in each
+// which objects it would retain. Repositories which no longer exist are
+// unregistered. If any other repository cannot be asked, nothing can safely be
+// pruned, so an error is returned.
+func retainSharedObjects(retained tools.StringSet) error {
+	f := cfg.Filesystem()
+	if !f.IsSharedStore() {
+		return nil
+	}
+
+	repos, err := f.SharedRepositories()
+	if err != nil {
+		return errors.Wrap(err, tr.Tr.Get("unable to list repositories sharing the object store"))
+	}
+
+	for _, repo := range repos {
+		if !tools.DirExists(repo.GitDir) {
+			tracerx.Printf("prune: unregistering missing repository %s", repo.GitDir)
+			repo.Unregister()
+			continue
+		}
+
+		storage := git.NewReadOnlyConfig(repo.WorkDir, repo.GitDir).Find("lfs.storage")
+		if !repo.UsesStore(f, storage) {
+			tracerx.Printf("prune: unregistering repository %s, which no longer uses the object store", repo.GitDir)
+			repo.Unregister()
+			continue
+		}
+
+		oids, err := sharedRetainedObjects(repo)
+		if err != nil {
+			return errors.Wrap(err, tr.Tr.Get("unable to find objects needed by %s, which shares the object store", repo.GitDir))
+		}
+		for _, oid := range oids {
+			retained.Add(oid)
+		}
+	}
+	return nil
+}
+
+// sharedRetainedObjects returns the objects which a prune in the repository
+// "repo" would retain.
+func sharedRetainedObjects(repo *fs.SharedRepository) ([]string, error) {
+	args := []string{"--git-dir=" + repo.GitDir}
+	if len(repo.WorkDir) > 0 {
+		args = append(args, "--work-tree="+repo.WorkDir)
+	}
+	args = append(args, "lfs", "prune", "--retained-objects")
+
+	cmd, err := subprocess.ExecCommand("git", args...)
+	if err != nil {
+		return nil, err
+	}
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, errors.Wrap(err, strings.TrimSpace(stderr.String()))
+	}
+
+	var oids []string
+	for _, line := range strings.Split(string(out), "\n") {
+		oid := strings.TrimSpace(line)
+		if len(oid) == 0 {
+			continue
+		}
+		if err := fs.ValidateOid(oid); err != nil {
+			return nil, err
+		}
+		oids = append(oids, oid)
+	}
+	return oids, nil
+}
+
 func init() {
 	RegisterCommand("prune", pruneCommand, func(cmd *cobra.Command) {
 		cmd.Flags().BoolVarP(&pruneDryRunArg, "dry-run", "d", false, "Don't delete anything, just report")
@@ -549,5 +646,7 @@ func init() {
 		cmd.Flags().BoolVarP(&pruneForceArg, "force", "f", false, "Prune everything that has been pushed")
 		cmd.Flags().BoolVarP(&pruneVerifyArg, "verify-remote", "c", false, "Verify that remote has LFS files before deleting")
 		cmd.Flags().BoolVar(&pruneDoNotVerifyArg, "no-verify-remote", false, "Override lfs.pruneverifyremotealways and don't verify")
+		cmd.Flags().BoolVar(&pruneRetainedArg, "retained-objects", false, "Print the objects which would be retained, for another repository sharing the object store")
+		cmd.Flags().MarkHidden("retained-objects")
 	})
 }
@@ -15,6 +15,7 @@ import (
 
 var (
 	pushDryRun    = false
+	pushVerify    = false
 	pushObjectIDs = false
 	pushAll       = false
 	useStdin      = false
@@ -48,6 +49,12 @@ func pushCommand(cmd *cobra.Command, args []string) {
 	}
 
 	ctx := newUploadContext(pushDryRun)
+	if pushVerify {
+		if !pushDryRun {
+			Exit(tr.Tr.Get("Cannot use --verify-remote without --dry-run"))
+		}
+		ctx.dryRunReport = newDryRunReport(tr.Tr.Get("push"))
+	}
 
 	var argList []string
 	if useStdin {
@@ -103,7 +110,11 @@ func uploadsWithObjectIDs(ctx *uploadContext, oids []string) {
 			ExitWithError(errors.Wrap(err, tr.Tr.Get("Unable to find local media path:")))
 		}
 
-		stat, err := os.Stat(mp)
+		store, err := cfg.Filesystem().Store()
+		if err != nil {
+			ExitWithError(err)
+		}
+		size, err := store.Size(oid)
 		if err != nil {
 			ExitWithError(errors.Wrap(err, tr.Tr.Get("Unable to stat local media path")))
 		}
@@ -112,7 +123,7 @@ func uploadsWithObjectIDs(ctx *uploadContext, oids []string) {
 			Name: mp,
 			Pointer: &lfs.Pointer{
 				Oid:  oid,
-				Size: stat.Size(),
+				Size: size,
 			},
 		}
 	}
@@ -161,6 +172,7 @@ func lfsPushRefs(refnames []string, pushAll bool) ([]*git.RefUpdate, error) {
 func init() {
 	RegisterCommand("push", pushCommand, func(cmd *cobra.Command) {
 		cmd.Flags().BoolVarP(&pushDryRun, "dry-run", "d", false, "Do everything except actually send the updates")
+		cmd.Flags().BoolVarP(&pushVerify, "verify-remote", "", false, "With --dry-run, only list objects the remote needs")
 		cmd.Flags().BoolVarP(&pushObjectIDs, "object-id", "o", false, "Push LFS object ID(s)")
 		cmd.Flags().BoolVarP(&useStdin, "stdin", "", false, "Read object IDs or refs from stdin")
 		cmd.Flags().BoolVarP(&pushAll, "all", "a", false, "Push all objects for the current ref to the remote.")
@@ -0,0 +1,103 @@
+package commands
+
+import (
+	"encoding/json"
+	"os"
+	"time"
+
+	"github.com/git-lfs/git-lfs/v3/tools/humanize"
+	"github.com/git-lfs/git-lfs/v3/tq"
+	"github.com/git-lfs/git-lfs/v3/tr"
+	"github.com/spf13/cobra"
+)
+
+var (
+	queueInspectJSON bool
+
+	// queueStallThreshold is how long a transfer may go without making
+	// progress before it is reported as stalled.
+	queueStallThreshold = 30 * time.Second
+)
+
+func queueInspectCommand(cmd *cobra.Command, args []string) {
+	setupRepository()
+
+	states, err := tq.ReadQueueStates(transferProgressDir())
+	if err != nil {
+		Exit(tr.Tr.Get("Unable to read transfer queue state: %s", err))
+	}
+
+	if queueInspectJSON {
+		if states == nil {
+			states = []*tq.QueueState{}
+		}
+		data := struct {
+			Queues []*tq.QueueState `json:"queues"`
+		}{Queues: states}
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", " ")
+		if err := encoder.Encode(data); err != nil {
+			ExitWithError(err)
+		}
+		return
+	}
+
+	if len(states) == 0 {
+		Print(tr.Tr.Get("No transfers in progress"))
+		return
+	}
+
+	for i, state := range states {
+		if i > 0 {
+			Print("")
+		}
+		printQueueState(state)
+	}
+}
+
+// printQueueState prints a summary of the state of a single transfer queue,
+// followed by its in-flight, retried and failed objects.
+func printQueueState(state *tq.QueueState) {
+	// TRANSLATORS: The first format specifier is the direction of the
+	// queue's transfers, e.g., "upload", and the second is the name of
+	// the remote.
+	Print(tr.Tr.Get("%s to %q (process %d): %d pending, %d active, %d retried, %d failed",
+		state.Direction, state.Remote, state.Pid, state.Pending,
+		len(state.Active), len(state.Retried), len(state.Failed)))
+
+	for _, p := range state.Active {
+		size := humanize.FormatBytes(uint64(p.Size))
+		if len(p.Host) > 0 {
+			Print("\t%s", tr.Tr.Get("active: %s (%s), %d%% of %s via %s", p.Name, p.Oid, p.Percent(), size, p.Host))
+		} else {
+			Print("\t%s", tr.Tr.Get("active: %s (%s), %d%% of %s", p.Name, p.Oid, p.Percent(), size))
+		}
+
+		if idle := state.Updated.Sub(p.Updated); idle >= queueStallThreshold {
+			Print("\t\t%s", tr.Tr.Get("no progress for %s", formatETA(idle)))
+		}
+	}
+
+	for _, o := range state.Retried {
+		Print("\t%s", tr.Tr.GetN("retried: %s (%s), %d time", "retried: %s (%s), %d times", o.Retries, o.Name, o.Oid, o.Retries))
+		if len(o.Error) > 0 {
+			Print("\t\t%s", o.Error)
+		}
+	}
+
+	for _, o := range state.Failed {
+		Print("\t%s", tr.Tr.Get("failed: %s (%s)", o.Name, o.Oid))
+		if len(o.Error) > 0 {
+			Print("\t\t%s", o.Error)
+		}
+	}
+}
+
+func init() {
+	RegisterCommand("queue", nil, func(cmd *cobra.Command) {
+		inspect := NewCommand("inspect", queueInspectCommand)
+		inspect.Flags().BoolVarP(&queueInspectJSON, "json", "", false, "print output in JSON")
+
+		cmd.AddCommand(inspect)
+	})
+}
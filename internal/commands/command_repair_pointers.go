@@ -0,0 +1,264 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/git-lfs/git-lfs/v3/errors"
+	"github.com/git-lfs/git-lfs/v3/filepathfilter"
+	"github.com/git-lfs/git-lfs/v3/fs"
+	"github.com/git-lfs/git-lfs/v3/git"
+	"github.com/git-lfs/git-lfs/v3/lfs"
+	"github.com/git-lfs/git-lfs/v3/tr"
+	"github.com/git-lfs/gitobj/v2"
+	"github.com/spf13/cobra"
+)
+
+var (
+	repairPointersDryRun bool
+)
+
+// pointerSizeCutoff is the size at or above which a blob or file cannot hold
+// a pointer, damaged or otherwise.
+const pointerSizeCutoff = 1024
+
+func repairPointersCommand(cmd *cobra.Command, args []string) {
+	setupWorkingCopy()
+
+	var pathFilter *filepathfilter.Filter
+	if len(args) > 0 {
+		pathFilter = filepathfilter.New(rootedPaths(args), nil, filepathfilter.GitIgnore)
+	}
+
+	entries, err := git.IndexEntries()
+	if err != nil {
+		ExitWithError(err)
+	}
+
+	db, err := getObjectDatabase()
+	if err != nil {
+		ExitWithError(err)
+	}
+	defer db.Close()
+
+	r := &pointerRepairer{db: db}
+	attrFilter := git.GetAttributeFilter(cfg.LocalWorkingDir(), cfg.LocalGitDir())
+
+	var unrecoverable int
+	for _, entry := range entries {
+		if entry.Stage != 0 || (entry.Mode != "100644" && entry.Mode != "100755") {
+			continue
+		}
+		if !attrFilter.Allows(entry.Path) || (pathFilter != nil && !pathFilter.Allows(entry.Path)) {
+			continue
+		}
+
+		if err := r.repairEntry(entry); err != nil {
+			Error(tr.Tr.Get("Unrecoverable: %s: %s", entry.Path, err))
+			unrecoverable++
+		}
+	}
+
+	if unrecoverable > 0 {
+		Exit(tr.Tr.GetN(
+			"%d pointer could not be repaired",
+			"%d pointers could not be repaired",
+			unrecoverable,
+			unrecoverable,
+		))
+	}
+}
+
+type pointerRepairer struct {
+	db *gitobj.ObjectDatabase
+
+	// objects holds the objects in the local store, and is populated
+	// the first time it is needed.
+	objects []fs.Object
+}
+
+// repairEntry repairs the pointer held by the given index entry, and by its
+// working tree file, if either is damaged.
+func (r *pointerRepairer) repairEntry(entry *git.IndexEntry) error {
+	sha, err := hex.DecodeString(entry.Sha)
+	if err != nil {
+		return err
+	}
+	blob, err := r.db.Blob(sha)
+	if err != nil {
+		return err
+	}
+	defer blob.Close()
+
+	var data []byte
+	if blob.Size < pointerSizeCutoff {
+		if data, err = ioutil.ReadAll(blob.Contents); err != nil {
+			return err
+		}
+	}
+
+	if data != nil {
+		p, err := r.repair(entry.Path, data)
+		if err != nil {
+			return err
+		}
+		if p != nil {
+			if err := r.updateIndex(entry, p); err != nil {
+				return err
+			}
+			if repairPointersDryRun {
+				Print(tr.Tr.Get("Would repair pointer in index: %s", entry.Path))
+			} else {
+				Print(tr.Tr.Get("Repaired pointer in index: %s", entry.Path))
+			}
+		}
+	}
+
+	return r.repairWorkingTreeFile(entry.Path, data)
+}
+
+// repairWorkingTreeFile repairs the working tree file at the given path if it
+// holds a damaged pointer. Files which hold the same damaged data as the index
+// are replaced with the index's repaired pointer.
+func (r *pointerRepairer) repairWorkingTreeFile(path string, indexData []byte) error {
+	filename := filepath.FromSlash(path)
+	stat, err := os.Lstat(filename)
+	if err != nil || !stat.Mode().IsRegular() || stat.Size() >= pointerSizeCutoff {
+		return nil
+	}
+
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+
+	p, err := r.repair(path, data)
+	if err != nil {
+		if indexData != nil && bytes.Equal(data, indexData) {
+			// The failure was already reported for the index.
+			return nil
+		}
+		return err
+	}
+	if p == nil {
+		return nil
+	}
+
+	if repairPointersDryRun {
+		Print(tr.Tr.Get("Would repair pointer in working tree: %s", path))
+		return nil
+	}
+
+	if err := ioutil.WriteFile(filename, []byte(p.Encoded()), stat.Mode().Perm()); err != nil {
+		return errors.Wrap(err, tr.Tr.Get("could not write working tree file"))
+	}
+	Print(tr.Tr.Get("Repaired pointer in working tree: %s", path))
+	return nil
+}
+
+// repair returns the pointer which "data" was meant to hold, or nil if "data"
+// is either a canonical pointer or not a pointer at all.
+func (r *pointerRepairer) repair(path string, data []byte) (*lfs.Pointer, error) {
+	if p, err := lfs.DecodePointer(bytes.NewReader(data)); err == nil && p.Canonical {
+		return nil, nil
+	}
+
+	p, err := lfs.RepairPointer(data, func(oidPrefix string, size int64) (string, int64, bool) {
+		if oid, size, ok := r.resolveFromStore(oidPrefix, size); ok {
+			return oid, size, true
+		}
+		return r.resolveFromHistory(path, oidPrefix, size)
+	})
+	if errors.IsNotAPointerError(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if p.Encoded() == string(data) {
+		return nil, nil
+	}
+	return p, nil
+}
+
+func (r *pointerRepairer) updateIndex(entry *git.IndexEntry, p *lfs.Pointer) error {
+	if repairPointersDryRun {
+		return nil
+	}
+
+	encoded := p.Encoded()
+	sha, err := r.db.WriteBlob(&gitobj.Blob{
+		Contents: strings.NewReader(encoded),
+		Size:     int64(len(encoded)),
+	})
+	if err != nil {
+		return errors.Wrap(err, tr.Tr.Get("could not write repaired pointer"))
+	}
+	return git.UpdateIndexEntry(entry.Mode, hex.EncodeToString(sha), entry.Path)
+}
+
+// resolveFromStore finds the single object in the local store matching the
+// given OID prefix and, if it is known, size.
+func (r *pointerRepairer) resolveFromStore(oidPrefix string, size int64) (string, int64, bool) {
+	if r.objects == nil {
+		r.objects = make([]fs.Object, 0)
+		cfg.EachLFSObject(func(obj fs.Object) error {
+			r.objects = append(r.objects, obj)
+			return nil
+		})
+	}
+
+	var match *fs.Object
+	for i, obj := range r.objects {
+		if !strings.HasPrefix(obj.Oid, oidPrefix) || (size >= 0 && obj.Size != size) {
+			continue
+		}
+		if match != nil && match.Oid != obj.Oid {
+			return "", 0, false
+		}
+		match = &r.objects[i]
+	}
+	if match == nil {
+		return "", 0, false
+	}
+	return match.Oid, match.Size, true
+}
+
+// resolveFromHistory finds the most recent pointer previously committed at
+// the given path which matches the given OID prefix and, if it is known, size.
+func (r *pointerRepairer) resolveFromHistory(path, oidPrefix string, size int64) (string, int64, bool) {
+	blobs, err := git.PathBlobHistory("HEAD", path)
+	if err != nil {
+		return "", 0, false
+	}
+
+	for _, blobSha := range blobs {
+		sha, err := hex.DecodeString(blobSha)
+		if err != nil {
+			continue
+		}
+		blob, err := r.db.Blob(sha)
+		if err != nil {
+			continue
+		}
+		p, err := lfs.DecodePointerFromBlob(blob)
+		blob.Close()
+		if err != nil {
+			continue
+		}
+		if strings.HasPrefix(p.Oid, oidPrefix) && (size < 0 || p.Size == size) {
+			return p.Oid, p.Size, true
+		}
+	}
+	return "", 0, false
+}
+
+func init() {
+	RegisterCommand("repair-pointers", repairPointersCommand, func(cmd *cobra.Command) {
+		cmd.Flags().BoolVarP(&repairPointersDryRun, "dry-run", "d", false, "List damaged pointers without repairing them.")
+	})
+}
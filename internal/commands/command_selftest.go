@@ -0,0 +1,268 @@
+package commands
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/git-lfs/git-lfs/v3/config"
+	"github.com/git-lfs/git-lfs/v3/errors"
+	"github.com/git-lfs/git-lfs/v3/fs"
+	"github.com/git-lfs/git-lfs/v3/lfs"
+	"github.com/git-lfs/git-lfs/v3/subprocess"
+	"github.com/git-lfs/git-lfs/v3/tr"
+	"github.com/spf13/cobra"
+)
+
+// defaultSelftestEndpoint is the built-in server against which the self test
+// runs when no endpoint is given.
+const defaultSelftestEndpoint = "memory://selftest"
+
+var (
+	selftestEndpoint string
+	selftestKeep     bool
+)
+
+// selftestFile is a file which the self test stores with Git LFS.
+type selftestFile struct {
+	name    string
+	size    int64
+	sum     []byte
+	pointer *lfs.Pointer
+}
+
+// selftest is a scenario run in a scratch repository whose objects are
+// transferred to and from an endpoint.
+type selftest struct {
+	dir    string
+	cfg    *config.Configuration
+	gf     *lfs.GitFilter
+	client *lfs.Client
+	files  []*selftestFile
+}
+
+// selftestStep is a single step of the self test.
+type selftestStep struct {
+	name string
+	run  func() error
+}
+
+func selftestCommand(cmd *cobra.Command, args []string) {
+	dir, err := ioutil.TempDir("", "git-lfs-selftest")
+	if err != nil {
+		ExitWithError(err)
+	}
+	if selftestKeep {
+		Print(tr.Tr.Get("Keeping scratch repository in %s", dir))
+	} else {
+		defer os.RemoveAll(dir)
+	}
+
+	st := &selftest{dir: dir}
+	steps := []selftestStep{
+		{"init", st.init},
+		{"track", st.track},
+		{"clean", st.clean},
+		{"push", st.push},
+		{"wipe", st.wipe},
+		{"fetch", st.fetch},
+		{"checkout", st.checkout},
+		{"verify", st.verify},
+	}
+
+	Print(tr.Tr.Get("Testing %s", selftestEndpoint))
+
+	var failed bool
+	for _, step := range steps {
+		if failed {
+			Print("%-10s %s", step.name, tr.Tr.Get("skipped"))
+			continue
+		}
+
+		if err := step.run(); err != nil {
+			Print("%-10s %s", step.name, tr.Tr.Get("FAIL: %s", err))
+			failed = true
+			continue
+		}
+		Print("%-10s %s", step.name, tr.Tr.Get("ok"))
+	}
+
+	if failed {
+		if !selftestKeep {
+			os.RemoveAll(dir)
+		}
+		os.Exit(1)
+	}
+}
+
+// init creates the scratch repository, configured to use the endpoint under
+// test, and the files to be stored in it.
+func (st *selftest) init() error {
+	cmd, err := subprocess.ExecCommand("git", "init", "-q", st.dir)
+	if err != nil {
+		return err
+	}
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return errors.New(tr.Tr.Get("could not initialize repository: %s", strings.TrimSpace(string(out))))
+	}
+
+	st.cfg = config.NewIn(st.dir, filepath.Join(st.dir, ".git"))
+	if _, err := st.cfg.GitConfig().SetLocal("lfs.url", selftestEndpoint); err != nil {
+		return err
+	}
+	st.gf = lfs.NewGitFilter(st.cfg)
+
+	for _, f := range []struct {
+		name string
+		size int64
+	}{
+		{"small.dat", 1024},
+		{"large.dat", 5*1024*1024 + 1},
+	} {
+		data := make([]byte, f.size)
+		if _, err := rand.Read(data); err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(filepath.Join(st.dir, f.name), data, 0644); err != nil {
+			return err
+		}
+
+		sum := sha256.Sum256(data)
+		st.files = append(st.files, &selftestFile{name: f.name, size: f.size, sum: sum[:]})
+	}
+	return nil
+}
+
+// track adds the files to .gitattributes, and checks that Git would pass them
+// through the Git LFS filter.
+func (st *selftest) track() error {
+	attrs := "*.dat filter=lfs diff=lfs merge=lfs -text\n"
+	if err := ioutil.WriteFile(filepath.Join(st.dir, ".gitattributes"), []byte(attrs), 0644); err != nil {
+		return err
+	}
+
+	for _, f := range st.files {
+		cmd, err := subprocess.ExecCommand("git", "check-attr", "filter", "--", f.name)
+		if err != nil {
+			return err
+		}
+		cmd.Dir = st.dir
+
+		out, err := cmd.Output()
+		if err != nil {
+			return err
+		}
+		if !strings.HasSuffix(strings.TrimSpace(string(out)), ": filter: lfs") {
+			return errors.New(tr.Tr.Get("%s is not tracked: %s", f.name, strings.TrimSpace(string(out))))
+		}
+	}
+	return nil
+}
+
+// clean converts each file to a pointer, storing its contents as an object.
+func (st *selftest) clean() error {
+	for _, f := range st.files {
+		file, err := os.Open(filepath.Join(st.dir, f.name))
+		if err != nil {
+			return err
+		}
+		cleaned, err := st.gf.Clean(file, f.name, f.size, nil)
+		file.Close()
+		if err != nil {
+			return err
+		}
+
+		store, err := st.cfg.Filesystem().Store()
+		if err == nil {
+			err = fs.ImportFile(store, cleaned.Oid, cleaned.Filename)
+		}
+		cleaned.Teardown()
+		if err != nil {
+			return err
+		}
+
+		if cleaned.Size != f.size {
+			return errors.New(tr.Tr.Get("%s: expected size %d, got %d", f.name, f.size, cleaned.Size))
+		}
+		f.pointer = cleaned.Pointer
+	}
+	return nil
+}
+
+func (st *selftest) push() error {
+	client, err := lfs.NewClient(st.cfg, "")
+	if err != nil {
+		return err
+	}
+	st.client = client
+
+	return client.Upload(st.pointers()...)
+}
+
+// wipe removes the objects and the files, so that they can only be restored
+// from the endpoint.
+func (st *selftest) wipe() error {
+	if err := os.RemoveAll(st.cfg.LFSObjectDir()); err != nil {
+		return err
+	}
+	for _, f := range st.files {
+		if err := os.Remove(filepath.Join(st.dir, f.name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (st *selftest) fetch() error {
+	return st.client.Download(st.pointers()...)
+}
+
+func (st *selftest) checkout() error {
+	for _, f := range st.files {
+		if err := st.gf.SmudgeToFile(filepath.Join(st.dir, f.name), f.pointer, false, nil, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// verify checks that each checked-out file has its original contents.
+func (st *selftest) verify() error {
+	for _, f := range st.files {
+		file, err := os.Open(filepath.Join(st.dir, f.name))
+		if err != nil {
+			return err
+		}
+		h := sha256.New()
+		_, err = io.Copy(h, file)
+		file.Close()
+		if err != nil {
+			return err
+		}
+
+		if !bytes.Equal(h.Sum(nil), f.sum) {
+			return errors.New(tr.Tr.Get("%s does not match its original contents", f.name))
+		}
+	}
+	return nil
+}
+
+func (st *selftest) pointers() []*lfs.Pointer {
+	pointers := make([]*lfs.Pointer, 0, len(st.files))
+	for _, f := range st.files {
+		pointers = append(pointers, f.pointer)
+	}
+	return pointers
+}
+
+func init() {
+	RegisterCommand("selftest", selftestCommand, func(cmd *cobra.Command) {
+		cmd.Flags().StringVarP(&selftestEndpoint, "endpoint", "e", defaultSelftestEndpoint, "URL of the LFS server to test")
+		cmd.Flags().BoolVarP(&selftestKeep, "keep", "k", false, "keep the scratch repository")
+	})
+}
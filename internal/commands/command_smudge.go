@@ -23,13 +23,13 @@ var (
 )
 
 // delayedSmudge performs a 'delayed' smudge, adding the LFS pointer to the
-// `*tq.TransferQueue` "q" if the file is not present locally, passes the given
-// filepathfilter, and is not skipped. If the pointer is malformed, or already
+// `*tq.TransferQueue` "q" with the given priority if the file is not present
+// locally, passes the given filepathfilter, and is not skipped. If the pointer is malformed, or already
 // exists, it streams the contents to be written into the working copy to "to".
 //
 // delayedSmudge returns the number of bytes written, whether the checkout was
 // delayed, the *lfs.Pointer that was smudged, and an error, if one occurred.
-func delayedSmudge(gf *lfs.GitFilter, s *git.FilterProcessScanner, to io.Writer, from io.Reader, q *tq.TransferQueue, filename string, skip bool, filter *filepathfilter.Filter) (int64, bool, *lfs.Pointer, error) {
+func delayedSmudge(gf *lfs.GitFilter, s *git.FilterProcessScanner, to io.Writer, from io.Reader, q *tq.TransferQueue, filename string, priority int, skip bool, filter *filepathfilter.Filter) (int64, bool, *lfs.Pointer, error) {
 	ptr, pbuf, perr := lfs.DecodeFrom(from)
 	if perr != nil {
 		// Write 'statusFromErr(nil)', even though 'perr != nil', since
@@ -59,7 +59,8 @@ func delayedSmudge(gf *lfs.GitFilter, s *git.FilterProcessScanner, to io.Writer,
 	}
 
 	if !skip && filter.Allows(filename) {
-		if _, statErr := os.Stat(path); statErr != nil && ptr.Size != 0 {
+		if !cfg.LFSObjectExists(ptr.Oid, ptr.Size) && ptr.Size != 0 {
+			q.SetPriority(ptr.Oid, priority)
 			q.Add(filename, path, ptr.Oid, ptr.Size, false, err)
 			return 0, true, ptr, nil
 		}
@@ -131,12 +132,20 @@ func smudge(gf *lfs.GitFilter, to io.Writer, from io.Reader, filename string, sk
 	}
 
 	if err != nil {
-		ptr.Encode(to)
 		var oid string = ptr.Oid
 		if len(oid) >= 7 {
 			oid = oid[:7]
 		}
 
+		if n > 0 {
+			// Part of the object was streamed to "to" before the
+			// download failed, so the pointer can't be written in
+			// its place, even when download errors are skipped.
+			// Fail instead, so that Git discards what was written.
+			return n, errors.Wrap(err, tr.Tr.Get("Error downloading object: %s (%s)", filename, oid))
+		}
+
+		ptr.Encode(to)
 		LoggedError(err, tr.Tr.Get("Error downloading object: %s (%s): %s", filename, oid, err))
 		if !cfg.SkipDownloadErrors() {
 			os.Exit(2)
@@ -162,6 +171,11 @@ func smudgeCommand(cmd *cobra.Command, args []string) {
 			fmt.Fprintln(os.Stderr, err.Error())
 		} else {
 			Error(err.Error())
+			if n > 0 {
+				// Don't let Git take a partly written object
+				// as the file's contents.
+				os.Exit(2)
+			}
 		}
 	} else if possiblyMalformedObjectSize(n) {
 		fmt.Fprintln(os.Stderr, tr.Tr.Get("Possibly malformed smudge on Windows: see `git lfs help smudge` for more info."))
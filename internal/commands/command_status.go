@@ -8,10 +8,13 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/git-lfs/git-lfs/v3/git"
 	"github.com/git-lfs/git-lfs/v3/lfs"
 	"github.com/git-lfs/git-lfs/v3/tools"
+	"github.com/git-lfs/git-lfs/v3/tools/humanize"
+	"github.com/git-lfs/git-lfs/v3/tq"
 	"github.com/git-lfs/git-lfs/v3/tr"
 	"github.com/spf13/cobra"
 )
@@ -19,6 +22,7 @@ import (
 var (
 	porcelain  = false
 	statusJson = false
+	statusNull = false
 )
 
 func statusCommand(cmd *cobra.Command, args []string) {
@@ -41,6 +45,10 @@ func statusCommand(cmd *cobra.Command, args []string) {
 		ExitWithError(err)
 	}
 
+	if statusNull && !porcelain {
+		Exit(tr.Tr.Get("-z requires --porcelain"))
+	}
+
 	if porcelain {
 		porcelainStagedPointers(scanIndexAt)
 		return
@@ -83,6 +91,8 @@ func statusCommand(cmd *cobra.Command, args []string) {
 		Print("\t%s (%s)", src, formatBlobInfo(scanner, entry))
 	}
 
+	printTransferProgress()
+
 	Print("")
 
 	if err = scanner.Close(); err != nil {
@@ -90,6 +100,47 @@ func statusCommand(cmd *cobra.Command, args []string) {
 	}
 }
 
+// printTransferProgress prints the progress of any transfers currently being
+// made by other Git LFS processes in this repository.
+func printTransferProgress() {
+	progress, err := tq.ReadTransferProgress(transferProgressDir())
+	if err != nil {
+		Debug(tr.Tr.Get("Unable to read transfer progress: %s", err))
+		return
+	}
+	if len(progress) == 0 {
+		return
+	}
+
+	Print("\n%s\n", tr.Tr.Get("Transfers in progress:"))
+	for _, p := range progress {
+		rate := humanize.FormatByteRate(uint64(p.Bytes), p.Updated.Sub(p.Start))
+		if eta, ok := p.ETA(); ok {
+			// TRANSLATORS: The first format specifier is the
+			// direction of the transfer, e.g., "upload".
+			Print("\t%s", tr.Tr.Get("%s: %d%% of %s (%s), ETA %s", p.Direction, p.Percent(), p.Name, rate, formatETA(eta)))
+		} else {
+			Print("\t%s", tr.Tr.Get("%s: %d%% of %s", p.Direction, p.Percent(), p.Name))
+		}
+	}
+}
+
+// formatETA formats "d" using its two most significant units, e.g.,
+// "1h 10m".
+func formatETA(d time.Duration) string {
+	d = d.Round(time.Second)
+	h, m, s := int(d.Hours()), int(d.Minutes())%60, int(d.Seconds())%60
+
+	switch {
+	case h > 0:
+		return fmt.Sprintf("%dh %dm", h, m)
+	case m > 0:
+		return fmt.Sprintf("%dm %ds", m, s)
+	default:
+		return fmt.Sprintf("%ds", s)
+	}
+}
+
 func formatBlobInfo(s *lfs.PointerScanner, entry *lfs.DiffIndexEntry) string {
 	fromSha, fromSrc, err := blobInfoFrom(s, entry)
 	if err != nil {
@@ -314,7 +365,11 @@ func porcelainStagedPointers(ref string) {
 		}
 
 		if _, seen := seenNames[name]; !seen {
-			Print(porcelainStatusLine(entry))
+			if statusNull {
+				fmt.Fprint(os.Stdout, porcelainNullStatusLine(entry))
+			} else {
+				Print(porcelainStatusLine(entry))
+			}
 
 			seenNames[name] = struct{}{}
 		}
@@ -332,6 +387,21 @@ func porcelainStatusLine(entry *lfs.DiffIndexEntry) string {
 	return fmt.Sprintf("%s  %s", entry.Status, entry.SrcName)
 }
 
+// porcelainNullStatusLine formats an entry like porcelainStatusLine, but
+// terminates it with a NUL and leaves the file names unquoted, in the same
+// manner as `git status --porcelain -z`. For renames and copies, the
+// destination name is written first, followed by the source name.
+func porcelainNullStatusLine(entry *lfs.DiffIndexEntry) string {
+	switch entry.Status {
+	case lfs.StatusRename, lfs.StatusCopy:
+		return fmt.Sprintf("%s  %s\x00%s\x00", entry.Status, entry.DstName, entry.SrcName)
+	case lfs.StatusModification:
+		return fmt.Sprintf(" %s %s\x00", entry.Status, entry.SrcName)
+	}
+
+	return fmt.Sprintf("%s  %s\x00", entry.Status, entry.SrcName)
+}
+
 // relativize relatives a path from "from" to "to". For instance, note that, for
 // any paths "from" and "to", that:
 //
@@ -369,5 +439,6 @@ func init() {
 	RegisterCommand("status", statusCommand, func(cmd *cobra.Command) {
 		cmd.Flags().BoolVarP(&porcelain, "porcelain", "p", false, "Give the output in an easy-to-parse format for scripts.")
 		cmd.Flags().BoolVarP(&statusJson, "json", "j", false, "Give the output in a stable json format for scripts.")
+		cmd.Flags().BoolVarP(&statusNull, "null", "z", false, "Terminate entries with NUL in --porcelain output.")
 	})
 }
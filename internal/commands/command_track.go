@@ -9,6 +9,7 @@ import (
 	"os"
 	"path"
 	"path/filepath"
+	"regexp"
 	"strings"
 	"time"
 
@@ -32,6 +33,13 @@ var (
 	trackNoExcludedFlag     bool
 	trackFilenameFlag       bool
 	trackJSONFlag           bool
+	trackMacroFlag          string
+
+	// trackAttributes are the attributes written for each tracked
+	// pattern, or used to define the attribute macro given by --macro.
+	trackAttributes = "filter=lfs diff=lfs merge=lfs -text"
+
+	attrMacroNamePattern = regexp.MustCompile(`^[_.0-9A-Za-z][-_.0-9A-Za-z]*$`)
 )
 
 func trackCommand(cmd *cobra.Command, args []string) {
@@ -70,6 +78,26 @@ func trackCommand(cmd *cobra.Command, args []string) {
 		Exit(tr.Tr.Get("Current directory %q outside of Git working directory %q.", wd, cfg.LocalWorkingDir()))
 	}
 
+	macro := trackMacroFlag
+	if !cmd.Flags().Changed("macro") {
+		macro, _ = cfg.Git.Get("lfs.trackmacro")
+	}
+	var defineMacro bool
+	if len(macro) > 0 && !trackNoModifyAttrsFlag {
+		if !attrMacroNamePattern.MatchString(macro) {
+			Exit(tr.Tr.Get("Invalid attribute macro name: %q", macro))
+		}
+
+		// Git only reads macro definitions from the top-level
+		// .gitattributes file, so we can only define one there.
+		if _, ok := mp.Macro(macro); !ok {
+			if relpath != "." {
+				Exit(tr.Tr.Get("Attribute macro %q is not defined; run `git lfs track` from the root of the repository to define it.", macro))
+			}
+			defineMacro = true
+		}
+	}
+
 	changedAttribLines := make(map[string]string)
 	var readOnlyPatterns []string
 	var writeablePatterns []string
@@ -103,7 +131,12 @@ ArgsLoop:
 			lockableArg = " " + git.LockableAttrib
 		}
 
-		changedAttribLines[pattern] = fmt.Sprintf("%s filter=lfs diff=lfs merge=lfs -text%v%s", encodedArg, lockableArg, lineEnd)
+		attrs := trackAttributes
+		if len(macro) > 0 {
+			attrs = macro
+		}
+
+		changedAttribLines[pattern] = fmt.Sprintf("%s %s%v%s", encodedArg, attrs, lockableArg, lineEnd)
 
 		if trackLockableFlag {
 			readOnlyPatterns = append(readOnlyPatterns, pattern)
@@ -137,6 +170,12 @@ ArgsLoop:
 		}
 		defer attributesFile.Close()
 
+		// Define the macro ahead of any lines which refer to it.
+		if defineMacro && len(changedAttribLines) > 0 {
+			Print(tr.Tr.Get("Defining attribute macro %q", macro))
+			attributesFile.WriteString(fmt.Sprintf("[attr]%s %s%s", macro, trackAttributes, lineEnd))
+		}
+
 		if len(attribContents) > 0 {
 			scanner := bufio.NewScanner(bytes.NewReader(attribContents))
 			for scanner.Scan() {
@@ -164,6 +203,7 @@ ArgsLoop:
 
 	// Any items left in the map, write new lines at the end of the file
 	// Note this is only new patterns, not ones which changed locking flags
+	addedPatterns := len(changedAttribLines) > 0
 	for pattern, newline := range changedAttribLines {
 		if !trackNoModifyAttrsFlag {
 			// Newline already embedded
@@ -175,11 +215,6 @@ ArgsLoop:
 		// modified note this is relative to current dir which is how we write
 		// .gitattributes deliberately not done in parallel as a chan because
 		// we'll be marking modified
-		//
-		// NOTE: `git ls-files` does not do well with leading slashes.
-		// Since all `git-lfs track` calls are relative to the root of
-		// the repository, the leading slash is simply removed for its
-		// implicit counterpart.
 		if trackVerboseLoggingFlag {
 			Print(tr.Tr.Get("Searching for files matching pattern: %s", pattern))
 		}
@@ -220,6 +255,12 @@ ArgsLoop:
 		}
 	}
 
+	// Only files matching a new pattern may have become tracked, so the
+	// index need not be checked again otherwise.
+	if !trackNoModifyAttrsFlag && addedPatterns {
+		warnLineEndingConversion()
+	}
+
 	// now flip read-only mode based on lockable / not lockable changes
 	lockClient := newLockClient()
 	err = lockClient.FixFileWriteFlagsInDir(relpath, readOnlyPatterns, writeablePatterns)
@@ -228,6 +269,21 @@ ArgsLoop:
 	}
 }
 
+// warnLineEndingConversion warns about any files tracked by Git LFS to which
+// Git would also apply end-of-line conversion, since converting the line
+// endings of a pointer file corrupts it.
+func warnLineEndingConversion() {
+	paths, err := git.GetLineEndingConvertedPaths(cfg.Git.Bool("core.autocrlf", false))
+	if err != nil {
+		Debug(tr.Tr.Get("Unable to check line ending attributes: %s", err))
+		return
+	}
+
+	for _, p := range paths {
+		Error(tr.Tr.Get("warning: %q is tracked by Git LFS but has line ending conversion enabled, which may corrupt its pointer; set the \"-text\" attribute for it", p))
+	}
+}
+
 type PatternData struct {
 	Pattern  string `json:"pattern"`
 	Source   string `json:"source"`
@@ -373,5 +429,6 @@ func init() {
 		cmd.Flags().BoolVarP(&trackNoExcludedFlag, "no-excluded", "", false, "skip listing excluded paths")
 		cmd.Flags().BoolVarP(&trackFilenameFlag, "filename", "", false, "treat this pattern as a literal filename")
 		cmd.Flags().BoolVarP(&trackJSONFlag, "json", "", false, "print output in JSON")
+		cmd.Flags().StringVarP(&trackMacroFlag, "macro", "", "", "write patterns using the named attribute macro")
 	})
 }
@@ -2,6 +2,7 @@ package commands
 
 import (
 	"bufio"
+	"bytes"
 	"io/ioutil"
 	"os"
 	"strings"
@@ -37,13 +38,14 @@ func untrackCommand(cmd *cobra.Command, args []string) {
 	}
 	defer attributesFile.Close()
 
+	macros := lfsAttributeMacros(data)
 	scanner := bufio.NewScanner(attributes)
 
 	// Iterate through each line of the attributes file and rewrite it,
 	// if the path was meant to be untracked, omit it, and print a message instead.
 	for scanner.Scan() {
 		line := scanner.Text()
-		if !strings.Contains(line, "filter=lfs") {
+		if !isLFSAttributeLine(line, macros) {
 			attributesFile.WriteString(line + "\n")
 			continue
 		}
@@ -57,6 +59,47 @@ func untrackCommand(cmd *cobra.Command, args []string) {
 	}
 }
 
+// lfsAttributeMacros returns the set of attribute macros defined in the given
+// attributes file contents which set "filter=lfs", such as those written by
+// `git lfs track --macro`.
+func lfsAttributeMacros(data []byte) map[string]bool {
+	macros := make(map[string]bool)
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 || !strings.HasPrefix(fields[0], "[attr]") {
+			continue
+		}
+		for _, attr := range fields[1:] {
+			if attr == "filter=lfs" {
+				macros[strings.TrimPrefix(fields[0], "[attr]")] = true
+			}
+		}
+	}
+	return macros
+}
+
+// isLFSAttributeLine returns whether the given line of an attributes file
+// tracks a pattern with Git LFS, either directly or with one of the given
+// macros.
+func isLFSAttributeLine(line string, macros map[string]bool) bool {
+	if strings.Contains(line, "filter=lfs") {
+		return true
+	}
+
+	fields := strings.Fields(line)
+	if len(fields) < 2 || strings.HasPrefix(fields[0], "[attr]") {
+		return false
+	}
+	for _, attr := range fields[1:] {
+		if macros[attr] {
+			return true
+		}
+	}
+	return false
+}
+
 func removePath(path string, args []string) bool {
 	withoutCurrentDir := tools.TrimCurrentPrefix(path)
 	for _, t := range args {
@@ -2,6 +2,7 @@ package commands
 
 import (
 	"bytes"
+	"crypto/sha256"
 	"fmt"
 	"io"
 	"log"
@@ -23,10 +24,11 @@ import (
 	"github.com/git-lfs/git-lfs/v3/tools"
 	"github.com/git-lfs/git-lfs/v3/tq"
 	"github.com/git-lfs/git-lfs/v3/tr"
+	"github.com/rubyist/tracerx"
 )
 
 // Populate man pages
-//go:generate go run ../docs/man/mangen.go
+//go:generate go run ../../docs/man/mangen.go
 
 var (
 	Debugging    = false
@@ -36,9 +38,10 @@ var (
 	ManPages     = make(map[string]string, 20)
 	tqManifest   = make(map[string]tq.Manifest)
 
-	cfg       *config.Configuration
-	apiClient *lfsapi.Client
-	global    sync.Mutex
+	cfg           *config.Configuration
+	apiClient     *lfsapi.Client
+	transferStats *tq.Stats
+	global        sync.Mutex
 
 	oldEnv = make(map[string]string)
 
@@ -83,6 +86,33 @@ func getAPIClient() *lfsapi.Client {
 	return apiClient
 }
 
+// getTransferStats returns the collector shared by all of the command's
+// transfer queues, or nil if GIT_LFS_STATS is not enabled.
+func getTransferStats() *tq.Stats {
+	global.Lock()
+	defer global.Unlock()
+
+	if transferStats == nil && cfg.Os.Bool("GIT_LFS_STATS", false) {
+		transferStats = tq.NewStats()
+	}
+	return transferStats
+}
+
+// transferProgressDir returns the directory in which transfer queues persist
+// the progress of their in-flight transfers.
+func transferProgressDir() string {
+	return filepath.Join(cfg.LFSStorageDir(), "progress")
+}
+
+// uploadJournalFile returns the path of the journal of objects uploaded to
+// "remote", with which a push that was interrupted can resume where it left
+// off.
+func uploadJournalFile(remote string) string {
+	e := getAPIClient().Endpoints.Endpoint("upload", remote)
+	sum := sha256.Sum256([]byte(e.Url))
+	return filepath.Join(cfg.TempDir(), fmt.Sprintf("upload-%x.journal", sum[:8]))
+}
+
 func closeAPIClient() error {
 	global.Lock()
 	defer global.Unlock()
@@ -122,6 +152,8 @@ func newDownloadCheckQueue(manifest tq.Manifest, remote string, options ...tq.Op
 func newDownloadQueue(manifest tq.Manifest, remote string, options ...tq.Option) *tq.TransferQueue {
 	return tq.NewTransferQueue(tq.Download, manifest, remote, append(options,
 		tq.RemoteRef(currentRemoteRef()),
+		tq.WithStats(getTransferStats()),
+		tq.WithProgressDir(transferProgressDir()),
 	)...)
 }
 
@@ -135,7 +167,8 @@ func buildFilepathFilter(config *config.Configuration, includeArg, excludeArg *s
 
 func buildFilepathFilterWithPatternType(config *config.Configuration, includeArg, excludeArg *string, useFetchOptions bool, patternType filepathfilter.PatternType) *filepathfilter.Filter {
 	inc, exc := determineIncludeExcludePaths(config, includeArg, excludeArg, useFetchOptions)
-	return filepathfilter.New(inc, exc, patternType)
+	return filepathfilter.New(inc, exc, patternType,
+		filepathfilter.CaseFold(config.Os.Bool("GIT_ICASE_PATHSPECS", false)))
 }
 
 func downloadTransfer(p *lfs.WrappedPointer) (name, path, oid string, size int64, missing bool, err error) {
@@ -220,6 +253,7 @@ func Print(format string, args ...interface{}) {
 // Exit prints a formatted message and exits.
 func Exit(format string, args ...interface{}) {
 	Error(format, args...)
+	writeTransferStats()
 	os.Exit(2)
 }
 
@@ -274,6 +308,7 @@ func LoggedError(err error, format string, args ...interface{}) {
 // a log file before exiting.
 func Panic(err error, format string, args ...interface{}) {
 	LoggedError(err, format, args...)
+	writeTransferStats()
 	os.Exit(2)
 }
 
@@ -328,6 +363,7 @@ func setupRepository() {
 	if !bare {
 		changeToWorkingCopy()
 	}
+	registerSharedStore()
 }
 
 func verifyRepositoryVersion() {
@@ -346,6 +382,16 @@ func setupWorkingCopy() {
 	requireWorkingCopy()
 	verifyRepositoryVersion()
 	changeToWorkingCopy()
+	registerSharedStore()
+}
+
+// registerSharedStore records that the repository uses its object store, if
+// the store is shared with other repositories, so that pruning in them retains
+// the objects this one needs.
+func registerSharedStore() {
+	if err := cfg.Filesystem().RegisterRepository(cfg.LocalWorkingDir()); err != nil {
+		tracerx.Printf("unable to register repository with shared object store: %s", err)
+	}
 }
 
 func changeToWorkingCopy() {
@@ -0,0 +1,89 @@
+package commands
+
+import (
+	"sync"
+
+	"github.com/git-lfs/git-lfs/v3/tools"
+	"github.com/git-lfs/git-lfs/v3/tools/humanize"
+	"github.com/git-lfs/git-lfs/v3/tq"
+	"github.com/git-lfs/git-lfs/v3/tr"
+)
+
+// dryRunReport prints each object which one or more dry-run transfer queues
+// would have transferred, having asked the server about them, and then the
+// number and total size of those objects. A nil *dryRunReport is valid and
+// reports nothing.
+type dryRunReport struct {
+	// verb is printed before each object, e.g., "push".
+	verb string
+
+	mu    sync.Mutex
+	seen  tools.StringSet
+	count int
+	size  int64
+
+	wg sync.WaitGroup
+}
+
+func newDryRunReport(verb string) *dryRunReport {
+	return &dryRunReport{
+		verb: verb,
+		seen: tools.NewStringSet(),
+	}
+}
+
+// Watch reports the objects which the queue "q" would transfer. The queue must
+// have been created with tq.DryRun(true).
+func (r *dryRunReport) Watch(q *tq.TransferQueue) {
+	if r == nil {
+		return
+	}
+
+	watch := q.Watch()
+
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+
+		for t := range watch {
+			r.add(t)
+		}
+	}()
+}
+
+func (r *dryRunReport) add(t *tq.Transfer) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Empty objects are never transferred, and the queue reports each
+	// path at which an object appears.
+	if t.Size == 0 || r.seen.Contains(t.Oid) {
+		return
+	}
+	r.seen.Add(t.Oid)
+
+	Print("%s %s => %s", r.verb, t.Oid, t.Name)
+	r.count++
+	r.size += t.Size
+}
+
+// Summarize waits for all of the watched queues to finish, and prints the
+// number and total size of the objects they would have transferred.
+func (r *dryRunReport) Summarize() {
+	if r == nil {
+		return
+	}
+
+	r.wg.Wait()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	Print(tr.Tr.GetN(
+		"%d object would be transferred (%s)",
+		"%d objects would be transferred (%s)",
+		r.count,
+		r.count,
+		humanize.FormatBytes(uint64(r.size)),
+	))
+}
@@ -0,0 +1,112 @@
+package commands
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/git-lfs/git-lfs/v3/errors"
+	"github.com/git-lfs/git-lfs/v3/filepathfilter"
+	"github.com/git-lfs/git-lfs/v3/lfs"
+	"github.com/git-lfs/git-lfs/v3/tools"
+	"github.com/git-lfs/git-lfs/v3/tr"
+	"github.com/spf13/cobra"
+)
+
+var (
+	pathsFromArg  string
+	pathsFromNull bool
+)
+
+// addPathsFromFlags registers the --paths-from and -z flags on the given
+// command.
+func addPathsFromFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&pathsFromArg, "paths-from", "", "", "Read a list of paths from the given file, or stdin if '-'")
+	cmd.Flags().BoolVarP(&pathsFromNull, "null", "z", false, "Paths read with --paths-from are NUL-separated")
+}
+
+// readPathsFrom reads a list of paths from the file with the given name, or
+// from stdin if the name is "-". Paths are separated by newlines, or by NUL
+// characters if nul is true. Empty entries are ignored.
+func readPathsFrom(name string, nul bool) ([]string, error) {
+	var r io.Reader
+	if name == "-" {
+		r = os.Stdin
+	} else {
+		f, err := os.Open(name)
+		if err != nil {
+			return nil, errors.Wrap(err, tr.Tr.Get("Error reading paths from %q:", name))
+		}
+		defer f.Close()
+		r = f
+	}
+
+	scanner := bufio.NewScanner(r)
+	if nul {
+		scanner.Split(tools.SplitOnNul)
+	}
+
+	var paths []string
+	for scanner.Scan() {
+		path := scanner.Text()
+		if !nul {
+			path = strings.TrimSuffix(path, "\r")
+		}
+		if path != "" {
+			paths = append(paths, path)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrap(err, tr.Tr.Get("Error reading paths from %q:", name))
+	}
+	return paths, nil
+}
+
+// pathsFromList reads the list of paths given by --paths-from. It returns
+// false if --paths-from was not given.
+func pathsFromList() ([]string, bool) {
+	if len(pathsFromArg) == 0 {
+		if pathsFromNull {
+			Exit(tr.Tr.Get("-z requires --paths-from"))
+		}
+		return nil, false
+	}
+
+	paths, err := readPathsFrom(pathsFromArg, pathsFromNull)
+	if err != nil {
+		ExitWithError(err)
+	}
+	return paths, true
+}
+
+// literalPathPatterns returns a literal pattern for each of the given paths,
+// after making them relative to the root of the repository.
+func literalPathPatterns(paths []string) []filepathfilter.Pattern {
+	pathConverter, err := lfs.NewCurrentToRepoPathConverter(cfg)
+	if err != nil {
+		ExitWithError(err)
+	}
+
+	patterns := make([]filepathfilter.Pattern, 0, len(paths))
+	for _, p := range paths {
+		rooted := path.Clean(filepath.ToSlash(pathConverter.Convert(p)))
+		patterns = append(patterns, filepathfilter.NewLiteralPattern(rooted))
+	}
+	return patterns
+}
+
+// buildPathsFromFilter returns a filter which includes only the paths matched
+// by the given patterns, and which excludes any paths matched by the exclude
+// patterns in effect for the command.
+func buildPathsFromFilter(patterns []filepathfilter.Pattern, excludeArg *string, useFetchOptions bool) *filepathfilter.Filter {
+	_, exclude := determineIncludeExcludePaths(cfg, nil, excludeArg, useFetchOptions)
+
+	excludePatterns := make([]filepathfilter.Pattern, 0, len(exclude))
+	for _, p := range exclude {
+		excludePatterns = append(excludePatterns, filepathfilter.NewPattern(p, filepathfilter.GitIgnore))
+	}
+	return filepathfilter.NewFromPatterns(patterns, excludePatterns)
+}
@@ -0,0 +1,94 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/git-lfs/git-lfs/v3/subprocess"
+	"github.com/git-lfs/git-lfs/v3/tr"
+	"github.com/spf13/cobra"
+)
+
+// pluginPrefix is prepended to the name of a subcommand which Git LFS does not
+// provide itself, to find the executable on PATH which implements it, so that
+// "git lfs foo" runs "git-lfs-foo", in the same way as Git runs "git-foo".
+const pluginPrefix = "git-lfs-"
+
+// runPlugin runs the executable implementing the subcommand named by args[0],
+// with the remaining arguments, if it is not one of root's own commands. It
+// returns the plugin's exit code, and whether a plugin was run at all.
+func runPlugin(root *cobra.Command, args []string) (int, bool) {
+	if len(args) == 0 || !isPluginName(args[0]) || isBuiltinCommand(root, args[0]) {
+		return 0, false
+	}
+
+	path, err := subprocess.LookPath(pluginPrefix + args[0])
+	if err != nil {
+		return 0, false
+	}
+
+	cmd, err := subprocess.ExecCommand(path, args[1:]...)
+	if err != nil {
+		return 0, false
+	}
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.Env = append(append([]string{}, cmd.Env...), pluginEnvironment()...)
+
+	err = cmd.Run()
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		if code := exitErr.ExitCode(); code >= 0 {
+			return code, true
+		}
+		// The plugin was killed by a signal.
+		return 1, true
+	} else if err != nil {
+		fmt.Fprintln(os.Stderr, tr.Tr.Get("Unable to run %s: %v", pluginPrefix+args[0], err))
+		return 127, true
+	}
+	return 0, true
+}
+
+// isPluginName returns whether "name" may name a plugin, as opposed to being a
+// flag or containing a path.
+func isPluginName(name string) bool {
+	return len(name) > 0 && !strings.HasPrefix(name, "-") && !strings.ContainsAny(name, `/\`)
+}
+
+// isBuiltinCommand returns whether "name" is one of root's own commands, or one
+// which cobra adds when the command is executed.
+func isBuiltinCommand(root *cobra.Command, name string) bool {
+	if name == "help" {
+		return true
+	}
+
+	for _, cmd := range root.Commands() {
+		if cmd.Name() == name || cmd.HasAlias(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// pluginEnvironment returns the variables describing the repository's remote
+// and its endpoint which are passed to plugins, so that they need not resolve
+// them themselves. No credentials are passed; plugins which need them should
+// ask "git credential fill" for the endpoint.
+func pluginEnvironment() []string {
+	if !cfg.InRepo() {
+		return nil
+	}
+
+	remote := cfg.Remote()
+	endpoint := getAPIClient().Endpoints.Endpoint("download", remote)
+	access := getAPIClient().Endpoints.AccessFor(endpoint.Url)
+
+	return []string{
+		"GIT_LFS_REMOTE=" + remote,
+		"GIT_LFS_ENDPOINT=" + endpoint.Url,
+		fmt.Sprintf("GIT_LFS_ENDPOINT_ACCESS=%s", access.Mode()),
+	}
+}
@@ -21,6 +21,9 @@ var (
 	commandMu    sync.Mutex
 
 	rootVersion bool
+	rootLogFile string
+
+	writeStatsOnce sync.Once
 )
 
 // NewCommand creates a new 'git-lfs' sub command, given a command name and
@@ -162,6 +165,8 @@ Simply type ` + root.Name() + ` help [path to command] for full details.`,
 	root.SetUsageFunc(usageCommand)
 
 	root.Flags().BoolVarP(&rootVersion, "version", "v", false, "")
+	root.PersistentFlags().StringVar(&rootLogFile, "log-file", "", "")
+	root.PersistentPreRun = setupLogFile
 
 	canonicalizeEnvironment()
 
@@ -173,8 +178,14 @@ Simply type ` + root.Name() + ` help [path to command] for full details.`,
 		}
 	}
 
+	if code, ok := runPlugin(root, os.Args[1:]); ok {
+		closeAPIClient()
+		return code
+	}
+
 	err := root.Execute()
 	closeAPIClient()
+	writeTransferStats()
 
 	if err != nil {
 		return 127
@@ -213,6 +224,23 @@ func printHelp(commandName string) {
 	}
 }
 
+// setupLogFile duplicates all diagnostic output to the file given by the
+// --log-file flag, if any.
+func setupLogFile(cmd *cobra.Command, args []string) {
+	if len(rootLogFile) == 0 {
+		return
+	}
+
+	file, err := os.OpenFile(rootLogFile, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0666)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, tr.Tr.Get("Error opening log file: %s", err))
+		return
+	}
+
+	ErrorWriter = newMultiWriter(os.Stderr, ErrorBuffer, file)
+	log.SetOutput(ErrorWriter)
+}
+
 func setupHTTPLogger(cmd *cobra.Command, args []string) {
 	if len(os.Getenv("GIT_LOG_STATS")) < 1 {
 		return
@@ -232,3 +260,38 @@ func setupHTTPLogger(cmd *cobra.Command, args []string) {
 		getAPIClient().LogHTTPStats(file)
 	}
 }
+
+// writeTransferStats writes a JSON report of the objects transferred by the
+// command to the log directory, if GIT_LFS_STATS is enabled. Only the first
+// call has any effect.
+func writeTransferStats() {
+	writeStatsOnce.Do(func() {
+		global.Lock()
+		stats := transferStats
+		global.Unlock()
+
+		if stats == nil {
+			return
+		}
+
+		logBase := filepath.Join(cfg.LocalLogDir(), "stats")
+		if err := tools.MkdirAll(logBase, cfg); err != nil {
+			fmt.Fprintln(os.Stderr, tr.Tr.Get("Error writing transfer stats: %s", err))
+			return
+		}
+
+		name := filepath.Join(logBase, fmt.Sprintf("stats-%d.json", time.Now().UnixNano()))
+		file, err := os.Create(name)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, tr.Tr.Get("Error writing transfer stats: %s", err))
+			return
+		}
+		defer file.Close()
+
+		if err := stats.WriteJSON(file); err != nil {
+			fmt.Fprintln(os.Stderr, tr.Tr.Get("Error writing transfer stats: %s", err))
+			return
+		}
+		fmt.Fprintln(os.Stderr, tr.Tr.Get("Transfer stats written to %s", name))
+	})
+}
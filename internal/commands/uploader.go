@@ -49,7 +49,9 @@ func uploadForRefUpdates(ctx *uploadContext, updates []*git.RefUpdate, pushAll b
 }
 
 func uploadRangeOrAll(g *lfs.GitScanner, ctx *uploadContext, q *tq.TransferQueue, exclude []string, update *git.RefUpdate, pushAll bool) error {
-	cb := ctx.gitScannerCallback(q)
+	cb, flush := ctx.gitScannerCallback(q)
+	defer flush()
+
 	if pushAll {
 		if err := g.ScanRefWithDeleted(update.LocalRefCommitish(), cb); err != nil {
 			return err
@@ -72,6 +74,11 @@ type uploadContext struct {
 	logger *tasklog.Logger
 	meter  *tq.Meter
 
+	// dryRunReport, if set, reports the objects which the server says it
+	// needs during a dry run, rather than every object which would be
+	// considered for upload.
+	dryRunReport *dryRunReport
+
 	committerName  string
 	committerEmail string
 
@@ -81,6 +88,10 @@ type uploadContext struct {
 	// pointers should allow pushing Git blobs
 	allowMissing bool
 
+	// usePreflight specifies whether the server is asked which objects
+	// it already has before their files are read
+	usePreflight bool
+
 	// tracks errors from gitscanner callbacks
 	scannerErr error
 	errMu      sync.Mutex
@@ -102,12 +113,13 @@ func newUploadContext(dryRun bool) *uploadContext {
 		gitfilter:    lfs.NewGitFilter(cfg),
 		lockVerifier: newLockVerifier(manifest),
 		allowMissing: cfg.Git.Bool("lfs.allowincompletepush", false),
+		usePreflight: cfg.Git.Bool("lfs.pushpreflight", false),
 		missing:      make(map[string]string),
 		corrupt:      make(map[string]string),
 		otherErrs:    make([]error, 0),
 	}
 
-	var sink io.Writer = os.Stdout
+	var sink io.Writer = os.Stderr
 	if dryRun {
 		sink = ioutil.Discard
 	}
@@ -122,10 +134,15 @@ func newUploadContext(dryRun bool) *uploadContext {
 }
 
 func (c *uploadContext) NewQueue(options ...tq.Option) *tq.TransferQueue {
-	return tq.NewTransferQueue(tq.Upload, c.Manifest, c.Remote, append(options,
+	q := tq.NewTransferQueue(tq.Upload, c.Manifest, c.Remote, append(options,
 		tq.DryRun(c.DryRun),
 		tq.WithProgress(c.meter),
+		tq.WithStats(getTransferStats()),
+		tq.WithProgressDir(transferProgressDir()),
+		tq.WithJournal(uploadJournalFile(c.Remote)),
 	)...)
+	c.dryRunReport.Watch(q)
+	return q
 }
 
 func (c *uploadContext) scannerError() error {
@@ -150,14 +167,51 @@ func (c *uploadContext) buildGitScanner() *lfs.GitScanner {
 	return lfs.NewGitScannerForPush(cfg, c.Remote, func(n string) { c.lockVerifier.LockedByThem(n) }, c.lockVerifier)
 }
 
-func (c *uploadContext) gitScannerCallback(tqueue *tq.TransferQueue) func(*lfs.WrappedPointer, error) {
-	return func(p *lfs.WrappedPointer, err error) {
+// gitScannerCallback returns a callback for the GitScanner which adds the
+// pointers it finds to "tqueue", and a function which adds any pointers which
+// remain once scanning is complete. If lfs.pushpreflight is set, the pointers
+// are added in groups, so that the server may be asked which of them it
+// already has at once.
+func (c *uploadContext) gitScannerCallback(tqueue *tq.TransferQueue) (func(*lfs.WrappedPointer, error), func()) {
+	if !c.usePreflight {
+		return func(p *lfs.WrappedPointer, err error) {
+			if err != nil {
+				c.addScannerError(err)
+			} else {
+				c.UploadPointers(tqueue, p)
+			}
+		}, func() {}
+	}
+
+	var mu sync.Mutex
+	pending := make([]*lfs.WrappedPointer, 0, tqueue.BatchSize())
+
+	flush := func() {
+		c.UploadPointers(tqueue, pending...)
+		pending = pending[:0]
+	}
+
+	cb := func(p *lfs.WrappedPointer, err error) {
 		if err != nil {
 			c.addScannerError(err)
-		} else {
-			c.UploadPointers(tqueue, p)
+			return
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		pending = append(pending, p)
+		if len(pending) >= cap(pending) {
+			flush()
 		}
 	}
+
+	return cb, func() {
+		mu.Lock()
+		defer mu.Unlock()
+
+		flush()
+	}
 }
 
 // AddUpload adds the given oid to the set of oids that have been uploaded in
@@ -230,7 +284,13 @@ func (c *uploadContext) UploadPointers(q *tq.TransferQueue, unfiltered ...*lfs.W
 				continue
 			}
 
-			Print("%s %s => %s", tr.Tr.Get("push"), p.Oid, p.Name)
+			if c.dryRunReport != nil {
+				// The queue asks the server whether it needs
+				// the object, without reading its file.
+				q.Add(p.Name, "", p.Oid, p.Size, false, nil)
+			} else {
+				Print("%s %s => %s", tr.Tr.Get("push"), p.Oid, p.Name)
+			}
 			c.SetUploaded(p.Oid)
 		}
 
@@ -238,6 +298,9 @@ func (c *uploadContext) UploadPointers(q *tq.TransferQueue, unfiltered ...*lfs.W
 	}
 
 	pointers := c.prepareUpload(unfiltered...)
+	if c.usePreflight {
+		pointers = c.preflight(q, pointers)
+	}
 	for _, p := range pointers {
 		t, err := c.uploadTransfer(p)
 		if err != nil && !errors.IsCleanPointerError(err) {
@@ -249,6 +312,33 @@ func (c *uploadContext) UploadPointers(q *tq.TransferQueue, unfiltered ...*lfs.W
 	}
 }
 
+// preflight returns those of the given pointers whose objects the server does
+// not already have, marking the rest as uploaded, before any of their files
+// are read.
+func (c *uploadContext) preflight(q *tq.TransferQueue, pointers []*lfs.WrappedPointer) []*lfs.WrappedPointer {
+	transfers := make([]*tq.Transfer, 0, len(pointers))
+	for _, p := range pointers {
+		transfers = append(transfers, &tq.Transfer{Name: p.Name, Oid: p.Oid, Size: p.Size})
+	}
+
+	needed := tools.NewStringSet()
+	for _, t := range q.Preflight(transfers) {
+		needed.Add(t.Oid)
+	}
+
+	remaining := make([]*lfs.WrappedPointer, 0, needed.Cardinality())
+	for _, p := range pointers {
+		if needed.Contains(p.Oid) {
+			remaining = append(remaining, p)
+			continue
+		}
+
+		c.meter.Skip(p.Size)
+		c.SetUploaded(p.Oid)
+	}
+	return remaining
+}
+
 func (c *uploadContext) CollectErrors(tqueue *tq.TransferQueue) {
 	tqueue.Wait()
 
@@ -267,6 +357,7 @@ func (c *uploadContext) CollectErrors(tqueue *tq.TransferQueue) {
 
 func (c *uploadContext) ReportErrors() {
 	c.meter.Finish()
+	c.dryRunReport.Summarize()
 
 	for _, err := range c.otherErrs {
 		FullError(err)
@@ -280,14 +371,14 @@ func (c *uploadContext) ReportErrors() {
 			action = tr.Tr.Get("failed")
 		}
 
-		Print(tr.Tr.Get("Git LFS upload %s:", action))
+		Error(tr.Tr.Get("Git LFS upload %s:", action))
 		for name, oid := range c.missing {
 			// TRANSLATORS: Leading spaces should be preserved.
-			Print(tr.Tr.Get("  (missing) %s (%s)", name, oid))
+			Error(tr.Tr.Get("  (missing) %s (%s)", name, oid))
 		}
 		for name, oid := range c.corrupt {
 			// TRANSLATORS: Leading spaces should be preserved.
-			Print(tr.Tr.Get("  (corrupt) %s (%s)", name, oid))
+			Error(tr.Tr.Get("  (corrupt) %s (%s)", name, oid))
 		}
 
 		if !c.allowMissing {
@@ -295,19 +386,21 @@ func (c *uploadContext) ReportErrors() {
 				tr.Tr.Get("hint: Your push was rejected due to missing or corrupt local objects."),
 				tr.Tr.Get("hint: You can disable this check with: `git config lfs.allowincompletepush true`"),
 			}
-			Print(strings.Join(pushMissingHint, "\n"))
+			Error(strings.Join(pushMissingHint, "\n"))
+			writeTransferStats()
 			os.Exit(2)
 		}
 	}
 
 	if len(c.otherErrs) > 0 {
+		writeTransferStats()
 		os.Exit(2)
 	}
 
 	if c.lockVerifier.HasUnownedLocks() {
-		Print(tr.Tr.Get("Unable to push locked files:"))
+		Error(tr.Tr.Get("Unable to push locked files:"))
 		for _, unowned := range c.lockVerifier.UnownedLocks() {
-			Print("* %s - %s", unowned.Path(), unowned.Owners())
+			Error("* %s - %s", unowned.Path(), unowned.Owners())
 		}
 
 		if c.lockVerifier.Enabled() {
@@ -316,9 +409,9 @@ func (c *uploadContext) ReportErrors() {
 			Error(tr.Tr.Get("warning: The above files would have halted this push."))
 		}
 	} else if c.lockVerifier.HasOwnedLocks() {
-		Print(tr.Tr.Get("Consider unlocking your own locked files: (`git lfs unlock <path>`)"))
+		Error(tr.Tr.Get("Consider unlocking your own locked files: (`git lfs unlock <path>`)"))
 		for _, owned := range c.lockVerifier.OwnedLocks() {
-			Print("* %s", owned.Path())
+			Error("* %s", owned.Path())
 		}
 	}
 }
@@ -348,10 +441,9 @@ func (c *uploadContext) uploadTransfer(p *lfs.WrappedPointer) (*tq.Transfer, err
 		return nil, errors.Wrap(err, tr.Tr.Get("Error uploading file %s (%s)", filename, oid))
 	}
 
-	// Skip the object if its corresponding file does not exist in
-	// .git/lfs/objects/.
+	// Skip the object if it is not in the local object store.
 	if len(filename) > 0 {
-		if missing, err = c.ensureFile(filename, localMediaPath, oid); err != nil && !errors.IsCleanPointerError(err) {
+		if missing, err = c.ensureFile(filename, oid); err != nil && !errors.IsCleanPointerError(err) {
 			return nil, err
 		}
 	}
@@ -365,10 +457,15 @@ func (c *uploadContext) uploadTransfer(p *lfs.WrappedPointer) (*tq.Transfer, err
 	}, nil
 }
 
-// ensureFile makes sure that the cleanPath exists before pushing it.  If it
-// does not exist, it attempts to clean it by reading the file at smudgePath.
-func (c *uploadContext) ensureFile(smudgePath, cleanPath, oid string) (bool, error) {
-	if _, err := os.Stat(cleanPath); err == nil {
+// ensureFile makes sure that the object oid is in the local object store
+// before pushing it.  If it is not, it attempts to clean it by reading the file
+// at smudgePath.
+func (c *uploadContext) ensureFile(smudgePath, oid string) (bool, error) {
+	store, err := cfg.Filesystem().Store()
+	if err != nil {
+		return false, err
+	}
+	if _, err := store.Size(oid); err == nil {
 		return false, nil
 	}
 
@@ -0,0 +1,216 @@
+package lfs
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/git-lfs/git-lfs/v3/config"
+	"github.com/git-lfs/git-lfs/v3/fs"
+	"github.com/rubyist/tracerx"
+)
+
+// cleanIndexCompactLines is the number of lines, beyond twice the number of
+// files it describes, at which the clean index is rewritten to drop the
+// entries which later ones replaced.
+const cleanIndexCompactLines = 256
+
+// cleanIndexEntry records the OID to which a file hashed, the file's size and
+// modification time when it did, and when the entry was recorded.
+type cleanIndexEntry struct {
+	oid      string
+	size     int64
+	mtime    int64
+	recorded int64
+}
+
+// racy returns whether the entry was recorded too soon after the file was
+// modified to be trusted.
+func (e *cleanIndexEntry) racy() bool {
+	return e.recorded < e.mtime+int64(time.Second)
+}
+
+// cleanIndex remembers the OID to which each working tree file last hashed, so
+// that a file which has not changed since need not be hashed again. As with
+// Git's index, a file is taken to be unchanged if it has the same size and
+// modification time as it did. Entries are appended to a file in the LFS
+// storage directory as they are recorded, and each line is one entry, so that
+// any number of processes may share it.
+//
+// Only files which were read directly are recorded, and not the contents
+// given to the clean filter, since those need not be the file's, as with "git
+// hash-object --stdin --path".
+type cleanIndex struct {
+	cfg *config.Configuration
+
+	mu      sync.Mutex
+	path    string
+	entries map[string]*cleanIndexEntry
+}
+
+func newCleanIndex(cfg *config.Configuration) *cleanIndex {
+	return &cleanIndex{cfg: cfg}
+}
+
+// lookup returns the entry for the file "abs", whose current state is "stat",
+// if it has the same size and modification time as it did when the entry was
+// recorded.
+//
+// An entry recorded within a second of the file's modification is not trusted,
+// since the file may have been changed again within that second without its
+// modification time changing, on filesystems which record it only to the
+// second; Git treats such "racily clean" entries alike.
+func (i *cleanIndex) lookup(abs string, stat os.FileInfo) (*cleanIndexEntry, bool) {
+	if stat == nil || !stat.Mode().IsRegular() {
+		return nil, false
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	i.load()
+
+	e, ok := i.entries[abs]
+	if !ok || e.size != stat.Size() || e.mtime != stat.ModTime().UnixNano() {
+		return nil, false
+	}
+	if e.racy() {
+		return nil, false
+	}
+	return e, true
+}
+
+// record notes that the file "abs", whose state was "stat", hashed to "oid".
+func (i *cleanIndex) record(abs string, stat os.FileInfo, oid string) {
+	if stat == nil || !stat.Mode().IsRegular() || strings.ContainsAny(abs, "\r\n") {
+		return
+	}
+
+	e := &cleanIndexEntry{
+		oid:      oid,
+		size:     stat.Size(),
+		mtime:    stat.ModTime().UnixNano(),
+		recorded: time.Now().UnixNano(),
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	i.load()
+	if len(i.path) == 0 {
+		return
+	}
+	if old, ok := i.entries[abs]; ok && !old.racy() && old.oid == e.oid && old.size == e.size && old.mtime == e.mtime {
+		return
+	}
+	i.entries[abs] = e
+
+	f, err := os.OpenFile(i.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		tracerx.Printf("clean: unable to write index %s: %s", i.path, err)
+		return
+	}
+	defer f.Close()
+
+	fmt.Fprint(f, formatCleanIndexEntry(abs, e))
+}
+
+// load reads the index from its file, the first time it is needed, and
+// compacts the file if it has grown too long.
+func (i *cleanIndex) load() {
+	if i.entries != nil {
+		return
+	}
+	i.entries = make(map[string]*cleanIndexEntry)
+
+	dir := i.cfg.LFSStorageDir()
+	if len(dir) == 0 {
+		return
+	}
+	i.path = filepath.Join(dir, "clean-index")
+
+	f, err := os.Open(i.path)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	var lines int
+	r := bufio.NewReader(f)
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			// A final line without a newline is incomplete.
+			break
+		}
+		lines++
+
+		if abs, e, ok := parseCleanIndexEntry(strings.TrimSuffix(line, "\n")); ok {
+			i.entries[abs] = e
+		}
+	}
+
+	if lines > 2*len(i.entries)+cleanIndexCompactLines {
+		i.compact()
+	}
+}
+
+// compact rewrites the index file with only the current entry of each file.
+func (i *cleanIndex) compact() {
+	tmp := fmt.Sprintf("%s.%d", i.path, os.Getpid())
+	f, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		tracerx.Printf("clean: unable to compact index %s: %s", i.path, err)
+		return
+	}
+
+	w := bufio.NewWriter(f)
+	for abs, e := range i.entries {
+		w.WriteString(formatCleanIndexEntry(abs, e))
+	}
+	err = w.Flush()
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp, i.path)
+	}
+	if err != nil {
+		tracerx.Printf("clean: unable to compact index %s: %s", i.path, err)
+		os.Remove(tmp)
+	}
+}
+
+// formatCleanIndexEntry returns the line of the index file recording "e" for
+// the file "abs".
+func formatCleanIndexEntry(abs string, e *cleanIndexEntry) string {
+	return fmt.Sprintf("%s %d %d %d %s\n", e.oid, e.size, e.mtime, e.recorded, abs)
+}
+
+// parseCleanIndexEntry parses a line of the index file, without its newline.
+func parseCleanIndexEntry(line string) (string, *cleanIndexEntry, bool) {
+	fields := strings.SplitN(line, " ", 5)
+	if len(fields) != 5 || fs.ValidateOid(fields[0]) != nil {
+		return "", nil, false
+	}
+
+	size, err := strconv.ParseInt(fields[1], 10, 64)
+	if err != nil {
+		return "", nil, false
+	}
+	mtime, err := strconv.ParseInt(fields[2], 10, 64)
+	if err != nil {
+		return "", nil, false
+	}
+	recorded, err := strconv.ParseInt(fields[3], 10, 64)
+	if err != nil {
+		return "", nil, false
+	}
+
+	return fields[4], &cleanIndexEntry{oid: fields[0], size: size, mtime: mtime, recorded: recorded}, true
+}
@@ -0,0 +1,39 @@
+package lfs
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCleanIndexEntryRoundTrip(t *testing.T) {
+	e := &cleanIndexEntry{
+		oid:      "4d7a214614ab2935c943f9e0ff69d22eadbb8f32b1258daaa5e2ca24d17e2393",
+		size:     12345,
+		mtime:    1500000000000000000,
+		recorded: 1500000002000000000,
+	}
+
+	line := formatCleanIndexEntry("/repo/a b.dat", e)
+	assert.Equal(t, "4d7a214614ab2935c943f9e0ff69d22eadbb8f32b1258daaa5e2ca24d17e2393 12345 1500000000000000000 1500000002000000000 /repo/a b.dat\n", line)
+
+	abs, parsed, ok := parseCleanIndexEntry(strings.TrimSuffix(line, "\n"))
+	assert.True(t, ok)
+	assert.Equal(t, "/repo/a b.dat", abs)
+	assert.Equal(t, e, parsed)
+
+	// Entries from before the time they were recorded was kept are
+	// ignored.
+	_, _, ok = parseCleanIndexEntry("4d7a214614ab2935c943f9e0ff69d22eadbb8f32b1258daaa5e2ca24d17e2393 12345 1500000000000000000 /repo/a.dat")
+	assert.False(t, ok)
+}
+
+func TestCleanIndexEntryRacy(t *testing.T) {
+	mtime := time.Now().UnixNano()
+
+	assert.True(t, (&cleanIndexEntry{mtime: mtime, recorded: mtime}).racy())
+	assert.True(t, (&cleanIndexEntry{mtime: mtime, recorded: mtime + int64(time.Second) - 1}).racy())
+	assert.False(t, (&cleanIndexEntry{mtime: mtime, recorded: mtime + int64(time.Second)}).racy())
+}
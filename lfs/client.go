@@ -0,0 +1,125 @@
+package lfs
+
+import (
+	"io"
+
+	"github.com/git-lfs/git-lfs/v3/config"
+	"github.com/git-lfs/git-lfs/v3/errors"
+	"github.com/git-lfs/git-lfs/v3/lfsapi"
+	"github.com/git-lfs/git-lfs/v3/tools"
+	"github.com/git-lfs/git-lfs/v3/tq"
+	"github.com/git-lfs/git-lfs/v3/tr"
+)
+
+// Client transfers the contents of Git LFS objects between a repository's
+// local object store and one of its remotes. Everything it needs, including
+// the endpoint, credentials, and HTTP transport, comes from the Configuration
+// it is created with, so a program which embeds Git LFS may use any number of
+// Clients at once, for different repositories or remotes.
+type Client struct {
+	cfg    *config.Configuration
+	api    *lfsapi.Client
+	remote string
+}
+
+// NewClient returns a Client for the repository described by "cfg", which
+// transfers objects to and from the remote named "remote", or the default
+// remote if that is empty.
+func NewClient(cfg *config.Configuration, remote string) (*Client, error) {
+	api, err := lfsapi.NewClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(remote) == 0 {
+		remote = cfg.Remote()
+	}
+
+	return &Client{cfg: cfg, api: api, remote: remote}, nil
+}
+
+// APIClient returns the API client with which the Client makes its requests,
+// for instance so that TransferHooks may be added to it.
+func (c *Client) APIClient() *lfsapi.Client {
+	return c.api
+}
+
+// Remote returns the name of the remote the Client transfers objects to and
+// from.
+func (c *Client) Remote() string {
+	return c.remote
+}
+
+// Download fetches the given objects from the remote into the local object
+// store. Objects which are already present, or which can be copied from a
+// reference repository, are not downloaded again.
+func (c *Client) Download(objects ...*Pointer) error {
+	missing := make([]*Pointer, 0, len(objects))
+	for _, p := range objects {
+		LinkOrCopyFromReference(c.cfg, p.Oid, p.Size)
+		if !c.cfg.LFSObjectExists(p.Oid, p.Size) {
+			missing = append(missing, p)
+		}
+	}
+
+	return c.transfer(tq.Download, missing)
+}
+
+// Upload sends the given objects from the local object store to the remote,
+// which must each be present locally.
+func (c *Client) Upload(objects ...*Pointer) error {
+	for _, p := range objects {
+		if !c.cfg.LFSObjectExists(p.Oid, p.Size) {
+			return errors.New(tr.Tr.Get("Unable to find source for object %v (try running `git lfs fetch --all`)", p.Oid))
+		}
+	}
+
+	return c.transfer(tq.Upload, objects)
+}
+
+// UploadFrom sends the object "oid" of "size" bytes to the remote, reading its
+// contents from "r" rather than the local object store, so that an object
+// produced by another process can be uploaded without being written to disk
+// first where possible. See tq.UploadFrom for details.
+func (c *Client) UploadFrom(r io.Reader, oid string, size int64, cb tools.CopyCallback) error {
+	manifest := tq.NewManifest(c.cfg.Filesystem(), c.api, tq.Upload.String(), c.remote)
+	return tq.UploadFrom(manifest, c.remote, r, oid, size, cb)
+}
+
+// GetObjectMeta asks the remote for the size, storage class, and any other
+// attributes of the object with the given OID and size, which may be zero if
+// it is not known, without downloading it. See tq.GetObjectsMeta for details.
+func (c *Client) GetObjectMeta(oid string, size int64) (*tq.ObjectMeta, error) {
+	manifest := tq.NewManifest(c.cfg.Filesystem(), c.api, tq.Download.String(), c.remote)
+	return tq.GetObjectMeta(manifest, c.remote, nil, oid, size)
+}
+
+// GetObjectsMeta asks the remote about each of "pointers", as GetObjectMeta
+// does, in as few batch requests as possible. An error which the server
+// returns for one of the objects is reported in its metadata rather than
+// returned. See tq.GetObjectsMeta for details.
+func (c *Client) GetObjectsMeta(pointers ...*Pointer) (map[string]*tq.ObjectMeta, error) {
+	objects := make([]*tq.Transfer, 0, len(pointers))
+	for _, p := range pointers {
+		objects = append(objects, &tq.Transfer{Oid: p.Oid, Size: p.Size})
+	}
+
+	manifest := tq.NewManifest(c.cfg.Filesystem(), c.api, tq.Download.String(), c.remote)
+	return tq.GetObjectsMeta(manifest, c.remote, nil, objects)
+}
+
+func (c *Client) transfer(dir tq.Direction, objects []*Pointer) error {
+	if len(objects) == 0 {
+		return nil
+	}
+
+	manifest := tq.NewManifest(c.cfg.Filesystem(), c.api, dir.String(), c.remote)
+	q := tq.NewTransferQueue(dir, manifest, c.remote)
+	for _, p := range objects {
+		path, err := c.cfg.Filesystem().ObjectPath(p.Oid)
+		q.Add(p.Oid, path, p.Oid, p.Size, false, err)
+	}
+	q.Wait()
+
+	return errors.Combine(q.Errors())
+}
@@ -0,0 +1,50 @@
+package lfs
+
+import (
+	"testing"
+
+	"github.com/git-lfs/git-lfs/v3/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientsAreIndependent(t *testing.T) {
+	a, err := NewClient(config.NewFrom(config.Values{
+		Git: map[string][]string{
+			"remote.origin.url": []string{"https://a.example.com/repo.git"},
+		},
+	}), "origin")
+	require.Nil(t, err)
+
+	b, err := NewClient(config.NewFrom(config.Values{
+		Git: map[string][]string{
+			"remote.upstream.url": []string{"https://b.example.com/repo.git"},
+		},
+	}), "upstream")
+	require.Nil(t, err)
+
+	assert.Equal(t, "origin", a.Remote())
+	assert.Equal(t, "upstream", b.Remote())
+
+	endpoint := func(c *Client) string {
+		return c.APIClient().Endpoints.Endpoint("download", c.Remote()).Url
+	}
+	assert.Equal(t, "https://a.example.com/repo.git/info/lfs", endpoint(a))
+	assert.Equal(t, "https://b.example.com/repo.git/info/lfs", endpoint(b))
+}
+
+func TestClientTransfersNothing(t *testing.T) {
+	c, err := NewClient(config.NewFrom(config.Values{}), "origin")
+	require.Nil(t, err)
+
+	assert.Nil(t, c.Download())
+	assert.Nil(t, c.Upload())
+}
+
+func TestClientUploadRequiresLocalObject(t *testing.T) {
+	c, err := NewClient(config.NewFrom(config.Values{}), "origin")
+	require.Nil(t, err)
+
+	err = c.Upload(NewPointer("4d7a214614ab2935c943f9e0ff69d22eadbb8f32b1258daaa5e2ca24d17e2393", 12, nil))
+	assert.NotNil(t, err)
+}
@@ -3,6 +3,7 @@ package lfs
 import (
 	"bufio"
 	"fmt"
+	"io"
 	"strconv"
 	"strings"
 
@@ -161,7 +162,7 @@ func (s *DiffIndexScanner) Entry() *DiffIndexEntry { return s.next }
 func (s *DiffIndexScanner) Err() error { return s.err }
 
 // prepareScan clears out the results from the last Scan() loop, and advances
-// the internal scanner to fetch a new line of Text().
+// the internal scanner to fetch a new NUL-terminated field of Text().
 func (s *DiffIndexScanner) prepareScan() bool {
 	s.next, s.err = nil, nil
 	if !s.from.Scan() {
@@ -172,21 +173,29 @@ func (s *DiffIndexScanner) prepareScan() bool {
 	return true
 }
 
-// scan parses the given line and returns a `*DiffIndexEntry` or an error,
-// depending on whether or not the parse was successful.
-func (s *DiffIndexScanner) scan(line string) (*DiffIndexEntry, error) {
-	// Format is:
-	//   :100644 100644 c5b3d83a7542255ec7856487baa5e83d65b1624c 9e82ac1b514be060945392291b5b3108c22f6fe3 M foo.gif
-	//   :<old mode> <new mode> <old sha1> <new sha1> <status>\t<file name>[\t<file name>]
-
-	parts := strings.Split(line, "\t")
-	if len(parts) < 2 {
-		return nil, errors.Errorf(tr.Tr.Get("invalid line: %s", line))
+// scanName advances the internal scanner to the next NUL-terminated field
+// and returns it as a file name.
+func (s *DiffIndexScanner) scanName() (string, error) {
+	if !s.from.Scan() {
+		if err := s.from.Err(); err != nil {
+			return "", err
+		}
+		return "", io.ErrUnexpectedEOF
 	}
+	return s.from.Text(), nil
+}
 
-	desc := strings.Fields(parts[0])
+// scan parses the given description and the file name(s) that follow it and
+// returns a `*DiffIndexEntry` or an error, depending on whether or not the
+// parse was successful.
+func (s *DiffIndexScanner) scan(line string) (*DiffIndexEntry, error) {
+	// Format is (with "-z", so each field is terminated by a NUL):
+	//   :100644 100644 c5b3d83a7542255ec7856487baa5e83d65b1624c 9e82ac1b514be060945392291b5b3108c22f6fe3 M NUL foo.gif NUL
+	//   :<old mode> <new mode> <old sha1> <new sha1> <status> NUL <file name> NUL [<file name> NUL]
+
+	desc := strings.Fields(line)
 	if len(desc) < 5 {
-		return nil, errors.Errorf(tr.Tr.Get("invalid description: %s", parts[0]))
+		return nil, errors.Errorf(tr.Tr.Get("invalid description: %s", line))
 	}
 
 	entry := &DiffIndexEntry{
@@ -195,15 +204,23 @@ func (s *DiffIndexScanner) scan(line string) (*DiffIndexEntry, error) {
 		SrcSha:  desc[2],
 		DstSha:  desc[3],
 		Status:  DiffIndexStatus(rune(desc[4][0])),
-		SrcName: parts[1],
 	}
 
 	if score, err := strconv.Atoi(desc[4][1:]); err != nil {
 		entry.StatusScore = score
 	}
 
-	if len(parts) > 2 {
-		entry.DstName = parts[2]
+	var err error
+	if entry.SrcName, err = s.scanName(); err != nil {
+		return nil, errors.Errorf(tr.Tr.Get("missing file name: %s", line))
+	}
+
+	// Only renames and copies have a second file name.
+	switch entry.Status {
+	case StatusRename, StatusCopy:
+		if entry.DstName, err = s.scanName(); err != nil {
+			return nil, errors.Errorf(tr.Tr.Get("missing destination file name: %s", line))
+		}
 	}
 
 	return entry, nil
@@ -0,0 +1,191 @@
+package lfs
+
+import (
+	"bufio"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"io"
+	"strings"
+
+	"github.com/git-lfs/git-lfs/v3/config"
+	"github.com/git-lfs/git-lfs/v3/errors"
+	"github.com/git-lfs/git-lfs/v3/subprocess"
+	"github.com/git-lfs/git-lfs/v3/tr"
+)
+
+const (
+	// encryptionMagic begins every encrypted object, and identifies the
+	// version of its format.
+	encryptionMagic = "GLFSENC1"
+
+	// encryptionChunkSize is the amount of plaintext sealed in each
+	// chunk of an encrypted object, so that objects can be encrypted and
+	// decrypted without holding them in memory.
+	encryptionChunkSize = 64 * 1024
+
+	// minEncryptionKeyLength is the shortest secret accepted as an
+	// encryption key.
+	minEncryptionKeyLength = 16
+)
+
+// EncryptionKey returns the secret used to encrypt and decrypt objects. It is
+// read from the output of the "lfs.encryption.keycommand" shell command, if
+// that is set, and otherwise from "lfs.encryption.key".
+func EncryptionKey(cfg *config.Configuration) ([]byte, error) {
+	var secret string
+	if command, _ := cfg.Git.Get("lfs.encryption.keycommand"); len(command) > 0 {
+		name, args := subprocess.FormatForShell(command, "")
+		cmd, err := subprocess.ExecCommand(name, args...)
+		if err != nil {
+			return nil, errors.Wrap(err, tr.Tr.Get("unable to run encryption key command"))
+		}
+		out, err := cmd.Output()
+		if err != nil {
+			return nil, errors.Wrap(err, tr.Tr.Get("unable to run encryption key command"))
+		}
+		secret = strings.TrimSpace(string(out))
+	} else {
+		secret, _ = cfg.Git.Get("lfs.encryption.key")
+	}
+
+	if len(secret) == 0 {
+		return nil, errors.New(tr.Tr.Get("no encryption key: set `lfs.encryption.key` or `lfs.encryption.keycommand`"))
+	}
+	if len(secret) < minEncryptionKeyLength {
+		return nil, errors.New(tr.Tr.Get("encryption key must be at least %d characters long", minEncryptionKeyLength))
+	}
+	return []byte(secret), nil
+}
+
+// EncryptObject encrypts the contents of "r" with AES-256-GCM using a key
+// derived from "secret", writing the result to "w".
+//
+// Encryption is deterministic: the nonce is derived from the secret and the
+// SHA-256 of the plaintext, so the same contents always encrypt to the same
+// object. This keeps the clean filter idempotent, at the cost of revealing
+// which objects have identical contents. Because of this, "r" is read twice.
+func EncryptObject(secret []byte, r io.ReadSeeker, w io.Writer) error {
+	hasher := sha256.New()
+	if _, err := io.Copy(hasher, r); err != nil {
+		return err
+	}
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	aead, err := newEncryptionCipher(secret)
+	if err != nil {
+		return err
+	}
+	nonce := deriveKey(deriveKey(secret, "nonce"), string(hasher.Sum(nil)))[:aead.NonceSize()]
+
+	if _, err := io.WriteString(w, encryptionMagic); err != nil {
+		return err
+	}
+	if _, err := w.Write(nonce); err != nil {
+		return err
+	}
+
+	br := bufio.NewReaderSize(r, encryptionChunkSize)
+	buf := make([]byte, encryptionChunkSize)
+	for counter := uint64(0); ; counter++ {
+		n, err := io.ReadFull(br, buf)
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			return err
+		}
+		_, perr := br.Peek(1)
+		final := perr == io.EOF
+
+		sealed := aead.Seal(nil, chunkNonce(nonce, counter), buf[:n], chunkAdditionalData(final))
+		if _, err := w.Write(sealed); err != nil {
+			return err
+		}
+		if final {
+			return nil
+		}
+	}
+}
+
+// DecryptObject decrypts an object written by EncryptObject with the same
+// "secret" from "r", writing the plaintext to "w". It returns an error if the
+// object was encrypted with a different secret, or has been modified or
+// truncated.
+func DecryptObject(secret []byte, r io.Reader, w io.Writer) error {
+	aead, err := newEncryptionCipher(secret)
+	if err != nil {
+		return err
+	}
+
+	header := make([]byte, len(encryptionMagic)+aead.NonceSize())
+	if _, err := io.ReadFull(r, header); err != nil || string(header[:len(encryptionMagic)]) != encryptionMagic {
+		return errors.New(tr.Tr.Get("object is not encrypted"))
+	}
+	nonce := header[len(encryptionMagic):]
+
+	br := bufio.NewReaderSize(r, encryptionChunkSize+aead.Overhead())
+	buf := make([]byte, encryptionChunkSize+aead.Overhead())
+	for counter := uint64(0); ; counter++ {
+		n, err := io.ReadFull(br, buf)
+		if err == io.EOF {
+			return errors.New(tr.Tr.Get("encrypted object is truncated"))
+		}
+		if err != nil && err != io.ErrUnexpectedEOF {
+			return err
+		}
+		_, perr := br.Peek(1)
+		final := perr == io.EOF
+
+		plaintext, err := aead.Open(buf[:0], chunkNonce(nonce, counter), buf[:n], chunkAdditionalData(final))
+		if err != nil {
+			return errors.New(tr.Tr.Get("unable to decrypt object: wrong key, or corrupt data"))
+		}
+		if _, err := w.Write(plaintext); err != nil {
+			return err
+		}
+		if final {
+			return nil
+		}
+	}
+}
+
+func newEncryptionCipher(secret []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(deriveKey(secret, "key"))
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// deriveKey returns a 32-byte key for the given purpose derived from "secret",
+// so that the same secret is never used directly for two purposes.
+func deriveKey(secret []byte, purpose string) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte("git-lfs encryption " + purpose))
+	return mac.Sum(nil)
+}
+
+// chunkNonce returns the nonce for the given chunk, which is the object's
+// nonce with the chunk's counter XORed into its last eight bytes.
+func chunkNonce(nonce []byte, counter uint64) []byte {
+	chunk := make([]byte, len(nonce))
+	copy(chunk, nonce)
+
+	var ctr [8]byte
+	binary.BigEndian.PutUint64(ctr[:], counter)
+	for i := range ctr {
+		chunk[len(chunk)-8+i] ^= ctr[i]
+	}
+	return chunk
+}
+
+// chunkAdditionalData authenticates whether a chunk is the last in its object,
+// so that truncating an object at a chunk boundary is detected.
+func chunkAdditionalData(final bool) []byte {
+	if final {
+		return []byte{1}
+	}
+	return []byte{0}
+}
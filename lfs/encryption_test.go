@@ -0,0 +1,71 @@
+package lfs
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testEncryptionKey = []byte("0123456789abcdef0123456789abcdef")
+
+func encryptForTest(t *testing.T, key, plaintext []byte) []byte {
+	var encrypted bytes.Buffer
+	require.Nil(t, EncryptObject(key, bytes.NewReader(plaintext), &encrypted))
+	return encrypted.Bytes()
+}
+
+func TestEncryptObjectRoundTrip(t *testing.T) {
+	for _, size := range []int{0, 1, encryptionChunkSize - 1, encryptionChunkSize, encryptionChunkSize + 1, 3*encryptionChunkSize + 17} {
+		plaintext := make([]byte, size)
+		rand.Read(plaintext)
+
+		encrypted := encryptForTest(t, testEncryptionKey, plaintext)
+		// Short plaintexts may appear in the ciphertext by chance.
+		if size > 16 {
+			assert.NotContains(t, string(encrypted), string(plaintext), "size %d", size)
+		}
+
+		var decrypted bytes.Buffer
+		require.Nil(t, DecryptObject(testEncryptionKey, bytes.NewReader(encrypted), &decrypted), "size %d", size)
+		assert.True(t, bytes.Equal(plaintext, decrypted.Bytes()), "size %d", size)
+	}
+}
+
+func TestEncryptObjectIsDeterministic(t *testing.T) {
+	a := encryptForTest(t, testEncryptionKey, []byte("contents"))
+	b := encryptForTest(t, testEncryptionKey, []byte("contents"))
+	c := encryptForTest(t, testEncryptionKey, []byte("other contents"))
+
+	assert.Equal(t, a, b)
+	assert.NotEqual(t, a[:len(encryptionMagic)+12], c[:len(encryptionMagic)+12])
+}
+
+func TestDecryptObjectWrongKey(t *testing.T) {
+	encrypted := encryptForTest(t, testEncryptionKey, []byte("contents"))
+
+	err := DecryptObject([]byte("fedcba9876543210fedcba9876543210"), bytes.NewReader(encrypted), &bytes.Buffer{})
+	require.NotNil(t, err)
+	assert.Equal(t, "unable to decrypt object: wrong key, or corrupt data", err.Error())
+}
+
+func TestDecryptObjectTruncated(t *testing.T) {
+	plaintext := make([]byte, 2*encryptionChunkSize+1)
+	encrypted := encryptForTest(t, testEncryptionKey, plaintext)
+
+	// Truncate the object after its first full chunk.
+	truncated := encrypted[:len(encryptionMagic)+12+encryptionChunkSize+16]
+	assert.NotNil(t, DecryptObject(testEncryptionKey, bytes.NewReader(truncated), &bytes.Buffer{}))
+
+	// Truncate the object after its header.
+	truncated = encrypted[:len(encryptionMagic)+12]
+	assert.NotNil(t, DecryptObject(testEncryptionKey, bytes.NewReader(truncated), &bytes.Buffer{}))
+}
+
+func TestDecryptObjectNotEncrypted(t *testing.T) {
+	err := DecryptObject(testEncryptionKey, bytes.NewReader([]byte("plain old contents")), &bytes.Buffer{})
+	require.NotNil(t, err)
+	assert.Equal(t, "object is not encrypted", err.Error())
+}
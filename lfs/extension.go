@@ -103,6 +103,10 @@ func pipeExtensions(cfg *config.Configuration, request *pipeRequest) (response p
 	for i, ec := range extcmds {
 		ec.hasher = sha256.New()
 
+		var errBuff bytes.Buffer
+		ec.err = &errBuff
+		ec.cmd.Stderr = ec.err
+
 		if i == last {
 			ec.cmd.Stdout = io.MultiWriter(ec.hasher, output)
 			ec.out = output
@@ -124,10 +128,6 @@ func pipeExtensions(cfg *config.Configuration, request *pipeRequest) (response p
 		ec.out = nextStdin
 
 		input = stdout
-
-		var errBuff bytes.Buffer
-		ec.err = &errBuff
-		ec.cmd.Stderr = ec.err
 	}
 
 	for _, ec := range extcmds {
@@ -4,17 +4,32 @@ import (
 	"github.com/git-lfs/git-lfs/v3/config"
 	"github.com/git-lfs/git-lfs/v3/fs"
 	"github.com/git-lfs/git-lfs/v3/git"
+	"github.com/git-lfs/git-lfs/v3/tq"
 )
 
 // GitFilter provides clean and smudge capabilities
 type GitFilter struct {
 	cfg *config.Configuration
 	fs  *fs.Filesystem
+
+	// transferOptions are given to every transfer queue the filter
+	// creates to download objects while smudging.
+	transferOptions []tq.Option
+
+	// index remembers the OIDs to which working tree files hashed.
+	index *cleanIndex
 }
 
 // NewGitFilter initializes a new *GitFilter
 func NewGitFilter(cfg *config.Configuration) *GitFilter {
-	return &GitFilter{cfg: cfg, fs: cfg.Filesystem()}
+	return &GitFilter{cfg: cfg, fs: cfg.Filesystem(), index: newCleanIndex(cfg)}
+}
+
+// SetTransferOptions sets options to apply to every transfer queue created by
+// the filter, so that a long-running filter process can share state such as
+// its statistics between the downloads of each file it smudges.
+func (f *GitFilter) SetTransferOptions(options ...tq.Option) {
+	f.transferOptions = options
 }
 
 func (f *GitFilter) ObjectPath(oid string) (string, error) {
@@ -4,11 +4,16 @@ import (
 	"bytes"
 	"crypto/sha256"
 	"encoding/hex"
+	"fmt"
 	"io"
 	"os"
+	"path/filepath"
 
 	"github.com/git-lfs/git-lfs/v3/errors"
+	"github.com/git-lfs/git-lfs/v3/fs"
 	"github.com/git-lfs/git-lfs/v3/tools"
+	"github.com/git-lfs/git-lfs/v3/tr"
+	"github.com/rubyist/tracerx"
 )
 
 type cleanedAsset struct {
@@ -59,6 +64,127 @@ func (f *GitFilter) Clean(reader io.Reader, fileName string, fileSize int64, cb
 	return &cleanedAsset{tmp.Name(), pointer}, err
 }
 
+// CleanTo cleans the contents of the file "fileName", read from "reader", into
+// the local object store, and writes the pointer to them to "writer". The
+// contents are hashed as they are copied into a temporary file, rather than
+// held in memory, and the file is then moved into the store.
+//
+// If "fileName" is a working tree file which has the same size and
+// modification time as when it was last hashed, its contents are instead
+// compared with the object it hashed to, and if they match, that object's
+// pointer is written without hashing them again. Contents which don't match,
+// such as those given for a path other than the file's own, are hashed as
+// usual.
+//
+// If the contents are already a pointer, nothing is written, and a
+// CleanPointerError holding them is returned.
+func (f *GitFilter) CleanTo(writer io.Writer, reader io.Reader, fileName string, fileSize int64, cb tools.CopyCallback) (*Pointer, error) {
+	if len(fileName) > 0 && len(f.cfg.Extensions()) == 0 {
+		if abs, err := filepath.Abs(fileName); err == nil {
+			stat, _ := os.Stat(abs)
+			if e, ok := f.index.lookup(abs, stat); ok {
+				equal, rest, done, err := f.compareWithObject(reader, e.oid, e.size)
+				if err != nil {
+					return nil, err
+				}
+				defer done()
+
+				if equal {
+					tracerx.Printf("clean: %s is unchanged since it hashed to %s", fileName, e.oid)
+					pointer := NewPointer(e.oid, e.size, nil)
+					_, err := EncodePointer(writer, pointer)
+					return pointer, err
+				}
+				reader = rest
+			}
+		}
+	}
+
+	cleaned, err := f.Clean(reader, fileName, fileSize, cb)
+	if cleaned != nil {
+		defer cleaned.Teardown()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	store, err := f.fs.Store()
+	if err != nil {
+		return nil, err
+	}
+
+	if size, err := store.Size(cleaned.Oid); err == nil {
+		if size != cleaned.Size && len(cleaned.Pointer.Extensions) == 0 {
+			return nil, errors.New(fmt.Sprintf("%s\n%s\n%s", tr.Tr.Get("Files don't match:"), cleaned.Oid, cleaned.Filename))
+		}
+		tracerx.Printf("clean: %s exists", cleaned.Oid)
+	} else {
+		if err := fs.ImportFile(store, cleaned.Oid, cleaned.Filename); err != nil {
+			return nil, errors.Wrap(err, tr.Tr.Get("Unable to store %s as %s", cleaned.Filename, cleaned.Oid))
+		}
+		tracerx.Printf("clean: writing %s", cleaned.Oid)
+	}
+
+	_, err = EncodePointer(writer, cleaned.Pointer)
+	return cleaned.Pointer, err
+}
+
+// compareWithObject reads "reader" to its end, comparing it with the stored
+// object "oid" of size "size", and returns whether they are equal. If they are
+// not, it also returns a reader which yields everything "reader" did,
+// reading the bytes which matched again from the object. The returned function
+// must be called once that reader is no longer needed.
+func (f *GitFilter) compareWithObject(reader io.Reader, oid string, size int64) (bool, io.Reader, func(), error) {
+	store, err := f.fs.Store()
+	if err != nil {
+		return false, reader, func() {}, nil
+	}
+	obj, err := store.Get(oid)
+	if err != nil {
+		return false, reader, func() {}, nil
+	}
+	done := func() { obj.Close() }
+
+	// mismatch returns a reader which yields the "matched" bytes of the
+	// object which were compared, read from it again, then "pending",
+	// then the rest of "reader".
+	mismatch := func(matched int64, pending []byte) (bool, io.Reader, func(), error) {
+		done()
+		again, err := store.Get(oid)
+		if err != nil {
+			return false, nil, func() {}, err
+		}
+		return false, io.MultiReader(io.LimitReader(again, matched), bytes.NewReader(pending), reader), func() { again.Close() }, nil
+	}
+
+	buf := make([]byte, 32*1024)
+	objBuf := make([]byte, len(buf))
+	var matched int64
+	for {
+		n, rerr := io.ReadFull(reader, buf)
+		if n > 0 {
+			m, _ := io.ReadFull(obj, objBuf[:n])
+			if m < n || !bytes.Equal(buf[:n], objBuf[:n]) {
+				return mismatch(matched, buf[:n])
+			}
+			matched += int64(n)
+		}
+
+		if rerr == io.EOF || rerr == io.ErrUnexpectedEOF {
+			break
+		} else if rerr != nil {
+			done()
+			return false, nil, func() {}, rerr
+		}
+	}
+
+	if matched != size {
+		return mismatch(matched, nil)
+	}
+	done()
+	return true, nil, func() {}, nil
+}
+
 func (f *GitFilter) copyToTemp(reader io.Reader, fileSize int64, cb tools.CopyCallback) (oid string, size int64, tmp *os.File, err error) {
 	tmp, err = TempFile(f.cfg, "")
 	if err != nil {
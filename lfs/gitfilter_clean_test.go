@@ -0,0 +1,236 @@
+package lfs_test // avoid import cycle
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/git-lfs/git-lfs/v3/config"
+	"github.com/git-lfs/git-lfs/v3/fs"
+	"github.com/git-lfs/git-lfs/v3/lfs"
+	test "github.com/git-lfs/git-lfs/v3/t/cmd/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func cleanTestOid(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// hashCleanTestFile has "gf" record the OID to which the file "name" hashes, as
+// "git lfs object-status" does, with the file's modification time set far
+// enough in the past that the entry is not racy.
+func hashCleanTestFile(t *testing.T, gf *lfs.GitFilter, name string, ptr *lfs.Pointer) {
+	past := time.Now().Add(-time.Minute)
+	require.Nil(t, os.Chtimes(name, past, past))
+
+	status, err := gf.ObjectStatus(&lfs.WrappedPointer{Name: name, Pointer: ptr})
+	require.Nil(t, err)
+	require.False(t, status.Modified)
+}
+
+func TestCleanToStoresObject(t *testing.T) {
+	repo := test.NewRepo(t)
+	repo.Pushd()
+	defer func() {
+		repo.Popd()
+		repo.Cleanup()
+	}()
+
+	data := []byte(strings.Repeat("clean me ", 1000))
+	require.Nil(t, ioutil.WriteFile("big.dat", data, 0644))
+
+	gf := lfs.NewGitFilter(repo.Configuration())
+
+	var buf bytes.Buffer
+	ptr, err := gf.CleanTo(&buf, bytes.NewReader(data), "big.dat", int64(len(data)), nil)
+	require.Nil(t, err)
+	assert.Equal(t, cleanTestOid(data), ptr.Oid)
+	assert.EqualValues(t, len(data), ptr.Size)
+	assert.Equal(t, ptr.Encoded(), buf.String())
+
+	stored, err := ioutil.ReadFile(filepath.Join(repo.Configuration().LFSObjectDir(), ptr.Oid[0:2], ptr.Oid[2:4], ptr.Oid))
+	require.Nil(t, err)
+	assert.Equal(t, data, stored)
+
+	// Cleaning the same contents again gives the same pointer.
+	buf.Reset()
+	again, err := lfs.NewGitFilter(repo.Configuration()).CleanTo(&buf, bytes.NewReader(data), "big.dat", int64(len(data)), nil)
+	require.Nil(t, err)
+	assert.Equal(t, ptr, again)
+	assert.Equal(t, ptr.Encoded(), buf.String())
+}
+
+func TestCleanToComparesUnchangedFile(t *testing.T) {
+	repo := test.NewRepo(t)
+	repo.Pushd()
+	defer func() {
+		repo.Popd()
+		repo.Cleanup()
+	}()
+
+	data := []byte(strings.Repeat("clean me ", 1000))
+	require.Nil(t, ioutil.WriteFile("big.dat", data, 0644))
+
+	gf := lfs.NewGitFilter(repo.Configuration())
+	ptr, err := gf.CleanTo(ioutil.Discard, bytes.NewReader(data), "big.dat", int64(len(data)), nil)
+	require.Nil(t, err)
+	hashCleanTestFile(t, gf, "big.dat", ptr)
+
+	// The file is unchanged since it was hashed, so a new filter reading
+	// the index compares it with the object, rather than copying and
+	// hashing it again, which would report progress.
+	var progress int
+	cb := func(total, read int64, current int) error {
+		progress++
+		return nil
+	}
+
+	var buf bytes.Buffer
+	again, err := lfs.NewGitFilter(repo.Configuration()).CleanTo(&buf, bytes.NewReader(data), "big.dat", int64(len(data)), cb)
+	require.Nil(t, err)
+	assert.Equal(t, ptr, again)
+	assert.Equal(t, ptr.Encoded(), buf.String())
+	assert.Zero(t, progress)
+
+	// Once it was modified too recently for its index entry to be trusted,
+	// it is hashed again.
+	require.Nil(t, os.Chtimes("big.dat", time.Now(), time.Now()))
+	hashCleanTestFile(t, gf, "big.dat", ptr)
+	require.Nil(t, os.Chtimes("big.dat", time.Now(), time.Now()))
+	again, err = lfs.NewGitFilter(repo.Configuration()).CleanTo(ioutil.Discard, bytes.NewReader(data), "big.dat", int64(len(data)), cb)
+	require.Nil(t, err)
+	assert.Equal(t, ptr, again)
+	assert.NotZero(t, progress)
+}
+
+func TestCleanToHashesGivenContents(t *testing.T) {
+	repo := test.NewRepo(t)
+	repo.Pushd()
+	defer func() {
+		repo.Popd()
+		repo.Cleanup()
+	}()
+
+	data := []byte(strings.Repeat("clean me ", 10000))
+	require.Nil(t, ioutil.WriteFile("big.dat", data, 0644))
+
+	gf := lfs.NewGitFilter(repo.Configuration())
+	ptr, err := gf.CleanTo(ioutil.Discard, bytes.NewReader(data), "big.dat", int64(len(data)), nil)
+	require.Nil(t, err)
+	hashCleanTestFile(t, gf, "big.dat", ptr)
+
+	// Contents other than the file's, of the same size, such as those
+	// given to "git hash-object --stdin --path", are not mistaken for
+	// its object, however far into them they differ.
+	for _, at := range []int{0, len(data) / 2, len(data) - 1} {
+		other := append([]byte{}, data...)
+		other[at] = 'X'
+
+		var buf bytes.Buffer
+		ptr, err := gf.CleanTo(&buf, bytes.NewReader(other), "big.dat", int64(len(other)), nil)
+		require.Nil(t, err)
+		assert.Equal(t, cleanTestOid(other), ptr.Oid)
+		assert.EqualValues(t, len(other), ptr.Size)
+	}
+
+	// As are longer and shorter contents.
+	for _, other := range [][]byte{append(data, '!'), data[:len(data)-1]} {
+		ptr, err := gf.CleanTo(ioutil.Discard, bytes.NewReader(other), "big.dat", -1, nil)
+		require.Nil(t, err)
+		assert.Equal(t, cleanTestOid(other), ptr.Oid)
+	}
+
+	_, err = os.Stat("big.dat")
+	assert.Nil(t, err)
+}
+
+// memoryStore is a LocalStore which keeps objects in memory, rather than in
+// files.
+type memoryStore struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+}
+
+func (s *memoryStore) Get(oid string) (io.ReadCloser, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, ok := s.objects[oid]
+	if !ok {
+		return nil, os.ErrNotExist
+	}
+	return ioutil.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (s *memoryStore) Put(oid string, r io.Reader) error {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.objects[oid] = data
+	return nil
+}
+
+func (s *memoryStore) Exists(oid string, size int64) bool {
+	n, err := s.Size(oid)
+	return err == nil && n == size
+}
+
+func (s *memoryStore) Size(oid string) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, ok := s.objects[oid]
+	if !ok {
+		return 0, os.ErrNotExist
+	}
+	return int64(len(data)), nil
+}
+
+func TestCleanAndSmudgeThroughStoreWithoutFiles(t *testing.T) {
+	store := &memoryStore{objects: make(map[string][]byte)}
+	fs.RegisterLocalStore("lfs-test-memory", func(*fs.Filesystem) (fs.LocalStore, error) {
+		return store, nil
+	})
+
+	repo := test.NewRepo(t)
+	repo.Pushd()
+	defer func() {
+		repo.Popd()
+		repo.Cleanup()
+	}()
+	_, err := repo.GitConfig().SetLocal("lfs.storebackend", "lfs-test-memory")
+	require.Nil(t, err)
+
+	data := []byte(strings.Repeat("clean me ", 1000))
+	require.Nil(t, ioutil.WriteFile("big.dat", data, 0644))
+
+	cfg := config.NewIn(repo.Path, repo.GitDir)
+	gf := lfs.NewGitFilter(cfg)
+	ptr, err := gf.CleanTo(ioutil.Discard, bytes.NewReader(data), "big.dat", int64(len(data)), nil)
+	require.Nil(t, err)
+	assert.Equal(t, data, store.objects[ptr.Oid])
+
+	_, err = os.Stat(filepath.Join(cfg.LFSObjectDir(), ptr.Oid[0:2], ptr.Oid[2:4], ptr.Oid))
+	assert.True(t, os.IsNotExist(err))
+
+	var buf bytes.Buffer
+	n, err := gf.Smudge(&buf, ptr, "big.dat", false, nil, nil)
+	require.Nil(t, err)
+	assert.EqualValues(t, len(data), n)
+	assert.Equal(t, data, buf.Bytes())
+}
@@ -8,6 +8,7 @@ import (
 
 	"github.com/git-lfs/git-lfs/v3/config"
 	"github.com/git-lfs/git-lfs/v3/errors"
+	"github.com/git-lfs/git-lfs/v3/fs"
 	"github.com/git-lfs/git-lfs/v3/tools"
 	"github.com/git-lfs/git-lfs/v3/tools/humanize"
 	"github.com/git-lfs/git-lfs/v3/tq"
@@ -39,7 +40,11 @@ func (f *GitFilter) SmudgeToFile(filename string, ptr *Pointer, download bool, m
 		return errors.New(tr.Tr.Get("could not create working directory file: %v", err))
 	}
 	defer file.Close()
-	if _, err := f.Smudge(file, ptr, filename, download, manifest, cb); err != nil {
+
+	// Leave blocks of zeros in the object as holes in the file, so that
+	// sparse files remain sparse when checked out.
+	writer := tools.NewSparseWriter(file)
+	if _, err := f.Smudge(writer, ptr, filename, download, manifest, cb); err != nil {
 		if errors.IsDownloadDeclinedError(err) {
 			// write placeholder data instead
 			file.Seek(0, io.SeekStart)
@@ -49,6 +54,9 @@ func (f *GitFilter) SmudgeToFile(filename string, ptr *Pointer, download bool, m
 			return errors.New(tr.Tr.Get("could not write working directory file: %v", err))
 		}
 	}
+	if err := writer.Close(); err != nil {
+		return errors.New(tr.Tr.Get("could not write working directory file: %v", err))
+	}
 	return nil
 }
 
@@ -60,13 +68,20 @@ func (f *GitFilter) Smudge(writer io.Writer, ptr *Pointer, workingfile string, d
 
 	LinkOrCopyFromReference(f.cfg, ptr.Oid, ptr.Size)
 
-	stat, statErr := os.Stat(mediafile)
-	if statErr == nil && stat != nil {
-		fileSize := stat.Size()
-		if fileSize != ptr.Size {
-			tracerx.Printf("Removing %s, size %d is invalid", mediafile, fileSize)
+	store, err := f.fs.Store()
+	if err != nil {
+		return 0, err
+	}
+
+	stored := false
+	size, sizeErr := store.Size(ptr.Oid)
+	if sizeErr == nil {
+		if size != ptr.Size {
+			tracerx.Printf("Removing %s, size %d is invalid", mediafile, size)
 			os.RemoveAll(mediafile)
-			stat = nil
+			sizeErr = errors.New(tr.Tr.Get("expected %d bytes of %s, found %d", ptr.Size, ptr.Oid, size))
+		} else {
+			stored = true
 		}
 	}
 
@@ -74,32 +89,43 @@ func (f *GitFilter) Smudge(writer io.Writer, ptr *Pointer, workingfile string, d
 
 	if ptr.Size == 0 {
 		return 0, nil
-	} else if statErr != nil || stat == nil {
+	} else if !stored {
 		if download {
 			n, err = f.downloadFile(writer, ptr, workingfile, mediafile, manifest, cb)
 
 			// In case of a cherry-pick the newly created commit is likely not yet
 			// be found in the history of a remote branch. Thus, the first attempt might fail.
-			if err != nil && f.cfg.SearchAllRemotesEnabled() {
+			// Part of the object may already have been streamed to "writer",
+			// though, in which case it cannot be written again from the start.
+			if err != nil && n == 0 && f.cfg.SearchAllRemotesEnabled() {
 				tracerx.Printf("git: smudge: default remote failed. searching alternate remotes")
 				n, err = f.downloadFileFallBack(writer, ptr, workingfile, mediafile, manifest, cb)
 			}
 
 		} else {
-			return 0, errors.NewDownloadDeclinedError(statErr, tr.Tr.Get("smudge filter"))
+			return 0, errors.NewDownloadDeclinedError(sizeErr, tr.Tr.Get("smudge filter"))
 		}
 	} else {
 		n, err = f.readLocalFile(writer, ptr, mediafile, workingfile, cb)
 	}
 
 	if err != nil {
-		return 0, errors.NewSmudgeError(err, ptr.Oid, mediafile)
+		// Return the number of bytes written even so, since a caller
+		// must not write anything else, such as the pointer, in place
+		// of an object which was partly written before it failed.
+		return n, errors.NewSmudgeError(err, ptr.Oid, mediafile)
 	}
 
 	return n, nil
 }
 
 func (f *GitFilter) downloadFile(writer io.Writer, ptr *Pointer, workingfile, mediafile string, manifest tq.Manifest, cb tools.CopyCallback) (int64, error) {
+	// An object with extensions must be passed through their smudge
+	// programs once it is stored, so only one without them is streamed.
+	if len(ptr.Extensions) == 0 {
+		return f.DownloadStream(writer, ptr, workingfile, mediafile, manifest, cb)
+	}
+
 	fmt.Fprintln(os.Stderr, tr.Tr.Get("Downloading %s (%s)", workingfile, humanize.FormatBytes(uint64(ptr.Size))))
 
 	// NOTE: if given, "cb" is a tools.CopyCallback which writes updates
@@ -109,28 +135,82 @@ func (f *GitFilter) downloadFile(writer io.Writer, ptr *Pointer, workingfile, me
 	// sent over correctly.
 
 	q := tq.NewTransferQueue(tq.Download, manifest, f.cfg.Remote(),
-		tq.WithProgressCallback(cb),
-		tq.RemoteRef(f.RemoteRef()),
+		f.downloadOptions(cb)...,
 	)
+	q.SetRoutePath(ptr.Oid, workingfile)
 	q.Add(filepath.Base(workingfile), mediafile, ptr.Oid, ptr.Size, false, nil)
 	q.Wait()
 
-	if errs := q.Errors(); len(errs) > 0 {
-		var multiErr error
-		for _, e := range errs {
-			if multiErr != nil {
-				multiErr = fmt.Errorf("%v\n%v", multiErr, e)
-			} else {
-				multiErr = e
-			}
-		}
-
-		return 0, errors.Wrapf(multiErr, tr.Tr.Get("Error downloading %s (%s)", workingfile, ptr.Oid))
+	if err := downloadError(q, workingfile, ptr.Oid); err != nil {
+		return 0, err
 	}
 
 	return f.readLocalFile(writer, ptr, mediafile, workingfile, nil)
 }
 
+// DownloadStream downloads the object "ptr" into the local object store at
+// "mediafile", writing it to "writer" as it is downloaded, rather than reading
+// it back from the store afterwards. It returns the number of bytes written to
+// "writer", which may be more than zero even if the download fails, in which
+// case what was written must be discarded, since it may not be the object's.
+//
+// The object is written as it is stored, without applying any smudge
+// extensions.
+func (f *GitFilter) DownloadStream(writer io.Writer, ptr *Pointer, workingfile, mediafile string, manifest tq.Manifest, cb tools.CopyCallback) (int64, error) {
+	fmt.Fprintln(os.Stderr, tr.Tr.Get("Downloading %s (%s)", workingfile, humanize.FormatBytes(uint64(ptr.Size))))
+
+	cw := &countingWriter{w: writer}
+	q := tq.NewTransferQueue(tq.Download, manifest, f.cfg.Remote(),
+		f.downloadOptions(cb)...,
+	)
+	q.SetRoutePath(ptr.Oid, workingfile)
+	q.AddStream(filepath.Base(workingfile), mediafile, ptr.Oid, ptr.Size, cw)
+	q.Wait()
+
+	return cw.n, downloadError(q, workingfile, ptr.Oid)
+}
+
+// downloadError returns the errors of the queue "q", which downloaded the
+// object "oid", combined into one, or nil if there were none.
+func downloadError(q *tq.TransferQueue, workingfile, oid string) error {
+	errs := q.Errors()
+	if len(errs) == 0 {
+		return nil
+	}
+
+	var multiErr error
+	for _, e := range errs {
+		if multiErr != nil {
+			multiErr = fmt.Errorf("%v\n%v", multiErr, e)
+		} else {
+			multiErr = e
+		}
+	}
+
+	return errors.Wrapf(multiErr, tr.Tr.Get("Error downloading %s (%s)", workingfile, oid))
+}
+
+// countingWriter counts the bytes written to the writer it wraps.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// downloadOptions returns the options for a transfer queue downloading a
+// single object to smudge it.
+func (f *GitFilter) downloadOptions(cb tools.CopyCallback) []tq.Option {
+	return append([]tq.Option{
+		tq.WithProgressCallback(cb),
+		tq.RemoteRef(f.RemoteRef()),
+	}, f.transferOptions...)
+}
+
 func (f *GitFilter) downloadFileFallBack(writer io.Writer, ptr *Pointer, workingfile, mediafile string, manifest tq.Manifest, cb tools.CopyCallback) (int64, error) {
 	// Attempt to find the LFS objects in all currently registered remotes.
 	// When a valid remote is found, this remote is taken persistent for
@@ -140,22 +220,13 @@ func (f *GitFilter) downloadFileFallBack(writer io.Writer, ptr *Pointer, working
 	remotes := f.cfg.Remotes()
 	for index, remote := range remotes {
 		q := tq.NewTransferQueue(tq.Download, manifest, remote,
-			tq.WithProgressCallback(cb),
-			tq.RemoteRef(f.RemoteRef()),
+			f.downloadOptions(cb)...,
 		)
+		q.SetRoutePath(ptr.Oid, workingfile)
 		q.Add(filepath.Base(workingfile), mediafile, ptr.Oid, ptr.Size, false, nil)
 		q.Wait()
 
-		if errs := q.Errors(); len(errs) > 0 {
-			var multiErr error
-			for _, e := range errs {
-				if multiErr != nil {
-					multiErr = fmt.Errorf("%v\n%v", multiErr, e)
-				} else {
-					multiErr = e
-				}
-			}
-			wrappedError := errors.Wrapf(multiErr, tr.Tr.Get("Error downloading %s (%s)", workingfile, ptr.Oid))
+		if wrappedError := downloadError(q, workingfile, ptr.Oid); wrappedError != nil {
 			if index >= len(remotes)-1 {
 				return 0, wrappedError
 			} else {
@@ -172,15 +243,25 @@ func (f *GitFilter) downloadFileFallBack(writer io.Writer, ptr *Pointer, working
 }
 
 func (f *GitFilter) readLocalFile(writer io.Writer, ptr *Pointer, mediafile string, workingfile string, cb tools.CopyCallback) (int64, error) {
-	reader, err := tools.RobustOpen(mediafile)
+	store, err := f.fs.Store()
+	if err != nil {
+		return 0, err
+	}
+
+	var reader io.ReadCloser
+	if _, ok := store.(fs.FileBacked); ok {
+		reader, err = tools.RobustOpen(mediafile)
+	} else {
+		reader, err = store.Get(ptr.Oid)
+	}
 	if err != nil {
 		return 0, errors.Wrapf(err, tr.Tr.Get("error opening media file"))
 	}
 	defer reader.Close()
 
 	if ptr.Size == 0 {
-		if stat, _ := os.Stat(mediafile); stat != nil {
-			ptr.Size = stat.Size()
+		if size, err := store.Size(ptr.Oid); err == nil {
+			ptr.Size = size
 		}
 	}
 
@@ -0,0 +1,239 @@
+package lfs
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/git-lfs/git-lfs/v3/config"
+)
+
+// gitMediaFilterKeys are the configuration keys which git-media sets to
+// install its filter.
+var gitMediaFilterKeys = []string{"filter.media.clean", "filter.media.smudge"}
+
+// gitMediaImportedKey is the configuration key which records that the objects
+// in git-media's object store have been imported.
+const gitMediaImportedKey = "lfs.gitmedia.imported"
+
+// gitMediaOidRE matches the name of an object in git-media's object store,
+// which is the SHA-1 of its contents.
+var gitMediaOidRE = regexp.MustCompile(`\A[0-9a-f]{40}\z`)
+
+// GitMediaRepository describes what git-media, the tool which Git LFS replaces,
+// has left in a repository.
+type GitMediaRepository struct {
+	// Filters maps each configuration key of git-media's filter which is
+	// set to its value.
+	Filters map[string]string
+	// Attributes are the attributes files which give files git-media's
+	// filter.
+	Attributes []*GitMediaAttributes
+	// ObjectDir is the directory of git-media's object store, or empty if
+	// there is none.
+	ObjectDir string
+	// Imported is whether the objects in ObjectDir have already been
+	// imported with ImportObjects.
+	Imported bool
+}
+
+// GitMediaAttributes is an attributes file which gives files git-media's
+// filter.
+type GitMediaAttributes struct {
+	// Path is the path of the attributes file.
+	Path string
+	// Lines are the lines of the file which give files the filter.
+	Lines []string
+}
+
+// GitMediaImport reports the objects imported from git-media's object store.
+type GitMediaImport struct {
+	// Imported is the number of objects added to the LFS object store,
+	// and Present the number which it already had.
+	Imported, Present int
+	// Size is the total size of the imported objects.
+	Size int64
+}
+
+// DetectGitMedia returns what git-media has left in the repository described by
+// "cfg", or nil if it has left nothing.
+func DetectGitMedia(cfg *config.Configuration) *GitMediaRepository {
+	if !cfg.InRepo() {
+		return nil
+	}
+
+	r := &GitMediaRepository{Filters: make(map[string]string)}
+	for _, key := range gitMediaFilterKeys {
+		if value, ok := cfg.Git.Get(key); ok {
+			r.Filters[key] = value
+		}
+	}
+
+	for _, path := range gitMediaAttributesPaths(cfg) {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			continue
+		}
+
+		var lines []string
+		for _, line := range strings.Split(string(data), "\n") {
+			if isGitMediaAttribute(line) {
+				lines = append(lines, strings.TrimSuffix(line, "\r"))
+			}
+		}
+		if len(lines) > 0 {
+			r.Attributes = append(r.Attributes, &GitMediaAttributes{Path: path, Lines: lines})
+		}
+	}
+
+	dir := filepath.Join(cfg.LocalGitDir(), "media", "objects")
+	if stat, err := os.Stat(dir); err == nil && stat.IsDir() {
+		r.ObjectDir = dir
+		r.Imported = cfg.Git.Bool(gitMediaImportedKey, false)
+	}
+
+	if len(r.Filters) == 0 && len(r.Attributes) == 0 && len(r.ObjectDir) == 0 {
+		return nil
+	}
+	return r
+}
+
+// gitMediaAttributesPaths returns the paths of the attributes files in which
+// git-media's filter may be given: those of the working tree's root and of the
+// repository.
+func gitMediaAttributesPaths(cfg *config.Configuration) []string {
+	paths := []string{filepath.Join(cfg.LocalGitDir(), "info", "attributes")}
+	if wd := cfg.LocalWorkingDir(); len(wd) > 0 {
+		paths = append([]string{filepath.Join(wd, ".gitattributes")}, paths...)
+	}
+	return paths
+}
+
+// isGitMediaAttribute returns whether the attributes file line "line" gives
+// files git-media's filter.
+func isGitMediaAttribute(line string) bool {
+	fields := strings.Fields(line)
+	if len(fields) < 2 || strings.HasPrefix(fields[0], "#") {
+		return false
+	}
+	for _, attr := range fields[1:] {
+		if attr == "filter=media" {
+			return true
+		}
+	}
+	return false
+}
+
+// ConvertGitMediaAttribute returns the attributes file line "line", which
+// gives files git-media's filter, changed to give them Git LFS's instead, as
+// "git lfs track" would.
+func ConvertGitMediaAttribute(line string) string {
+	fields := strings.Fields(line)
+
+	converted := []string{fields[0]}
+	for _, attr := range fields[1:] {
+		switch attr {
+		case "filter=media", "-crlf", "-text":
+			continue
+		}
+		if strings.HasPrefix(attr, "diff=") || strings.HasPrefix(attr, "merge=") {
+			continue
+		}
+		converted = append(converted, attr)
+	}
+	converted = append(converted, "filter=lfs", "diff=lfs", "merge=lfs", "-text")
+
+	return strings.Join(converted, " ")
+}
+
+// ConvertAttributes rewrites each line of the repository's attributes files
+// which gives files git-media's filter to give them Git LFS's instead.
+func (r *GitMediaRepository) ConvertAttributes() error {
+	for _, attrs := range r.Attributes {
+		data, err := ioutil.ReadFile(attrs.Path)
+		if err != nil {
+			return err
+		}
+
+		lines := bytes.Split(data, []byte("\n"))
+		for i, line := range lines {
+			if !isGitMediaAttribute(string(line)) {
+				continue
+			}
+
+			eol := ""
+			if bytes.HasSuffix(line, []byte("\r")) {
+				eol = "\r"
+			}
+			lines[i] = []byte(ConvertGitMediaAttribute(string(line)) + eol)
+		}
+
+		stat, err := os.Stat(attrs.Path)
+		if err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(attrs.Path, bytes.Join(lines, []byte("\n")), stat.Mode()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ImportObjects copies each object in git-media's object store into the
+// repository's LFS object store, under its SHA-256 OID, and records that it has
+// done so in the repository's configuration. git-media's object store is left
+// as it is.
+func (r *GitMediaRepository) ImportObjects(cfg *config.Configuration) (*GitMediaImport, error) {
+	report := &GitMediaImport{}
+	if len(r.ObjectDir) == 0 {
+		return report, nil
+	}
+
+	entries, err := ioutil.ReadDir(r.ObjectDir)
+	if err != nil {
+		return nil, err
+	}
+
+	store, err := cfg.Filesystem().Store()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, entry := range entries {
+		if !entry.Mode().IsRegular() || !gitMediaOidRE.MatchString(entry.Name()) {
+			continue
+		}
+		path := filepath.Join(r.ObjectDir, entry.Name())
+
+		oid, err := hashFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if store.Exists(oid, entry.Size()) {
+			report.Present++
+			continue
+		}
+
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		err = store.Put(oid, f)
+		f.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		report.Imported++
+		report.Size += entry.Size()
+	}
+
+	if _, err := cfg.SetGitLocalKey(gitMediaImportedKey, "true"); err != nil {
+		return nil, err
+	}
+	r.Imported = true
+	return report, nil
+}
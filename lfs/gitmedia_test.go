@@ -0,0 +1,28 @@
+package lfs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConvertGitMediaAttribute(t *testing.T) {
+	for line, expected := range map[string]string{
+		"*.mov filter=media -crlf":                "*.mov filter=lfs diff=lfs merge=lfs -text",
+		"*.psd filter=media":                      "*.psd filter=lfs diff=lfs merge=lfs -text",
+		"assets/** filter=media diff=media -text": "assets/** filter=lfs diff=lfs merge=lfs -text",
+		"*.bin  lockable\tfilter=media":           "*.bin lockable filter=lfs diff=lfs merge=lfs -text",
+	} {
+		assert.True(t, isGitMediaAttribute(line), line)
+		assert.Equal(t, expected, ConvertGitMediaAttribute(line), line)
+	}
+
+	for _, line := range []string{
+		"*.mov filter=lfs diff=lfs merge=lfs -text",
+		"# *.mov filter=media",
+		"filter=media",
+		"",
+	} {
+		assert.False(t, isGitMediaAttribute(line), line)
+	}
+}
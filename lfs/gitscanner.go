@@ -125,6 +125,32 @@ func (s *GitScanner) ScanRefRange(include, exclude string, cb GitScannerFoundPoi
 	return err
 }
 
+// ScanRefRangeOids returns the OID and size of each unique pointer reachable
+// from the "include" ref but not reachable from the "exclude" ref, such as
+// everything new on a branch since it was last pushed. If any pointer could
+// not be read, the first such error is returned.
+func (s *GitScanner) ScanRefRangeOids(include, exclude string) (map[string]int64, error) {
+	oids := make(map[string]int64)
+	var scanErr error
+
+	err := s.ScanRefRange(include, exclude, func(p *WrappedPointer, err error) {
+		if err != nil {
+			if scanErr == nil {
+				scanErr = err
+			}
+			return
+		}
+		oids[p.Oid] = p.Size
+	})
+	if err != nil {
+		return nil, err
+	}
+	if scanErr != nil {
+		return nil, scanErr
+	}
+	return oids, nil
+}
+
 // ScanRefRangeByTree scans through all objects reachable from the "include"
 // ref but not reachable from the "exclude" ref, including objects that have
 // been modified or deleted.  Objects which appear in multiple trees will
@@ -0,0 +1,31 @@
+package lfs
+
+import (
+	"bytes"
+
+	"github.com/git-lfs/git-lfs/v3/errors"
+	"github.com/git-lfs/gitobj/v2"
+)
+
+// ImportBlob stores the contents of the blob "b", found at "path", in the local
+// object store, and returns a blob holding the pointer to them, which the
+// pre-push hook uploads when the commits holding it are pushed. A blob which
+// is already a pointer is returned with its line endings repaired.
+//
+// ImportBlob may be given as the BlobFn of a githistory.Rewriter to convert
+// the blobs of existing commits to pointers, as "git lfs migrate import" does.
+func (f *GitFilter) ImportBlob(path string, b *gitobj.Blob) (*gitobj.Blob, error) {
+	var buf bytes.Buffer
+
+	_, err := f.CleanTo(&buf, b.Contents, path, b.Size, nil)
+	if errors.IsCleanPointerError(err) {
+		buf.Reset()
+		buf.Write(RepairPointerLineEndings(errors.GetContext(err, "bytes").([]byte)))
+	} else if err != nil {
+		return nil, err
+	}
+
+	return &gitobj.Blob{
+		Contents: &buf, Size: int64(buf.Len()),
+	}, nil
+}
@@ -0,0 +1,47 @@
+package lfs_test // avoid import cycle
+
+import (
+	"bytes"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/git-lfs/git-lfs/v3/lfs"
+	test "github.com/git-lfs/git-lfs/v3/t/cmd/util"
+	"github.com/git-lfs/gitobj/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImportBlob(t *testing.T) {
+	repo := test.NewRepo(t)
+	repo.Pushd()
+	defer func() {
+		repo.Popd()
+		repo.Cleanup()
+	}()
+
+	gf := lfs.NewGitFilter(repo.Configuration())
+
+	data := []byte(strings.Repeat("import me ", 100))
+	blob, err := gf.ImportBlob("a.dat", &gitobj.Blob{Contents: bytes.NewReader(data), Size: int64(len(data))})
+	require.Nil(t, err)
+
+	contents, err := ioutil.ReadAll(blob.Contents)
+	require.Nil(t, err)
+	assert.EqualValues(t, len(contents), blob.Size)
+
+	ptr, err := lfs.DecodePointer(bytes.NewReader(contents))
+	require.Nil(t, err)
+	assert.Equal(t, cleanTestOid(data), ptr.Oid)
+	assert.True(t, repo.Configuration().LFSObjectExists(ptr.Oid, ptr.Size))
+
+	// A blob which is already a pointer is kept, in its canonical form.
+	crlf := strings.Replace(ptr.Encoded(), "\n", "\r\n", -1)
+	blob, err = gf.ImportBlob("a.dat", &gitobj.Blob{Contents: strings.NewReader(crlf), Size: int64(len(crlf))})
+	require.Nil(t, err)
+
+	contents, err = ioutil.ReadAll(blob.Contents)
+	require.Nil(t, err)
+	assert.Equal(t, ptr.Encoded(), string(contents))
+}
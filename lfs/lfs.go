@@ -1,5 +1,7 @@
-// Package lfs brings together the core LFS functionality
-// NOTE: Subject to change, do not rely on this package from outside git-lfs source
+// Package lfs brings together the core LFS functionality.
+//
+// Client is part of the stable Go API of Git LFS described in docs/library.md;
+// the rest of the package may change between releases.
 package lfs
 
 import (
@@ -9,6 +11,7 @@ import (
 	"strings"
 
 	"github.com/git-lfs/git-lfs/v3/config"
+	"github.com/git-lfs/git-lfs/v3/fs"
 	"github.com/git-lfs/git-lfs/v3/lfsapi"
 	"github.com/git-lfs/git-lfs/v3/tools"
 	"github.com/git-lfs/git-lfs/v3/tq"
@@ -107,6 +110,18 @@ const (
 	gitPtrPrefix = "gitdir: "
 )
 
+// putFile copies the file at "path" into "store" as the object "oid", leaving
+// the file itself in place.
+func putFile(store fs.LocalStore, oid, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return store.Put(oid, f)
+}
+
 func LinkOrCopyFromReference(cfg *config.Configuration, oid string, size int64) error {
 	if cfg.LFSObjectExists(oid, size) {
 		return nil
@@ -116,10 +131,19 @@ func LinkOrCopyFromReference(cfg *config.Configuration, oid string, size int64)
 	if err != nil {
 		return err
 	}
+	store, err := cfg.Filesystem().Store()
+	if err != nil {
+		return err
+	}
+	_, fileBacked := store.(fs.FileBacked)
 	for _, altMediafile := range altMediafiles {
 		tracerx.Printf("altMediafile: %s", altMediafile)
 		if altMediafile != "" && tools.FileExistsOfSize(altMediafile, size) {
-			err = LinkOrCopy(cfg, altMediafile, mediafile)
+			if fileBacked {
+				err = LinkOrCopy(cfg, altMediafile, mediafile)
+			} else {
+				err = putFile(store, oid, altMediafile)
+			}
 			if err == nil {
 				break
 			}
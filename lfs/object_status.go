@@ -0,0 +1,137 @@
+package lfs
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/git-lfs/git-lfs/v3/tq"
+)
+
+// ObjectStatus describes where a Git LFS object is found: in the working tree,
+// the local object store, and the remote.
+type ObjectStatus struct {
+	// Name is the path of the file in the working tree which the object
+	// was asked about for, relative to the root of the repository, or
+	// empty if it was asked about by OID.
+	Name string `json:"name,omitempty"`
+	Oid  string `json:"oid"`
+	// Size is the size of the object, or zero if it is not known, as for
+	// an object which was asked about by OID and is not in the local
+	// object store.
+	Size int64 `json:"size"`
+	// Pointer is whether the file holds the object's pointer rather than
+	// its contents, as when the object has not been downloaded.
+	Pointer bool `json:"pointer"`
+	// Modified is whether the file has been changed, or removed, since the
+	// object was added to the index.
+	Modified bool `json:"modified"`
+	// Local is whether the object is in the local object store.
+	Local bool `json:"local"`
+	// Pushed is whether the remote has the object, or nil if the remote
+	// was not asked.
+	Pushed *bool `json:"pushed,omitempty"`
+}
+
+// ObjectStatus returns the status of the object of "p" in the working tree and
+// the local object store, without asking the remote. If "p" has no name, the
+// object is only looked for in the local object store.
+func (f *GitFilter) ObjectStatus(p *WrappedPointer) (*ObjectStatus, error) {
+	status := &ObjectStatus{Name: p.Name, Oid: p.Oid, Size: p.Size}
+	if p.Size > 0 || len(p.Name) > 0 {
+		status.Local = f.cfg.LFSObjectExists(p.Oid, p.Size)
+	} else if size, err := f.objectSize(p.Oid); err == nil {
+		// The size of an object asked about by OID alone is
+		// that of the object in the store, if it's there.
+		status.Size = size
+		status.Local = true
+	}
+	if len(p.Name) == 0 {
+		return status, nil
+	}
+
+	path := filepath.Join(f.cfg.LocalWorkingDir(), p.Name)
+	stat, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		status.Modified = true
+		return status, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	if ptr, err := DecodePointerFromFile(path); err == nil {
+		status.Pointer = true
+		status.Modified = ptr.Oid != p.Oid
+		return status, nil
+	}
+
+	// The file's contents hash to the OID of the object's pointer, or,
+	// if the object was stored with extensions, to the OID which the
+	// first extension was given.
+	expected := p.Oid
+	priority := -1
+	for _, ext := range p.Extensions {
+		if priority < 0 || ext.Priority < priority {
+			expected, priority = ext.Oid, ext.Priority
+		}
+	}
+
+	if e, ok := f.index.lookup(path, stat); ok && e.oid == expected {
+		return status, nil
+	}
+
+	oid, err := hashFile(path)
+	if err != nil {
+		return nil, err
+	}
+	f.index.record(path, stat, oid)
+	status.Modified = oid != expected
+	return status, nil
+}
+
+// objectSize returns the size of the object "oid" in the local object store.
+func (f *GitFilter) objectSize(oid string) (int64, error) {
+	store, err := f.fs.Store()
+	if err != nil {
+		return 0, err
+	}
+	return store.Size(oid)
+}
+
+// ObjectStatus returns the status of the object of each of "pointers", as
+// GitFilter.ObjectStatus does, and asks the remote which of them it has. The
+// remote's push endpoint is asked, as it is before pushing, with as few batch
+// requests as possible, and with the size given by each pointer rather than
+// any found in the local object store. Each call uses a manifest of its own,
+// so the remote is asked afresh rather than answered from what an earlier
+// call found out. See tq.ObjectsExist for details.
+func (c *Client) ObjectStatus(pointers ...*WrappedPointer) ([]*ObjectStatus, error) {
+	gf := NewGitFilter(c.cfg)
+
+	statuses := make([]*ObjectStatus, 0, len(pointers))
+	objects := make([]*tq.Transfer, 0, len(pointers))
+	seen := make(map[string]bool, len(pointers))
+	for _, p := range pointers {
+		status, err := gf.ObjectStatus(p)
+		if err != nil {
+			return nil, err
+		}
+		statuses = append(statuses, status)
+
+		if !seen[p.Oid] {
+			objects = append(objects, &tq.Transfer{Oid: p.Oid, Size: p.Size})
+			seen[p.Oid] = true
+		}
+	}
+
+	manifest := tq.NewManifest(c.cfg.Filesystem(), c.api, tq.Upload.String(), c.remote)
+	exists, err := tq.ObjectsExist(manifest, c.remote, nil, objects)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, status := range statuses {
+		pushed := exists[status.Oid]
+		status.Pushed = &pushed
+	}
+	return statuses, nil
+}
@@ -0,0 +1,63 @@
+package lfs_test // avoid import cycle
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/git-lfs/git-lfs/v3/lfs"
+	test "github.com/git-lfs/git-lfs/v3/t/cmd/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGitFilterObjectStatus(t *testing.T) {
+	repo := test.NewRepo(t)
+	repo.Pushd()
+	defer func() {
+		repo.Popd()
+		repo.Cleanup()
+	}()
+
+	data := []byte("status me")
+	require.Nil(t, ioutil.WriteFile("a.dat", data, 0644))
+
+	gf := lfs.NewGitFilter(repo.Configuration())
+	ptr, err := gf.CleanTo(ioutil.Discard, bytes.NewReader(data), "a.dat", int64(len(data)), nil)
+	require.Nil(t, err)
+	p := &lfs.WrappedPointer{Name: "a.dat", Pointer: ptr}
+
+	status, err := gf.ObjectStatus(p)
+	require.Nil(t, err)
+	assert.Equal(t, &lfs.ObjectStatus{Name: "a.dat", Oid: ptr.Oid, Size: ptr.Size, Local: true}, status)
+
+	// The object may be asked about by OID alone.
+	status, err = gf.ObjectStatus(&lfs.WrappedPointer{Pointer: lfs.NewPointer(ptr.Oid, 0, nil)})
+	require.Nil(t, err)
+	assert.Equal(t, &lfs.ObjectStatus{Oid: ptr.Oid, Size: ptr.Size, Local: true}, status)
+
+	require.Nil(t, ioutil.WriteFile("a.dat", []byte("changed!!"), 0644))
+	status, err = gf.ObjectStatus(p)
+	require.Nil(t, err)
+	assert.True(t, status.Modified)
+	assert.False(t, status.Pointer)
+
+	// The file's hash is remembered for the next time it is asked about.
+	index, err := ioutil.ReadFile(filepath.Join(repo.Configuration().LFSStorageDir(), "clean-index"))
+	require.Nil(t, err)
+	assert.Contains(t, string(index), cleanTestOid([]byte("changed!!")))
+
+	require.Nil(t, ioutil.WriteFile("a.dat", []byte(ptr.Encoded()), 0644))
+	status, err = gf.ObjectStatus(p)
+	require.Nil(t, err)
+	assert.True(t, status.Pointer)
+	assert.False(t, status.Modified)
+
+	require.Nil(t, os.Remove("a.dat"))
+	status, err = gf.ObjectStatus(p)
+	require.Nil(t, err)
+	assert.True(t, status.Modified)
+	assert.Nil(t, status.Pushed)
+}
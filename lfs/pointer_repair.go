@@ -0,0 +1,88 @@
+package lfs
+
+import (
+	"bytes"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/git-lfs/git-lfs/v3/errors"
+	"github.com/git-lfs/git-lfs/v3/pointer"
+	"github.com/git-lfs/git-lfs/v3/tr"
+)
+
+var (
+	utf8BOM = []byte("\xef\xbb\xbf")
+
+	oidPrefixRE = regexp.MustCompile(`\A[0-9a-f]{1,64}\z`)
+)
+
+// ObjectResolver finds the Git LFS object whose OID begins with "oidPrefix",
+// and whose size is "size" if that is non-negative, returning its full OID
+// and size, or false if there is no single such object.
+type ObjectResolver func(oidPrefix string, size int64) (string, int64, bool)
+
+// RepairPointer attempts to reconstruct the pointer encoded by "data", which
+// may be damaged by a leading UTF-8 byte order mark, by CRLF line endings, or
+// by being truncated within its "oid" or "size" lines. Any value which was
+// lost to truncation is completed using "resolve".
+//
+// It returns an error if the data is not recognizably a pointer, or the
+// object it refers to cannot be determined.
+func RepairPointer(data []byte, resolve ObjectResolver) (*Pointer, error) {
+	data = bytes.TrimPrefix(data, utf8BOM)
+	data = bytes.ReplaceAll(data, []byte("\r\n"), []byte("\n"))
+
+	// Without its final newline, the pointer may have been truncated
+	// within its last line, even if what remains still decodes.
+	text := string(data)
+	complete := strings.HasSuffix(text, "\n")
+	if p, err := DecodePointer(bytes.NewReader(data)); err == nil && complete {
+		return p, nil
+	}
+	lines := strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+
+	parts := strings.SplitN(lines[0], " ", 2)
+	if len(parts) < 2 || parts[0] != "version" {
+		return nil, errors.NewNotAPointerError(errors.New(tr.Tr.Get("Missing version")))
+	}
+	if err := pointer.VerifyVersion(parts[1]); err != nil {
+		return nil, err
+	}
+
+	var oidPrefix string
+	size := int64(-1)
+	for i, line := range lines[1:] {
+		parts := strings.SplitN(line, " ", 2)
+		if len(parts) < 2 {
+			continue
+		}
+
+		switch key, value := parts[0], parts[1]; {
+		case key == "oid":
+			oidPrefix = strings.TrimPrefix(value, pointer.OidType+":")
+		case key == "size":
+			// A truncated size line holds only the leading
+			// digits of the size, so it cannot be trusted.
+			last := i == len(lines)-2
+			if n, err := strconv.ParseInt(value, 10, 64); err == nil && n >= 0 && (complete || !last) {
+				size = n
+			}
+		case pointer.IsExtensionKey(key):
+			return nil, errors.New(tr.Tr.Get("Unable to repair pointer with extensions"))
+		}
+	}
+
+	if !oidPrefixRE.MatchString(oidPrefix) {
+		return nil, errors.New(tr.Tr.Get("Unable to repair pointer without an OID"))
+	}
+	if pointer.IsValidOid(oidPrefix) && size >= 0 {
+		return NewPointer(oidPrefix, size, nil), nil
+	}
+
+	oid, size, ok := resolve(oidPrefix, size)
+	if !ok {
+		return nil, errors.New(tr.Tr.Get("Unable to find an object matching OID %s", oidPrefix))
+	}
+	return NewPointer(oid, size, nil), nil
+}
@@ -0,0 +1,87 @@
+package lfs
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/git-lfs/git-lfs/v3/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const repairOid = "4d7a214614ab2935c943f9e0ff69d22eadbb8f32b1258daaa5e2ca24d17e2393"
+
+func repairResolver(calls *int) ObjectResolver {
+	return func(oidPrefix string, size int64) (string, int64, bool) {
+		*calls++
+		if strings.HasPrefix(repairOid, oidPrefix) && (size < 0 || size == 12345) {
+			return repairOid, 12345, true
+		}
+		return "", 0, false
+	}
+}
+
+func TestRepairPointerByteOrderMark(t *testing.T) {
+	var calls int
+	data := "\xef\xbb\xbfversion https://git-lfs.github.com/spec/v1\n" +
+		"oid sha256:" + repairOid + "\nsize 12345\n"
+
+	p, err := RepairPointer([]byte(data), repairResolver(&calls))
+	require.Nil(t, err)
+	assert.Equal(t, repairOid, p.Oid)
+	assert.EqualValues(t, 12345, p.Size)
+	assert.Equal(t, 0, calls)
+}
+
+func TestRepairPointerCRLF(t *testing.T) {
+	var calls int
+	data := "version https://git-lfs.github.com/spec/v1\r\n" +
+		"oid sha256:" + repairOid + "\r\nsize 12345\r\n"
+
+	p, err := RepairPointer([]byte(data), repairResolver(&calls))
+	require.Nil(t, err)
+	assert.Equal(t, NewPointer(repairOid, 12345, nil).Encoded(), p.Encoded())
+	assert.Equal(t, 0, calls)
+}
+
+func TestRepairPointerTruncatedOid(t *testing.T) {
+	var calls int
+	data := "version https://git-lfs.github.com/spec/v1\n" +
+		"oid sha256:" + repairOid[:20]
+
+	p, err := RepairPointer([]byte(data), repairResolver(&calls))
+	require.Nil(t, err)
+	assert.Equal(t, repairOid, p.Oid)
+	assert.EqualValues(t, 12345, p.Size)
+	assert.Equal(t, 1, calls)
+}
+
+func TestRepairPointerTruncatedSize(t *testing.T) {
+	var calls int
+	data := "version https://git-lfs.github.com/spec/v1\n" +
+		"oid sha256:" + repairOid + "\nsize 123"
+
+	p, err := RepairPointer([]byte(data), repairResolver(&calls))
+	require.Nil(t, err)
+	assert.EqualValues(t, 12345, p.Size)
+	assert.Equal(t, 1, calls)
+}
+
+func TestRepairPointerUnresolved(t *testing.T) {
+	var calls int
+	data := "version https://git-lfs.github.com/spec/v1\n" +
+		"oid sha256:ffff"
+
+	_, err := RepairPointer([]byte(data), repairResolver(&calls))
+	require.NotNil(t, err)
+	assert.False(t, errors.IsNotAPointerError(err))
+	assert.Equal(t, 1, calls)
+}
+
+func TestRepairPointerNotAPointer(t *testing.T) {
+	var calls int
+
+	_, err := RepairPointer([]byte("just some text\n"), repairResolver(&calls))
+	assert.True(t, errors.IsNotAPointerError(err))
+	assert.Equal(t, 0, calls)
+}
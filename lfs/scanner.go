@@ -3,13 +3,14 @@ package lfs
 import (
 	"github.com/git-lfs/git-lfs/v3/config"
 	"github.com/git-lfs/git-lfs/v3/git"
+	"github.com/git-lfs/git-lfs/v3/pointer"
 	"github.com/git-lfs/git-lfs/v3/tools"
 )
 
 const (
 	// blobSizeCutoff is used to determine which files to scan for Git LFS
 	// pointers.  Any file with a size below this cutoff will be scanned.
-	blobSizeCutoff = 1024
+	blobSizeCutoff = pointer.SizeCutoff
 
 	// stdoutBufSize is the size of the buffers given to a sub-process stdout
 	stdoutBufSize = 16384
@@ -112,6 +112,47 @@ func scanUnpushed(remoteName string) ([]*WrappedPointer, error) {
 	return pointers, multiErr
 }
 
+func TestScanRefRangeOids(t *testing.T) {
+	repo := test.NewRepo(t)
+	repo.Pushd()
+	defer func() {
+		repo.Popd()
+		repo.Cleanup()
+	}()
+
+	inputs := []*test.CommitInput{
+		{ // 0
+			Files: []*test.FileInput{
+				{Filename: "file1.txt", Size: 20},
+			},
+		},
+		{ // 1
+			NewBranch: "topic",
+			Files: []*test.FileInput{
+				{Filename: "file1.txt", Size: 25},
+				{Filename: "file2.txt", Size: 30},
+			},
+		},
+		{ // 2
+			Files: []*test.FileInput{
+				{Filename: "file2.txt", Size: 35},
+			},
+		},
+	}
+	outputs := repo.AddCommits(inputs)
+
+	oids, err := NewGitScanner(config.New(), nil).ScanRefRangeOids("topic", "master")
+	assert.Nil(t, err)
+
+	expected := map[string]int64{}
+	for _, output := range outputs[1:] {
+		for _, p := range output.Files {
+			expected[p.Oid] = p.Size
+		}
+	}
+	assert.Equal(t, expected, oids)
+}
+
 func TestScanPreviousVersions(t *testing.T) {
 	repo := test.NewRepo(t)
 	repo.Pushd()
@@ -1,6 +1,7 @@
 package lfs
 
 import (
+	"encoding/hex"
 	"fmt"
 	"io"
 	"os"
@@ -269,3 +270,18 @@ func LinkOrCopy(cfg *config.Configuration, src string, dst string) error {
 func TempFile(cfg *config.Configuration, pattern string) (*os.File, error) {
 	return tools.TempFile(cfg.TempDir(), pattern, cfg)
 }
+
+// hashFile returns the OID of the contents of the file at "path".
+func hashFile(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	h := tools.NewLfsContentHash()
+	if _, err := io.Copy(h, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
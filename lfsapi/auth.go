@@ -149,7 +149,7 @@ func (c *Client) getCreds(remote string, access creds.Access, req *http.Request)
 			return creds.CredentialHelperWrapper{CredentialHelper: creds.NullCreds, Input: nil, Url: nil, Creds: nil}, nil
 		}
 
-		credsURL, err := getCredURLForAPI(ef, operation, remote, apiEndpoint, req)
+		credsURL, err := getCredURLForAPI(ef, operation, remote, apiEndpoint, access, req)
 		if err != nil {
 			return creds.CredentialHelperWrapper{CredentialHelper: creds.NullCreds, Input: nil, Url: nil, Creds: nil}, errors.Wrap(err, tr.Tr.Get("credentials"))
 		}
@@ -183,7 +183,7 @@ func (c *Client) getGitCredsWrapper(ef EndpointFinder, req *http.Request, u *url
 	return c.credContext.GetCredentialHelper(c.Credentials, u)
 }
 
-func getCredURLForAPI(ef EndpointFinder, operation, remote string, apiEndpoint lfshttp.Endpoint, req *http.Request) (*url.URL, error) {
+func getCredURLForAPI(ef EndpointFinder, operation, remote string, apiEndpoint lfshttp.Endpoint, access creds.Access, req *http.Request) (*url.URL, error) {
 	apiURL, err := url.Parse(apiEndpoint.Url)
 	if err != nil {
 		return nil, err
@@ -193,7 +193,7 @@ func getCredURLForAPI(ef EndpointFinder, operation, remote string, apiEndpoint l
 	// attempting to set the Authorization header from the LFS or Git remote URLs.
 	if req.URL.Scheme != apiURL.Scheme ||
 		req.URL.Host != apiURL.Host {
-		return req.URL, nil
+		return getCredURLForRequest(access, req), nil
 	}
 
 	if setRequestAuthFromURL(req, apiURL) {
@@ -224,6 +224,24 @@ func getCredURLForAPI(ef EndpointFinder, operation, remote string, apiEndpoint l
 	return apiURL, nil
 }
 
+// getCredURLForRequest returns the URL to ask for credentials for a request
+// which is not made to the LFS API. If the request is made beneath the URL of
+// "access", as requests to transfer objects are beneath the URL of their
+// storage endpoint, that URL is returned, so that credentials are asked for,
+// and cached, once for the endpoint rather than once for each object, even
+// when credential.useHttpPath is set. Otherwise, the request's URL is
+// returned.
+func getCredURLForRequest(access creds.Access, req *http.Request) *url.URL {
+	accessURL, err := url.Parse(access.URL())
+	if err != nil || len(accessURL.Path) == 0 ||
+		accessURL.Scheme != req.URL.Scheme ||
+		accessURL.Host != req.URL.Host ||
+		!strings.HasPrefix(req.URL.Path, accessURL.Path) {
+		return req.URL
+	}
+	return accessURL
+}
+
 // fixSchemelessURL prepends an empty scheme "//" if none was found in
 // the URL and replaces the first colon with a slash in order to satisfy RFC
 // 3986 §3.3, and `net/url.Parse()`.
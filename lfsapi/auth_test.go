@@ -589,6 +589,29 @@ func TestGetCreds(t *testing.T) {
 				},
 			},
 		},
+		"host mismatch beneath access url": getCredsTest{
+			Remote:   "origin",
+			Method:   "PUT",
+			Href:     "https://lfs-server.com/storage/0123456789abcdef?expires=60",
+			Endpoint: "https://lfs-server.com/storage/",
+			Config: map[string]string{
+				"lfs.url": "https://git-server.com/repo/lfs",
+				"lfs.https://lfs-server.com/storage/.access": "basic",
+				"credential.usehttppath":                     "true",
+			},
+			Expected: getCredsExpected{
+				Access:        creds.BasicAccess,
+				Authorization: basicAuth("lfs-server.com", "monkey"),
+				CredsURL:      "https://lfs-server.com/storage/",
+				Creds: map[string][]string{
+					"protocol": []string{"https"},
+					"host":     []string{"lfs-server.com"},
+					"path":     []string{"storage/"},
+					"username": []string{"lfs-server.com"},
+					"password": []string{"monkey"},
+				},
+			},
+		},
 		"port mismatch": getCredsTest{
 			Remote:   "origin",
 			Method:   "GET",
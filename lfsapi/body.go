@@ -8,11 +8,13 @@ import (
 	"strconv"
 )
 
+// ReadSeekCloser is a request body which may be rewound and sent again.
 type ReadSeekCloser interface {
 	io.Seeker
 	io.ReadCloser
 }
 
+// MarshalToRequest sets the body of "req" to the JSON encoding of "obj".
 func MarshalToRequest(req *http.Request, obj interface{}) error {
 	by, err := json.Marshal(obj)
 	if err != nil {
@@ -26,6 +28,7 @@ func MarshalToRequest(req *http.Request, obj interface{}) error {
 	return nil
 }
 
+// NewByteBody returns a request body which reads "by".
 func NewByteBody(by []byte) ReadSeekCloser {
 	return &closingByteReader{Reader: bytes.NewReader(by)}
 }
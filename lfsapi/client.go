@@ -9,6 +9,8 @@ import (
 	"github.com/git-lfs/git-lfs/v3/lfshttp"
 )
 
+// NewRequest returns a request to the path "suffix" of the endpoint "e", whose
+// body, if "body" is not nil, is its JSON encoding.
 func (c *Client) NewRequest(method string, e lfshttp.Endpoint, suffix string, body interface{}) (*http.Request, error) {
 	return c.client.NewRequest(method, e, suffix, body)
 }
@@ -30,26 +32,34 @@ func (c *Client) doWithAccess(req *http.Request, remote string, via []*http.Requ
 	return c.client.DoWithAccess(req, mode)
 }
 
+// LogRequest returns "r", marked so that its statistics are logged under the
+// key "reqKey" if HTTP statistics are enabled.
 func (c *Client) LogRequest(r *http.Request, reqKey string) *http.Request {
 	return c.client.LogRequest(r, reqKey)
 }
 
+// GitEnv returns the Git configuration used by the client.
 func (c *Client) GitEnv() config.Environment {
 	return c.client.GitEnv()
 }
 
+// OSEnv returns the environment variables used by the client.
 func (c *Client) OSEnv() config.Environment {
 	return c.client.OSEnv()
 }
 
+// ConcurrentTransfers returns the number of objects which may be transferred
+// at once.
 func (c *Client) ConcurrentTransfers() int {
 	return c.client.ConcurrentTransfers
 }
 
+// LogHTTPStats writes statistics about each request the client makes to "w".
 func (c *Client) LogHTTPStats(w io.WriteCloser) {
 	c.client.LogHTTPStats(w)
 }
 
+// Close closes the client, and any statistics log it is writing.
 func (c *Client) Close() error {
 	return c.client.Close()
 }
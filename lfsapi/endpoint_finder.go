@@ -20,11 +20,15 @@ const (
 	defaultRemote = "origin"
 )
 
+// EndpointFinder determines the Git LFS endpoints of a repository's remotes,
+// from its configuration and their Git URLs.
 type EndpointFinder interface {
 	NewEndpointFromCloneURL(operation, rawurl string) lfshttp.Endpoint
 	NewEndpoint(operation, rawurl string) lfshttp.Endpoint
 	Endpoint(operation, remote string) lfshttp.Endpoint
 	RemoteEndpoint(operation, remote string) lfshttp.Endpoint
+	RouteEndpoint(operation, path string) (lfshttp.Endpoint, bool)
+	MirrorEndpoints(operation, remote string) []lfshttp.Endpoint
 	GitRemoteURL(remote string, forpush bool) string
 	AccessFor(rawurl string) creds.Access
 	SetAccess(access creds.Access)
@@ -40,11 +44,18 @@ type endpointGitFinder struct {
 	aliases     map[string]string
 	pushAliases map[string]string
 
+	// routes and pushRoutes map path prefixes within the repository to
+	// the endpoint URLs configured for them.
+	routes     map[string]string
+	pushRoutes map[string]string
+
 	accessMu  sync.Mutex
 	urlAccess map[string]creds.AccessMode
 	urlConfig *config.URLConfig
 }
 
+// NewEndpointFinder returns an EndpointFinder for the configuration "ctx", or
+// for the current repository's configuration if "ctx" is nil.
 func NewEndpointFinder(ctx lfshttp.Context) EndpointFinder {
 	if ctx == nil {
 		ctx = lfshttp.NewContext(nil, nil, nil)
@@ -56,6 +67,8 @@ func NewEndpointFinder(ctx lfshttp.Context) EndpointFinder {
 		gitProtocol: "https",
 		aliases:     make(map[string]string),
 		pushAliases: make(map[string]string),
+		routes:      make(map[string]string),
+		pushRoutes:  make(map[string]string),
 		urlAccess:   make(map[string]creds.AccessMode),
 	}
 
@@ -64,6 +77,7 @@ func NewEndpointFinder(ctx lfshttp.Context) EndpointFinder {
 		e.gitProtocol = v
 	}
 	initAliases(e, e.gitEnv)
+	initRoutes(e, e.gitEnv)
 
 	return e
 }
@@ -125,6 +139,71 @@ func (e *endpointGitFinder) RemoteEndpoint(operation, remote string) lfshttp.End
 	return lfshttp.Endpoint{}
 }
 
+// MirrorEndpoints returns the read-only mirrors of the remote's endpoint,
+// configured with `remote.<remote>.lfsmirror` and `lfs.mirror`, in the order in
+// which they should be tried if the endpoint cannot be reached. Since mirrors
+// are read-only, there are none for uploads.
+func (e *endpointGitFinder) MirrorEndpoints(operation, remote string) []lfshttp.Endpoint {
+	if e.gitEnv == nil || operation == "upload" {
+		return nil
+	}
+
+	if len(remote) == 0 {
+		remote = defaultRemote
+	}
+
+	urls := append(e.gitEnv.GetAll("remote."+remote+".lfsmirror"), e.gitEnv.GetAll("lfs.mirror")...)
+	mirrors := make([]lfshttp.Endpoint, 0, len(urls))
+	for _, url := range urls {
+		if len(url) == 0 {
+			continue
+		}
+
+		ep := e.NewEndpoint(operation, url)
+		ep.Operation = operation
+		mirrors = append(mirrors, ep)
+	}
+	return mirrors
+}
+
+// RouteEndpoint returns the endpoint for objects at the given path within the
+// repository, if the path falls under a prefix configured with
+// `lfs.route.<path>.url` or, when uploading, `lfs.route.<path>.pushurl`. If
+// several prefixes match, the longest is used. Otherwise, it returns false,
+// and the remote's endpoint should be used.
+func (e *endpointGitFinder) RouteEndpoint(operation, path string) (lfshttp.Endpoint, bool) {
+	url, ok := "", false
+	if operation == "upload" {
+		url, ok = longestRoute(e.pushRoutes, path)
+	}
+	if !ok {
+		url, ok = longestRoute(e.routes, path)
+	}
+	if !ok {
+		return lfshttp.Endpoint{}, false
+	}
+
+	ep := e.NewEndpoint(operation, url)
+	ep.Operation = operation
+	return ep, true
+}
+
+// longestRoute returns the URL of the longest prefix in "routes" which
+// contains "path", matching whole path components only.
+func longestRoute(routes map[string]string, path string) (string, bool) {
+	var longest string
+	var found bool
+	for prefix := range routes {
+		if path != prefix && !strings.HasPrefix(path, prefix+"/") {
+			continue
+		}
+		if !found || len(prefix) > len(longest) {
+			longest, found = prefix, true
+		}
+	}
+	return routes[longest], found
+}
+
 func (e *endpointGitFinder) GitRemoteURL(remote string, forpush bool) string {
 	if e.gitEnv != nil {
 		if forpush {
@@ -188,6 +267,9 @@ func (e *endpointGitFinder) NewEndpoint(operation, rawurl string) lfshttp.Endpoi
 		return endpointFromGitUrl(u, e)
 	case "file":
 		return lfshttp.EndpointFromFileUrl(u)
+	case "memory":
+		// An in-process remote; see tq.MemoryRemote.
+		return lfshttp.Endpoint{Url: rawurl}
 	case "":
 		// If it looks like a local path, it probably is.
 		if _, err := os.Stat(rawurl); err == nil {
@@ -344,6 +426,37 @@ func storeAlias(aliases map[string]string, key string, values []string, suffix s
 	}
 }
 
+const (
+	routePrefix = "lfs.route."
+)
+
+func initRoutes(e *endpointGitFinder, git config.Environment) {
+	suffix := ".url"
+	pushSuffix := ".pushurl"
+	for gitkey, gitval := range git.All() {
+		if len(gitval) == 0 || !strings.HasPrefix(gitkey, routePrefix) {
+			continue
+		}
+		if strings.HasSuffix(gitkey, suffix) {
+			storeRoute(e.routes, gitkey, gitval, suffix)
+		} else if strings.HasSuffix(gitkey, pushSuffix) {
+			storeRoute(e.pushRoutes, gitkey, gitval, pushSuffix)
+		}
+	}
+}
+
+func storeRoute(routes map[string]string, key string, values []string, suffix string) {
+	if len(key) <= len(routePrefix)+len(suffix) {
+		return
+	}
+
+	prefix := strings.Trim(key[len(routePrefix):len(key)-len(suffix)], "/")
+	if len(prefix) == 0 {
+		return
+	}
+	routes[prefix] = values[len(values)-1]
+}
+
 func endpointFromGitUrl(u *url.URL, e *endpointGitFinder) lfshttp.Endpoint {
 	u.Scheme = e.gitProtocol
 	return lfshttp.Endpoint{Url: u.String()}
@@ -726,3 +726,55 @@ func TestNewEndpointFromCloneURLWithConfig(t *testing.T) {
 		}
 	}
 }
+
+func TestRouteEndpoint(t *testing.T) {
+	finder := NewEndpointFinder(lfshttp.NewContext(nil, nil, map[string]string{
+		"lfs.url":                        "https://example.com/repo.git/info/lfs",
+		"lfs.route.assets.url":           "https://assets.example.com/lfs",
+		"lfs.route.assets/video.url":     "https://video.example.com/lfs",
+		"lfs.route.assets/video.pushurl": "https://upload.example.com/lfs",
+		"lfs.route./textures/.url":       "https://textures.example.com/lfs",
+	}))
+
+	for desc, c := range map[string]struct {
+		Operation string
+		Path      string
+		Url       string
+	}{
+		"unrouted path":          {"download", "README.md", ""},
+		"prefix of a component":  {"download", "assets-old/a.dat", ""},
+		"routed path":            {"download", "assets/a.dat", "https://assets.example.com/lfs"},
+		"route itself":           {"download", "assets", "https://assets.example.com/lfs"},
+		"longest route":          {"download", "assets/video/a.mp4", "https://video.example.com/lfs"},
+		"push route":             {"upload", "assets/video/a.mp4", "https://upload.example.com/lfs"},
+		"no push route":          {"upload", "assets/a.dat", "https://assets.example.com/lfs"},
+		"route trimmed of slash": {"download", "textures/a.png", "https://textures.example.com/lfs"},
+	} {
+		t.Run(desc, func(t *testing.T) {
+			e, ok := finder.RouteEndpoint(c.Operation, c.Path)
+			assert.Equal(t, len(c.Url) > 0, ok)
+			assert.Equal(t, c.Url, e.Url)
+			if ok {
+				assert.Equal(t, c.Operation, e.Operation)
+			}
+		})
+	}
+}
+
+func TestMirrorEndpoints(t *testing.T) {
+	finder := NewEndpointFinder(lfshttp.NewContext(nil, nil, map[string]string{
+		"lfs.url":                 "https://example.com/lfs",
+		"lfs.mirror":              "https://eu.example.com/lfs",
+		"remote.origin.lfsmirror": "https://us.example.com/lfs",
+	}))
+
+	mirrors := finder.MirrorEndpoints("download", "")
+	if assert.Len(t, mirrors, 2) {
+		assert.Equal(t, "https://us.example.com/lfs", mirrors[0].Url)
+		assert.Equal(t, "https://eu.example.com/lfs", mirrors[1].Url)
+		assert.Equal(t, "download", mirrors[0].Operation)
+	}
+
+	assert.Empty(t, finder.MirrorEndpoints("upload", ""))
+	assert.Len(t, finder.MirrorEndpoints("download", "other"), 1)
+}
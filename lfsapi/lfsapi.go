@@ -1,6 +1,13 @@
+// Package lfsapi is a client of the Git LFS API of a repository's remotes,
+// which finds their endpoints and fills in credentials for them.
+//
+// NewClient and the Client it returns are part of the stable Go API of Git LFS
+// described in docs/library.md.
 package lfsapi
 
 import (
+	"sync"
+
 	"github.com/git-lfs/git-lfs/v3/creds"
 	"github.com/git-lfs/git-lfs/v3/errors"
 	"github.com/git-lfs/git-lfs/v3/lfshttp"
@@ -9,6 +16,8 @@ import (
 	"github.com/rubyist/tracerx"
 )
 
+// Client makes requests to the Git LFS API of a repository's remotes, finding
+// their endpoints and filling in credentials for them as needed.
 type Client struct {
 	Endpoints   EndpointFinder
 	Credentials creds.CredentialHelper
@@ -18,8 +27,13 @@ type Client struct {
 	client  *lfshttp.Client
 	context lfshttp.Context
 	access  []creds.AccessMode
+
+	hooks   []*TransferHook
+	hooksMu sync.RWMutex
 }
 
+// NewClient returns a Client configured by "ctx", or by the current
+// repository's configuration and environment if "ctx" is nil.
 func NewClient(ctx lfshttp.Context) (*Client, error) {
 	if ctx == nil {
 		ctx = lfshttp.NewContext(nil, nil, nil)
@@ -44,6 +58,7 @@ func NewClient(ctx lfshttp.Context) (*Client, error) {
 	return c, nil
 }
 
+// Context returns the configuration and environment of the client.
 func (c *Client) Context() lfshttp.Context {
 	return c.context
 }
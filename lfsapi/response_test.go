@@ -6,6 +6,7 @@ import (
 	"strings"
 	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/git-lfs/git-lfs/v3/errors"
 	"github.com/stretchr/testify/assert"
@@ -70,8 +71,6 @@ func TestWithNonFatal500WithBody(t *testing.T) {
 
 	nonFatalCodes := map[int]string{
 		501: "custom 501 error",
-		507: "custom 507 error",
-		509: "custom 509 error",
 	}
 
 	for nonFatalCode, expectedErr := range nonFatalCodes {
@@ -97,7 +96,85 @@ func TestWithNonFatal500WithBody(t *testing.T) {
 		srv.Close()
 	}
 
-	assert.EqualValues(t, 3, called)
+	assert.EqualValues(t, 1, called)
+}
+
+func TestQuotaExceeded(t *testing.T) {
+	c, _ := NewClient(nil)
+
+	var called uint32
+
+	quotaCodes := map[int]string{
+		507: "Server quota exceeded: Insufficient server storage:",
+		509: "Server quota exceeded: Bandwidth limit exceeded:",
+	}
+
+	for quotaCode, errPrefix := range quotaCodes {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.String() != "/test" {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+
+			atomic.AddUint32(&called, 1)
+			w.WriteHeader(quotaCode)
+		}))
+
+		req, err := http.NewRequest("GET", srv.URL+"/test", nil)
+		assert.Nil(t, err)
+
+		_, err = c.Do(req)
+		t.Logf("quota code %d", quotaCode)
+		assert.NotNil(t, err)
+		assert.True(t, errors.IsQuotaExceededError(err))
+		assert.False(t, errors.IsFatalError(err))
+		assert.False(t, errors.IsRetriableError(err))
+		assert.True(t, strings.HasPrefix(err.Error(), errPrefix), err.Error())
+		srv.Close()
+	}
+
+	assert.EqualValues(t, 2, called)
+}
+
+func TestRateLimitedPausesHost(t *testing.T) {
+	var called uint32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddUint32(&called, 1)
+		w.Header().Set("Retry-After", "60")
+		w.WriteHeader(429)
+	}))
+	defer srv.Close()
+
+	c, _ := NewClient(nil)
+	for i := 0; i < 2; i++ {
+		req, err := http.NewRequest("GET", srv.URL+"/test", nil)
+		assert.Nil(t, err)
+
+		_, err = c.Do(req)
+		assert.NotNil(t, err)
+		readyAt, ok := errors.IsRetriableLaterError(err)
+		assert.True(t, ok)
+		assert.True(t, readyAt.After(time.Now().Add(50*time.Second)))
+	}
+
+	// The second request was never sent.
+	assert.EqualValues(t, 1, called)
+}
+
+func TestRateLimitedWithoutRetryAfter(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(429)
+	}))
+	defer srv.Close()
+
+	req, err := http.NewRequest("GET", srv.URL+"/test", nil)
+	assert.Nil(t, err)
+
+	c, _ := NewClient(nil)
+	_, err = c.Do(req)
+	assert.NotNil(t, err)
+	assert.True(t, errors.IsRetriableError(err))
+	assert.Contains(t, err.Error(), "Rate limit exceeded:")
 }
 
 func TestAuthErrWithoutBody(t *testing.T) {
@@ -155,8 +232,6 @@ func TestWithNonFatal500WithoutBody(t *testing.T) {
 
 	nonFatalCodes := map[int]string{
 		501: "Not Implemented:",
-		507: "Insufficient server storage:",
-		509: "Bandwidth limit exceeded:",
 	}
 
 	for nonFatalCode, errPrefix := range nonFatalCodes {
@@ -180,5 +255,5 @@ func TestWithNonFatal500WithoutBody(t *testing.T) {
 		srv.Close()
 	}
 
-	assert.EqualValues(t, 3, called)
+	assert.EqualValues(t, 1, called)
 }
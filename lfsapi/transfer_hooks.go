@@ -0,0 +1,85 @@
+package lfsapi
+
+import (
+	"net/http"
+
+	"github.com/git-lfs/git-lfs/v3/tr"
+)
+
+// TransferInfo describes the object whose contents an HTTP request is made to
+// transfer.
+type TransferInfo struct {
+	// Operation is the direction of the transfer, such as "download" or
+	// "upload".
+	Operation string
+	Oid       string
+	Size      int64
+	Name      string
+}
+
+// A TransferHook is called around each HTTP request made to transfer the
+// contents of an object, so that programs which embed Git LFS can alter the
+// request's headers, record metrics, or veto the transfer. Either function may
+// be nil.
+type TransferHook struct {
+	// BeforeRequest is called before the request is sent. If it returns
+	// an error, the request is not sent, and the transfer fails with a
+	// *TransferVetoedError, which is not retried.
+	BeforeRequest func(info *TransferInfo, req *http.Request) error
+
+	// AfterResponse is called with the response to the request, or the
+	// error which prevented one from being received.
+	AfterResponse func(info *TransferInfo, res *http.Response, err error)
+}
+
+// TransferVetoedError is the error with which a transfer fails when a
+// TransferHook's BeforeRequest function returns an error.
+type TransferVetoedError struct {
+	Oid string
+	Err error
+}
+
+func (e *TransferVetoedError) Error() string {
+	return tr.Tr.Get("transfer of %s vetoed: %s", e.Oid, e.Err)
+}
+
+// AddTransferHook registers a hook to be called around each HTTP request made
+// to transfer the contents of an object, after any already registered.
+func (c *Client) AddTransferHook(hook *TransferHook) {
+	c.hooksMu.Lock()
+	defer c.hooksMu.Unlock()
+
+	c.hooks = append(c.hooks, hook)
+}
+
+func (c *Client) transferHooks() []*TransferHook {
+	c.hooksMu.RLock()
+	defer c.hooksMu.RUnlock()
+
+	return c.hooks
+}
+
+// BeforeTransferRequest calls the BeforeRequest function of each registered
+// TransferHook in turn, returning a *TransferVetoedError from the first which
+// fails.
+func (c *Client) BeforeTransferRequest(info *TransferInfo, req *http.Request) error {
+	for _, hook := range c.transferHooks() {
+		if hook.BeforeRequest == nil {
+			continue
+		}
+		if err := hook.BeforeRequest(info, req); err != nil {
+			return &TransferVetoedError{Oid: info.Oid, Err: err}
+		}
+	}
+	return nil
+}
+
+// AfterTransferResponse calls the AfterResponse function of each registered
+// TransferHook in turn.
+func (c *Client) AfterTransferResponse(info *TransferInfo, res *http.Response, err error) {
+	for _, hook := range c.transferHooks() {
+		if hook.AfterResponse != nil {
+			hook.AfterResponse(info, res, err)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package lfsapi
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/git-lfs/git-lfs/v3/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransferHooksCalledInOrder(t *testing.T) {
+	var calls []string
+	c := &Client{}
+	c.AddTransferHook(&TransferHook{
+		BeforeRequest: func(info *TransferInfo, req *http.Request) error {
+			calls = append(calls, "before 1")
+			req.Header.Set("X-Transfer-Oid", info.Oid)
+			return nil
+		},
+		AfterResponse: func(info *TransferInfo, res *http.Response, err error) {
+			calls = append(calls, "after 1")
+			assert.Equal(t, 200, res.StatusCode)
+		},
+	})
+	c.AddTransferHook(&TransferHook{
+		BeforeRequest: func(info *TransferInfo, req *http.Request) error {
+			calls = append(calls, "before 2")
+			return nil
+		},
+	})
+
+	info := &TransferInfo{Operation: "upload", Oid: "abc", Size: 3}
+	req, err := http.NewRequest("PUT", "https://example.com/abc", nil)
+	require.Nil(t, err)
+
+	assert.Nil(t, c.BeforeTransferRequest(info, req))
+	c.AfterTransferResponse(info, &http.Response{StatusCode: 200}, nil)
+
+	assert.Equal(t, "abc", req.Header.Get("X-Transfer-Oid"))
+	assert.Equal(t, []string{"before 1", "before 2", "after 1"}, calls)
+}
+
+func TestTransferHooksVeto(t *testing.T) {
+	var called bool
+	c := &Client{}
+	c.AddTransferHook(&TransferHook{
+		BeforeRequest: func(info *TransferInfo, req *http.Request) error {
+			return errors.New("quota exceeded")
+		},
+	})
+	c.AddTransferHook(&TransferHook{
+		BeforeRequest: func(info *TransferInfo, req *http.Request) error {
+			called = true
+			return nil
+		},
+	})
+
+	info := &TransferInfo{Operation: "download", Oid: "abc", Size: 3}
+	req, err := http.NewRequest("GET", "https://example.com/abc", nil)
+	require.Nil(t, err)
+
+	err = c.BeforeTransferRequest(info, req)
+	veto, ok := err.(*TransferVetoedError)
+	require.True(t, ok)
+	assert.Equal(t, "abc", veto.Oid)
+	assert.EqualError(t, veto.Err, "quota exceeded")
+	assert.False(t, called)
+}
@@ -0,0 +1,146 @@
+package lfshttp
+
+import (
+	"sync"
+	"time"
+
+	"github.com/git-lfs/git-lfs/v3/tr"
+	"github.com/rubyist/tracerx"
+)
+
+const (
+	// defaultCircuitThreshold is the default number of consecutive
+	// network failures after which requests to a host fail fast.
+	defaultCircuitThreshold = 5
+
+	// defaultCircuitCooldown is the default number of seconds for which
+	// requests to a host fail fast before it is tried again.
+	defaultCircuitCooldown = 30
+)
+
+// circuitBreaker tracks consecutive network failures per host. Once a host has
+// failed "threshold" times in a row, its circuit is opened, and requests to it
+// fail immediately rather than each waiting for the network to time out. After
+// "cooldown", a single request is let through to probe the host: if it
+// succeeds the circuit is closed again, and if it fails the circuit reopens.
+type circuitBreaker struct {
+	threshold int
+	cooldown  time.Duration
+
+	mu    sync.Mutex
+	hosts map[string]*circuit
+
+	// now returns the current time, and is replaced in tests.
+	now func() time.Time
+}
+
+type circuit struct {
+	failures  int
+	openUntil time.Time
+	probing   bool
+}
+
+func newCircuitBreaker(threshold int, cooldown time.Duration) *circuitBreaker {
+	return &circuitBreaker{
+		threshold: threshold,
+		cooldown:  cooldown,
+		hosts:     make(map[string]*circuit),
+		now:       time.Now,
+	}
+}
+
+// allow returns an error if requests to "host" should fail fast, because its
+// circuit is open. Otherwise, it returns whether the request is the one let
+// through to probe the host, which must be passed to either record or release
+// once it is done.
+func (b *circuitBreaker) allow(host string) (bool, error) {
+	if b == nil || b.threshold < 1 {
+		return false, nil
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	c, ok := b.hosts[host]
+	if !ok || c.failures < b.threshold {
+		return false, nil
+	}
+
+	now := b.now()
+	if now.Before(c.openUntil) {
+		return false, &circuitOpenError{host: host, failures: c.failures, until: c.openUntil}
+	}
+	if c.probing {
+		// Another request is already probing the host, so wait to
+		// see how it fares.
+		return false, &circuitOpenError{host: host, failures: c.failures, until: now.Add(b.cooldown)}
+	}
+
+	tracerx.Printf("http: circuit for %s is half-open; probing after %d failures", host, c.failures)
+	c.probing = true
+	return true, nil
+}
+
+// release lets another request probe "host" after the probing request was
+// abandoned, such as by being cancelled, without learning anything about the
+// host.
+func (b *circuitBreaker) release(host string) {
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if c, ok := b.hosts[host]; ok {
+		c.probing = false
+	}
+}
+
+// record records the outcome of a request to "host", where "failed" is true
+// if no response was received.
+func (b *circuitBreaker) record(host string, failed bool) {
+	if b == nil || b.threshold < 1 {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	c, ok := b.hosts[host]
+	if !failed {
+		if ok && c.failures >= b.threshold {
+			tracerx.Printf("http: circuit for %s closed", host)
+		}
+		delete(b.hosts, host)
+		return
+	}
+
+	if !ok {
+		c = &circuit{}
+		b.hosts[host] = c
+	}
+	c.failures++
+	c.probing = false
+	if c.failures >= b.threshold {
+		c.openUntil = b.now().Add(b.cooldown)
+		tracerx.Printf("http: circuit for %s opened after %d failures, until %s", host, c.failures, c.openUntil.Format(time.RFC3339))
+	}
+}
+
+// circuitOpenError is returned in place of making a request to a host whose
+// circuit is open. It may be retried once the circuit's cooldown has passed.
+type circuitOpenError struct {
+	host     string
+	failures int
+	until    time.Time
+}
+
+func (e *circuitOpenError) Error() string {
+	return tr.Tr.Get("%s is unavailable after %d consecutive failures; not retrying until %s",
+		e.host, e.failures, e.until.In(time.Local).Format(time.RFC822))
+}
+
+func (e *circuitOpenError) RetriableLaterError() (time.Time, bool) {
+	return e.until, true
+}
@@ -0,0 +1,158 @@
+package lfshttp
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/git-lfs/git-lfs/v3/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCircuitBreakerOpensAfterThreshold(t *testing.T) {
+	now := time.Unix(1000, 0)
+	b := newCircuitBreaker(2, 30*time.Second)
+	b.now = func() time.Time { return now }
+
+	assertAllowed(t, b, "example.com")
+	b.record("example.com", true)
+	assertAllowed(t, b, "example.com")
+	b.record("example.com", true)
+
+	_, err := b.allow("example.com")
+	require.NotNil(t, err)
+	until, ok := errors.IsRetriableLaterError(err)
+	assert.True(t, ok)
+	assert.Equal(t, now.Add(30*time.Second), until)
+
+	// Other hosts are unaffected.
+	assertAllowed(t, b, "other.example.com")
+}
+
+func TestCircuitBreakerProbesAfterCooldown(t *testing.T) {
+	now := time.Unix(1000, 0)
+	b := newCircuitBreaker(1, 30*time.Second)
+	b.now = func() time.Time { return now }
+
+	b.record("example.com", true)
+	assertNotAllowed(t, b, "example.com")
+
+	now = now.Add(31 * time.Second)
+	assertAllowed(t, b, "example.com")
+	// Only one request probes the host at a time.
+	assertNotAllowed(t, b, "example.com")
+
+	b.record("example.com", true)
+	assertNotAllowed(t, b, "example.com")
+
+	now = now.Add(31 * time.Second)
+	assertAllowed(t, b, "example.com")
+	b.record("example.com", false)
+	assertAllowed(t, b, "example.com")
+	assertAllowed(t, b, "example.com")
+}
+
+func TestCircuitBreakerReleasedProbeLetsAnotherProbe(t *testing.T) {
+	now := time.Unix(1000, 0)
+	b := newCircuitBreaker(1, 30*time.Second)
+	b.now = func() time.Time { return now }
+
+	b.record("example.com", true)
+	now = now.Add(31 * time.Second)
+
+	probe, err := b.allow("example.com")
+	assert.Nil(t, err)
+	assert.True(t, probe)
+	assertNotAllowed(t, b, "example.com")
+
+	// A cancelled probe neither closes nor reopens the circuit.
+	b.release("example.com")
+	probe, err = b.allow("example.com")
+	assert.Nil(t, err)
+	assert.True(t, probe)
+}
+
+func TestClientReleasesCancelledProbe(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	c, err := NewClient(NewContext(nil, nil, map[string]string{
+		"lfs.circuitbreaker.threshold": "1",
+	}))
+	require.Nil(t, err)
+
+	req, err := http.NewRequest("GET", srv.URL, nil)
+	require.Nil(t, err)
+	c.circuits.record(req.URL.Host, true)
+	c.circuits.hosts[req.URL.Host].openUntil = time.Time{}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = c.Do(req.WithContext(ctx))
+	require.NotNil(t, err)
+	_, ok := err.(*circuitOpenError)
+	assert.False(t, ok)
+
+	res, err := c.Do(req)
+	require.Nil(t, err)
+	res.Body.Close()
+	assert.Empty(t, c.circuits.hosts)
+}
+
+func TestCircuitBreakerSuccessResetsFailures(t *testing.T) {
+	b := newCircuitBreaker(2, 30*time.Second)
+
+	b.record("example.com", true)
+	b.record("example.com", false)
+	b.record("example.com", true)
+	assertAllowed(t, b, "example.com")
+}
+
+func TestCircuitBreakerDisabled(t *testing.T) {
+	b := newCircuitBreaker(0, 30*time.Second)
+
+	for i := 0; i < 10; i++ {
+		b.record("example.com", true)
+	}
+	assertAllowed(t, b, "example.com")
+}
+
+func assertAllowed(t *testing.T, b *circuitBreaker, host string) {
+	_, err := b.allow(host)
+	assert.Nil(t, err)
+}
+
+func assertNotAllowed(t *testing.T, b *circuitBreaker, host string) {
+	_, err := b.allow(host)
+	assert.NotNil(t, err)
+}
+
+func TestClientFailsFastWithOpenCircuit(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	url := srv.URL
+	srv.Close()
+
+	c, err := NewClient(NewContext(nil, nil, map[string]string{
+		"lfs.circuitbreaker.threshold": "2",
+	}))
+	require.Nil(t, err)
+
+	for i := 0; i < 2; i++ {
+		req, err := http.NewRequest("GET", url, nil)
+		require.Nil(t, err)
+		_, err = c.Do(req)
+		require.NotNil(t, err)
+		_, ok := err.(*circuitOpenError)
+		assert.False(t, ok)
+	}
+
+	req, err := http.NewRequest("GET", url, nil)
+	require.Nil(t, err)
+	_, err = c.Do(req)
+	require.NotNil(t, err)
+	_, ok := err.(*circuitOpenError)
+	assert.True(t, ok)
+}
@@ -49,8 +49,14 @@ type Client struct {
 	ConcurrentTransfers int
 	SkipSSLVerify       bool
 
+	// DialContext, if set, is used to make connections in place of a
+	// TCP dialer, for instance so that a program which embeds Git LFS may
+	// talk to a server over some other kind of connection.
+	DialContext func(ctx context.Context, network, addr string) (net.Conn, error)
+
 	Verbose          bool
 	DebuggingVerbose bool
+	TraceCurl        bool
 	VerboseOut       io.Writer
 
 	hostClients map[hostData]*http.Client
@@ -65,6 +71,9 @@ type Client struct {
 	credHelperContext *creds.CredentialHelperContext
 
 	sshTries int
+
+	circuits   *circuitBreaker
+	rateLimits *rateLimits
 }
 
 func NewClient(ctx Context) (*Client, error) {
@@ -90,11 +99,17 @@ func NewClient(ctx Context) (*Client, error) {
 		SkipSSLVerify:       !gitEnv.Bool("http.sslverify", true) || osEnv.Bool("GIT_SSL_NO_VERIFY", false),
 		Verbose:             osEnv.Bool("GIT_CURL_VERBOSE", false),
 		DebuggingVerbose:    osEnv.Bool("LFS_DEBUG_HTTP", false),
+		TraceCurl:           osEnv.Bool("GIT_LFS_TRACE_CURL", false),
 		gitEnv:              gitEnv,
 		osEnv:               osEnv,
 		uc:                  config.NewURLConfig(gitEnv),
 		sshTries:            gitEnv.Int("lfs.ssh.retries", 5),
 		credHelperContext:   creds.NewCredentialHelperContext(gitEnv, osEnv),
+		circuits: newCircuitBreaker(
+			gitEnv.Int("lfs.circuitbreaker.threshold", defaultCircuitThreshold),
+			time.Duration(gitEnv.Int("lfs.circuitbreaker.cooldown", defaultCircuitCooldown))*time.Second,
+		),
+		rateLimits: newRateLimits(),
 	}
 
 	return c, nil
@@ -285,14 +300,27 @@ func (c *Client) DoWithRedirect(cli *http.Client, req *http.Request, remote stri
 		retries = defaultRequestRetries
 	}
 
+	probe, err := c.circuits.allow(req.URL.Host)
+	if err != nil {
+		c.traceResponse(req, tracedReq, nil)
+		return nil, nil, err
+	}
+	if err := c.rateLimits.allow(req.URL.Host); err != nil {
+		c.traceResponse(req, tracedReq, nil)
+		return nil, nil, err
+	}
+
 	var res *http.Response
 
 	requests := tools.MaxInt(0, retries) + 1
 	for i := 0; i < requests; i++ {
-		res, err = cli.Do(req)
+		watchdog := c.newLowSpeedWatchdog(req.URL)
+		res, err = cli.Do(watchdog.request(req))
 		if err == nil {
+			watchdog.watch(res)
 			break
 		}
+		err = watchdog.stop(err)
 
 		if seek, ok := req.Body.(io.Seeker); ok {
 			seek.Seek(0, io.SeekStart)
@@ -301,6 +329,15 @@ func (c *Client) DoWithRedirect(cli *http.Client, req *http.Request, remote stri
 		c.traceResponse(req, tracedReq, nil)
 	}
 
+	// A request which was cancelled says nothing about the host, but if
+	// it was probing the host, another request must be let through to do
+	// so instead.
+	if req.Context().Err() == nil {
+		c.circuits.record(req.URL.Host, err != nil)
+	} else if probe {
+		c.circuits.release(req.URL.Host)
+	}
+
 	if err != nil {
 		c.traceResponse(req, tracedReq, nil)
 		// SPNEGO (Negotiate) errors are authentication errors.
@@ -322,6 +359,10 @@ func (c *Client) DoWithRedirect(cli *http.Client, req *http.Request, remote stri
 
 	c.traceResponse(req, tracedReq, res)
 
+	if res.StatusCode == 429 {
+		c.rateLimits.pause(req.URL.Host, res.Header.Get("Retry-After"))
+	}
+
 	if res.StatusCode != 301 &&
 		res.StatusCode != 302 &&
 		res.StatusCode != 303 &&
@@ -390,6 +431,32 @@ func (c *Client) configureProtocols(u *url.URL, transport *http.Transport) error
 	return nil
 }
 
+// tlsVersions maps the values which lfs.tls.minversion accepts to the TLS
+// versions they name.
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// configureTLSVersion sets the minimum TLS version which the client will
+// negotiate, if lfs.tls.minversion is set. Versions may be given as "1.2" or,
+// as for Git's http.sslVersion, "tlsv1.2".
+func (c *Client) configureTLSVersion(config *tls.Config) error {
+	version, ok := c.gitEnv.Get("lfs.tls.minversion")
+	if !ok || len(version) == 0 {
+		return nil
+	}
+
+	v, ok := tlsVersions[strings.TrimPrefix(strings.ToLower(version), "tlsv")]
+	if !ok {
+		return errors.New(tr.Tr.Get("Unknown TLS version %q", version))
+	}
+	config.MinVersion = v
+	return nil
+}
+
 func (c *Client) Transport(u *url.URL, access creds.AccessMode) (http.RoundTripper, error) {
 	host := u.Host
 
@@ -441,10 +508,28 @@ func (c *Client) Transport(u *url.URL, access creds.AccessMode) (http.RoundTripp
 		DualStack: true,
 	}
 
+	dial := dialer.DialContext
+	if c.DialContext != nil {
+		dial = c.DialContext
+	}
+
+	if socket, ok := c.uc.Get("lfs", u.String(), "unixsocket"); ok && len(socket) > 0 {
+		path, err := tools.ExpandPath(socket, false)
+		if err != nil {
+			return nil, err
+		}
+
+		tracerx.Printf("http: connecting to %s via Unix socket %s", host, path)
+		tr.Proxy = nil
+		dial = func(ctx context.Context, network, addr string) (net.Conn, error) {
+			return dialer.DialContext(ctx, "unix", path)
+		}
+	}
+
 	if activityTimeout > 0 {
 		activityDuration := time.Duration(activityTimeout) * time.Second
 		tr.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
-			c, err := dialer.DialContext(ctx, network, addr)
+			c, err := dial(ctx, network, addr)
 			if c == nil {
 				return c, err
 			}
@@ -455,7 +540,7 @@ func (c *Client) Transport(u *url.URL, access creds.AccessMode) (http.RoundTripp
 			return &deadlineConn{Timeout: activityDuration, Conn: c}, err
 		}
 	} else {
-		tr.DialContext = dialer.DialContext
+		tr.DialContext = dial
 	}
 
 	tr.TLSClientConfig = &tls.Config{
@@ -477,6 +562,10 @@ func (c *Client) Transport(u *url.URL, access creds.AccessMode) (http.RoundTripp
 		tr.TLSClientConfig.RootCAs = getRootCAsForHost(c, host)
 	}
 
+	if err := c.configureTLSVersion(tr.TLSClientConfig); err != nil {
+		return nil, err
+	}
+
 	if err := c.configureProtocols(u, tr); err != nil {
 		return nil, err
 	}
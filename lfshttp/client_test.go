@@ -1,12 +1,14 @@
 package lfshttp
 
 import (
+	"context"
 	"crypto/tls"
 	"encoding/json"
 	"fmt"
 	"net"
 	"net/http"
 	"net/http/httptest"
+	"path/filepath"
 	"sync/atomic"
 	"testing"
 
@@ -409,3 +411,150 @@ func TestHttpVersion(t *testing.T) {
 		}
 	}
 }
+
+func TestTLSMinVersion(t *testing.T) {
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(200)
+	}))
+	srv.TLS = &tls.Config{MaxVersion: tls.VersionTLS12}
+	srv.StartTLS()
+	defer srv.Close()
+
+	for setting, ok := range map[string]bool{
+		"":        true,
+		"1.2":     true,
+		"tlsv1.2": true,
+		"1.3":     false,
+		"TLSv1.3": false,
+	} {
+		c, err := NewClient(NewContext(nil, nil, map[string]string{
+			"http.sslverify":     "false",
+			"lfs.tls.minversion": setting,
+		}))
+		require.Nil(t, err)
+
+		req, err := http.NewRequest("GET", srv.URL, nil)
+		require.Nil(t, err)
+
+		res, err := c.Do(req)
+		if ok {
+			require.Nil(t, err, setting)
+			assert.Equal(t, 200, res.StatusCode, setting)
+		} else {
+			assert.NotNil(t, err, setting)
+		}
+	}
+}
+
+func TestTLSMinVersionUnknown(t *testing.T) {
+	c, err := NewClient(NewContext(nil, nil, map[string]string{
+		"lfs.tls.minversion": "ssl3",
+	}))
+	require.Nil(t, err)
+
+	req, err := http.NewRequest("GET", "https://example.com", nil)
+	require.Nil(t, err)
+
+	_, err = c.Do(req)
+	if assert.NotNil(t, err) {
+		assert.Equal(t, `Unknown TLS version "ssl3"`, err.Error())
+	}
+}
+
+func TestClientUnixSocket(t *testing.T) {
+	dir := t.TempDir()
+	socket := filepath.Join(dir, "lfs.sock")
+
+	l, err := net.Listen("unix", socket)
+	require.Nil(t, err)
+
+	var called uint32
+	srv := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddUint32(&called, 1)
+		assert.Equal(t, "/info/lfs", r.URL.Path)
+		w.WriteHeader(200)
+	})}
+	go srv.Serve(l)
+	defer srv.Close()
+
+	c, err := NewClient(NewContext(nil, nil, map[string]string{
+		"lfs.http://lfs.local.unixsocket": socket,
+	}))
+	require.Nil(t, err)
+
+	req, err := http.NewRequest("GET", "http://lfs.local/info/lfs", nil)
+	require.Nil(t, err)
+
+	res, err := c.Do(req)
+	require.Nil(t, err)
+	assert.Equal(t, 200, res.StatusCode)
+	assert.EqualValues(t, 1, atomic.LoadUint32(&called))
+}
+
+func TestClientCustomDialer(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(200)
+	}))
+	defer srv.Close()
+
+	c, err := NewClient(nil)
+	require.Nil(t, err)
+
+	var dialed string
+	c.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		dialed = addr
+		return (&net.Dialer{}).DialContext(ctx, "tcp", srv.Listener.Addr().String())
+	}
+
+	req, err := http.NewRequest("GET", "http://lfs.local/", nil)
+	require.Nil(t, err)
+
+	res, err := c.Do(req)
+	require.Nil(t, err)
+	assert.Equal(t, 200, res.StatusCode)
+	assert.Equal(t, "lfs.local:80", dialed)
+}
+
+func TestClientStorageError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/xml")
+		w.WriteHeader(403)
+		fmt.Fprint(w, `<?xml version="1.0" encoding="UTF-8"?>
+<Error><Code>SignatureDoesNotMatch</Code><Message>The request signature we calculated does not match the signature you provided.</Message><RequestId>4442587FB7D0A2F9</RequestId></Error>`)
+	}))
+	defer srv.Close()
+
+	c, err := NewClient(nil)
+	require.Nil(t, err)
+
+	req, err := http.NewRequest("PUT", srv.URL, nil)
+	require.Nil(t, err)
+
+	res, err := c.Do(req)
+	require.NotNil(t, err)
+	assert.Equal(t, 403, res.StatusCode)
+	assert.Equal(t, "SignatureDoesNotMatch: The request signature we calculated does not match the signature you provided.", err.Error())
+
+	cliErr, ok := err.(*ClientError)
+	require.True(t, ok)
+	assert.Equal(t, "4442587FB7D0A2F9", cliErr.RequestId)
+}
+
+func TestClientStorageErrorOtherXML(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/xml; charset=utf-8")
+		w.WriteHeader(404)
+		fmt.Fprint(w, `<html><body>Not here</body></html>`)
+	}))
+	defer srv.Close()
+
+	c, err := NewClient(nil)
+	require.Nil(t, err)
+
+	req, err := http.NewRequest("GET", srv.URL, nil)
+	require.Nil(t, err)
+
+	_, err = c.Do(req)
+	require.NotNil(t, err)
+	assert.Contains(t, err.Error(), "Repository or object not found")
+}
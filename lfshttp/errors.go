@@ -1,14 +1,27 @@
 package lfshttp
 
 import (
+	"bytes"
+	"encoding/xml"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"net/http"
+	"regexp"
 	"strings"
 
 	"github.com/git-lfs/git-lfs/v3/errors"
 	"github.com/git-lfs/git-lfs/v3/tr"
 )
 
+var xmlMediaTypeRE = regexp.MustCompile(`\A(application|text)/xml(;|\z)`)
+
+// maxErrorSize is the most of the body of an error response which is read.
+const maxErrorSize = 1024 * 1024
+
+// maxStorageErrorSize is the most of an XML error body which is read.
+const maxStorageErrorSize = 64 * 1024
+
 type httpError interface {
 	Error() string
 	HTTPResponse() *http.Response
@@ -41,11 +54,24 @@ func (c *Client) handleResponse(res *http.Response) error {
 		return nil
 	}
 
+	// A JSON body is kept, so that callers may decode more of it than
+	// the error, such as the lock a lock request conflicted with.
+	var body []byte
+	if ctype := res.Header.Get("Content-Type"); lfsMediaTypeRE.MatchString(ctype) || jsonMediaTypeRE.MatchString(ctype) {
+		body, _ = ioutil.ReadAll(io.LimitReader(res.Body, maxErrorSize))
+		res.Body.Close()
+		res.Body = ioutil.NopCloser(bytes.NewReader(body))
+	}
+
 	cliErr := &ClientError{response: res}
 	err := DecodeJSON(res, cliErr)
 	if IsDecodeTypeError(err) {
+		decodeStorageError(res, cliErr)
 		err = nil
 	}
+	if body != nil {
+		res.Body = ioutil.NopCloser(bytes.NewReader(body))
+	}
 
 	if err == nil {
 		if len(cliErr.Message) == 0 {
@@ -63,6 +89,10 @@ func (c *Client) handleResponse(res *http.Response) error {
 		return errors.NewUnprocessableEntityError(err)
 	}
 
+	if res.StatusCode == 423 {
+		return errors.NewLockConflictError(err)
+	}
+
 	if res.StatusCode == 429 {
 		// The Retry-After header could be set, check to see if it exists.
 		h := res.Header.Get("Retry-After")
@@ -70,6 +100,11 @@ func (c *Client) handleResponse(res *http.Response) error {
 		if retLaterErr != nil {
 			return retLaterErr
 		}
+		return errors.NewRetriableError(err)
+	}
+
+	if res.StatusCode == 507 || res.StatusCode == 509 {
+		return errors.NewQuotaExceededError(err)
 	}
 
 	if res.StatusCode > 499 && res.StatusCode != 501 && res.StatusCode != 507 && res.StatusCode != 509 {
@@ -79,6 +114,36 @@ func (c *Client) handleResponse(res *http.Response) error {
 	return err
 }
 
+// storageError is the body of an error response from storage services which
+// describe errors in XML, such as Amazon S3 and Google Cloud Storage.
+type storageError struct {
+	Code      string `xml:"Code"`
+	Message   string `xml:"Message"`
+	RequestId string `xml:"RequestId"`
+}
+
+// decodeStorageError sets the message and request ID of "cliErr" from the
+// body of "res", if it is an XML error as returned by storage services. Any
+// other body is ignored.
+func decodeStorageError(res *http.Response, cliErr *ClientError) {
+	if !xmlMediaTypeRE.MatchString(res.Header.Get("Content-Type")) {
+		return
+	}
+
+	var storageErr storageError
+	err := xml.NewDecoder(io.LimitReader(res.Body, maxStorageErrorSize)).Decode(&storageErr)
+	res.Body.Close()
+	if err != nil || len(storageErr.Code) == 0 {
+		return
+	}
+
+	cliErr.Message = storageErr.Code
+	if len(storageErr.Message) > 0 {
+		cliErr.Message = fmt.Sprintf("%s: %s", storageErr.Code, storageErr.Message)
+	}
+	cliErr.RequestId = storageErr.RequestId
+}
+
 type statusCodeError struct {
 	response *http.Response
 }
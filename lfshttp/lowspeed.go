@@ -0,0 +1,335 @@
+package lfshttp
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptrace"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/git-lfs/git-lfs/v3/tools/humanize"
+	"github.com/git-lfs/git-lfs/v3/tr"
+	"github.com/rubyist/tracerx"
+)
+
+// lowSpeedTimeUnit is the unit in which http.lowSpeedTime is given, and is
+// replaced in tests.
+var lowSpeedTimeUnit = time.Second
+
+// lowSpeedWatchdog watches the rate at which a single request's body is sent
+// and its response's body is received. If fewer than "limit" bytes per second
+// are transferred over any period of "window", it logs a snapshot of the
+// request's connection and, if "restart" is set, aborts the request so that
+// it may be retried on a fresh connection.
+//
+// Such a connection is stuck, but not dead: because a little data still
+// arrives, lfs.activitytimeout never fires. A nil *lowSpeedWatchdog is valid
+// and watches nothing.
+type lowSpeedWatchdog struct {
+	// members managed via sync/atomic must be aligned at the top of this
+	// structure (see: https://github.com/git-lfs/git-lfs/pull/2880).
+
+	// bytes is the number of bytes transferred in the current window,
+	// and total is the number transferred in all.
+	bytes int64
+	total int64
+
+	limit   int64
+	window  time.Duration
+	restart bool
+	host    string
+	start   time.Time
+
+	cancel context.CancelFunc
+	done   chan struct{}
+	once   sync.Once
+
+	mu  sync.Mutex
+	err error
+
+	// The following describe the request's connection, and are guarded
+	// by mu.
+	addrs      []string
+	dnsTime    time.Duration
+	remoteAddr string
+	reused     bool
+	tlsState   *tls.ConnectionState
+	tlsTime    time.Duration
+}
+
+// newLowSpeedWatchdog returns a watchdog for a request to "u", or nil if
+// http.lowSpeedLimit and http.lowSpeedTime, or the GIT_HTTP_LOW_SPEED_LIMIT
+// and GIT_HTTP_LOW_SPEED_TIME environment variables, do not enable one.
+func (c *Client) newLowSpeedWatchdog(u *url.URL) *lowSpeedWatchdog {
+	limit := c.lowSpeedSetting(u, "GIT_HTTP_LOW_SPEED_LIMIT", "lowspeedlimit")
+	seconds := c.lowSpeedSetting(u, "GIT_HTTP_LOW_SPEED_TIME", "lowspeedtime")
+	if limit < 1 || seconds < 1 {
+		return nil
+	}
+
+	return &lowSpeedWatchdog{
+		limit:   int64(limit),
+		window:  time.Duration(seconds) * lowSpeedTimeUnit,
+		restart: c.uc.Bool("lfs", u.String(), "lowspeedrestart", true),
+		host:    u.Host,
+		done:    make(chan struct{}),
+	}
+}
+
+// lowSpeedSetting returns the value of the environment variable "env", if set,
+// as Git does, or otherwise of the http.<key> configuration for "u".
+func (c *Client) lowSpeedSetting(u *url.URL, env, key string) int {
+	if n := c.osEnv.Int(env, 0); n > 0 {
+		return n
+	}
+
+	if v, ok := c.uc.Get("http", u.String(), key); ok {
+		if n, err := strconv.Atoi(v); err == nil {
+			return n
+		}
+	}
+	return 0
+}
+
+// request returns a copy of "req" whose progress is watched, and starts
+// watching it.
+func (w *lowSpeedWatchdog) request(req *http.Request) *http.Request {
+	if w == nil {
+		return req
+	}
+
+	var ctx context.Context
+	ctx, w.cancel = context.WithCancel(req.Context())
+	w.start = time.Now()
+
+	var dnsStart, tlsStart time.Time
+	ctx = httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+		DNSStart: func(_ httptrace.DNSStartInfo) {
+			w.mu.Lock()
+			defer w.mu.Unlock()
+
+			dnsStart = time.Now()
+		},
+		DNSDone: func(info httptrace.DNSDoneInfo) {
+			w.mu.Lock()
+			defer w.mu.Unlock()
+
+			w.dnsTime = time.Since(dnsStart)
+			for _, addr := range info.Addrs {
+				w.addrs = append(w.addrs, addr.String())
+			}
+		},
+		TLSHandshakeStart: func() {
+			w.mu.Lock()
+			defer w.mu.Unlock()
+
+			tlsStart = time.Now()
+		},
+		TLSHandshakeDone: func(state tls.ConnectionState, _ error) {
+			w.mu.Lock()
+			defer w.mu.Unlock()
+
+			w.tlsTime = time.Since(tlsStart)
+			w.tlsState = &state
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			w.mu.Lock()
+			defer w.mu.Unlock()
+
+			w.reused = info.Reused
+			if info.Conn != nil {
+				w.remoteAddr = info.Conn.RemoteAddr().String()
+			}
+		},
+	})
+
+	watched := req.WithContext(ctx)
+	if req.Body != nil && req.Body != http.NoBody {
+		watched.Body = &lowSpeedBody{ReadCloser: req.Body, watchdog: w}
+	}
+
+	go w.run()
+	return watched
+}
+
+// watch continues watching the request as its response's body is read.
+func (w *lowSpeedWatchdog) watch(res *http.Response) {
+	if w == nil || res == nil || res.Body == nil {
+		return
+	}
+	res.Body = &lowSpeedBody{ReadCloser: res.Body, watchdog: w, response: true}
+}
+
+// stop stops watching the request, returning "err", or the reason the request
+// was aborted if it was aborted. If "err" is not nil, the request is also
+// cancelled.
+func (w *lowSpeedWatchdog) stop(err error) error {
+	if w == nil {
+		return err
+	}
+
+	w.once.Do(func() { close(w.done) })
+	if err != nil {
+		w.cancel()
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.err != nil && err != nil {
+		return w.err
+	}
+	return err
+}
+
+func (w *lowSpeedWatchdog) add(n int) {
+	atomic.AddInt64(&w.bytes, int64(n))
+	atomic.AddInt64(&w.total, int64(n))
+}
+
+func (w *lowSpeedWatchdog) run() {
+	ticker := time.NewTicker(w.window)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.done:
+			return
+		case <-ticker.C:
+			n := atomic.SwapInt64(&w.bytes, 0)
+			if float64(n)/w.window.Seconds() >= float64(w.limit) {
+				continue
+			}
+
+			tracerx.Printf("http: slow transfer %s", w.diagnostics(n))
+			if w.restart {
+				w.abort(n)
+				return
+			}
+		}
+	}
+}
+
+// abort cancels the request, after "n" bytes were transferred in the last
+// window.
+func (w *lowSpeedWatchdog) abort(n int64) {
+	w.mu.Lock()
+	w.err = &lowSpeedError{
+		host:   w.host,
+		rate:   humanize.FormatByteRate(uint64(n), w.window),
+		limit:  humanize.FormatByteRate(uint64(w.limit), time.Second),
+		window: w.window,
+	}
+	w.mu.Unlock()
+
+	w.cancel()
+}
+
+// diagnostics describes the request's progress and connection, after "n"
+// bytes were transferred in the last window.
+func (w *lowSpeedWatchdog) diagnostics(n int64) string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	action := "continue"
+	if w.restart {
+		action = "restart"
+	}
+
+	fields := []string{
+		fmt.Sprintf("host=%s", w.host),
+		fmt.Sprintf("rate=%s", humanize.FormatByteRate(uint64(n), w.window)),
+		fmt.Sprintf("limit=%s", humanize.FormatByteRate(uint64(w.limit), time.Second)),
+		fmt.Sprintf("window=%s", w.window),
+		fmt.Sprintf("bytes=%d", atomic.LoadInt64(&w.total)),
+		fmt.Sprintf("elapsed=%s", time.Since(w.start).Round(time.Millisecond)),
+		fmt.Sprintf("addr=%s", w.remoteAddr),
+		fmt.Sprintf("reused=%t", w.reused),
+	}
+	if len(w.addrs) > 0 {
+		fields = append(fields,
+			fmt.Sprintf("dns=%s", strings.Join(w.addrs, ",")),
+			fmt.Sprintf("dnstime=%s", w.dnsTime.Round(time.Millisecond)))
+	}
+	if w.tlsState != nil {
+		fields = append(fields,
+			fmt.Sprintf("tls=%s", tlsVersionName(w.tlsState.Version)),
+			fmt.Sprintf("tlsresumed=%t", w.tlsState.DidResume),
+			fmt.Sprintf("tlstime=%s", w.tlsTime.Round(time.Millisecond)))
+	}
+	fields = append(fields, fmt.Sprintf("action=%s", action))
+
+	return strings.Join(fields, " ")
+}
+
+func tlsVersionName(version uint16) string {
+	switch version {
+	case tls.VersionTLS10:
+		return "1.0"
+	case tls.VersionTLS11:
+		return "1.1"
+	case tls.VersionTLS12:
+		return "1.2"
+	case tls.VersionTLS13:
+		return "1.3"
+	default:
+		return fmt.Sprintf("0x%04x", version)
+	}
+}
+
+// lowSpeedBody counts the bytes read from a request's or response's body for
+// its watchdog.
+type lowSpeedBody struct {
+	io.ReadCloser
+	watchdog *lowSpeedWatchdog
+
+	// response is set if the body is that of the response, so that the
+	// request is done once it has been read.
+	response bool
+}
+
+func (b *lowSpeedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.watchdog.add(n)
+
+	if err == io.EOF {
+		if b.response {
+			b.watchdog.stop(nil)
+		}
+	} else if err != nil {
+		err = b.watchdog.stop(err)
+	}
+	return n, err
+}
+
+func (b *lowSpeedBody) Close() error {
+	err := b.ReadCloser.Close()
+	if b.response {
+		// Cancelling the request's context only once its body is
+		// closed lets the connection be reused.
+		b.watchdog.stop(nil)
+		b.watchdog.cancel()
+	}
+	return err
+}
+
+// lowSpeedError is returned when a request is aborted because it transferred
+// too little data for too long.
+type lowSpeedError struct {
+	host   string
+	rate   string
+	limit  string
+	window time.Duration
+}
+
+func (e *lowSpeedError) Error() string {
+	return tr.Tr.Get("transfer with %s slowed to %s, below the limit of %s, for %s",
+		e.host, e.rate, e.limit, e.window)
+}
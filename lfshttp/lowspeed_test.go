@@ -0,0 +1,111 @@
+package lfshttp
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/git-lfs/git-lfs/v3/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// slowServer returns a server which responds with "n" bytes, one at a time,
+// every "interval".
+func slowServer(n int, interval time.Duration) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(200)
+		for i := 0; i < n; i++ {
+			w.Write([]byte("x"))
+			w.(http.Flusher).Flush()
+
+			select {
+			case <-r.Context().Done():
+				return
+			case <-time.After(interval):
+			}
+		}
+	}))
+}
+
+func TestLowSpeedWatchdogAbortsSlowTransfer(t *testing.T) {
+	defer func(unit time.Duration) {
+		lowSpeedTimeUnit = unit
+	}(lowSpeedTimeUnit)
+	lowSpeedTimeUnit = 100 * time.Millisecond
+
+	srv := slowServer(100, 20*time.Millisecond)
+	defer srv.Close()
+
+	c, err := NewClient(NewContext(nil, nil, map[string]string{
+		"http.lowspeedlimit": "1000",
+		"http.lowspeedtime":  "2",
+	}))
+	require.Nil(t, err)
+
+	req, err := http.NewRequest("GET", srv.URL, nil)
+	require.Nil(t, err)
+
+	res, err := c.Do(req)
+	require.Nil(t, err)
+	defer res.Body.Close()
+
+	by, err := ioutil.ReadAll(res.Body)
+	require.NotNil(t, err)
+	assert.Less(t, len(by), 100)
+
+	_, ok := errors.Cause(err).(*lowSpeedError)
+	assert.True(t, ok, "expected low speed error, got %T: %s", err, err)
+}
+
+func TestLowSpeedWatchdogWithoutRestart(t *testing.T) {
+	defer func(unit time.Duration) {
+		lowSpeedTimeUnit = unit
+	}(lowSpeedTimeUnit)
+	lowSpeedTimeUnit = 20 * time.Millisecond
+
+	srv := slowServer(10, 20*time.Millisecond)
+	defer srv.Close()
+
+	c, err := NewClient(NewContext(nil, nil, map[string]string{
+		"http.lowspeedlimit":  "1000",
+		"http.lowspeedtime":   "1",
+		"lfs.lowspeedrestart": "false",
+	}))
+	require.Nil(t, err)
+
+	req, err := http.NewRequest("GET", srv.URL, nil)
+	require.Nil(t, err)
+
+	res, err := c.Do(req)
+	require.Nil(t, err)
+	defer res.Body.Close()
+
+	by, err := ioutil.ReadAll(res.Body)
+	require.Nil(t, err)
+	assert.Len(t, by, 10)
+}
+
+func TestLowSpeedWatchdogDisabled(t *testing.T) {
+	c, err := NewClient(NewContext(nil, nil, map[string]string{
+		"http.lowspeedlimit": "1000",
+	}))
+	require.Nil(t, err)
+
+	u, _ := http.NewRequest("GET", "https://example.com", nil)
+	assert.Nil(t, c.newLowSpeedWatchdog(u.URL))
+
+	c, err = NewClient(NewContext(nil, map[string]string{
+		"GIT_HTTP_LOW_SPEED_LIMIT": "1000",
+		"GIT_HTTP_LOW_SPEED_TIME":  "5",
+	}, nil))
+	require.Nil(t, err)
+
+	w := c.newLowSpeedWatchdog(u.URL)
+	require.NotNil(t, w)
+	assert.EqualValues(t, 1000, w.limit)
+	assert.Equal(t, 5*time.Second, w.window)
+	assert.True(t, w.restart)
+}
@@ -0,0 +1,88 @@
+package lfshttp
+
+import (
+	"sync"
+	"time"
+
+	"github.com/git-lfs/git-lfs/v3/errors"
+	"github.com/git-lfs/git-lfs/v3/tr"
+	"github.com/rubyist/tracerx"
+)
+
+// rateLimits records the hosts which have responded with HTTP 429 and a
+// Retry-After header. Until the time it gave has passed, requests to such a
+// host fail immediately, so that every transfer to it is paused rather than
+// only the one which was refused.
+type rateLimits struct {
+	mu    sync.Mutex
+	hosts map[string]time.Time
+
+	// now returns the current time, and is replaced in tests.
+	now func() time.Time
+}
+
+func newRateLimits() *rateLimits {
+	return &rateLimits{
+		hosts: make(map[string]time.Time),
+		now:   time.Now,
+	}
+}
+
+// allow returns an error if requests to "host" should not be made yet,
+// because it has asked for them to be paused.
+func (r *rateLimits) allow(host string) error {
+	if r == nil {
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	until, ok := r.hosts[host]
+	if !ok {
+		return nil
+	}
+	if !r.now().Before(until) {
+		delete(r.hosts, host)
+		return nil
+	}
+	return &rateLimitedError{host: host, until: until}
+}
+
+// pause records that "host" has asked for requests to it to be paused until
+// the time given by "retryAfter", the value of a Retry-After header.
+func (r *rateLimits) pause(host, retryAfter string) {
+	if r == nil {
+		return
+	}
+
+	until, ok := errors.ParseRetryAfter(retryAfter)
+	if !ok || !r.now().Before(until) {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if until.After(r.hosts[host]) {
+		tracerx.Printf("http: pausing requests to %s until %s", host, until.Format(time.RFC3339))
+		r.hosts[host] = until
+	}
+}
+
+// rateLimitedError is returned in place of making a request to a host which
+// has asked for requests to be paused. It may be retried once the pause has
+// ended.
+type rateLimitedError struct {
+	host  string
+	until time.Time
+}
+
+func (e *rateLimitedError) Error() string {
+	return tr.Tr.Get("Rate limit exceeded for %s; not retrying until %s",
+		e.host, e.until.In(time.Local).Format(time.RFC822))
+}
+
+func (e *rateLimitedError) RetriableLaterError() (time.Time, bool) {
+	return e.until, true
+}
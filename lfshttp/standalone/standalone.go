@@ -155,6 +155,26 @@ func gitDirAtPath(path string) (string, error) {
 	return tools.CanonicalizeSystemPath(gitdir)
 }
 
+// isObjectStoreAtPath returns whether the given path is a plain directory,
+// rather than a Git repository, in which case it is used directly as a store
+// for Git LFS objects. Objects are kept in its "lfs/objects" subdirectory, just
+// as they would be in a bare repository, so that the directory may be turned
+// into one later.
+func isObjectStoreAtPath(path string) bool {
+	if filepath.Base(path) == ".git" {
+		return false
+	}
+	if stat, err := os.Stat(path); err != nil || !stat.IsDir() {
+		return false
+	}
+	for _, name := range []string{".git", "HEAD"} {
+		if _, err := os.Stat(filepath.Join(path, name)); err == nil {
+			return false
+		}
+	}
+	return true
+}
+
 func fixUrlPath(path string) string {
 	if runtime.GOOS != "windows" {
 		return path
@@ -181,12 +201,25 @@ func newHandler(cfg *config.Configuration, output *os.File, msg *inputMessage) (
 		return nil, errors.New(tr.Tr.Get("no valid file:// URLs found"))
 	}
 
-	path, err := tools.TranslateCygwinPath(fixUrlPath(url.Path))
+	urlPath := url.Path
+	if runtime.GOOS == "windows" && len(url.Host) > 0 && url.Host != "localhost" {
+		// A file URL with a host refers to a network share.
+		urlPath = "//" + url.Host + urlPath
+	}
+
+	path, err := tools.TranslateCygwinPath(fixUrlPath(urlPath))
 	if err != nil {
 		return nil, err
 	}
 
-	gitdir, err := gitDirAtPath(path)
+	var gitdir string
+	if isObjectStoreAtPath(path) {
+		gitdir, err = tools.CanonicalizeSystemPath(path)
+		tracerx.Printf("using %q as remote object store", gitdir)
+	} else {
+		gitdir, err = gitDirAtPath(path)
+		tracerx.Printf("using %q as remote git directory", gitdir)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -196,8 +229,6 @@ func newHandler(cfg *config.Configuration, output *os.File, msg *inputMessage) (
 		return nil, err
 	}
 
-	tracerx.Printf("using %q as remote git directory", gitdir)
-
 	return &fileHandler{
 		remotePath:   path,
 		remoteConfig: config.NewIn(gitdir, gitdir),
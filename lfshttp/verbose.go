@@ -5,15 +5,43 @@ import (
 	"bytes"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"net/http"
 	"net/http/httputil"
+	"regexp"
+	"sort"
 	"strings"
 
 	"github.com/git-lfs/git-lfs/v3/errors"
+	"github.com/git-lfs/git-lfs/v3/subprocess"
 	"github.com/git-lfs/git-lfs/v3/tr"
 	"github.com/rubyist/tracerx"
 )
 
+const (
+	redacted      = "* * * * *"
+	redactedParam = "*****"
+)
+
+// sensitiveHeaders are the headers, in lower case, whose values carry
+// credentials, and so are hidden in verbose output.
+var sensitiveHeaders = []string{"authorization", "proxy-authorization", "cookie", "set-cookie"}
+
+// sensitiveQuerySuffixes are the suffixes, in lower case, of the names of
+// query parameters which carry credentials, such as the signatures of
+// pre-signed URLs, and so are hidden in verbose output.
+var sensitiveQuerySuffixes = []string{"signature", "sig", "token", "credential", "key"}
+
+var (
+	// jsonString matches a string in a JSON document.
+	jsonString = regexp.MustCompile(`"(?:[^"\\]|\\.)*"`)
+
+	// jsonSensitiveHeader matches a member of a JSON object, such as the
+	// "header" of an action in a batch response, whose name is that of a
+	// sensitive header.
+	jsonSensitiveHeader = regexp.MustCompile(`(?i)"(authorization|proxy-authorization|cookie|set-cookie)"(\s*:\s*)"((?:[^"\\]|\\.)*)"`)
+)
+
 func (c *Client) traceRequest(req *http.Request) (*tracedRequest, error) {
 	tracerx.Printf("HTTP: %s", traceReq(req))
 
@@ -28,11 +56,18 @@ func (c *Client) traceRequest(req *http.Request) (*tracedRequest, error) {
 		return nil, errors.New(tr.Tr.Get("Request body must implement io.ReadCloser and io.Seeker: %T", body))
 	}
 
+	if c.TraceCurl {
+		tracerx.Printf("HTTP: %s", c.curlCommand(req, body))
+	}
+
 	if body != nil && ok {
 		body.Seek(0, io.SeekStart)
 		tr := &tracedRequest{
-			verbose:        c.Verbose && isTraceableContent(req.Header),
-			verboseOut:     c.VerboseOut,
+			body: &tracedBody{
+				verbose:    c.Verbose && isTraceableContent(req.Header),
+				verboseOut: c.VerboseOut,
+				redact:     !c.DebuggingVerbose,
+			},
 			ReadSeekCloser: body,
 		}
 		req.Body = tr
@@ -43,18 +78,22 @@ func (c *Client) traceRequest(req *http.Request) (*tracedRequest, error) {
 }
 
 type tracedRequest struct {
-	BodySize   int64
-	verbose    bool
-	verboseOut io.Writer
+	BodySize int64
+	body     *tracedBody
 	ReadSeekCloser
 }
 
 func (r *tracedRequest) Read(b []byte) (int, error) {
-	n, err := tracedRead(r.ReadSeekCloser, b, r.verboseOut, false, r.verbose)
+	n, err := r.body.read(r.ReadSeekCloser, b)
 	r.BodySize += int64(n)
 	return n, err
 }
 
+func (r *tracedRequest) Close() error {
+	r.body.flush()
+	return r.ReadSeekCloser.Close()
+}
+
 func (c *Client) traceResponse(req *http.Request, tracedReq *tracedRequest, res *http.Response) {
 	if tracedReq != nil {
 		c.httpLogger.LogRequest(req, tracedReq.BodySize)
@@ -71,9 +110,12 @@ func (c *Client) traceResponse(req *http.Request, tracedReq *tracedRequest, res
 	res.Body = &tracedResponse{
 		httpLogger: c.httpLogger,
 		response:   res,
-		gitTrace:   verboseBody,
-		verbose:    verboseBody && c.Verbose,
-		verboseOut: c.VerboseOut,
+		body: &tracedBody{
+			gitTrace:   verboseBody,
+			verbose:    verboseBody && c.Verbose,
+			verboseOut: c.VerboseOut,
+			redact:     !c.DebuggingVerbose,
+		},
 		ReadCloser: res.Body,
 	}
 
@@ -95,15 +137,13 @@ type tracedResponse struct {
 	BodySize   int64
 	httpLogger *syncLogger
 	response   *http.Response
-	verbose    bool
-	gitTrace   bool
-	verboseOut io.Writer
+	body       *tracedBody
 	eof        bool
 	io.ReadCloser
 }
 
 func (r *tracedResponse) Read(b []byte) (int, error) {
-	n, err := tracedRead(r.ReadCloser, b, r.verboseOut, r.gitTrace, r.verbose)
+	n, err := r.body.read(r.ReadCloser, b)
 	r.BodySize += int64(n)
 
 	if err == io.EOF && !r.eof {
@@ -113,35 +153,205 @@ func (r *tracedResponse) Read(b []byte) (int, error) {
 	return n, err
 }
 
-func tracedRead(r io.Reader, b []byte, verboseOut io.Writer, gitTrace, verbose bool) (int, error) {
+func (r *tracedResponse) Close() error {
+	r.body.flush()
+	return r.ReadCloser.Close()
+}
+
+// tracedBody traces the body of a request or response as it is read. When
+// credentials are to be hidden, the body is held until it has been read in
+// full, so that a credential split across two reads is still found.
+type tracedBody struct {
+	verbose    bool
+	gitTrace   bool
+	redact     bool
+	verboseOut io.Writer
+	buf        bytes.Buffer
+}
+
+func (t *tracedBody) read(r io.Reader, b []byte) (int, error) {
 	n, err := r.Read(b)
 	if err == nil || err == io.EOF {
-		if n > 0 && (gitTrace || verbose) {
-			chunk := string(b[0:n])
-			if gitTrace {
-				tracerx.Printf("HTTP: %s", chunk)
-			}
-
-			if verbose {
-				fmt.Fprint(verboseOut, chunk)
+		if n > 0 && (t.gitTrace || t.verbose) {
+			if t.redact {
+				t.buf.Write(b[0:n])
+			} else {
+				t.print(string(b[0:n]))
 			}
 		}
 	}
 
+	if err != nil {
+		t.flush()
+	}
 	return n, err
 }
 
+func (t *tracedBody) flush() {
+	if t.buf.Len() == 0 {
+		return
+	}
+	t.print(redactBody(t.buf.String()))
+	t.buf.Reset()
+}
+
+func (t *tracedBody) print(chunk string) {
+	if t.gitTrace {
+		tracerx.Printf("HTTP: %s", chunk)
+	}
+
+	if t.verbose {
+		fmt.Fprint(t.verboseOut, chunk)
+	}
+}
+
 func (c *Client) traceHTTPDump(direction string, dump []byte) {
 	scanner := bufio.NewScanner(bytes.NewBuffer(dump))
 
-	for scanner.Scan() {
+	for first := true; scanner.Scan(); first = false {
 		line := scanner.Text()
-		if !c.DebuggingVerbose && strings.HasPrefix(strings.ToLower(line), "authorization: basic") {
-			fmt.Fprintf(c.VerboseOut, "%s Authorization: Basic * * * * *\n", direction)
-		} else {
-			fmt.Fprintf(c.VerboseOut, "%s %s\n", direction, line)
+		if !c.DebuggingVerbose {
+			if first && direction == ">" {
+				line = redactRequestLine(line)
+			} else if i := strings.Index(line, ":"); i > 0 && isSensitiveHeader(line[:i]) {
+				line = line[:i] + ": " + redactHeaderValue(line[:i], strings.TrimSpace(line[i+1:]))
+			} else if i > 0 && strings.EqualFold(line[:i], "location") {
+				line = line[:i] + ": " + redactURL(strings.TrimSpace(line[i+1:]))
+			}
+		}
+		fmt.Fprintf(c.VerboseOut, "%s %s\n", direction, line)
+	}
+}
+
+// curlCommand returns a curl command line which repeats the request "req",
+// whose body is "body", with any credentials hidden unless debugging.
+func (c *Client) curlCommand(req *http.Request, body ReadSeekCloser) string {
+	args := []string{"curl", "-X", req.Method}
+
+	names := make([]string, 0, len(req.Header))
+	for name := range req.Header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		for _, value := range req.Header[name] {
+			if !c.DebuggingVerbose && isSensitiveHeader(name) {
+				value = redactHeaderValue(name, value)
+			}
+			args = append(args, "-H", name+": "+value)
+		}
+	}
+
+	if body != nil && isTraceableContent(req.Header) {
+		data, err := ioutil.ReadAll(body)
+		body.Seek(0, io.SeekStart)
+		if err == nil && len(data) > 0 {
+			if !c.DebuggingVerbose {
+				data = []byte(redactBody(string(data)))
+			}
+			args = append(args, "--data-binary", string(data))
+		}
+	}
+
+	rawurl := req.URL.String()
+	if !c.DebuggingVerbose {
+		rawurl = redactURL(rawurl)
+	}
+	args = append(args, rawurl)
+
+	return strings.Join(subprocess.ShellQuote(args), " ")
+}
+
+func isSensitiveHeader(name string) bool {
+	name = strings.ToLower(strings.TrimSpace(name))
+	for _, h := range sensitiveHeaders {
+		if name == h {
+			return true
 		}
 	}
+	return false
+}
+
+// redactHeaderValue hides the credentials in the value of the sensitive header
+// "name". Cookies are hidden in full, while the value of an authorization
+// header keeps any authentication scheme, such as "Basic".
+func redactHeaderValue(name, value string) string {
+	if strings.HasSuffix(strings.ToLower(strings.TrimSpace(name)), "cookie") {
+		return redacted
+	}
+	if i := strings.Index(value, " "); i > 0 {
+		return value[:i] + " " + redacted
+	}
+	return redacted
+}
+
+func isSensitiveQueryParam(name string) bool {
+	name = strings.ToLower(name)
+	for _, suffix := range sensitiveQuerySuffixes {
+		if strings.HasSuffix(name, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactURL hides the values of any query parameters in "rawurl" which carry
+// credentials, leaving the rest of the URL as it was.
+func redactURL(rawurl string) string {
+	parts := strings.SplitN(rawurl, "?", 2)
+	if len(parts) < 2 {
+		return rawurl
+	}
+
+	fragment := ""
+	if i := strings.Index(parts[1], "#"); i >= 0 {
+		parts[1], fragment = parts[1][:i], parts[1][i:]
+	}
+
+	params := strings.Split(parts[1], "&")
+	for i, param := range params {
+		kv := strings.SplitN(param, "=", 2)
+		if len(kv) == 2 && isSensitiveQueryParam(kv[0]) {
+			params[i] = kv[0] + "=" + redactedParam
+		}
+	}
+	return parts[0] + "?" + strings.Join(params, "&") + fragment
+}
+
+// redactBody hides the credentials in a traced body, such as the signed hrefs
+// and authorization headers of the actions in a batch response.
+func redactBody(body string) string {
+	body = jsonString.ReplaceAllStringFunc(body, func(s string) string {
+		if !strings.Contains(s, "?") {
+			return s
+		}
+		// Go escapes "&" in JSON strings, which would hide the separate
+		// query parameters from redactURL.
+		value := s[1 : len(s)-1]
+		escaped := strings.Contains(value, `\u0026`)
+		value = redactURL(strings.Replace(value, `\u0026`, "&", -1))
+		if escaped {
+			value = strings.Replace(value, "&", `\u0026`, -1)
+		}
+		return `"` + value + `"`
+	})
+
+	return jsonSensitiveHeader.ReplaceAllStringFunc(body, func(s string) string {
+		m := jsonSensitiveHeader.FindStringSubmatch(s)
+		return `"` + m[1] + `"` + m[2] + `"` + redactHeaderValue(m[1], m[3]) + `"`
+	})
+}
+
+// redactRequestLine hides any credentials in the URL of an HTTP request line,
+// such as "GET /path?query HTTP/1.1".
+func redactRequestLine(line string) string {
+	fields := strings.Split(line, " ")
+	if len(fields) != 3 {
+		return line
+	}
+	fields[1] = redactURL(fields[1])
+	return strings.Join(fields, " ")
 }
 
 var tracedTypes = []string{"json", "text", "xml", "html"}
@@ -228,3 +228,82 @@ func TestVerboseDisabled(t *testing.T) {
 	assert.EqualValues(t, 1, called)
 	assert.EqualValues(t, 0, out.Len(), out.String())
 }
+
+func TestVerboseRedactsCredentials(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/vnd.git-lfs+json")
+		w.Header().Set("Set-Cookie", "session=secret")
+		w.WriteHeader(200)
+		w.Write([]byte(`{"objects":[{"actions":{"download":{"href":"https://storage.example.com/obj?X-Amz-Signature=secret\u0026part=1","header":{"Authorization":"Bearer secret"}}}}]}`))
+	}))
+	defer srv.Close()
+
+	out := &bytes.Buffer{}
+	c, _ := NewClient(nil)
+	c.Verbose = true
+	c.VerboseOut = out
+
+	req, err := http.NewRequest("GET", srv.URL+"/obj?X-Amz-Signature=secret&part=1", nil)
+	require.Nil(t, err)
+	req.Header.Set("Authorization", "Bearer secret")
+	req.Header.Set("Proxy-Authorization", "secret")
+	req.Header.Set("Cookie", "session=secret")
+
+	res, err := c.Do(req)
+	require.Nil(t, err)
+	io.Copy(ioutil.Discard, res.Body)
+	res.Body.Close()
+
+	s := out.String()
+	t.Log(s)
+
+	assert.NotContains(t, s, "secret")
+	assert.Contains(t, s, "> GET /obj?X-Amz-Signature=*****&part=1 HTTP/1.1\n")
+	assert.Contains(t, s, "\n> Authorization: Bearer * * * * *\n")
+	assert.Contains(t, s, "\n> Proxy-Authorization: * * * * *\n")
+	assert.Contains(t, s, "\n> Cookie: * * * * *\n")
+	assert.Contains(t, s, "\n< Set-Cookie: * * * * *\n")
+	assert.Contains(t, s, `"href":"https://storage.example.com/obj?X-Amz-Signature=*****\u0026part=1"`)
+	assert.Contains(t, s, `"Authorization":"Bearer * * * * *"`)
+}
+
+func TestRedactBody(t *testing.T) {
+	for body, expected := range map[string]string{
+		`{"Status":"Ok"}`:                                `{"Status":"Ok"}`,
+		`{"href":"https://example.com/a?sig=abc"}`:       `{"href":"https://example.com/a?sig=*****"}`,
+		`{"href":"/a?token=a\"b&ok=1"}`:                  `{"href":"/a?token=*****&ok=1"}`,
+		`{"header": {"cookie" : "a=b", "X-Id": "c"}}`:    `{"header": {"cookie" : "* * * * *", "X-Id": "c"}}`,
+		`{"header":{"Proxy-Authorization":"Basic abc"}}`: `{"header":{"Proxy-Authorization":"Basic * * * * *"}}`,
+	} {
+		assert.Equal(t, expected, redactBody(body), body)
+	}
+}
+
+func TestRedactURL(t *testing.T) {
+	for rawurl, expected := range map[string]string{
+		"https://example.com/a":                             "https://example.com/a",
+		"https://example.com/a?part=1":                      "https://example.com/a?part=1",
+		"https://example.com/a?sig=abc&se=2020":             "https://example.com/a?sig=*****&se=2020",
+		"https://example.com/a?X-Goog-Credential=abc#frag":  "https://example.com/a?X-Goog-Credential=*****#frag",
+		"/a?access_token=abc&X-Amz-Security-Token=def&ok=1": "/a?access_token=*****&X-Amz-Security-Token=*****&ok=1",
+	} {
+		assert.Equal(t, expected, redactURL(rawurl), rawurl)
+	}
+}
+
+func TestCurlCommand(t *testing.T) {
+	c, _ := NewClient(nil)
+
+	req, err := http.NewRequest("POST", "https://example.com/objects/batch?token=abc", nil)
+	require.Nil(t, err)
+	req.Header.Set("Authorization", "Basic abc")
+	req.Header.Set("Content-Type", "application/json")
+	require.Nil(t, MarshalToRequest(req, verboseTest{"Curl"}))
+
+	assert.Equal(t,
+		`curl -X POST -H 'Authorization: Basic * * * * *' -H 'Content-Length: 15' -H 'Content-Type: application/json' --data-binary '{"Test":"Curl"}' 'https://example.com/objects/batch?token=*****'`,
+		c.curlCommand(req, req.Body.(ReadSeekCloser)))
+
+	c.DebuggingVerbose = true
+	assert.Contains(t, c.curlCommand(req, req.Body.(ReadSeekCloser)), "'Authorization: Basic abc'")
+}
@@ -70,6 +70,14 @@ func (c *httpLockClient) Lock(remote string, lockReq *lockRequest) (*lockRespons
 	res, err := c.DoAPIRequestWithAuth(remote, req)
 	if err != nil {
 		if res != nil {
+			// A conflict is described by the existing lock, which
+			// the body gives along with the error.
+			if res.StatusCode == http.StatusConflict {
+				lockRes := &lockResponse{}
+				if lfshttp.DecodeJSON(res, lockRes) == nil && lockRes.Lock != nil {
+					return lockRes, res.StatusCode, nil
+				}
+			}
 			return nil, res.StatusCode, err
 		}
 		return nil, 0, err
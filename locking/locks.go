@@ -100,7 +100,7 @@ func (c *Client) Close() error {
 // path must be relative to the root of the repository
 // Returns the lock id if successful, or an error
 func (c *Client) LockFile(path string) (Lock, error) {
-	lockRes, _, err := c.client.Lock(c.Remote, &lockRequest{
+	lockRes, status, err := c.client.Lock(c.Remote, &lockRequest{
 		Path: path,
 		Ref:  &lockRef{Name: c.RemoteRef.Refspec()},
 	})
@@ -108,6 +108,13 @@ func (c *Client) LockFile(path string) (Lock, error) {
 		return Lock{}, errors.Wrap(err, tr.Tr.Get("locking API"))
 	}
 
+	if status == http.StatusConflict && lockRes.Lock != nil {
+		if len(lockRes.RequestID) > 0 {
+			tracerx.Printf("Server Request ID: %s", lockRes.RequestID)
+		}
+		return Lock{}, &LockConflictError{Lock: *lockRes.Lock, Message: lockRes.Message}
+	}
+
 	if len(lockRes.Message) > 0 {
 		if len(lockRes.RequestID) > 0 {
 			tracerx.Printf("Server Request ID: %s", lockRes.RequestID)
@@ -135,6 +142,30 @@ func (c *Client) LockFile(path string) (Lock, error) {
 	return lock, nil
 }
 
+// LockConflictError is returned by LockFile when the file is already locked,
+// and holds the existing lock. errors.IsLockConflictError recognizes it.
+type LockConflictError struct {
+	// Lock is the existing lock on the file.
+	Lock Lock
+	// Message is the server's description of the conflict, if any.
+	Message string
+}
+
+func (e *LockConflictError) Error() string {
+	message := e.Message
+	if len(message) == 0 {
+		message = tr.Tr.Get("lock already exists")
+	}
+	if e.Lock.Owner != nil && len(e.Lock.Owner.Name) > 0 {
+		return tr.Tr.Get("server unable to create lock: %s (locked by %s)", message, e.Lock.Owner.Name)
+	}
+	return tr.Tr.Get("server unable to create lock: %s", message)
+}
+
+func (e *LockConflictError) LockConflictError() bool {
+	return true
+}
+
 // getAbsolutePath takes a repository-relative path and makes it absolute.
 //
 // For instance, given a repository in /usr/local/src/my-repo and a file called
@@ -147,7 +178,9 @@ func getAbsolutePath(p string) (string, error) {
 		return "", err
 	}
 
-	return filepath.Join(root, p), nil
+	// The path may have come from the server, so it must not be allowed
+	// to refer to a file outside of the repository.
+	return tools.JoinWithinDir(root, p)
 }
 
 // UnlockFile attempts to unlock a file on the current remote
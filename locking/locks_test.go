@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"github.com/git-lfs/git-lfs/v3/config"
+	"github.com/git-lfs/git-lfs/v3/errors"
 	"github.com/git-lfs/git-lfs/v3/git"
 	"github.com/git-lfs/git-lfs/v3/lfsapi"
 	"github.com/git-lfs/git-lfs/v3/lfshttp"
@@ -314,3 +315,37 @@ func TestSearchLocksVerifiableWithCache(t *testing.T) {
 	sort.Sort(LocksById(theirLocks))
 	assert.Equal(t, expectedTheirLocks, theirLocks)
 }
+
+func TestLockFileConflict(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "POST", r.Method)
+		assert.Equal(t, "/api/locks", r.URL.Path)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusConflict)
+		err := json.NewEncoder(w).Encode(&lockResponse{
+			Lock:    &Lock{Id: "100", Path: "a.psd", Owner: &User{Name: "Alice"}},
+			Message: "already created lock",
+		})
+		assert.Nil(t, err)
+	}))
+	defer srv.Close()
+
+	lfsclient, err := lfsapi.NewClient(lfshttp.NewContext(nil, nil, map[string]string{
+		"lfs.url": srv.URL + "/api",
+	}))
+	require.Nil(t, err)
+
+	client, err := NewClient("", lfsclient, config.New())
+	require.Nil(t, err)
+	client.RemoteRef = &git.Ref{Name: "refs/heads/master"}
+
+	_, err = client.LockFile("a.psd")
+	require.NotNil(t, err)
+	assert.True(t, errors.IsLockConflictError(err))
+	if conflict, ok := err.(*LockConflictError); assert.True(t, ok) {
+		assert.Equal(t, "100", conflict.Lock.Id)
+		assert.Equal(t, "Alice", conflict.Lock.Owner.Name)
+	}
+	assert.Equal(t, "server unable to create lock: already created lock (locked by Alice)", err.Error())
+}
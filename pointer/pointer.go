@@ -0,0 +1,352 @@
+// Package pointer parses and serializes Git LFS pointer files, which stand in
+// for the contents of large objects in a Git repository.
+//
+// A pointer file is a short list of key-value pairs, one per line:
+//
+//	version https://git-lfs.github.com/spec/v1
+//	oid sha256:4d7a214614ab2935c943f9e0ff69d22eadbb8f32b1258daaa5e2ca24d17e2393
+//	size 12345
+//
+// Content which is not a pointer at all is reported with an error for which
+// errors.IsNotAPointerError returns true, so that callers can tell it apart
+// from a malformed pointer.
+package pointer
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/git-lfs/git-lfs/v3/errors"
+	"github.com/git-lfs/git-lfs/v3/fs"
+	"github.com/git-lfs/git-lfs/v3/tr"
+)
+
+const (
+	// SizeCutoff is the size at or above which content is never a
+	// pointer, so that no more than this need be read to decode one.
+	SizeCutoff = 1024
+
+	// OidType is the type of the OIDs of objects and extensions.
+	OidType = "sha256"
+)
+
+var (
+	v1Aliases = []string{
+		"http://git-media.io/v/2",            // alpha
+		"https://hawser.github.com/spec/v1",  // pre-release
+		"https://git-lfs.github.com/spec/v1", // public launch
+	}
+	latest      = "https://git-lfs.github.com/spec/v1"
+	matcherRE   = regexp.MustCompile("git-media|hawser|git-lfs")
+	extRE       = regexp.MustCompile(`\Aext-\d{1}-\w+`)
+	pointerKeys = []string{"version", "oid", "size"}
+)
+
+// A Pointer stands in for the contents of a Git LFS object, which it
+// identifies by OID and size, in a Git repository.
+type Pointer struct {
+	Version    string
+	Oid        string
+	Size       int64
+	OidType    string
+	Extensions []*Extension
+	// Canonical is true if the pointer was decoded from exactly the
+	// bytes its Encode method would write.
+	Canonical bool
+}
+
+// An Extension is parsed from an "ext-{priority}-{name}" line of a pointer,
+// and records that the object's content was transformed by the extension
+// "name", such as one which compresses or encrypts it, and the OID of the
+// content before it was. Extensions are applied in ascending order of
+// priority when cleaning, and reversed in descending order when smudging.
+//
+// Readers which don't know an extension still decode and preserve it, so
+// pointers written with extensions may be fetched, pushed and listed by any
+// client; only smudging the content requires the extension.
+type Extension struct {
+	Name     string
+	Priority int
+	Oid      string
+	OidType  string
+}
+
+// ByPriority sorts extensions in the order in which they are applied.
+type ByPriority []*Extension
+
+func (p ByPriority) Len() int           { return len(p) }
+func (p ByPriority) Swap(i, j int)      { p[i], p[j] = p[j], p[i] }
+func (p ByPriority) Less(i, j int) bool { return p[i].Priority < p[j].Priority }
+
+// New returns a pointer of the latest version to the object "oid" of the given
+// size, which was transformed by the extensions "exts".
+func New(oid string, size int64, exts []*Extension) *Pointer {
+	return &Pointer{latest, oid, size, OidType, exts, true}
+}
+
+// NewExtension returns an Extension "name" of the given priority, whose input
+// had the OID "oid".
+func NewExtension(name string, priority int, oid string) *Extension {
+	return &Extension{name, priority, oid, OidType}
+}
+
+// Empty returns the pointer to the empty object, which is encoded as no
+// content at all.
+func Empty() *Pointer {
+	return New(fs.EmptyObjectSHA256, 0, nil)
+}
+
+// Encode writes the canonical encoding of the pointer to "writer".
+func (p *Pointer) Encode(writer io.Writer) (int, error) {
+	return writer.Write([]byte(p.Encoded()))
+}
+
+// Encoded returns the canonical encoding of the pointer, in which its
+// extensions are listed in order of priority, whatever their order in
+// Extensions.
+func (p *Pointer) Encoded() string {
+	if p.Size == 0 {
+		return ""
+	}
+
+	exts := make([]*Extension, len(p.Extensions))
+	copy(exts, p.Extensions)
+	sort.Stable(ByPriority(exts))
+
+	var buffer bytes.Buffer
+	buffer.WriteString(fmt.Sprintf("version %s\n", latest))
+	for _, ext := range exts {
+		buffer.WriteString(fmt.Sprintf("ext-%d-%s %s:%s\n", ext.Priority, ext.Name, ext.OidType, ext.Oid))
+	}
+	buffer.WriteString(fmt.Sprintf("oid %s:%s\n", p.OidType, p.Oid))
+	buffer.WriteString(fmt.Sprintf("size %d\n", p.Size))
+	return buffer.String()
+}
+
+// Decode decodes a *Pointer from the given io.Reader, "reader", reading no more
+// than SizeCutoff bytes from it.
+func Decode(reader io.Reader) (*Pointer, error) {
+	p, _, err := DecodeFrom(reader)
+	return p, err
+}
+
+// DecodeFrom decodes a *Pointer from the given io.Reader, "reader".
+// If the pointer encoded in the reader could successfully be read and decoded,
+// it will be returned with a nil error.
+//
+// If the pointer could not be decoded, an io.Reader containing the entire
+// blob's data will be returned, along with a parse error.
+func DecodeFrom(reader io.Reader) (*Pointer, io.Reader, error) {
+	buf := make([]byte, SizeCutoff)
+	n, err := reader.Read(buf)
+	buf = buf[:n]
+
+	var contents io.Reader = bytes.NewReader(buf)
+	if err != io.EOF {
+		contents = io.MultiReader(contents, reader)
+	}
+
+	if err != nil && err != io.EOF {
+		return nil, contents, err
+	}
+
+	if len(buf) == 0 {
+		return Empty(), contents, nil
+	}
+
+	p, err := decodeKV(bytes.TrimSpace(buf))
+	if err == nil && p != nil {
+		p.Canonical = p.Encoded() == string(buf)
+	}
+	return p, contents, err
+}
+
+// IsValidOid returns whether "oid" is a well-formed object ID, without its
+// type prefix.
+func IsValidOid(oid string) bool {
+	return fs.ValidateOid(oid) == nil
+}
+
+// Extension returns the pointer's extension named "name", or nil if it has
+// none by that name.
+func (p *Pointer) Extension(name string) *Extension {
+	for _, ext := range p.Extensions {
+		if ext.Name == name {
+			return ext
+		}
+	}
+	return nil
+}
+
+// IsExtensionKey returns whether "key" is the key of an extension line of a
+// pointer, such as "ext-0-foo".
+func IsExtensionKey(key string) bool {
+	return extRE.MatchString(key)
+}
+
+// VerifyVersion returns an error unless "version" is one of the versions of
+// the pointer format which can be decoded.
+func VerifyVersion(version string) error {
+	if len(version) == 0 {
+		return errors.NewNotAPointerError(errors.New(tr.Tr.Get("Missing version")))
+	}
+
+	for _, v := range v1Aliases {
+		if v == version {
+			return nil
+		}
+	}
+
+	return errors.New(tr.Tr.Get("Invalid version: %s", version))
+}
+
+func decodeKV(data []byte) (*Pointer, error) {
+	kvps, exts, err := decodeKVData(data)
+	if err != nil {
+		if errors.IsBadPointerKeyError(err) {
+			return nil, errors.StandardizeBadPointerError(err)
+		}
+		return nil, err
+	}
+
+	if err := VerifyVersion(kvps["version"]); err != nil {
+		return nil, err
+	}
+
+	value, ok := kvps["oid"]
+	if !ok {
+		return nil, errors.New(tr.Tr.Get("Invalid OID"))
+	}
+
+	oid, err := parseOid(value)
+	if err != nil {
+		return nil, err
+	}
+
+	value, ok = kvps["size"]
+	size, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || size < 0 {
+		return nil, errors.New(tr.Tr.Get("invalid size: %q", value))
+	}
+
+	var extensions []*Extension
+	if exts != nil {
+		for key, value := range exts {
+			ext, err := parseExtension(key, value)
+			if err != nil {
+				return nil, err
+			}
+			extensions = append(extensions, ext)
+		}
+		if err = validateExtensions(extensions); err != nil {
+			return nil, err
+		}
+		sort.Sort(ByPriority(extensions))
+	}
+
+	return New(oid, size, extensions), nil
+}
+
+func parseOid(value string) (string, error) {
+	parts := strings.SplitN(value, ":", 2)
+	if len(parts) != 2 {
+		return "", errors.New(tr.Tr.Get("Invalid OID value: %s", value))
+	}
+	if parts[0] != OidType {
+		return "", errors.New(tr.Tr.Get("Invalid OID type: %s", parts[0]))
+	}
+	oid := parts[1]
+	if !IsValidOid(oid) {
+		return "", errors.New(tr.Tr.Get("Invalid OID: %s", oid))
+	}
+	return oid, nil
+}
+
+func parseExtension(key string, value string) (*Extension, error) {
+	keyParts := strings.SplitN(key, "-", 3)
+	if len(keyParts) != 3 || keyParts[0] != "ext" {
+		return nil, errors.New(tr.Tr.Get("Invalid extension value: %s", value))
+	}
+
+	p, err := strconv.Atoi(keyParts[1])
+	if err != nil || p < 0 {
+		return nil, errors.New(tr.Tr.Get("Invalid priority: %s", keyParts[1]))
+	}
+
+	name := keyParts[2]
+
+	oid, err := parseOid(value)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewExtension(name, p, oid), nil
+}
+
+func validateExtensions(exts []*Extension) error {
+	m := make(map[int]struct{})
+	for _, ext := range exts {
+		if _, exist := m[ext.Priority]; exist {
+			return errors.New(tr.Tr.Get("duplicate priority found: %d", ext.Priority))
+		}
+		m[ext.Priority] = struct{}{}
+	}
+	return nil
+}
+
+func decodeKVData(data []byte) (kvps map[string]string, exts map[string]string, err error) {
+	kvps = make(map[string]string)
+
+	if !matcherRE.Match(data) {
+		err = errors.NewNotAPointerError(errors.New(tr.Tr.Get("invalid header")))
+		return
+	}
+
+	scanner := bufio.NewScanner(bytes.NewBuffer(data))
+	line := 0
+	numKeys := len(pointerKeys)
+	for scanner.Scan() {
+		text := scanner.Text()
+		if len(text) == 0 {
+			continue
+		}
+
+		parts := strings.SplitN(text, " ", 2)
+		if len(parts) < 2 {
+			err = errors.NewNotAPointerError(errors.New(tr.Tr.Get("error reading line %d: %s", line, text)))
+			return
+		}
+
+		key := parts[0]
+		value := parts[1]
+
+		if numKeys <= line {
+			err = errors.NewNotAPointerError(errors.New(tr.Tr.Get("extra line: %s", text)))
+			return
+		}
+
+		if expected := pointerKeys[line]; key != expected {
+			if !extRE.Match([]byte(key)) {
+				err = errors.NewBadPointerKeyError(expected, key)
+				return
+			}
+			if exts == nil {
+				exts = make(map[string]string)
+			}
+			exts[key] = value
+			continue
+		}
+
+		line += 1
+		kvps[key] = value
+	}
+
+	err = scanner.Err()
+	return
+}
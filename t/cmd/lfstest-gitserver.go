@@ -14,6 +14,7 @@ import (
 	"crypto/x509"
 	"crypto/x509/pkix"
 	"encoding/base64"
+	"encoding/binary"
 	"encoding/hex"
 	"encoding/json"
 	"encoding/pem"
@@ -60,6 +61,7 @@ var (
 		"status-batch-resume-206", "batch-resume-fail-fallback", "return-expired-action", "return-expired-action-forever", "return-invalid-size",
 		"object-authenticated", "storage-download-retry", "storage-upload-retry", "storage-upload-retry-later", "unknown-oid",
 		"send-verify-action", "send-deprecated-links", "redirect-storage-upload", "storage-compress", "batch-hash-algo-empty", "batch-hash-algo-invalid",
+		"storage-restore-retry",
 	}
 
 	reqCookieReposRE = regexp.MustCompile(`\A/require-cookie-`)
@@ -185,6 +187,7 @@ type lfsObject struct {
 
 type lfsLink struct {
 	Href      string            `json:"href"`
+	Mirrors   []string          `json:"mirrors,omitempty"`
 	Header    map[string]string `json:"header,omitempty"`
 	ExpiresAt time.Time         `json:"expires_at,omitempty"`
 	ExpiresIn int               `json:"expires_in,omitempty"`
@@ -424,6 +427,9 @@ func lfsBatchHandler(w http.ResponseWriter, r *http.Request, id, repo string) {
 
 	res := []lfsObject{}
 	testingChunked := testingChunkedTransferEncoding(r)
+	testingGzip := testingGzipUpload(r)
+	testingDelta := testingDeltaTransfer(r)
+	testingMirrors := testingDownloadMirrors(r)
 	testingTus := testingTusUploadInBatchReq(r)
 	testingTusInterrupt := testingTusUploadInterruptedInBatchReq(r)
 	testingCustomTransfer := testingCustomTransfer(r)
@@ -534,6 +540,28 @@ func lfsBatchHandler(w http.ResponseWriter, r *http.Request, id, repo string) {
 				o.Actions[action].Header["Transfer-Encoding"] = "chunked"
 			}
 		}
+		if testingGzip && addAction && action == "upload" {
+			// Advertise a codec the client doesn't have first, so
+			// that it has to negotiate one it does.
+			o.Actions[action].Header["Content-Encoding"] = "zstd, gzip"
+		}
+		if testingMirrors && addAction && action == "download" {
+			// Make the primary href unavailable, so that the
+			// client has to fail over to a mirror.
+			link := o.Actions[action]
+			link.Mirrors = []string{link.Href + "&mirror=1", link.Href + "&mirror=2"}
+			link.Href += "&unavailable=1"
+		}
+		if testingDelta && addAction {
+			if base, ok := largeObjects.Other(repo, obj.Oid); ok {
+				o.Actions[action+"-delta"] = &lfsLink{
+					Href: lfsUrl(repo, obj.Oid, false) + "&base=" + base,
+					Header: map[string]string{
+						"LFS-Delta-Base": base,
+					},
+				}
+			}
+		}
 		if testingTusInterrupt && addAction {
 			if handler == "send-deprecated-links" {
 				o.Links[action].Header["Lfs-Tus-Interrupt"] = "true"
@@ -615,7 +643,7 @@ var tusStorageAttempts = 0
 var (
 	vmu           sync.Mutex
 	verifyCounts  = make(map[string]int)
-	verifyRetryRe = regexp.MustCompile(`verify-fail-(\d+)-times?$`)
+	verifyRetryRe = regexp.MustCompile(`verify-(fail|mismatch)-(\d+)-times?$`)
 )
 
 func verifyHandler(w http.ResponseWriter, r *http.Request) {
@@ -631,10 +659,12 @@ func verifyHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var max int
-	if matches := verifyRetryRe.FindStringSubmatch(repo); len(matches) < 2 {
+	var mismatch bool
+	if matches := verifyRetryRe.FindStringSubmatch(repo); len(matches) < 3 {
 		return
 	} else {
-		max, _ = strconv.Atoi(matches[1])
+		mismatch = matches[1] == "mismatch"
+		max, _ = strconv.Atoi(matches[2])
 	}
 
 	key := strings.Join([]string{repo, payload.Oid}, ":")
@@ -644,7 +674,14 @@ func verifyHandler(w http.ResponseWriter, r *http.Request) {
 	count := verifyCounts[key]
 	vmu.Unlock()
 
-	if count < max {
+	if mismatch && count <= max {
+		writeLFSError(w, http.StatusUnprocessableEntity, fmt.Sprintf(
+			"intentionally mismatched verify request %d (out of %d)", count, max,
+		))
+		return
+	}
+
+	if !mismatch && count < max {
 		writeLFSError(w, http.StatusServiceUnavailable, fmt.Sprintf(
 			"intentionally failing verify request %d (out of %d)", count, max,
 		))
@@ -730,10 +767,38 @@ func storageHandler(w http.ResponseWriter, r *http.Request) {
 			}
 		}
 
+		var body io.Reader = r.Body
+		if r.Header.Get("Content-Encoding") == "gzip" {
+			gz, err := gzip.NewReader(r.Body)
+			if err != nil {
+				w.WriteHeader(400)
+				w.Write([]byte("invalid gzip body"))
+				return
+			}
+			defer gz.Close()
+
+			debug(id, "storage decompressing gzipped upload")
+			body = gz
+		}
+
+		if baseOid := r.URL.Query().Get("base"); len(baseOid) > 0 {
+			base, _ := largeObjects.Get(repo, baseOid)
+			delta, _ := ioutil.ReadAll(body)
+			target, err := applyDelta(base, delta)
+			if err != nil {
+				w.WriteHeader(400)
+				w.Write([]byte(err.Error()))
+				return
+			}
+
+			debug(id, "storage applied delta of %d bytes against %s", len(delta), baseOid)
+			body = bytes.NewReader(target)
+		}
+
 		hash := sha256.New()
 		buf := &bytes.Buffer{}
 
-		io.Copy(io.MultiWriter(hash, buf), r.Body)
+		io.Copy(io.MultiWriter(hash, buf), body)
 		oid := hex.EncodeToString(hash.Sum(nil))
 		if !strings.HasSuffix(r.URL.Path, "/"+oid) {
 			w.WriteHeader(403)
@@ -750,6 +815,14 @@ func storageHandler(w http.ResponseWriter, r *http.Request) {
 		resumeAt := int64(0)
 		compress := false
 
+		if len(r.URL.Query().Get("unavailable")) > 0 {
+			writeLFSError(w, 503, "storage is temporarily unavailable")
+			return
+		}
+		if mirror := r.URL.Query().Get("mirror"); len(mirror) > 0 {
+			debug(id, "storage serving %s from mirror %s", oid, mirror)
+		}
+
 		if by, ok := largeObjects.Get(repo, oid); ok {
 			if len(by) == len("storage-download-retry-later") && string(by) == "storage-download-retry-later" {
 				if secsToWait, wait := checkRateLimit("storage", "download", repo, oid); wait {
@@ -763,6 +836,15 @@ func storageHandler(w http.ResponseWriter, r *http.Request) {
 					statusCode = 500
 					by = []byte("malformed content")
 				}
+			} else if len(by) == len("storage-restore-retry") && string(by) == "storage-restore-retry" {
+				// Pretend that the object is in cold storage, and
+				// is restored by the third request for it.
+				if restores, ok := incrementRetriesFor("storage", "restore", repo, oid, false); ok && restores < 3 {
+					w.Header().Set("Content-Type", "application/json")
+					w.WriteHeader(http.StatusAccepted)
+					w.Write([]byte(`{"retry_after": 1}`))
+					return
+				}
 			} else if len(by) == len("storage-compress") && string(by) == "storage-compress" {
 				if r.Header.Get("Accept-Encoding") != "gzip" {
 					statusCode = 500
@@ -828,6 +910,12 @@ func storageHandler(w http.ResponseWriter, r *http.Request) {
 					}
 				}
 			}
+			if baseOid := r.URL.Query().Get("base"); len(baseOid) > 0 {
+				base, _ := largeObjects.Get(repo, baseOid)
+				by = makeDelta(base, by)
+				debug(id, "storage sending delta of %d bytes against %s", len(by), baseOid)
+			}
+
 			var wrtr io.Writer = w
 			if compress {
 				w.Header().Set("Content-Encoding", "gzip")
@@ -1441,6 +1529,19 @@ func testingChunkedTransferEncoding(r *http.Request) bool {
 	return strings.HasPrefix(r.URL.String(), "/test-chunked-transfer-encoding")
 }
 
+func testingGzipUpload(r *http.Request) bool {
+	return strings.HasPrefix(r.URL.String(), "/test-gzip-upload") ||
+		strings.HasPrefix(r.URL.String(), "/test-chunked-transfer-encoding-gzip")
+}
+
+func testingDeltaTransfer(r *http.Request) bool {
+	return strings.HasPrefix(r.URL.String(), "/test-delta-transfer")
+}
+
+func testingDownloadMirrors(r *http.Request) bool {
+	return strings.HasPrefix(r.URL.String(), "/test-download-mirrors")
+}
+
 func testingTusUploadInBatchReq(r *http.Request) bool {
 	return strings.HasPrefix(r.URL.String(), "/test-tus-upload")
 }
@@ -1451,6 +1552,77 @@ func testingCustomTransfer(r *http.Request) bool {
 	return strings.HasPrefix(r.URL.String(), "/test-custom-transfer")
 }
 
+// makeDelta returns a delta, in the format used by the "download-delta" and
+// "upload-delta" actions, which turns "base" into "target". It only copies the
+// prefix and suffix which the two have in common, which is enough for tests.
+func makeDelta(base, target []byte) []byte {
+	var prefix, suffix int
+	for prefix < len(base) && prefix < len(target) && base[prefix] == target[prefix] {
+		prefix++
+	}
+	for suffix < len(base)-prefix && suffix < len(target)-prefix &&
+		base[len(base)-1-suffix] == target[len(target)-1-suffix] {
+		suffix++
+	}
+
+	buf := bytes.NewBufferString("GLFSDLT1")
+	if prefix > 0 {
+		buf.WriteByte(1)
+		binary.Write(buf, binary.BigEndian, uint64(0))
+		binary.Write(buf, binary.BigEndian, uint64(prefix))
+	}
+	if literal := target[prefix : len(target)-suffix]; len(literal) > 0 {
+		buf.WriteByte(2)
+		binary.Write(buf, binary.BigEndian, uint64(len(literal)))
+		buf.Write(literal)
+	}
+	if suffix > 0 {
+		buf.WriteByte(1)
+		binary.Write(buf, binary.BigEndian, uint64(len(base)-suffix))
+		binary.Write(buf, binary.BigEndian, uint64(suffix))
+	}
+	return buf.Bytes()
+}
+
+// applyDelta returns the result of applying "delta" to "base".
+func applyDelta(base, delta []byte) ([]byte, error) {
+	if !bytes.HasPrefix(delta, []byte("GLFSDLT1")) {
+		return nil, errors.New("invalid delta")
+	}
+
+	r := bytes.NewReader(delta[8:])
+	out := &bytes.Buffer{}
+	for {
+		op, err := r.ReadByte()
+		if err == io.EOF {
+			return out.Bytes(), nil
+		}
+
+		switch op {
+		case 1:
+			var offset, length uint64
+			binary.Read(r, binary.BigEndian, &offset)
+			if err := binary.Read(r, binary.BigEndian, &length); err != nil {
+				return nil, errors.New("invalid delta")
+			}
+			if offset > uint64(len(base)) || length > uint64(len(base))-offset {
+				return nil, errors.New("invalid delta: copy outside of base object")
+			}
+			out.Write(base[offset : offset+length])
+		case 2:
+			var length uint64
+			if err := binary.Read(r, binary.BigEndian, &length); err != nil {
+				return nil, errors.New("invalid delta")
+			}
+			if _, err := io.CopyN(out, r, int64(length)); err != nil {
+				return nil, errors.New("invalid delta")
+			}
+		default:
+			return nil, fmt.Errorf("invalid delta: unknown instruction %d", op)
+		}
+	}
+}
+
 var lfsUrlRE = regexp.MustCompile(`\A/?([^/]+)/info/lfs`)
 
 func repoFromLfsUrl(urlpath string) (string, error) {
@@ -1507,6 +1679,21 @@ func (s *lfsStorage) Set(repo, oid string, by []byte) {
 	repoObjects[oid] = by
 }
 
+// Other returns the OID of an object in the repository other than the given
+// one, choosing the smallest so that it is chosen consistently.
+func (s *lfsStorage) Other(repo, oid string) (string, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	var other string
+	for o := range s.objects[repo] {
+		if o != oid && (len(other) == 0 || o < other) {
+			other = o
+		}
+	}
+	return other, len(other) > 0
+}
+
 func (s *lfsStorage) Delete(repo, oid string) {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
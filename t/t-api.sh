@@ -0,0 +1,91 @@
+#!/usr/bin/env bash
+
+. "$(dirname "$0")/testlib.sh"
+
+begin_test "api: batch request"
+(
+  set -e
+
+  reponame="api-batch"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  contents="api"
+  contents_oid="$(calc_oid "$contents")"
+
+  git lfs api POST objects/batch --operation=upload \
+    --data="{\"operation\": \"upload\", \"objects\": [{\"oid\": \"$contents_oid\", \"size\": 3}]}" \
+    2>&1 | tee api.log
+  if [ "0" -ne "${PIPESTATUS[0]}" ]; then
+    echo >&2 "fatal: expected api request to succeed"
+    exit 1
+  fi
+
+  grep "HTTP/1.1 200 OK" api.log
+  grep "Content-Type: application/vnd.git-lfs+json" api.log
+  grep "^  \"objects\": \[" api.log
+  grep "\"oid\": \"$contents_oid\"" api.log
+)
+end_test
+
+begin_test "api: invalid data"
+(
+  set -e
+
+  reponame="api-invalid-data"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs api POST objects/batch --data="{not json" 2>&1 | tee api.log
+  if [ "0" -eq "${PIPESTATUS[0]}" ]; then
+    echo >&2 "fatal: expected api request to fail"
+    exit 1
+  fi
+
+  grep "Invalid JSON in --data" api.log
+)
+end_test
+
+begin_test "api: error response"
+(
+  set -e
+
+  reponame="api-error-response"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs api GET no-such-path 2>&1 | tee api.log
+  if [ "0" -eq "${PIPESTATUS[0]}" ]; then
+    echo >&2 "fatal: expected api request to fail"
+    exit 1
+  fi
+
+  grep "HTTP/1.1 404 Not Found" api.log
+  grep "API request failed" api.log
+)
+end_test
+
+begin_test "api: operation"
+(
+  set -e
+
+  reponame="api-operation"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git config remote.origin.lfspushurl "$GITSERVER/$reponame-push.git/info/lfs"
+
+  GIT_TRACE=1 git lfs api GET locks 2>&1 | tee api.log
+  grep "HTTP: GET $GITSERVER/$reponame.git/info/lfs/locks" api.log
+
+  GIT_TRACE=1 git lfs api GET locks --operation=upload 2>&1 | tee api.log
+  grep "HTTP: GET $GITSERVER/$reponame-push.git/info/lfs/locks" api.log
+
+  git lfs api GET locks --operation=delete 2>&1 | tee api.log
+  if [ "0" -eq "${PIPESTATUS[0]}" ]; then
+    echo >&2 "fatal: expected api request to fail"
+    exit 1
+  fi
+  grep "Invalid operation \"delete\"" api.log
+)
+end_test
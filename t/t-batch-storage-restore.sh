@@ -0,0 +1,88 @@
+#!/usr/bin/env bash
+
+. "$(dirname "$0")/testlib.sh"
+
+begin_test "batch storage download waits for restore"
+(
+  set -e
+
+  reponame="batch-storage-download-restore"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" batch-storage-repo-restore
+
+  contents="storage-restore-retry"
+  oid="$(calc_oid "$contents")"
+  printf "%s" "$contents" > a.dat
+
+  git lfs track "*.dat"
+  git add .gitattributes a.dat
+  git commit -m "initial commit"
+
+  git push origin main
+  assert_server_object "$reponame" "$oid"
+
+  pushd ..
+    git \
+      -c "filter.lfs.process=" \
+      -c "filter.lfs.smudge=cat" \
+      -c "filter.lfs.required=false" \
+      clone "$GITSERVER/$reponame" "$reponame-assert"
+
+    cd "$reponame-assert"
+
+    git config credential.helper lfstest
+
+    # Waiting for the restore must not use up the object's retries.
+    GIT_TRACE=1 git -c lfs.transfer.maxretries=1 lfs pull origin main 2>&1 | tee pull.log
+    if [ "0" -ne "${PIPESTATUS[0]}" ]; then
+      echo >&2 "fatal: expected \`git lfs pull origin main\` to succeed ..."
+      exit 1
+    fi
+
+    grep "tq: object $oid is being restored" pull.log
+    assert_local_object "$oid" "${#contents}"
+  popd
+)
+end_test
+
+begin_test "batch storage download fails for restore without timeout"
+(
+  set -e
+
+  reponame="batch-storage-download-restore-timeout"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" batch-storage-repo-restore-timeout
+
+  contents="storage-restore-retry"
+  oid="$(calc_oid "$contents")"
+  printf "%s" "$contents" > a.dat
+
+  git lfs track "*.dat"
+  git add .gitattributes a.dat
+  git commit -m "initial commit"
+
+  git push origin main
+  assert_server_object "$reponame" "$oid"
+
+  pushd ..
+    git \
+      -c "filter.lfs.process=" \
+      -c "filter.lfs.smudge=cat" \
+      -c "filter.lfs.required=false" \
+      clone "$GITSERVER/$reponame" "$reponame-assert"
+
+    cd "$reponame-assert"
+
+    git config credential.helper lfstest
+
+    git -c lfs.transfer.restoretimeout=0 lfs pull origin main 2>&1 | tee pull.log
+    if [ "0" -eq "${PIPESTATUS[0]}" ]; then
+      echo >&2 "fatal: expected \`git lfs pull origin main\` to fail ..."
+      exit 1
+    fi
+
+    grep "Object $oid is being restored from cold storage" pull.log
+    refute_local_object "$oid"
+  popd
+)
+end_test
@@ -126,12 +126,12 @@ begin_test "checkout: without clean filter"
   }
   ls -al
 
-  git lfs checkout | tee checkout.txt
-  grep "Git LFS is not installed" checkout.txt
+  git lfs checkout 2>&1 | tee checkout.txt
   if [ "0" -ne "${PIPESTATUS[0]}" ]; then
     echo >&2 "fatal: expected checkout to succeed ..."
     exit 1
   fi
+  grep "Git LFS is not installed" checkout.txt
 
   contentsize=19
   contents_oid=$(calc_oid "something something")
@@ -140,6 +140,12 @@ begin_test "checkout: without clean filter"
   [ "$(pointer $contents_oid $contentsize)" = "$(cat file3.dat)" ]
   [ "$(pointer $contents_oid $contentsize)" = "$(cat folder1/nested.dat)" ]
   [ "$(pointer $contents_oid $contentsize)" = "$(cat folder2/nested.dat)" ]
+
+  # Diagnostics go to standard error, and are duplicated to any log file.
+  git lfs checkout --log-file="$TRASHDIR/checkout-diag.log" >stdout.txt 2>stderr.txt
+  [ -z "$(cat stdout.txt)" ]
+  grep "Git LFS is not installed" stderr.txt
+  grep "Git LFS is not installed" "$TRASHDIR/checkout-diag.log"
 )
 end_test
 
@@ -272,3 +278,34 @@ begin_test "checkout: GIT_WORK_TREE"
   [ "$contents" = "$(cat "$reponame/file1.dat")" ]
 )
 end_test
+
+begin_test "checkout: --paths-from"
+(
+  set -e
+
+  reponame="checkout-paths-from"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+
+  contents="something something"
+  mkdir folder
+  printf "%s" "$contents" > "file*.dat"
+  printf "%s" "$contents" > file1.dat
+  printf "%s" "$contents" > folder/nested.dat
+  git add "file*.dat" file1.dat folder/nested.dat .gitattributes
+  git commit -m "add files"
+
+  rm -rf "file*.dat" file1.dat folder
+
+  printf "file*.dat\n" | git lfs checkout --paths-from=-
+  [ "$contents" = "$(cat "file*.dat")" ]
+  [ ! -f file1.dat ]
+  [ ! -f folder/nested.dat ]
+
+  printf "folder\0" | git lfs checkout --paths-from=- -z
+  [ "$contents" = "$(cat folder/nested.dat)" ]
+  [ ! -f file1.dat ]
+)
+end_test
@@ -28,6 +28,21 @@ begin_test "clean a pointer"
 )
 end_test
 
+begin_test "clean a pointer with CRLF line endings"
+(
+  set -e
+  clean_setup "pointer-crlf"
+
+  pointer cd293be6cea034bd45a0352775a219ef5dc7825ce55d1f7dae9762d80ce64411 9 | sed -e 's/$/\r/' > crlf.txt
+  grep -q "$(printf '\r')" crlf.txt
+
+  git lfs clean < crlf.txt > clean.log
+  [ "$(pointer cd293be6cea034bd45a0352775a219ef5dc7825ce55d1f7dae9762d80ce64411 9)" = "$(cat clean.log)" ]
+  grep -q "$(printf '\r')" clean.log && exit 1
+  true
+)
+end_test
+
 begin_test "clean pseudo pointer"
 (
   set -e
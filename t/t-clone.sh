@@ -507,7 +507,7 @@ begin_test "clone (without clean filter)"
   }
 
   local_reponame="clone_without_clean"
-  git lfs clone "$GITSERVER/$reponame" "$local_reponame" -I "a*.dat" | tee clone.txt
+  git lfs clone "$GITSERVER/$reponame" "$local_reponame" -I "a*.dat" 2>&1 | tee clone.txt
   if [ "0" -ne "${PIPESTATUS[0]}" ]; then
     echo >&2 "fatal: expected clone to succeed ..."
     exit 1
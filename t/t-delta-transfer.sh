@@ -0,0 +1,97 @@
+#!/usr/bin/env bash
+
+. "$(dirname "$0")/testlib.sh"
+
+# delta_repo_setup creates a repository with two commits, each adding a
+# version of "a.dat" which differ in a single line, and pushes the first.
+delta_repo_setup() {
+  local reponame="$1"
+
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  for i in $(seq 1 1000); do
+    echo "line $i of a large file which changes only slightly"
+  done > a.dat
+  git add .gitattributes a.dat
+  git commit -m "add a.dat"
+  git push origin main
+
+  sed -e "s/^line 500 of/line five hundred of/" a.dat > a.dat.new
+  mv a.dat.new a.dat
+  git add a.dat
+  git commit -m "change a.dat"
+}
+
+begin_test "delta transfer: upload and download"
+(
+  set -e
+
+  reponame="test-delta-transfer"
+  delta_repo_setup "$reponame"
+  contents_oid="$(calc_oid_file a.dat)"
+
+  GIT_TRACE=1 git push origin main 2>&1 | tee push.log
+  if [ "0" -ne "${PIPESTATUS[0]}" ]; then
+    echo >&2 "fatal: expected push to succeed"
+    exit 1
+  fi
+
+  grep "xfer: uploaded \"$contents_oid\" as a delta" push.log
+  assert_server_object "$reponame" "$contents_oid"
+
+  cd ..
+  GIT_LFS_SKIP_SMUDGE=1 git clone "$GITSERVER/$reponame" "$reponame-assert"
+  cd "$reponame-assert"
+
+  git lfs fetch origin HEAD~1
+  GIT_TRACE=1 git lfs pull 2>&1 | tee pull.log
+  if [ "0" -ne "${PIPESTATUS[0]}" ]; then
+    echo >&2 "fatal: expected pull to succeed"
+    exit 1
+  fi
+
+  grep "xfer: downloaded \"$contents_oid\" as a delta" pull.log
+  [ "$contents_oid" = "$(calc_oid_file a.dat)" ]
+)
+end_test
+
+begin_test "delta transfer: base object not present locally"
+(
+  set -e
+
+  reponame="test-delta-transfer-no-base"
+  delta_repo_setup "$reponame"
+  contents_oid="$(calc_oid_file a.dat)"
+
+  cd ..
+  GIT_LFS_SKIP_SMUDGE=1 git clone "$GITSERVER/$reponame" "$reponame-assert"
+
+  cd "$reponame"
+  git push origin main
+
+  cd "../$reponame-assert"
+  GIT_LFS_SKIP_SMUDGE=1 git pull origin main
+  GIT_TRACE=1 git lfs pull 2>&1 | tee pull.log
+
+  [ "0" -eq "$(grep -c "as a delta" pull.log)" ]
+  [ "$contents_oid" = "$(calc_oid_file a.dat)" ]
+)
+end_test
+
+begin_test "delta transfer: disabled"
+(
+  set -e
+
+  reponame="test-delta-transfer-disabled"
+  delta_repo_setup "$reponame"
+  contents_oid="$(calc_oid_file a.dat)"
+
+  git config lfs.deltatransfers false
+  GIT_TRACE=1 git push origin main 2>&1 | tee push.log
+
+  [ "0" -eq "$(grep -c "as a delta" push.log)" ]
+  assert_server_object "$reponame" "$contents_oid"
+)
+end_test
@@ -0,0 +1,34 @@
+#!/usr/bin/env bash
+
+. "$(dirname "$0")/testlib.sh"
+
+begin_test "download mirrors: fail over to mirror"
+(
+  set -e
+
+  reponame="test-download-mirrors"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" download-mirrors
+
+  git lfs track "*.dat"
+  contents="mirrored content"
+  contents_oid="$(calc_oid "$contents")"
+  printf "%s" "$contents" > a.dat
+
+  git add .gitattributes a.dat
+  git commit -m "add a.dat"
+  git push origin main
+  assert_server_object "$reponame" "$contents_oid"
+
+  cd ..
+  GIT_TRACE=1 git clone "$GITSERVER/$reponame" download-mirrors-clone 2>&1 | tee clone.log
+  if [ "0" -ne "${PIPESTATUS[0]}" ]; then
+    echo >&2 "fatal: expected clone to succeed"
+    exit 1
+  fi
+
+  grep "xfer: download of \"$contents_oid\" from .*&unavailable=1 failed, trying mirror .*&mirror=1" clone.log
+  [ "0" -eq "$(grep -c "trying mirror .*&mirror=2" clone.log)" ]
+  [ "$contents" = "$(cat download-mirrors-clone/a.dat)" ]
+)
+end_test
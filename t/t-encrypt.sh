@@ -0,0 +1,124 @@
+#!/usr/bin/env bash
+
+. "$(dirname "$0")/testlib.sh"
+
+setup_encryption () {
+  git config lfs.extension.encrypt.clean "git-lfs encrypt clean %f"
+  git config lfs.extension.encrypt.smudge "git-lfs encrypt smudge %f"
+  git config lfs.extension.encrypt.priority 0
+  git config lfs.encryption.key "${1:-0123456789abcdef0123456789abcdef}"
+}
+
+begin_test "encrypt: push and clone"
+(
+  set -e
+
+  reponame="encrypt-push-clone"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  setup_encryption
+  git lfs track "*.dat"
+
+  contents="super secret contents"
+  contents_oid="$(calc_oid "$contents")"
+  printf "%s" "$contents" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "add a.dat"
+
+  pointer="$(git cat-file -p :a.dat)"
+  echo "$pointer" | grep "ext-0-encrypt sha256:$contents_oid"
+  encrypted_oid="$(echo "$pointer" | grep "^oid " | cut -d : -f 2)"
+  [ "$encrypted_oid" != "$contents_oid" ]
+
+  # The object in the local store is the encrypted one.
+  [ -f ".git/lfs/objects/${encrypted_oid:0:2}/${encrypted_oid:2:2}/$encrypted_oid" ]
+  grep "$contents" ".git/lfs/objects/${encrypted_oid:0:2}/${encrypted_oid:2:2}/$encrypted_oid" && exit 1
+
+  # Adding the same contents again gives the same pointer.
+  touch a.dat
+  [ -z "$(git status --porcelain a.dat)" ]
+
+  git push origin main
+  assert_server_object "$reponame" "$encrypted_oid"
+  refute_server_object "$reponame" "$contents_oid"
+
+  cd ..
+  git init "$reponame-clone"
+  cd "$reponame-clone"
+  setup_encryption
+  git remote add origin "$GITSERVER/$reponame"
+  git pull origin main
+
+  [ "$contents" = "$(cat a.dat)" ]
+)
+end_test
+
+begin_test "encrypt: wrong key"
+(
+  set -e
+
+  reponame="encrypt-wrong-key"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  setup_encryption
+  git lfs track "*.dat"
+  printf "secret" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "add a.dat"
+  git push origin main
+
+  encrypted_oid="$(git cat-file -p :a.dat | grep "^oid " | cut -d : -f 2)"
+  encrypted=".git/lfs/objects/${encrypted_oid:0:2}/${encrypted_oid:2:2}/$encrypted_oid"
+
+  [ "secret" = "$(git lfs encrypt smudge a.dat < "$encrypted")" ]
+
+  git config lfs.encryption.key "fedcba9876543210fedcba9876543210"
+  git lfs encrypt smudge a.dat < "$encrypted" 2>&1 | tee smudge.log
+  if [ "0" -eq "${PIPESTATUS[0]}" ]; then
+    echo >&2 "fatal: expected smudge with the wrong key to fail"
+    exit 1
+  fi
+  grep "unable to decrypt object: wrong key, or corrupt data" smudge.log
+
+  cd ..
+  git init "$reponame-clone"
+  cd "$reponame-clone"
+  setup_encryption "fedcba9876543210fedcba9876543210"
+  git remote add origin "$GITSERVER/$reponame"
+  git pull origin main || true
+
+  [ "secret" != "$(cat a.dat)" ]
+)
+end_test
+
+begin_test "encrypt: no key"
+(
+  set -e
+
+  reponame="encrypt-no-key"
+  git init "$reponame"
+  cd "$reponame"
+
+  printf "secret" | git lfs encrypt clean a.dat 2>&1 | tee clean.log
+  if [ "0" -eq "${PIPESTATUS[1]}" ]; then
+    echo >&2 "fatal: expected clean without a key to fail"
+    exit 1
+  fi
+  grep "no encryption key" clean.log
+
+  git config lfs.encryption.key "too short"
+  printf "secret" | git lfs encrypt clean a.dat 2>&1 | tee clean.log
+  if [ "0" -eq "${PIPESTATUS[1]}" ]; then
+    echo >&2 "fatal: expected clean with a short key to fail"
+    exit 1
+  fi
+  grep "encryption key must be at least 16 characters long" clean.log
+
+  git config --unset lfs.encryption.key
+  git config lfs.encryption.keycommand "echo 0123456789abcdef0123456789abcdef"
+  printf "secret" | git lfs encrypt clean a.dat > encrypted.bin
+  [ "secret" = "$(git lfs encrypt smudge a.dat < encrypted.bin)" ]
+)
+end_test
@@ -1126,3 +1126,25 @@ EOF
   grep 'warning.*same alias' test.log
 )
 end_test
+
+begin_test "env with separate push endpoints"
+(
+  set -e
+  reponame="env-separate-push-endpoints"
+  unset_vars
+  mkdir $reponame
+  cd $reponame
+  git init
+  git remote add origin "$GITSERVER/env-origin-remote"
+  git remote add mirror "$GITSERVER/env-mirror-remote"
+  git config remote.origin.lfspushurl "http://write/origin"
+  git config remote.mirror.lfsurl "http://read/mirror"
+
+  git lfs env > env.log
+  grep "^Endpoint=$GITSERVER/env-origin-remote.git/info/lfs (auth=none)$" env.log
+  grep "^PushEndpoint=http://write/origin (auth=none)$" env.log
+  grep "^Endpoint (mirror)=http://read/mirror (auth=none)$" env.log
+  grep "PushEndpoint (mirror)" env.log && exit 1
+  true
+)
+end_test
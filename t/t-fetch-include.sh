@@ -35,7 +35,7 @@ begin_test "fetch: setup for include test"
   grep "create mode 100644 big/big2.big" commit.log
   grep "create mode 100644 big/big3.big" commit.log
 
-  git push origin main | tee push.log
+  git push origin main 2>&1 | tee push.log
   grep "Uploading LFS objects: 100% (2/2), 18 B" push.log
 
   assert_server_object "$reponame" "$contents_oid"
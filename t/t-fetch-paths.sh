@@ -92,3 +92,27 @@ begin_test "fetch unclean paths with exclude filter in cli"
   refute_local_object "$contents_oid"
 )
 end_test
+
+begin_test "fetch with --paths-from"
+(
+  set -e
+  cd clone
+  rm -rf .git/lfs/objects
+
+  echo "other.dat" | git lfs fetch --paths-from=-
+  refute_local_object "$contents_oid"
+
+  printf "dir/a.dat\0" | git lfs fetch --paths-from=- -z
+  assert_local_object "$contents_oid" 1
+)
+end_test
+
+begin_test "fetch with --paths-from and --include"
+(
+  set -e
+  cd clone
+
+  echo "dir/a.dat" | git lfs fetch --paths-from=- -I "dir/" 2>&1 | tee fetch.log
+  grep "Cannot combine --include with --paths-from" fetch.log
+)
+end_test
@@ -71,6 +71,25 @@ begin_test "fetch"
 )
 end_test
 
+begin_test "fetch --dry-run"
+(
+  set -e
+  cd clone
+  rm -rf .git/lfs/objects
+
+  git lfs fetch --dry-run 2>&1 | tee fetch.log
+  grep "fetch $contents_oid => a.dat" fetch.log
+  grep "1 object would be transferred (1 B)" fetch.log
+  refute_local_object "$contents_oid"
+
+  # Objects which are already present are not reported.
+  git lfs fetch
+  git lfs fetch --dry-run 2>&1 | tee fetch.log
+  grep "^fetch " fetch.log && exit 1
+  grep "0 objects would be transferred (0 B)" fetch.log
+)
+end_test
+
 begin_test "fetch (empty file)"
 (
   set -e
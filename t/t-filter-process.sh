@@ -271,3 +271,34 @@ begin_test "filter process: git archive does not invoke SSH"
   true
 )
 end_test
+
+begin_test "filter process: undelayed smudges share transfer stats"
+(
+  set -e
+
+  reponame="filter-process-undelayed-stats"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  printf "a" > a.dat
+  printf "bb" > b.dat
+  git add .gitattributes a.dat b.dat
+  git commit -m "add a.dat, b.dat"
+  git push origin main
+
+  cd ..
+  GIT_LFS_SKIP_SMUDGE=1 git clone "$GITSERVER/$reponame" "$reponame-2"
+  cd "$reponame-2"
+
+  # Git never delays the smudges performed by "git archive", so each object
+  # is downloaded by its own transfer queue.
+  GIT_LFS_STATS=1 git archive -o archive.tar HEAD 2>&1 | tee archive.log
+  [ "1" -eq "$(grep -c "Transfer stats written to" archive.log)" ]
+
+  report="$(ls .git/lfs/logs/stats/stats-*.json)"
+  grep "\"succeeded\": 2" "$report"
+  grep "\"oid\": \"$(calc_oid "a")\"" "$report"
+  grep "\"oid\": \"$(calc_oid "bb")\"" "$report"
+)
+end_test
@@ -0,0 +1,121 @@
+#!/usr/bin/env bash
+
+. "$(dirname "$0")/testlib.sh"
+
+begin_test "gzip upload: compressible object"
+(
+  set -e
+
+  reponame="test-gzip-upload"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" gzip-upload
+
+  git lfs track "*.txt"
+  for i in $(seq 1 200); do
+    echo "the same line of text, over and over again"
+  done > a.txt
+  contents_oid="$(calc_oid_file a.txt)"
+
+  git add .gitattributes a.txt
+  git commit -m "add a.txt"
+
+  GIT_TRACE=1 git push origin main 2>&1 | tee push.log
+  if [ "0" -ne "${PIPESTATUS[0]}" ]; then
+    echo >&2 "fatal: expected push to succeed"
+    exit 1
+  fi
+
+  grep "tq: compressed upload from 8600 to [0-9]* bytes with gzip" push.log
+  grep "Uploading LFS objects: 100% (1/1), 8.6 KB" push.log
+  assert_server_object "$reponame" "$contents_oid"
+)
+end_test
+
+begin_test "gzip upload: incompressible object"
+(
+  set -e
+
+  reponame="test-gzip-upload-binary"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" gzip-upload-binary
+
+  git lfs track "*.dat"
+  printf "\x89PNG\r\n\x1a\n" > a.dat
+  base64 /dev/urandom | head -c 1024 >> a.dat
+  contents_oid="$(calc_oid_file a.dat)"
+
+  git add .gitattributes a.dat
+  git commit -m "add a.dat"
+
+  GIT_TRACE=1 git push origin main 2>&1 | tee push.log
+  if [ "0" -ne "${PIPESTATUS[0]}" ]; then
+    echo >&2 "fatal: expected push to succeed"
+    exit 1
+  fi
+
+  [ "0" -eq "$(grep -c "tq: compressed upload" push.log)" ]
+  assert_server_object "$reponame" "$contents_oid"
+)
+end_test
+
+begin_test "gzip upload: disabled"
+(
+  set -e
+
+  reponame="test-gzip-upload-disabled"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" gzip-upload-disabled
+
+  git config lfs.compressuploads false
+  git lfs track "*.txt"
+  for i in $(seq 1 200); do
+    echo "the same line of text, over and over again"
+  done > a.txt
+  contents_oid="$(calc_oid_file a.txt)"
+
+  git add .gitattributes a.txt
+  git commit -m "add a.txt"
+
+  GIT_TRACE=1 git push origin main 2>&1 | tee push.log
+  if [ "0" -ne "${PIPESTATUS[0]}" ]; then
+    echo >&2 "fatal: expected push to succeed"
+    exit 1
+  fi
+
+  [ "0" -eq "$(grep -c "tq: compressed upload" push.log)" ]
+  assert_server_object "$reponame" "$contents_oid"
+)
+end_test
+
+begin_test "gzip upload: chunked transfer encoding"
+(
+  set -e
+
+  reponame="test-chunked-transfer-encoding-gzip"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" gzip-upload-chunked
+
+  git lfs track "*.txt"
+  for i in $(seq 1 200); do
+    echo "the same line of text, over and over again"
+  done > a.txt
+  contents_oid="$(calc_oid_file a.txt)"
+
+  git add .gitattributes a.txt
+  git commit -m "add a.txt"
+
+  GIT_TRACE=1 git push origin main 2>&1 | tee push.log
+  if [ "0" -ne "${PIPESTATUS[0]}" ]; then
+    echo >&2 "fatal: expected push to succeed"
+    exit 1
+  fi
+
+  grep "tq: streaming compressed upload of 8600 bytes" push.log
+  grep "Uploading LFS objects: 100% (1/1), 8.6 KB" push.log
+  assert_server_object "$reponame" "$contents_oid"
+
+  cd ..
+  GIT_TRACE=1 git clone "$GITSERVER/$reponame" gzip-upload-chunked-clone 2>&1 | tee clone.log
+  [ "$contents_oid" = "$(calc_oid_file gzip-upload-chunked-clone/a.txt)" ]
+)
+end_test
@@ -0,0 +1,60 @@
+#!/usr/bin/env bash
+
+. "$(dirname "$0")/testlib.sh"
+
+begin_test "install in a git-media repository"
+(
+  set -e
+
+  reponame="install-git-media"
+  git init "$reponame"
+  cd "$reponame"
+
+  git config filter.media.clean "git-media filter-clean"
+  git config filter.media.smudge "git-media filter-smudge"
+  printf "*.mov filter=media -crlf\n*.txt text\n" > .gitattributes
+  printf "*.psd filter=media\n" > .git/info/attributes
+
+  contents="a git-media object"
+  sha1="$(printf "%s" "$contents" | sha1sum | cut -d' ' -f1)"
+  oid="$(calc_oid "$contents")"
+  mkdir -p .git/media/objects
+  printf "%s" "$contents" > ".git/media/objects/$sha1"
+  touch .git/media/objects/not-an-object
+
+  git lfs install 2>&1 | tee install.log
+
+  grep "This repository was set up for git-media" install.log
+  grep "\"\\*.mov filter=media -crlf\" -> \"\\*.mov filter=lfs diff=lfs merge=lfs -text\"" install.log
+  grep "\"\\*.psd filter=media\" -> \"\\*.psd filter=lfs diff=lfs merge=lfs -text\"" install.log
+  grep "imported 1 object (18 B) from .*media/objects, 0 already present" install.log
+  grep "left filter.media.clean = \"git-media filter-clean\" in place" install.log
+  grep "Git LFS initialized." install.log
+
+  [ "$(printf "*.mov filter=lfs diff=lfs merge=lfs -text\n*.txt text\n")" = "$(cat .gitattributes)" ]
+  [ "*.psd filter=lfs diff=lfs merge=lfs -text" = "$(cat .git/info/attributes)" ]
+  assert_local_object "$oid" "${#contents}"
+  [ -f ".git/media/objects/$sha1" ]
+  [ "git-media filter-clean" = "$(git config filter.media.clean)" ]
+
+  [ "true" = "$(git config --local lfs.gitmedia.imported)" ]
+
+  git lfs install 2>&1 | tee install.log
+  [ "$(cat install.log)" = "$(printf "Updated Git hooks.\nGit LFS initialized.")" ]
+)
+end_test
+
+begin_test "install in a repository without git-media"
+(
+  set -e
+
+  reponame="install-no-git-media"
+  git init "$reponame"
+  cd "$reponame"
+
+  printf "*.mov filter=lfs diff=lfs merge=lfs -text\n" > .gitattributes
+
+  git lfs install 2>&1 | tee install.log
+  [ "$(cat install.log)" = "$(printf "Updated Git hooks.\nGit LFS initialized.")" ]
+)
+end_test
@@ -573,3 +573,29 @@ EOF
   diff -u actual expected
 )
 end_test
+
+begin_test "ls-files: -z"
+(
+  set -e
+
+  reponame="ls-files-null"
+  git init "$reponame"
+  cd "$reponame"
+
+  git lfs track "*.dat"
+  git add .gitattributes
+  git commit -m "initial commit"
+
+  printf "a" > "a b.dat"
+  printf "b" > "$(printf "new\nline.dat")"
+
+  git add *.dat
+  git commit -m "add files"
+
+  git lfs ls-files -z --name-only | tr '\0' '|' > ls.log
+  [ "a b.dat|$(printf "new\nline.dat")|" = "$(cat ls.log)" ]
+
+  git lfs ls-files -z --json 2>&1 | tee ls.log
+  grep "Cannot combine -z with --debug or --json" ls.log
+)
+end_test
@@ -0,0 +1,99 @@
+#!/usr/bin/env bash
+
+. "$(dirname "$0")/testlib.sh"
+
+begin_test "migrate-config: renames outdated keys"
+(
+  set -e
+
+  reponame="migrate-config-rename"
+  git init "$reponame"
+  cd "$reponame"
+
+  git config hawser.url "https://example.com/lfs"
+  git config --add hawser.fetchinclude "a"
+  git config --add hawser.fetchinclude "b"
+  git config remote.origin.media "https://example.com/origin"
+  git config lfs.batch true
+  git config media.batch true
+
+  git lfs migrate-config --local 2>&1 | tee migrate.log
+  if [ "0" -ne "${PIPESTATUS[0]}" ]; then
+    echo >&2 "fatal: expected migrate-config to succeed"
+    exit 1
+  fi
+
+  grep "local: rename hawser.url to lfs.url" migrate.log
+  grep "local: rename hawser.fetchinclude to lfs.fetchinclude" migrate.log
+  grep "local: rename remote.origin.media to remote.origin.lfsurl" migrate.log
+  grep "local: remove lfs.batch, which is no longer used" migrate.log
+  grep "local: remove media.batch, which is no longer used" migrate.log
+
+  [ "https://example.com/lfs" = "$(git config --local lfs.url)" ]
+  [ "a b" = "$(git config --local --get-all lfs.fetchinclude | tr '\n' ' ' | sed 's/ $//')" ]
+  [ "https://example.com/origin" = "$(git config --local remote.origin.lfsurl)" ]
+  [ -z "$(git config --local --get-regexp '^hawser\.|^media\.|\.media$|^lfs\.batch$')" ]
+
+  git lfs migrate-config --local 2>&1 | tee migrate.log
+  grep "No outdated configuration found." migrate.log
+)
+end_test
+
+begin_test "migrate-config: keeps current keys"
+(
+  set -e
+
+  reponame="migrate-config-current"
+  git init "$reponame"
+  cd "$reponame"
+
+  git config media.url "https://example.com/old"
+  git config lfs.url "https://example.com/new"
+
+  git lfs migrate-config --local 2>&1 | tee migrate.log
+  grep "local: remove media.url, since lfs.url is already set" migrate.log
+
+  [ "https://example.com/new" = "$(git config --local lfs.url)" ]
+  [ -z "$(git config --local media.url)" ]
+)
+end_test
+
+begin_test "migrate-config: --dry-run"
+(
+  set -e
+
+  reponame="migrate-config-dry-run"
+  git init "$reponame"
+  cd "$reponame"
+
+  git config hawser.url "https://example.com/lfs"
+
+  git lfs migrate-config --local --dry-run 2>&1 | tee migrate.log
+  grep "local: rename hawser.url to lfs.url" migrate.log
+
+  [ "https://example.com/lfs" = "$(git config --local hawser.url)" ]
+  [ -z "$(git config --local lfs.url)" ]
+)
+end_test
+
+begin_test "migrate-config: global"
+(
+  set -e
+
+  reponame="migrate-config-global"
+  git init "$reponame"
+  cd "$reponame"
+
+  git config --global hawser.concurrenttransfers 3
+  git config hawser.url "https://example.com/lfs"
+
+  git lfs migrate-config --global 2>&1 | tee migrate.log
+  grep "global: rename hawser.concurrenttransfers to lfs.concurrenttransfers" migrate.log
+  [ "0" -eq "$(grep -c "^local:" migrate.log)" ]
+
+  [ "3" = "$(git config --global lfs.concurrenttransfers)" ]
+  [ "https://example.com/lfs" = "$(git config --local hawser.url)" ]
+
+  git config --global --unset lfs.concurrenttransfers
+)
+end_test
@@ -0,0 +1,72 @@
+#!/usr/bin/env bash
+
+. "$(dirname "$0")/testlib.sh"
+
+begin_test "object-status"
+(
+  set -e
+
+  reponame="object-status"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  printf "pushed" > pushed.dat
+  git add .gitattributes pushed.dat
+  git commit -m "add pushed.dat"
+  git push origin main
+
+  printf "unpushed" > unpushed.dat
+  printf "modified" > modified.dat
+  git add unpushed.dat modified.dat
+  git commit -m "add unpushed.dat and modified.dat"
+  printf "changed" > modified.dat
+
+  git lfs object-status | tee status.log
+  [ "$(sed -n 1p status.log)" = "modified,local,unpushed modified.dat" ]
+  [ "$(sed -n 2p status.log)" = "local,pushed pushed.dat" ]
+  [ "$(sed -n 3p status.log)" = "local,unpushed unpushed.dat" ]
+  [ 3 -eq "$(wc -l < status.log)" ]
+
+  git lfs object-status --offline pushed.dat | tee status.log
+  [ "local pushed.dat" = "$(cat status.log)" ]
+
+  # A file whose object has not been downloaded holds its pointer.
+  pushed_oid="$(calc_oid "pushed")"
+  rm ".git/lfs/objects/${pushed_oid:0:2}/${pushed_oid:2:2}/$pushed_oid"
+  git lfs pointer --file=pushed.dat > pushed.ptr
+  mv pushed.ptr pushed.dat
+  git lfs object-status pushed.dat | tee status.log
+  [ "pointer,pushed pushed.dat" = "$(cat status.log)" ]
+
+  # Objects may be asked about by OID, and files which are not Git LFS
+  # files are left out.
+  unpushed_oid="$(calc_oid "unpushed")"
+  git lfs object-status --json "$unpushed_oid" .gitattributes | tee status.json
+  grep "\"oid\": \"$unpushed_oid\"" status.json
+  grep '"size": 8' status.json
+  grep '"local": true' status.json
+  grep '"pushed": false' status.json
+  [ 0 -eq "$(grep -c '"name"' status.json)" ]
+
+  # The remote is asked by its push endpoint.
+  git config lfs.pushurl "$GITSERVER/$reponame.git/info/lfs"
+  git config lfs.url "http://127.0.0.1:1/$reponame.git/info/lfs"
+  git lfs object-status pushed.dat unpushed.dat | tee status.log
+  [ "$(sed -n 1p status.log)" = "pointer,pushed pushed.dat" ]
+  [ "$(sed -n 2p status.log)" = "local,unpushed unpushed.dat" ]
+)
+end_test
+
+begin_test "object-status: no Git LFS files"
+(
+  set -e
+
+  reponame="object-status-empty"
+  git init "$reponame"
+  cd "$reponame"
+
+  git lfs object-status --json | tee status.json
+  grep '"objects": \[\]' status.json
+)
+end_test
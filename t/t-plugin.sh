@@ -0,0 +1,54 @@
+#!/usr/bin/env bash
+
+. "$(dirname "$0")/testlib.sh"
+
+begin_test "plugin: runs git-lfs-<name> for unknown commands"
+(
+  set -e
+
+  reponame="plugin-unknown-command"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  mkdir -p bin
+  cat > bin/git-lfs-hello <<-'EOS'
+	#!/bin/sh
+	echo "hello $*"
+	echo "remote=$GIT_LFS_REMOTE"
+	echo "endpoint=$GIT_LFS_ENDPOINT"
+	echo "access=$GIT_LFS_ENDPOINT_ACCESS"
+	exit 3
+	EOS
+  chmod +x bin/git-lfs-hello
+
+  set +e
+  PATH="$(pwd)/bin:$PATH" git lfs hello a b > hello.log 2>&1
+  res=$?
+  set -e
+
+  cat hello.log
+  [ "3" -eq "$res" ]
+  grep "hello a b" hello.log
+  grep "remote=origin" hello.log
+  grep "endpoint=$GITSERVER/$reponame.git/info/lfs" hello.log
+  grep "access=none" hello.log
+)
+end_test
+
+begin_test "plugin: built-in commands take precedence"
+(
+  set -e
+
+  mkdir plugin-builtin
+  cd plugin-builtin
+  git init
+
+  mkdir -p bin
+  printf '#!/bin/sh\necho plugin\n' > bin/git-lfs-version
+  chmod +x bin/git-lfs-version
+
+  PATH="$(pwd)/bin:$PATH" git lfs version > version.log 2>&1
+  grep "git-lfs/" version.log
+  [ "0" -eq "$(grep -c "plugin" version.log)" ]
+)
+end_test
@@ -1344,3 +1344,95 @@ begin_test "prune doesn't hang on long lines in stash diff"
     git lfs prune
 )
 end_test
+
+begin_test "prune retains objects needed by repositories sharing storage"
+(
+  set -e
+
+  reponame="prune_shared_storage"
+  store="$TRASHDIR/$reponame-store"
+  setup_remote_repo "remote_$reponame"
+  clone_repo "remote_$reponame" "clone_$reponame"
+
+  git config lfs.storage "$store"
+  git lfs track "*.dat"
+  git add .gitattributes
+  git commit -m "Track *.dat"
+  git push origin main
+
+  content_unreferenced="Unreferenced by either repository"
+  content_other="Needed by the other repository"
+  oid_unreferenced=$(calc_oid "$content_unreferenced")
+  oid_other=$(calc_oid "$content_other")
+
+  git checkout -b to_delete
+  printf "%s" "$content_unreferenced" > unreferenced.dat
+  git add unreferenced.dat
+  git commit -m "Add unreferenced.dat"
+  git checkout main
+  git branch -D to_delete
+
+  (
+    cd ..
+    git init "other_$reponame"
+    cd "other_$reponame"
+    git config lfs.storage "$store"
+    git lfs track "*.dat"
+    printf "%s" "$content_other" > other.dat
+    git add .gitattributes other.dat
+    git commit -m "Add other.dat"
+  )
+
+  assert_local_object "$oid_other" "${#content_other}"
+
+  git lfs prune
+  refute_local_object "$oid_unreferenced"
+  assert_local_object "$oid_other" "${#content_other}"
+
+  # Once the other repository is gone, its objects may be pruned.
+  rm -rf "../other_$reponame"
+  git lfs prune
+  refute_local_object "$oid_other"
+)
+end_test
+
+begin_test "prune ignores repositories which no longer share storage"
+(
+  set -e
+
+  reponame="prune_unshared_storage"
+  store="$TRASHDIR/$reponame-store"
+  setup_remote_repo "remote_$reponame"
+  clone_repo "remote_$reponame" "clone_$reponame"
+
+  git config lfs.storage "$store"
+  git lfs track "*.dat"
+  git add .gitattributes
+  git commit -m "Track *.dat"
+  git push origin main
+
+  content_other="Once needed by the other repository"
+  oid_other=$(calc_oid "$content_other")
+
+  (
+    cd ..
+    git init "other_$reponame"
+    cd "other_$reponame"
+    git config lfs.storage "$store"
+    git lfs track "*.dat"
+    printf "%s" "$content_other" > other.dat
+    git add .gitattributes other.dat
+    git commit -m "Add other.dat"
+
+    git config lfs.storage "$TRASHDIR/$reponame-elsewhere"
+  )
+
+  assert_local_object "$oid_other" "${#content_other}"
+
+  git lfs prune
+  refute_local_object "$oid_other"
+
+  # Only this repository remains registered.
+  [ "1" -eq "$(ls "$store/repos" | wc -l)" ]
+)
+end_test
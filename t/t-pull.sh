@@ -183,7 +183,7 @@ begin_test "pull without clean filter"
   }
   assert_local_object "$contents_oid"
 
-  git lfs pull | tee pull.txt
+  git lfs pull 2>&1 | tee pull.txt
   if [ "0" -ne "${PIPESTATUS[0]}" ]; then
     echo >&2 "fatal: expected pull to succeed ..."
     exit 1
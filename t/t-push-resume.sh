@@ -0,0 +1,64 @@
+#!/usr/bin/env bash
+
+. "$(dirname "$0")/testlib.sh"
+
+begin_test "push: resume skips objects uploaded by an interrupted push"
+(
+  set -e
+
+  reponame="push-resume"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  printf "hi" > good.dat
+  printf "status-storage-403" > bad.dat
+  git add .gitattributes good.dat bad.dat
+  git commit -m "initial commit"
+
+  good_oid="$(calc_oid "hi")"
+  git config lfs.transfer.maxretries 1
+
+  set +e
+  git push origin main 2>&1 | tee push.log
+  res="${PIPESTATUS[0]}"
+  set -e
+  if [ "$res" = "0" ]; then
+    echo >&2 "fatal: expected push to fail"
+    exit 1
+  fi
+
+  assert_server_object "$reponame" "$good_oid"
+  grep "$good_oid" .git/lfs/tmp/upload-*.journal
+
+  set +e
+  GIT_TRACE=1 git push origin main 2>&1 | tee push.log
+  set -e
+  grep "skipping \"$good_oid\", already transferred according to journal" push.log
+
+  git rm bad.dat
+  git commit --amend -m "initial commit"
+
+  git push origin main 2>&1 | tee push.log
+  [ -z "$(ls .git/lfs/tmp/upload-*.journal 2>/dev/null)" ]
+)
+end_test
+
+begin_test "push: dry run does not record a journal"
+(
+  set -e
+
+  reponame="push-resume-dry-run"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  printf "hi" > a.dat
+  printf "status-storage-403" > bad.dat
+  git add .gitattributes a.dat bad.dat
+  git commit -m "initial commit"
+
+  git lfs push --dry-run origin main 2>&1 | tee push.log
+  [ -z "$(ls .git/lfs/tmp/upload-*.journal 2>/dev/null)" ]
+)
+end_test
@@ -38,6 +38,58 @@ begin_test "push with tracked ref"
 )
 end_test
 
+begin_test "push with GIT_LFS_STATS"
+(
+  set -e
+  push_repo_setup "push-with-stats"
+
+  GIT_LFS_STATS=1 git lfs push origin main 2>&1 | tee push.log
+  grep "Transfer stats written to" push.log
+
+  report="$(ls .git/lfs/logs/stats/stats-*.json)"
+  oid="$(calc_oid "push a\n")"
+  grep "\"succeeded\": 1" "$report"
+  grep "\"oid\": \"$oid\"" "$report"
+  grep "\"bytes\": 7" "$report"
+)
+end_test
+
+begin_test "push --dry-run --verify-remote"
+(
+  set -e
+
+  reponame="push-dry-run-verify-remote"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  echo "push a" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "add a.dat"
+
+  git lfs push origin main
+
+  echo "push b" > b.dat
+  git add b.dat
+  git commit -m "add b.dat"
+
+  # The server already has a.dat, so only b.dat would be pushed.
+  git lfs push --dry-run --verify-remote origin main 2>&1 | tee push.log
+  grep "push 82be50ad35070a4ef3467a0a650c52d5b637035e7ad02c36652e59d01ba282b7 => b.dat" push.log
+  [ $(grep -c "^push " push.log) -eq 1 ]
+  grep "1 object would be transferred (7 B)" push.log
+
+  refute_server_object "$reponame" "82be50ad35070a4ef3467a0a650c52d5b637035e7ad02c36652e59d01ba282b7"
+
+  git lfs push --verify-remote origin main 2>&1 | tee push.log
+  if [ "0" -eq "${PIPESTATUS[0]}" ]; then
+    echo >&2 "fatal: expected --verify-remote without --dry-run to fail"
+    exit 1
+  fi
+  grep "Cannot use --verify-remote without --dry-run" push.log
+)
+end_test
+
 begin_test "push with bad ref"
 (
   set -e
@@ -802,6 +854,50 @@ begin_test 'push with data the server already has'
 )
 end_test
 
+begin_test 'push with preflight and data the server already has'
+(
+  set -e
+
+  reponame="push-preflight-server-data"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  git add .gitattributes
+  git commit -m "initial commit"
+
+  contents="abc123"
+  contents_oid="$(calc_oid "$contents")"
+  printf "%s" "$contents" > a.dat
+  git add a.dat
+  git commit -m "add a.dat"
+
+  git push origin main
+
+  assert_server_object "$reponame" "$contents_oid"
+
+  git checkout -b side
+
+  contents2="def456"
+  contents2_oid="$(calc_oid "$contents2")"
+  printf "%s" "$contents2" > b.dat
+  git add b.dat
+  git commit -m "add b.dat"
+
+  # The server already has this object, so it is skipped before its
+  # missing file is looked for.
+  delete_local_object "$contents_oid"
+
+  # Without any remote-tracking refs, pushing a new branch to the URL
+  # traverses the entire history.
+  git update-ref -d refs/remotes/origin/main
+  GIT_TRACE=1 git -c lfs.pushpreflight=true push "$(git config remote.origin.url)" side 2>&1 | tee push.log
+  grep "tq: server already has 1 of 2 object(s)" push.log
+
+  assert_server_object "$reponame" "$contents2_oid"
+)
+end_test
+
 begin_test 'push with multiple refs and data the server already has'
 (
   set -e
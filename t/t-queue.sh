@@ -0,0 +1,52 @@
+#!/usr/bin/env bash
+
+. "$(dirname "$0")/testlib.sh"
+
+begin_test "queue inspect: no transfers"
+(
+  set -e
+
+  reponame="queue-inspect-none"
+  git init "$reponame"
+  cd "$reponame"
+
+  [ "No transfers in progress" = "$(git lfs queue inspect)" ]
+
+  git lfs queue inspect --json | tee queue.json
+  grep '"queues": \[\]' queue.json
+)
+end_test
+
+begin_test "queue inspect: transfers in progress"
+(
+  set -e
+
+  reponame="queue-inspect-progress"
+  git init "$reponame"
+  cd "$reponame"
+
+  mkdir -p .git/lfs/progress
+  cat > .git/lfs/progress/12345.json <<-EOJ
+	[{"pid":12345,"direction":"upload","remote":"origin","pending":3,"active":[{"direction":"upload","name":"dataset.bin","oid":"abc","size":1000000,"bytes":420000,"start":"2020-01-01T00:00:00Z","updated":"2020-01-01T00:10:00Z","host":"lfs.example.com"}],"retried":[{"name":"a.dat","oid":"def","size":10,"retries":2,"error":"connection reset"}],"failed":[{"name":"b.dat","oid":"fed","size":10,"error":"forbidden"}],"updated":"2020-01-01T00:11:00Z"}]
+	EOJ
+
+  expected="upload to \"origin\" (process 12345): 3 pending, 1 active, 1 retried, 1 failed
+	active: dataset.bin (abc), 42% of 1.0 MB via lfs.example.com
+		no progress for 1m 0s
+	retried: a.dat (def), 2 times
+		connection reset
+	failed: b.dat (fed)
+		forbidden"
+
+  [ "$expected" = "$(git lfs queue inspect)" ]
+
+  git lfs queue inspect --json | tee queue.json
+  grep '"pid": 12345' queue.json
+  grep '"host": "lfs.example.com"' queue.json
+  grep '"error": "forbidden"' queue.json
+
+  # Progress files which are no longer being updated are ignored.
+  touch -t 200001010000 .git/lfs/progress/12345.json
+  [ "No transfers in progress" = "$(git lfs queue inspect)" ]
+)
+end_test
@@ -0,0 +1,163 @@
+#!/usr/bin/env bash
+
+. "$(dirname "$0")/testlib.sh"
+
+# add_damaged_pointer writes its standard input to the named file and stages
+# it without running the Git LFS filters.
+add_damaged_pointer() {
+  local path="$1"
+
+  cat > "$path"
+  local sha="$(git hash-object -w --no-filters "$path")"
+  git update-index --add --cacheinfo "100644,$sha,$path"
+}
+
+begin_test "repair-pointers: byte order mark and CRLF"
+(
+  set -e
+
+  reponame="repair-pointers-bom-crlf"
+  git init "$reponame"
+  cd "$reponame"
+
+  git lfs track "*.dat"
+  git add .gitattributes
+  git commit -m "initial commit"
+
+  contents="abc"
+  oid="$(calc_oid "$contents")"
+  expected="$(pointer "$oid" 3)"
+
+  (printf '\357\273\277' && pointer "$oid" 3) | add_damaged_pointer "bom.dat"
+  pointer "$oid" 3 | sed -e 's/$/\r/' | add_damaged_pointer "crlf.dat"
+
+  git lfs repair-pointers 2>&1 | tee repair.log
+  if [ "0" -ne "${PIPESTATUS[0]}" ]; then
+    echo >&2 "fatal: expected repair-pointers to succeed"
+    exit 1
+  fi
+
+  grep "Repaired pointer in index: bom.dat" repair.log
+  grep "Repaired pointer in index: crlf.dat" repair.log
+  grep "Repaired pointer in working tree: bom.dat" repair.log
+  grep "Repaired pointer in working tree: crlf.dat" repair.log
+
+  [ "$expected" = "$(git cat-file -p :bom.dat)" ]
+  [ "$expected" = "$(git cat-file -p :crlf.dat)" ]
+  [ "$expected" = "$(cat bom.dat)" ]
+  [ "$expected" = "$(cat crlf.dat)" ]
+)
+end_test
+
+begin_test "repair-pointers: truncated pointer resolved from store"
+(
+  set -e
+
+  reponame="repair-pointers-store"
+  git init "$reponame"
+  cd "$reponame"
+
+  git lfs track "*.dat"
+  git add .gitattributes
+  git commit -m "initial commit"
+
+  contents="stored contents"
+  oid="$(calc_oid "$contents")"
+  printf "%s" "$contents" > stored.dat
+  git add stored.dat
+  git rm --cached stored.dat
+  rm stored.dat
+
+  pointer "$oid" 15 | head -c 60 | add_damaged_pointer "a.dat"
+
+  git lfs repair-pointers 2>&1 | tee repair.log
+  if [ "0" -ne "${PIPESTATUS[0]}" ]; then
+    echo >&2 "fatal: expected repair-pointers to succeed"
+    exit 1
+  fi
+
+  grep "Repaired pointer in index: a.dat" repair.log
+  [ "$(pointer "$oid" 15)" = "$(git cat-file -p :a.dat)" ]
+)
+end_test
+
+begin_test "repair-pointers: truncated pointer resolved from history"
+(
+  set -e
+
+  reponame="repair-pointers-history"
+  git init "$reponame"
+  cd "$reponame"
+
+  git lfs track "*.dat"
+  printf "history" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "initial commit"
+
+  oid="$(calc_oid "history")"
+  rm -rf .git/lfs/objects
+
+  pointer "$oid" 7 | head -c 100 | add_damaged_pointer "a.dat"
+
+  git lfs repair-pointers 2>&1 | tee repair.log
+  if [ "0" -ne "${PIPESTATUS[0]}" ]; then
+    echo >&2 "fatal: expected repair-pointers to succeed"
+    exit 1
+  fi
+
+  grep "Repaired pointer in index: a.dat" repair.log
+  [ "$(pointer "$oid" 7)" = "$(git cat-file -p :a.dat)" ]
+)
+end_test
+
+begin_test "repair-pointers: unrecoverable pointer"
+(
+  set -e
+
+  reponame="repair-pointers-unrecoverable"
+  git init "$reponame"
+  cd "$reponame"
+
+  git lfs track "*.dat"
+  git add .gitattributes
+  git commit -m "initial commit"
+
+  pointer "$(calc_oid "missing")" 7 | head -c 60 | add_damaged_pointer "a.dat"
+  damaged="$(cat a.dat)"
+
+  git lfs repair-pointers 2>&1 | tee repair.log
+  if [ "0" -eq "${PIPESTATUS[0]}" ]; then
+    echo >&2 "fatal: expected repair-pointers to fail"
+    exit 1
+  fi
+
+  grep "Unrecoverable: a.dat" repair.log
+  grep "1 pointer could not be repaired" repair.log
+  [ "$damaged" = "$(git cat-file -p :a.dat)" ]
+)
+end_test
+
+begin_test "repair-pointers: --dry-run"
+(
+  set -e
+
+  reponame="repair-pointers-dry-run"
+  git init "$reponame"
+  cd "$reponame"
+
+  git lfs track "*.dat"
+  git add .gitattributes
+  git commit -m "initial commit"
+
+  pointer "$(calc_oid "abc")" 3 | sed -e 's/$/\r/' | add_damaged_pointer "a.dat"
+  damaged="$(cat a.dat)"
+
+  git lfs repair-pointers --dry-run 2>&1 | tee repair.log
+  grep "Would repair pointer in index: a.dat" repair.log
+  grep "Would repair pointer in working tree: a.dat" repair.log
+  [ 0 -eq "$(grep -c "Repaired pointer" repair.log)" ]
+
+  [ "$damaged" = "$(git cat-file -p :a.dat)" ]
+  [ "$damaged" = "$(cat a.dat)" ]
+)
+end_test
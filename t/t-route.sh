@@ -0,0 +1,71 @@
+#!/usr/bin/env bash
+
+. "$(dirname "$0")/testlib.sh"
+
+begin_test "route: push and clone objects routed by path"
+(
+  set -e
+
+  reponame="route-push-clone"
+  setup_remote_repo "$reponame"
+  setup_remote_repo "$reponame-video"
+  clone_repo "$reponame" "$reponame"
+
+  git config "lfs.route.assets/video.url" "$GITSERVER/$reponame-video.git/info/lfs"
+
+  git lfs track "*.dat"
+  mkdir -p assets/video
+  printf "image" > assets/image.dat
+  printf "video" > assets/video/clip.dat
+  git add .gitattributes assets
+  git commit -m "add assets"
+
+  GIT_TRACE=1 git push origin main 2>&1 | tee push.log
+  grep "tq: routing batch of size 1 to $GITSERVER/$reponame-video.git/info/lfs" push.log
+
+  image_oid="$(calc_oid "image")"
+  video_oid="$(calc_oid "video")"
+  assert_server_object "$reponame" "$image_oid"
+  refute_server_object "$reponame" "$video_oid"
+  assert_server_object "$reponame-video" "$video_oid"
+  refute_server_object "$reponame-video" "$image_oid"
+
+  cd ..
+  git -c "lfs.route.assets/video.url=$GITSERVER/$reponame-video.git/info/lfs" \
+    clone "$GITSERVER/$reponame" "$reponame-assert"
+  cd "$reponame-assert"
+
+  [ "image" = "$(cat assets/image.dat)" ]
+  [ "video" = "$(cat assets/video/clip.dat)" ]
+
+  rm -rf .git/lfs/objects
+  git config "lfs.route.assets/video.url" "$GITSERVER/$reponame-video.git/info/lfs"
+  [ "video" = "$(git cat-file -p ":assets/video/clip.dat" | git lfs smudge assets/video/clip.dat)" ]
+)
+end_test
+
+begin_test "route: .lfsconfig routes are used"
+(
+  set -e
+
+  reponame="route-lfsconfig"
+  setup_remote_repo "$reponame"
+  setup_remote_repo "$reponame-video"
+  clone_repo "$reponame" "$reponame"
+
+  git config -f .lfsconfig "lfs.route.assets/video.url" "$GITSERVER/$reponame-video.git/info/lfs"
+
+  git lfs track "*.dat"
+  mkdir -p assets/video
+  printf "video" > assets/video/clip.dat
+  git add .gitattributes .lfsconfig assets
+  git commit -m "add assets"
+
+  git push origin main 2>&1 | tee push.log
+  grep "unsafe '.lfsconfig' keys were ignored" push.log && exit 1
+
+  video_oid="$(calc_oid "video")"
+  refute_server_object "$reponame" "$video_oid"
+  assert_server_object "$reponame-video" "$video_oid"
+)
+end_test
@@ -0,0 +1,53 @@
+#!/usr/bin/env bash
+
+. "$(dirname "$0")/testlib.sh"
+
+begin_test "selftest: built-in server"
+(
+  set -e
+
+  git lfs selftest 2>&1 | tee selftest.log
+  if [ "0" -ne "${PIPESTATUS[0]}" ]; then
+    echo >&2 "fatal: expected selftest to succeed"
+    exit 1
+  fi
+
+  grep "Testing memory://selftest" selftest.log
+  for step in init track clean push wipe fetch checkout verify; do
+    grep "^$step  *ok$" selftest.log
+  done
+)
+end_test
+
+begin_test "selftest: server"
+(
+  set -e
+
+  reponame="selftest-server"
+  setup_remote_repo "$reponame"
+
+  git lfs selftest --endpoint="$GITSERVER/$reponame.git/info/lfs" 2>&1 | tee selftest.log
+  if [ "0" -ne "${PIPESTATUS[0]}" ]; then
+    echo >&2 "fatal: expected selftest to succeed"
+    exit 1
+  fi
+
+  grep "^verify  *ok$" selftest.log
+)
+end_test
+
+begin_test "selftest: failing server"
+(
+  set -e
+
+  git lfs selftest --endpoint="http://127.0.0.1:1/" 2>&1 | tee selftest.log
+  if [ "0" -eq "${PIPESTATUS[0]}" ]; then
+    echo >&2 "fatal: expected selftest to fail"
+    exit 1
+  fi
+
+  grep "^clean  *ok$" selftest.log
+  grep "^push  *FAIL: " selftest.log
+  grep "^verify  *skipped$" selftest.log
+)
+end_test
@@ -417,6 +417,50 @@ begin_test "standalone-file-lfs.url file URL"
 )
 end_test
 
+begin_test "standalone-file-lfs.url plain directory"
+(
+  set -e
+
+  reponame="standalone-file-lfsurl-directory"
+  setup_remote_repo "$reponame"
+
+  # clone directly, not through lfstest-gitserver
+  clone_repo_url "$REMOTEDIR/$reponame.git" $reponame
+
+  # A plain directory, rather than a Git repository, such as a mounted share.
+  store="$TRASHDIR/$reponame-store"
+  mkdir "$store"
+
+  git config lfs.url "file://$(urlify "$store")"
+
+  git lfs track "*.dat" 2>&1 | tee track.log
+  grep "Tracking \"\*.dat\"" track.log
+  printf "a" > a.dat
+  printf "b" > b.dat
+  git add .gitattributes a.dat b.dat
+  git commit -m "add a.dat and b.dat"
+
+  GIT_TRACE=1 GIT_TRANSFER_TRACE=1 git push origin main 2>&1 | tee push.log
+  [ ${PIPESTATUS[0]} = "0" ]
+
+  grep "xfer: started custom adapter process" push.log
+  grep "using \".*$reponame-store\" as remote object store" push.log
+  grep "Uploading LFS objects: 100% (2/2)" push.log
+
+  objectlist=$(find "$store/lfs/objects" -type f || true)
+  [ "$(echo "$objectlist" | wc -l)" -eq 2 ]
+  [ ! -e "$store/HEAD" ]
+
+  rm -fr .git/lfs/objects
+
+  GIT_TRACE=1 GIT_TRANSFER_TRACE=1 git lfs fetch --all 2>&1 | tee fetch.log
+  [ ${PIPESTATUS[0]} = "0" ]
+  grep "xfer: started custom adapter process" fetch.log
+
+  git lfs fsck
+)
+end_test
+
 begin_test "standalone-file-lfs.url http URL"
 (
   set -e
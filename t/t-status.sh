@@ -593,3 +593,68 @@ Objects not staged for commit:"
   [ "$expected" = "$actual" ]
 )
 end_test
+
+begin_test "status --porcelain -z"
+(
+  set -e
+
+  reponame="status-porcelain-null"
+  git init "$reponame"
+  cd "$reponame"
+
+  git lfs track "*.dat"
+  printf "some data" > "file 1.dat"
+  git add .gitattributes "file 1.dat"
+  git commit -m "file 1.dat"
+
+  git mv "file 1.dat" "$(printf "file\n2.dat")"
+
+  expected="R  $(printf "file\n2.dat")|file 1.dat|"
+
+  [ "$expected" = "$(git lfs status --porcelain -z | tr '\0' '|')" ]
+
+  git lfs status -z 2>&1 | tee status.log
+  grep -- "-z requires --porcelain" status.log
+)
+end_test
+
+begin_test "status: transfers in progress"
+(
+  set -e
+
+  reponame="status-transfers-in-progress"
+  git init "$reponame"
+  cd "$reponame"
+
+  git lfs track "*.dat"
+  git add .gitattributes
+  git commit -m "initial commit"
+
+  mkdir -p .git/lfs/progress
+  cat > .git/lfs/progress/12345.json <<-EOJ
+	[{"pid":12345,"direction":"upload","pending":0,"active":[{"direction":"upload","name":"dataset.bin","oid":"abc","size":1000000,"bytes":420000,"start":"2020-01-01T00:00:00Z","updated":"2020-01-01T00:10:00Z"}],"updated":"2020-01-01T00:10:00Z"}]
+	EOJ
+
+  expected="On branch main
+
+Objects to be committed:
+
+
+Objects not staged for commit:
+
+
+Transfers in progress:
+
+	upload: 42% of dataset.bin (700 B/s), ETA 13m 49s"
+
+  [ "$expected" = "$(git lfs status)" ]
+
+  # Progress files which are no longer being updated are ignored.
+  touch -t 200001010000 .git/lfs/progress/12345.json
+  git lfs status 2>&1 | tee status.log
+  if grep "Transfers in progress" status.log; then
+    echo >&2 "fatal: expected stale progress to be ignored"
+    exit 1
+  fi
+)
+end_test
@@ -35,3 +35,96 @@ begin_test "track (--no-modify-attrs)"
 )
 end_test
 
+
+begin_test "track (--macro)"
+(
+  set -e
+
+  reponame="track-macro"
+  git init "$reponame"
+  cd "$reponame"
+
+  git lfs track --macro=lfs "*.dat" | tee track.log
+  grep "Defining attribute macro \"lfs\"" track.log
+  grep "Tracking \"\*.dat\"" track.log
+
+  [ "[attr]lfs filter=lfs diff=lfs merge=lfs -text" = "$(head -n 1 .gitattributes)" ]
+  grep -x "\*.dat lfs" .gitattributes
+
+  [ "filter: lfs" = "$(git check-attr filter a.dat | cut -d' ' -f2-)" ]
+
+  git lfs track --macro=lfs "*.bin" | tee track.log
+  grep "Defining attribute macro" track.log && exit 1
+  [ 1 -eq "$(grep -c "^\[attr\]lfs" .gitattributes)" ]
+  grep -x "\*.bin lfs" .gitattributes
+
+  git lfs track | tee track.log
+  grep "\*.dat (.gitattributes)" track.log
+  grep "\*.bin (.gitattributes)" track.log
+
+  git lfs track --macro=lfs "*.dat" | tee track.log
+  grep "\"\*.dat\" already supported" track.log
+
+  git lfs untrack "*.dat"
+  grep "\*.dat" .gitattributes && exit 1
+  grep -x "\*.bin lfs" .gitattributes
+)
+end_test
+
+begin_test "track (lfs.trackmacro in subdirectory)"
+(
+  set -e
+
+  reponame="track-macro-subdirectory"
+  git init "$reponame"
+  cd "$reponame"
+
+  git config lfs.trackmacro lfs
+  mkdir dir
+  cd dir
+
+  git lfs track "*.dat" 2>&1 | tee track.log
+  if [ "0" -eq "${PIPESTATUS[0]}" ]; then
+    echo >&2 "expected \`git lfs track\` to fail without a macro definition"
+    exit 1
+  fi
+  grep "Attribute macro \"lfs\" is not defined" track.log
+  [ ! -f .gitattributes ]
+
+  cd ..
+  git lfs track "*.bin"
+  cd dir
+  git lfs track "*.dat"
+  grep -x "\*.dat lfs" .gitattributes
+  [ "filter: lfs" = "$(git check-attr filter a.dat | cut -d' ' -f2-)" ]
+)
+end_test
+
+begin_test "track (warns about line ending conversion)"
+(
+  set -e
+
+  reponame="track-line-ending-conversion"
+  git init "$reponame"
+  cd "$reponame"
+
+  echo "contents" > a.dat
+  echo "contents" > b.bin
+  git add a.dat b.bin
+  git commit -m "add files"
+
+  git lfs track "*.dat" 2>&1 | tee track.log
+  grep "line ending conversion" track.log && exit 1
+
+  git lfs track "*.bin"
+  printf '*.bin text eol=crlf\n' >> .gitattributes
+  git lfs track "*.txt" 2>&1 | tee track.log
+  grep "warning: \"b.bin\" is tracked by Git LFS but has line ending conversion enabled" track.log
+  grep "a.dat" track.log && exit 1
+
+  # The index is only checked when a pattern is added.
+  git lfs track "*.txt" 2>&1 | tee track.log
+  grep "line ending conversion" track.log && exit 1
+  true
+)
+end_test
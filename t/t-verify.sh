@@ -123,3 +123,35 @@ begin_test "verify with retries (bad .gitconfig)"
   [ "2" -eq "$(grep -c "verify $contents_short_oid attempt" push.log)" ]
 )
 end_test
+
+begin_test "verify with mismatch uploads again"
+(
+  set -e
+
+  reponame="verify-mismatch-1-time"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  git add .gitattributes
+  git commit -m "initial commit"
+
+  contents="send-verify-action"
+  contents_oid="$(calc_oid "$contents")"
+  contents_short_oid="$(echo "$contents_oid" | head -c 7)"
+  printf "%s" "$contents" > a.dat
+
+  git add a.dat
+  git commit -m "add a.dat"
+
+  GIT_TRACE=1 git push origin main 2>&1 | tee push.log
+  [ "0" -eq "${PIPESTATUS[0]}" ]
+
+  # The first verify reports a mismatch, so rather than retrying the verify
+  # request, the whole transfer is retried to upload the object again.
+  grep "tq: retrying object $contents_oid" push.log
+  grep "verify $contents_short_oid attempt #2" push.log && exit 1
+
+  assert_server_object "$reponame" "$contents_oid"
+)
+end_test
@@ -38,7 +38,7 @@ begin_test "push zero len file"
 
   assert_pointer "main" "full.dat" "$contents_oid" 4
 
-  git push origin main | tee push.log
+  git push origin main 2>&1 | tee push.log
   grep "Uploading LFS objects: 100% (1/1), 4 B" push.log
 )
 end_test
@@ -62,3 +62,31 @@ begin_test "pull zero len file"
   [ "full" = "$(cat full.dat)" ]
 )
 end_test
+
+begin_test "pull pointer with zero size"
+(
+  set -e
+
+  clone_repo "$reponame" zero-size-pointer
+
+  # e3b0c442... is the SHA-256 of the empty string.
+  printf "version https://git-lfs.github.com/spec/v1\noid sha256:e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855\nsize 0\n" > pointer.txt
+  blob="$(git hash-object -w pointer.txt)"
+  rm pointer.txt
+  git update-index --add --cacheinfo 100644 "$blob" zero.dat
+  git commit -m "add zero size pointer"
+  git push origin main
+
+  cd ..
+  GIT_TRACE=1 git clone "$GITSERVER/$reponame" zero-size-pointer-clone 2>&1 | tee clone.log
+  cd zero-size-pointer-clone
+
+  if [ -s "zero.dat" ]; then
+    echo >&2 "fatal: expected zero.dat to be empty"
+    exit 1
+  fi
+
+  git lfs pull 2>&1 | tee pull.log
+  [ 0 -eq "$(grep -c "Downloading" pull.log)" ]
+)
+end_test
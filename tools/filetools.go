@@ -21,6 +21,23 @@ import (
 	"github.com/git-lfs/git-lfs/v3/tr"
 )
 
+// JoinWithinDir joins the relative path "rel" to the directory "dir", and
+// returns an error if the result would lie outside of "dir", as it would if
+// "rel" were absolute or began with enough ".." elements. It should be used
+// for any path which comes from outside Git LFS, such as from a server.
+func JoinWithinDir(dir, rel string) (string, error) {
+	if filepath.IsAbs(rel) || len(filepath.VolumeName(rel)) > 0 {
+		return "", errors.New(tr.Tr.Get("path %q is not relative", rel))
+	}
+
+	joined := filepath.Join(dir, rel)
+	relToDir, err := filepath.Rel(dir, joined)
+	if err != nil || relToDir == ".." || strings.HasPrefix(relToDir, ".."+string(filepath.Separator)) {
+		return "", errors.New(tr.Tr.Get("path %q is outside of %q", rel, dir))
+	}
+	return joined, nil
+}
+
 // FileOrDirExists determines if a file/dir exists, returns IsDir() results too.
 func FileOrDirExists(path string) (exists bool, isDir bool) {
 	fi, err := os.Stat(path)
@@ -449,6 +466,19 @@ func SetFileWriteFlag(path string, writeEnabled bool) error {
 	return os.Chmod(path, os.FileMode(mode))
 }
 
+var (
+	// tempFiles holds the names of the temporary files which TempFile has
+	// created, so that RemoveTempFiles can remove those left behind.
+	tempFiles sync.Map
+	// tempFilesCreated counts the temporary files created since the names
+	// of those which no longer exist were last forgotten.
+	tempFilesCreated int32
+)
+
+// tempFilesSweep is the number of temporary files which may be created before
+// the names of those which have since been moved or removed are forgotten.
+const tempFilesSweep = 256
+
 // TempFile creates a temporary file in specified directory with proper permissions for the repository.
 // On success, it returns an open, non-nil *os.File, and the caller is responsible
 // for closing and/or removing it.  On failure, the temporary file is
@@ -469,9 +499,31 @@ func TempFile(dir, pattern string, cfg repositoryPermissionFetcher) (*os.File, e
 		os.Remove(tmp.Name())
 		return nil, err
 	}
+
+	if atomic.AddInt32(&tempFilesCreated, 1) >= tempFilesSweep {
+		atomic.StoreInt32(&tempFilesCreated, 0)
+		tempFiles.Range(func(name, _ interface{}) bool {
+			if _, err := os.Lstat(name.(string)); os.IsNotExist(err) {
+				tempFiles.Delete(name)
+			}
+			return true
+		})
+	}
+	tempFiles.Store(tmp.Name(), struct{}{})
 	return tmp, nil
 }
 
+// RemoveTempFiles removes the temporary files created by TempFile which have
+// not been moved into place or removed, as when a transfer is cancelled or the
+// process is interrupted.
+func RemoveTempFiles() {
+	tempFiles.Range(func(name, _ interface{}) bool {
+		os.Remove(name.(string))
+		tempFiles.Delete(name)
+		return true
+	})
+}
+
 // ExecutablePermissions takes a set of Unix permissions (which may or may not
 // have the executable bits set) and maps them into a set of permissions in
 // which the executable bits are set, using the same technique as Git does.
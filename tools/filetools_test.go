@@ -334,3 +334,51 @@ func TestExecutablePermissions(t *testing.T) {
 	assert.EqualValues(t, os.FileMode(0750), ExecutablePermissions(0640))
 	assert.EqualValues(t, os.FileMode(0700), ExecutablePermissions(0600))
 }
+
+func TestJoinWithinDir(t *testing.T) {
+	dir := filepath.Join(os.TempDir(), "repo")
+
+	joined, err := JoinWithinDir(dir, "a/b.dat")
+	assert.Nil(t, err)
+	assert.Equal(t, filepath.Join(dir, "a", "b.dat"), joined)
+
+	joined, err = JoinWithinDir(dir, "a/../b..dat")
+	assert.Nil(t, err)
+	assert.Equal(t, filepath.Join(dir, "b..dat"), joined)
+
+	for _, rel := range []string{
+		"..",
+		"../other/b.dat",
+		"a/../../b.dat",
+		filepath.Join(dir, "b.dat"),
+	} {
+		_, err := JoinWithinDir(dir, rel)
+		assert.NotNil(t, err, rel)
+	}
+}
+
+type testPermissions os.FileMode
+
+func (p testPermissions) RepositoryPermissions(executable bool) os.FileMode {
+	return os.FileMode(p)
+}
+
+func TestRemoveTempFiles(t *testing.T) {
+	dir := t.TempDir()
+
+	kept, err := TempFile(dir, "kept", testPermissions(0644))
+	assert.Nil(t, err)
+	kept.Close()
+
+	moved, err := TempFile(dir, "moved", testPermissions(0644))
+	assert.Nil(t, err)
+	moved.Close()
+	assert.Nil(t, os.Rename(moved.Name(), filepath.Join(dir, "object")))
+
+	RemoveTempFiles()
+
+	_, err = os.Stat(kept.Name())
+	assert.True(t, os.IsNotExist(err))
+	_, err = os.Stat(filepath.Join(dir, "object"))
+	assert.Nil(t, err)
+}
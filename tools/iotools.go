@@ -18,11 +18,21 @@ const (
 	// spooling the contents of an `io.Reader` in `Spool()` to a temporary
 	// file on disk.
 	memoryBufferLimit = 1024
+
+	// sparseBlockSize is the size of the blocks of zeros which a
+	// SparseWriter skips, which matches the block size of most
+	// filesystems.
+	sparseBlockSize = 4096
 )
 
 // CopyWithCallback copies reader to writer while performing a progress callback
 func CopyWithCallback(writer io.Writer, reader io.Reader, totalSize int64, cb CopyCallback) (int64, error) {
-	if success, _ := CloneFile(writer, reader); success {
+	dst := writer
+	if sw, ok := writer.(*SparseWriter); ok {
+		// A cloned file shares any holes in its source.
+		dst = sw.file
+	}
+	if success, _ := CloneFile(dst, reader); success {
 		if cb != nil {
 			cb(totalSize, totalSize, 0)
 		}
@@ -40,6 +50,82 @@ func CopyWithCallback(writer io.Writer, reader io.Reader, totalSize int64, cb Co
 	return io.Copy(writer, cbReader)
 }
 
+// SparseWriter writes to a newly created file, seeking over each block of
+// zeros rather than writing it, so that the file is left sparse on filesystems
+// which support it. Elsewhere, the skipped blocks are filled with zeros.
+type SparseWriter struct {
+	file *os.File
+
+	// hole is the number of zero bytes which have been skipped, but not
+	// yet seeked over.
+	hole int64
+}
+
+// NewSparseWriter returns a SparseWriter which writes to "file", which must be
+// empty.
+func NewSparseWriter(file *os.File) *SparseWriter {
+	return &SparseWriter{file: file}
+}
+
+func (w *SparseWriter) Write(p []byte) (int, error) {
+	var n int
+	for len(p) > 0 {
+		block := p
+		if len(block) > sparseBlockSize {
+			block = block[:sparseBlockSize]
+		}
+
+		if isZero(block) {
+			w.hole += int64(len(block))
+		} else {
+			if err := w.skipHole(); err != nil {
+				return n, err
+			}
+			if written, err := w.file.Write(block); err != nil {
+				return n + written, err
+			}
+		}
+
+		n += len(block)
+		p = p[len(block):]
+	}
+	return n, nil
+}
+
+// Close extends the file over any zeros which were skipped at its end. It
+// does not close the file itself.
+func (w *SparseWriter) Close() error {
+	if w.hole == 0 {
+		return nil
+	}
+
+	size, err := w.file.Seek(w.hole, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+	w.hole = 0
+	return w.file.Truncate(size)
+}
+
+func (w *SparseWriter) skipHole() error {
+	if w.hole == 0 {
+		return nil
+	}
+
+	_, err := w.file.Seek(w.hole, io.SeekCurrent)
+	w.hole = 0
+	return err
+}
+
+func isZero(p []byte) bool {
+	for _, b := range p {
+		if b != 0 {
+			return false
+		}
+	}
+	return true
+}
+
 // Get a new Hash instance of the type used to hash LFS content
 func NewLfsContentHash() hash.Hash {
 	return sha256.New()
@@ -145,12 +231,17 @@ func Spool(to io.Writer, from io.Reader, dir string) (n int64, err error) {
 	return io.Copy(to, spool)
 }
 
-// Split the input on the NUL character. Usable with bufio.Scanner.
+// Split the input on the NUL character. Usable with bufio.Scanner. Any
+// remaining data which is not terminated by a NUL is returned as the final
+// token.
 func SplitOnNul(data []byte, atEOF bool) (advance int, token []byte, err error) {
 	for i := 0; i < len(data); i++ {
 		if data[i] == '\x00' {
 			return i + 1, data[:i], nil
 		}
 	}
+	if atEOF && len(data) > 0 {
+		return len(data), data, nil
+	}
 	return 0, nil, nil
 }
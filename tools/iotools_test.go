@@ -1,13 +1,18 @@
 package tools_test
 
 import (
+	"bufio"
 	"bytes"
 	"io"
+	"io/ioutil"
+	"os"
+	"strings"
 	"testing"
 
 	"github.com/git-lfs/git-lfs/v3/errors"
 	"github.com/git-lfs/git-lfs/v3/tools"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestRetriableReaderReturnsSuccessfulReads(t *testing.T) {
@@ -74,3 +79,39 @@ type ErrReader struct {
 func (e *ErrReader) Read(p []byte) (n int, err error) {
 	return 0, e.err
 }
+
+func TestSplitOnNul(t *testing.T) {
+	scanner := bufio.NewScanner(strings.NewReader("a\x00b c\x00\x00d\ne"))
+	scanner.Split(tools.SplitOnNul)
+
+	var tokens []string
+	for scanner.Scan() {
+		tokens = append(tokens, scanner.Text())
+	}
+
+	assert.Nil(t, scanner.Err())
+	assert.Equal(t, []string{"a", "b c", "", "d\ne"}, tokens)
+}
+
+func TestSparseWriterPreservesContents(t *testing.T) {
+	f, err := ioutil.TempFile("", "lfstestsparsewriter")
+	require.Nil(t, err)
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	data := make([]byte, 3*4096+100)
+	copy(data[4096:], "not a hole")
+
+	w := tools.NewSparseWriter(f)
+	n, err := w.Write(data[:5000])
+	require.Nil(t, err)
+	assert.Equal(t, 5000, n)
+	n, err = w.Write(data[5000:])
+	require.Nil(t, err)
+	assert.Equal(t, len(data)-5000, n)
+	require.Nil(t, w.Close())
+
+	contents, err := ioutil.ReadFile(f.Name())
+	require.Nil(t, err)
+	assert.Equal(t, data, contents)
+}
@@ -1,10 +1,12 @@
 package tq
 
 import (
+	"context"
 	"net/http"
 	"regexp"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/git-lfs/git-lfs/v3/errors"
 	"github.com/git-lfs/git-lfs/v3/fs"
@@ -23,9 +25,14 @@ type adapterBase struct {
 	transferImpl transferImplementation
 	apiClient    *lfsapi.Client
 	remote       string
-	jobChan      chan *job
-	debugging    bool
-	cb           ProgressCallback
+	// state is that of the manifest of the queue which began the
+	// adapter, if any.
+	state     *serverState
+	ctx       context.Context
+	timeout   time.Duration
+	jobChan   chan *job
+	debugging bool
+	cb        ProgressCallback
 	// WaitGroup to sync the completion of all workers
 	workerWait sync.WaitGroup
 	// WaitGroup to sync the completion of all in-flight jobs
@@ -55,6 +62,11 @@ type transferImplementation interface {
 const (
 	enableHrefRewriteKey     = "lfs.transfer.enablehrefrewrite"
 	defaultEnableHrefRewrite = false
+
+	// transferTimeoutKey is the number of seconds within which each
+	// attempt to transfer an object must finish before it is abandoned
+	// and retried. There is no limit by default.
+	transferTimeoutKey = "lfs.transfer.timeout"
 )
 
 func newAdapterBase(f *fs.Filesystem, name string, dir Direction, ti transferImplementation) *adapterBase {
@@ -78,6 +90,11 @@ func (a *adapterBase) Direction() Direction {
 func (a *adapterBase) Begin(cfg AdapterConfig, cb ProgressCallback) error {
 	a.apiClient = cfg.APIClient()
 	a.remote = cfg.Remote()
+	if c, ok := cfg.(*adapterConfig); ok {
+		a.state = c.state
+	}
+	a.ctx = cfg.Context()
+	a.timeout = time.Duration(a.apiClient.GitEnv().Int(transferTimeoutKey, 0)) * time.Second
 	a.cb = cb
 	a.jobChan = make(chan *job, 100)
 	a.debugging = a.apiClient.OSEnv().Bool("GIT_TRANSFER_TRACE", false) ||
@@ -177,10 +194,24 @@ func (a *adapterBase) worker(workerNum int, ctx interface{}) {
 
 		// Actual transfer happens here
 		var err error
-		if t.Size < 0 {
+		if cerr := a.context().Err(); cerr != nil {
+			err = cerr
+		} else if t.Size < 0 {
 			err = errors.New(tr.Tr.Get("object %q has invalid size (got: %d)", t.Oid, t.Size))
 		} else {
-			err = a.transferImpl.DoTransfer(ctx, t, a.cb, authCallback)
+			err = a.lockedTransfer(ctx, t, authCallback)
+		}
+
+		// A transfer which failed because it was cancelled must not be
+		// retried, so report the cancellation rather than whatever
+		// (possibly retriable) error the transfer encountered.
+		if cerr := a.context().Err(); cerr != nil && err != nil {
+			err = cerr
+		}
+
+		// Nor must a transfer which a TransferHook vetoed.
+		if veto, ok := errors.Cause(err).(*lfsapi.TransferVetoedError); ok {
+			err = veto
 		}
 
 		// Mark the job as completed, and alter all listeners
@@ -197,6 +228,96 @@ func (a *adapterBase) worker(workerNum int, ctx interface{}) {
 	a.workerWait.Done()
 }
 
+// lockedTransfer performs the transfer "t". A download is made while holding
+// the lock on its object in the local object store, and is skipped if another
+// process stored the object while this one waited for the lock.
+func (a *adapterBase) lockedTransfer(ctx interface{}, t *Transfer, authCallback func()) error {
+	if a.direction == Upload && a.fs != nil {
+		return a.uploadFromStore(ctx, t, authCallback)
+	}
+	if a.direction != Download || a.fs == nil {
+		return a.doTransfer(ctx, t, authCallback)
+	}
+
+	store, err := a.fs.Store()
+	if err != nil {
+		return err
+	}
+	existed := store.Exists(t.Oid, t.Size)
+
+	lock, err := a.fs.LockObject(a.context(), t.Oid)
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
+
+	if !existed && store.Exists(t.Oid, t.Size) {
+		a.Trace("xfer: adapter %q found %q stored by another process", a.Name(), t.Oid)
+		if authCallback != nil {
+			authCallback()
+		}
+		if a.cb != nil {
+			return a.cb(t.Name, t.Size, t.Size, int(t.Size))
+		}
+		return nil
+	}
+	return a.doTransfer(ctx, t, authCallback)
+}
+
+// uploadFromStore uploads "t" from a temporary copy of its object when the
+// local object store does not keep objects in files, since adapters upload
+// from the file at the transfer's path.
+func (a *adapterBase) uploadFromStore(ctx interface{}, t *Transfer, authCallback func()) error {
+	store, err := a.fs.Store()
+	if err != nil {
+		return err
+	}
+	if _, ok := store.(fs.FileBacked); ok {
+		return a.doTransfer(ctx, t, authCallback)
+	}
+
+	path, cleanup, err := a.fs.ObjectFile(t.Oid)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	orig := t.Path
+	t.Path = path
+	defer func() { t.Path = orig }()
+
+	return a.doTransfer(ctx, t, authCallback)
+}
+
+// doTransfer performs the transfer "t" within lfs.transfer.timeout, if it is
+// set. A transfer which takes longer is abandoned with a retriable error, so
+// that a stuck transfer does not hold up the queue forever.
+func (a *adapterBase) doTransfer(ctx interface{}, t *Transfer, authCallback func()) error {
+	if a.timeout <= 0 {
+		return a.transferImpl.DoTransfer(ctx, t, a.cb, authCallback)
+	}
+
+	tctx, cancel := context.WithTimeout(a.context(), a.timeout)
+	defer cancel()
+
+	t.ctx = tctx
+	err := a.transferImpl.DoTransfer(ctx, t, a.cb, authCallback)
+	t.ctx = nil
+
+	if err != nil && tctx.Err() == context.DeadlineExceeded && a.context().Err() == nil {
+		return errors.NewRetriableError(errors.New(tr.Tr.Get("transfer of %s did not finish within %s", t.Oid, a.timeout)))
+	}
+	return err
+}
+
+// context returns the context governing the adapter's transfers.
+func (a *adapterBase) context() context.Context {
+	if a.ctx == nil {
+		return context.Background()
+	}
+	return a.ctx
+}
+
 var httpRE = regexp.MustCompile(`\Ahttps?://`)
 
 func (a *adapterBase) newHTTPRequest(method string, rel *Action) (*http.Request, error) {
@@ -212,7 +333,7 @@ func (a *adapterBase) newHTTPRequest(method string, rel *Action) (*http.Request,
 		return nil, errors.New(tr.Tr.Get("missing protocol: %q", urlfragment))
 	}
 
-	req, err := http.NewRequest(method, href, nil)
+	req, err := http.NewRequestWithContext(a.context(), method, href, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -224,12 +345,34 @@ func (a *adapterBase) newHTTPRequest(method string, rel *Action) (*http.Request,
 	return req, nil
 }
 
+// doHTTP makes the request "req" to transfer the contents of "t", calling any
+// TransferHooks registered with the API client around it.
 func (a *adapterBase) doHTTP(t *Transfer, req *http.Request) (*http.Response, error) {
+	info := &lfsapi.TransferInfo{
+		Operation: a.direction.String(),
+		Oid:       t.Oid,
+		Size:      t.Size,
+		Name:      t.Name,
+	}
+	if err := a.apiClient.BeforeTransferRequest(info, req); err != nil {
+		return nil, err
+	}
+
+	if t.ctx != nil {
+		req = req.WithContext(t.ctx)
+	}
+
+	var res *http.Response
+	var err error
 	if t.Authenticated {
-		return a.apiClient.Do(req)
+		res, err = a.apiClient.Do(req)
+	} else {
+		endpoint := endpointURL(req.URL.String(), t.Oid)
+		res, err = a.apiClient.DoWithAuthNoRetry(a.remote, a.apiClient.Endpoints.AccessFor(endpoint), req)
 	}
-	endpoint := endpointURL(req.URL.String(), t.Oid)
-	return a.apiClient.DoWithAuthNoRetry(a.remote, a.apiClient.Endpoints.AccessFor(endpoint), req)
+
+	a.apiClient.AfterTransferResponse(info, res, err)
+	return res, err
 }
 
 func advanceCallbackProgress(cb ProgressCallback, t *Transfer, numBytes int64) {
@@ -253,3 +396,13 @@ func advanceCallbackProgress(cb ProgressCallback, t *Transfer, numBytes int64) {
 func endpointURL(rawurl, oid string) string {
 	return strings.Split(rawurl, oid)[0]
 }
+
+// storeObject moves the complete download of the object of "t" at "path",
+// which has been checked to hash to its OID, into the local object store.
+func (a *adapterBase) storeObject(t *Transfer, path string) error {
+	store, err := a.fs.Store()
+	if err != nil {
+		return err
+	}
+	return fs.ImportFile(store, t.Oid, path)
+}
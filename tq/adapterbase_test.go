@@ -0,0 +1,139 @@
+package tq
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/git-lfs/git-lfs/v3/errors"
+	"github.com/git-lfs/git-lfs/v3/fs"
+	"github.com/git-lfs/git-lfs/v3/lfsapi"
+	"github.com/git-lfs/git-lfs/v3/lfshttp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// getImplementation transfers each object with a single GET request, and reads
+// its response's body.
+type getImplementation struct {
+	a *adapterBase
+}
+
+func (i *getImplementation) WorkerStarting(workerNum int) (interface{}, error) {
+	return nil, nil
+}
+
+func (i *getImplementation) WorkerEnding(workerNum int, ctx interface{}) {}
+
+func (i *getImplementation) DoTransfer(ctx interface{}, t *Transfer, cb ProgressCallback, authOkFunc func()) error {
+	if authOkFunc != nil {
+		authOkFunc()
+	}
+
+	req, err := i.a.newHTTPRequest("GET", t.Actions["download"])
+	if err != nil {
+		return err
+	}
+	res, err := i.a.doHTTP(t, req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	_, err = res.Body.Read(make([]byte, 1))
+	return err
+}
+
+func TestAdapterTransferTimeout(t *testing.T) {
+	done := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+		select {
+		case <-r.Context().Done():
+		case <-done:
+		}
+	}))
+	defer srv.Close()
+	defer close(done)
+
+	c, err := lfsapi.NewClient(lfshttp.NewContext(nil, nil, map[string]string{
+		"lfs.transfer.timeout": "1",
+	}))
+	require.Nil(t, err)
+
+	impl := &getImplementation{}
+	a := newAdapterBase(nil, "get", Download, impl)
+	impl.a = a
+	require.Nil(t, a.Begin(&adapterConfig{apiClient: c, concurrentTransfers: 1}, nil))
+
+	start := time.Now()
+	results := a.Add(&Transfer{
+		Oid:           "oid",
+		Size:          1,
+		Authenticated: true,
+		Actions:       ActionSet{"download": &Action{Href: srv.URL}},
+	})
+	a.End()
+
+	res := <-results
+	assert.WithinDuration(t, start.Add(time.Second), time.Now(), 5*time.Second)
+	if assert.NotNil(t, res.Error) {
+		assert.True(t, errors.IsRetriableError(res.Error))
+		assert.Contains(t, res.Error.Error(), "did not finish within 1s")
+	}
+	assert.Nil(t, res.Transfer.ctx)
+}
+
+func TestAdapterSkipsDownloadStoredByAnotherProcess(t *testing.T) {
+	var requests int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.Write([]byte("hello"))
+	}))
+	defer srv.Close()
+
+	dir, err := ioutil.TempDir("", "lfs-adapter-lock")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	c, err := lfsapi.NewClient(nil)
+	require.Nil(t, err)
+	f := fs.New(c.OSEnv(), dir, "", "", 0644)
+
+	oid := "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"
+
+	// Another process is downloading the object.
+	lock, err := f.LockObject(context.Background(), oid)
+	require.Nil(t, err)
+
+	impl := &getImplementation{}
+	a := newAdapterBase(f, "get", Download, impl)
+	impl.a = a
+	require.Nil(t, a.Begin(&adapterConfig{apiClient: c, concurrentTransfers: 1}, nil))
+
+	results := a.Add(&Transfer{
+		Oid:           oid,
+		Size:          5,
+		Authenticated: true,
+		Actions:       ActionSet{"download": &Action{Href: srv.URL}},
+	})
+
+	time.Sleep(200 * time.Millisecond)
+	store, err := f.Store()
+	require.Nil(t, err)
+	require.Nil(t, store.Put(oid, strings.NewReader("hello")))
+	require.Nil(t, lock.Unlock())
+
+	a.End()
+
+	res := <-results
+	assert.Nil(t, res.Error)
+	assert.EqualValues(t, 0, atomic.LoadInt32(&requests))
+}
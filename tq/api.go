@@ -1,6 +1,8 @@
 package tq
 
 import (
+	"context"
+	"net/http"
 	"time"
 
 	"github.com/git-lfs/git-lfs/v3/errors"
@@ -14,6 +16,9 @@ import (
 type tqClient struct {
 	maxRetries int
 	*lfsapi.Client
+
+	// state is that of the manifest which created the client.
+	state *serverState
 }
 
 type batchRef struct {
@@ -26,8 +31,12 @@ type batchRequest struct {
 	TransferAdapterNames []string    `json:"transfers,omitempty"`
 	Ref                  *batchRef   `json:"ref"`
 	HashAlgorithm        string      `json:"hash_algo"`
+	// ctx, if set, is the context the HTTP request is made with, so that
+	// cancelling it abandons the request.
+	ctx context.Context
 }
 
+// BatchResponse is the server's answer to a batch request.
 type BatchResponse struct {
 	Objects             []*Transfer `json:"objects"`
 	TransferAdapterName string      `json:"transfer"`
@@ -35,22 +44,59 @@ type BatchResponse struct {
 	endpoint            lfshttp.Endpoint
 }
 
+// Batch asks the server of the remote "remote" how to transfer each of
+// "objects" in the direction "dir".
 func Batch(m Manifest, dir Direction, remote string, remoteRef *git.Ref, objects []*Transfer) (*BatchResponse, error) {
+	return batchWithContext(context.Background(), m, dir, remote, remoteRef, objects)
+}
+
+// batchWithContext is like Batch, but makes the request with the context
+// "ctx".
+func batchWithContext(ctx context.Context, m Manifest, dir Direction, remote string, remoteRef *git.Ref, objects []*Transfer) (*BatchResponse, error) {
+	if len(objects) == 0 {
+		return &BatchResponse{}, nil
+	}
+
+	cm := m.Upgrade()
+	bReq := newBatchRequest(m, dir, remoteRef, objects)
+	bReq.ctx = ctx
+
+	return cm.batchClient().Batch(remote, bReq)
+}
+
+// BatchToEndpoint is like Batch, but sends the request to the given endpoint
+// rather than the one configured for the remote. It is used for objects whose
+// paths are routed elsewhere with `lfs.route.<path>.url`.
+func BatchToEndpoint(m Manifest, e lfshttp.Endpoint, dir Direction, remote string, remoteRef *git.Ref, objects []*Transfer) (*BatchResponse, error) {
+	return batchToEndpointWithContext(context.Background(), m, e, dir, remote, remoteRef, objects)
+}
+
+// batchToEndpointWithContext is like BatchToEndpoint, but makes the request
+// with the context "ctx".
+func batchToEndpointWithContext(ctx context.Context, m Manifest, e lfshttp.Endpoint, dir Direction, remote string, remoteRef *git.Ref, objects []*Transfer) (*BatchResponse, error) {
 	if len(objects) == 0 {
 		return &BatchResponse{}, nil
 	}
 
 	cm := m.Upgrade()
+	client := &tqClient{Client: cm.APIClient(), maxRetries: cm.MaxRetries(), state: cm.state}
+	bReq := newBatchRequest(m, dir, remoteRef, objects)
+	bReq.ctx = ctx
 
-	return cm.batchClient().Batch(remote, &batchRequest{
+	return client.batch(remote, &e, bReq)
+}
+
+func newBatchRequest(m Manifest, dir Direction, remoteRef *git.Ref, objects []*Transfer) *batchRequest {
+	return &batchRequest{
 		Operation:            dir.String(),
 		Objects:              objects,
 		TransferAdapterNames: m.GetAdapterNames(dir),
 		Ref:                  &batchRef{Name: remoteRef.Refspec()},
 		HashAlgorithm:        "sha256",
-	})
+	}
 }
 
+// BatchClient sends batch requests, over HTTP or SSH.
 type BatchClient interface {
 	Batch(remote string, bReq *batchRequest) (*BatchResponse, error)
 	MaxRetries() int
@@ -66,6 +112,12 @@ func (c *tqClient) SetMaxRetries(n int) {
 }
 
 func (c *tqClient) Batch(remote string, bReq *batchRequest) (*BatchResponse, error) {
+	return c.batch(remote, nil, bReq)
+}
+
+// batch sends the batch request to the given route's endpoint or, if "route"
+// is nil, to the remote's endpoint.
+func (c *tqClient) batch(remote string, route *lfshttp.Endpoint, bReq *batchRequest) (*BatchResponse, error) {
 	bRes := &BatchResponse{}
 	if len(bReq.Objects) == 0 {
 		return bRes, nil
@@ -80,21 +132,40 @@ func (c *tqClient) Batch(remote string, bReq *batchRequest) (*BatchResponse, err
 		missing[obj.Oid] = obj.Missing
 	}
 
-	bRes.endpoint = c.Endpoints.Endpoint(bReq.Operation, remote)
-	requestedAt := time.Now()
-
-	req, err := c.NewRequest("POST", bRes.endpoint, "objects/batch", bReq)
-	if err != nil {
-		return nil, errors.Wrap(err, tr.Tr.Get("batch request"))
+	if route != nil {
+		bRes.endpoint = *route
+	} else {
+		bRes.endpoint = c.Endpoints.Endpoint(bReq.Operation, remote)
+	}
+	if route == nil && serverCapabilities(c.state, c.Client, bReq.Operation, remote).lacks(capBatch) {
+		// Rather than probe for the batch API and recognize its
+		// absence from the response, believe the server.
+		err := errors.New(tr.Tr.Get("Server at %s does not support the Git LFS batch API", bRes.endpoint.Url))
+		c.state.setBatchUnsupported(bRes.endpoint.Url, errors.Wrap(err, tr.Tr.Get("batch response")))
+	}
+	if err := c.state.batchUnsupported(bRes.endpoint.Url); err != nil {
+		tracerx.Printf("api: skipping batch to %s after earlier failure", bRes.endpoint.Url)
+		return nil, err
 	}
 
-	tracerx.Printf("api: batch %d files", len(bReq.Objects))
+	requestedAt := time.Now()
 
-	req = c.Client.LogRequest(req, "lfs.batch")
-	res, err := c.DoAPIRequestWithAuth(remote, lfshttp.WithRetries(req, c.MaxRetries()))
+	res, err := c.doBatch(remote, bRes.endpoint, route != nil, bReq)
+	if err != nil && route == nil && canFailOver(res, err) {
+		for _, mirror := range c.Endpoints.MirrorEndpoints(bReq.Operation, remote) {
+			tracerx.Printf("api: batch to %s failed, trying mirror %s: %s", bRes.endpoint.Url, mirror.Url, err)
+
+			bRes.endpoint = mirror
+			res, err = c.doBatch(remote, mirror, true, bReq)
+			if err == nil || !canFailOver(res, err) {
+				break
+			}
+		}
+	}
 	if err != nil {
 		tracerx.Printf("api error: %s", err)
-		return nil, errors.Wrap(err, tr.Tr.Get("batch response"))
+		err = errors.Wrap(c.unsupportedError(bRes.endpoint, res, err), tr.Tr.Get("batch response"))
+		return nil, err
 	}
 
 	if err := lfshttp.DecodeJSON(res, bRes); err != nil {
@@ -109,12 +180,81 @@ func (c *tqClient) Batch(remote string, bReq *batchRequest) (*BatchResponse, err
 		return nil, lfshttp.NewStatusCodeError(res)
 	}
 
+	objects := bRes.Objects[:0]
 	for _, obj := range bRes.Objects {
-		obj.Missing = missing[obj.Oid]
+		// Only objects which were asked about may be returned, so
+		// that a malicious server can't introduce arbitrary OIDs,
+		// which are used to build paths in the object store.
+		isMissing, requested := missing[obj.Oid]
+		if !requested {
+			tracerx.Printf("api: ignoring unrequested object %q in batch response", obj.Oid)
+			continue
+		}
+
+		obj.Missing = isMissing
 		for _, a := range obj.Actions {
 			a.createdAt = requestedAt
 		}
+		objects = append(objects, obj)
+	}
+	bRes.Objects = objects
+
+	if bReq.Operation == Upload.String() {
+		c.state.objects().remember(bRes)
 	}
 
 	return bRes, nil
 }
+
+// doBatch sends the batch request to the endpoint "e". If "direct" is true, the
+// endpoint is not the remote's own, but a route or mirror which may be on
+// another server entirely, so the access mode configured for its own URL is
+// used.
+func (c *tqClient) doBatch(remote string, e lfshttp.Endpoint, direct bool, bReq *batchRequest) (*http.Response, error) {
+	req, err := c.NewRequest("POST", e, "objects/batch", bReq)
+	if err != nil {
+		return nil, errors.Wrap(err, tr.Tr.Get("batch request"))
+	}
+	if bReq.ctx != nil {
+		req = req.WithContext(bReq.ctx)
+	}
+
+	tracerx.Printf("api: batch %d files", len(bReq.Objects))
+
+	req = lfshttp.WithRetries(c.Client.LogRequest(req, "lfs.batch"), c.MaxRetries())
+	if direct {
+		return c.DoWithAuth(remote, c.Endpoints.AccessFor(e.Url), req)
+	}
+	return c.DoAPIRequestWithAuth(remote, req)
+}
+
+// canFailOver returns whether a batch request which failed with "err" and
+// response "res" may be sent to one of the endpoint's mirrors instead: if the
+// server could not be reached, or failed with a server error.
+func canFailOver(res *http.Response, err error) bool {
+	if res == nil {
+		return !errors.IsAuthError(err)
+	}
+	return res.StatusCode >= 500 && res.StatusCode != 501
+}
+
+// unsupportedError returns the error to report when a batch request to the
+// given endpoint fails with "err" and response "res". If the server responded
+// that the batch API is not available there, the error is remembered for any
+// later batches to the same endpoint.
+func (c *tqClient) unsupportedError(e lfshttp.Endpoint, res *http.Response, err error) error {
+	if res == nil {
+		return err
+	}
+
+	switch res.StatusCode {
+	case 404:
+	case 406:
+		err = errors.Wrap(err, tr.Tr.Get("Server at %s does not support the Git LFS batch API", e.Url))
+	default:
+		return err
+	}
+
+	c.state.setBatchUnsupported(e.Url, errors.Wrap(err, tr.Tr.Get("batch response")))
+	return err
+}
@@ -142,6 +142,99 @@ func TestAPIBatchEmptyObjects(t *testing.T) {
 	assert.Equal(t, 0, len(bRes.Objects))
 }
 
+func TestAPIBatchResponseSchemaFallback(t *testing.T) {
+	require.NotNil(t, batchResSchema.Schema, batchResSchema.Source)
+
+	assertSchema(t, batchResSchema, gojsonschema.NewStringLoader(`{"objects": [{
+		"oid": "a", "size": 1,
+		"actions": {"upload": {
+			"href": "https://storage.example.com/a",
+			"fallback": {
+				"href": "https://api.example.com/a",
+				"header": {"Authorization": "Token"},
+				"expires_in": 3600
+			}
+		}}
+	}]}`))
+
+	// A fallback may not have a fallback of its own.
+	res, err := batchResSchema.Validate(gojsonschema.NewStringLoader(`{"objects": [{
+		"oid": "a", "size": 1,
+		"actions": {"upload": {
+			"href": "https://storage.example.com/a",
+			"fallback": {
+				"href": "https://api.example.com/a",
+				"fallback": {"href": "https://other.example.com/a"}
+			}
+		}}
+	}]}`))
+	require.Nil(t, err)
+	assert.False(t, res.Valid())
+}
+
+func TestAPIBatchResponseSchemaObjectMeta(t *testing.T) {
+	require.NotNil(t, batchResSchema.Schema, batchResSchema.Source)
+
+	assertSchema(t, batchResSchema, gojsonschema.NewStringLoader(`{"objects": [{
+		"oid": "a", "size": 1,
+		"storage_class": "archive",
+		"attributes": {"source": "import", "revision": 3}
+	}]}`))
+}
+
+func TestAPIBatchResponseSchemaCheckpoints(t *testing.T) {
+	require.NotNil(t, batchResSchema.Schema, batchResSchema.Source)
+
+	assertSchema(t, batchResSchema, gojsonschema.NewStringLoader(`{"objects": [{
+		"oid": "a", "size": 3,
+		"actions": {"download": {
+			"href": "https://storage.example.com/a",
+			"checkpoints": {
+				"size": 2,
+				"hashes": [
+					"2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824",
+					"e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+				]
+			}
+		}}
+	}]}`))
+
+	// Each checkpoint must be a SHA-256 hash.
+	res, err := batchResSchema.Validate(gojsonschema.NewStringLoader(`{"objects": [{
+		"oid": "a", "size": 3,
+		"actions": {"download": {
+			"href": "https://storage.example.com/a",
+			"checkpoints": {"size": 2, "hashes": ["abc"]}
+		}}
+	}]}`))
+	require.Nil(t, err)
+	assert.False(t, res.Valid())
+}
+
+func TestAPIBatchResponseSchemaDeltas(t *testing.T) {
+	require.NotNil(t, batchResSchema.Schema, batchResSchema.Source)
+
+	assertSchema(t, batchResSchema, gojsonschema.NewStringLoader(`{"objects": [{
+		"oid": "a", "size": 1,
+		"actions": {
+			"download": {"href": "https://storage.example.com/a"},
+			"download-delta": {
+				"href": "https://storage.example.com/a/delta",
+				"header": {"LFS-Delta-Base": "b"}
+			}
+		}
+	}, {
+		"oid": "c", "size": 1,
+		"actions": {
+			"upload": {"href": "https://storage.example.com/c"},
+			"upload-delta": {
+				"href": "https://storage.example.com/c/delta",
+				"header": {"LFS-Delta-Base": "b"}
+			}
+		}
+	}]}`))
+}
+
 var (
 	batchReqSchema *sourcedSchema
 	batchResSchema *sourcedSchema
@@ -187,3 +280,82 @@ func assertSchema(t *testing.T, schema *sourcedSchema, dataLoader gojsonschema.J
 		t.Errorf("Schema: %s\n%s", schema.Source, strings.Join(valErrors, "\n"))
 	}
 }
+
+func TestAPIBatchRemembersUnsupportedEndpoint(t *testing.T) {
+	var requests int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(406)
+	}))
+	defer srv.Close()
+
+	c, err := lfsapi.NewClient(lfshttp.NewContext(nil, nil, map[string]string{
+		"lfs.url": srv.URL + "/api",
+	}))
+	require.Nil(t, err)
+
+	tqc := &tqClient{Client: c, state: newServerState()}
+	for i := 0; i < 2; i++ {
+		_, err = tqc.Batch("remote", &batchRequest{
+			Operation: "download",
+			Objects:   []*Transfer{&Transfer{Oid: "a", Size: 1}},
+		})
+		require.NotNil(t, err)
+		assert.Contains(t, err.Error(), "does not support the Git LFS batch API")
+	}
+	assert.Equal(t, 1, requests)
+
+	// What one manifest's client has been told is not known to another's.
+	tqc = &tqClient{Client: c, state: newServerState()}
+	_, err = tqc.Batch("remote", &batchRequest{
+		Operation: "download",
+		Objects:   []*Transfer{&Transfer{Oid: "a", Size: 1}},
+	})
+	require.NotNil(t, err)
+	assert.Equal(t, 2, requests)
+}
+
+func TestAPIBatchFailsOverToMirror(t *testing.T) {
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(503)
+	}))
+	defer primary.Close()
+
+	mirror := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/objects/batch" {
+			w.WriteHeader(404)
+			return
+		}
+
+		bReq := &batchRequest{}
+		err := json.NewDecoder(r.Body).Decode(bReq)
+		r.Body.Close()
+		assert.Nil(t, err)
+
+		w.Header().Set("Content-Type", "application/json")
+		err = json.NewEncoder(w).Encode(&BatchResponse{Objects: bReq.Objects})
+		assert.Nil(t, err)
+	}))
+	defer mirror.Close()
+
+	c, err := lfsapi.NewClient(lfshttp.NewContext(nil, nil, map[string]string{
+		"lfs.url":    primary.URL + "/api",
+		"lfs.mirror": mirror.URL + "/api",
+	}))
+	require.Nil(t, err)
+
+	tqc := &tqClient{Client: c}
+	bRes, err := tqc.Batch("remote", &batchRequest{
+		Operation: "download",
+		Objects:   []*Transfer{&Transfer{Oid: "a", Size: 1}},
+	})
+	require.Nil(t, err)
+	assert.Equal(t, mirror.URL+"/api", bRes.endpoint.Url)
+	assert.Len(t, bRes.Objects, 1)
+
+	_, err = tqc.Batch("remote", &batchRequest{
+		Operation: "upload",
+		Objects:   []*Transfer{&Transfer{Oid: "a", Size: 1}},
+	})
+	assert.NotNil(t, err)
+}
@@ -1,6 +1,7 @@
 package tq
 
 import (
+	"encoding/hex"
 	"fmt"
 	"hash"
 	"io"
@@ -76,9 +77,20 @@ func (a *basicDownloadAdapter) DoTransfer(ctx interface{}, t *Transfer, cb Progr
 		return err
 	}
 
-	// Ensure that partial file seems valid
+	// Ensure that partial file seems valid, and that the server can
+	// resume from it
 	if fromByte > 0 {
-		if fromByte < t.Size-1 {
+		if serverCapabilities(a.state, a.apiClient, Download.String(), a.remote).lacks(capRangeDownload) {
+			tracerx.Printf("xfer: server does not support range downloads; downloading %q from the start", t.Oid)
+			if _, err := f.Seek(0, io.SeekStart); err != nil {
+				return err
+			}
+			if err := f.Truncate(0); err != nil {
+				return err
+			}
+			fromByte = 0
+			hash = nil
+		} else if fromByte < t.Size-1 {
 			tracerx.Printf("xfer: Attempting to resume download of %q from byte %d", t.Oid, fromByte)
 		} else {
 			// Somehow we have more data than expected. Let's retry from the beginning.
@@ -93,9 +105,31 @@ func (a *basicDownloadAdapter) DoTransfer(ctx interface{}, t *Transfer, cb Progr
 		}
 	}
 
+	if fromByte == 0 {
+		if rel, basePath, ok := a.deltaAction(t, "download-delta"); ok {
+			authOkFunc = onceFunc(authOkFunc)
+			err := a.downloadDelta(t, cb, authOkFunc, f, rel, basePath)
+			if err == nil {
+				return nil
+			}
+
+			// Whatever went wrong, the object can still be
+			// downloaded in full.
+			tracerx.Printf("xfer: delta download of %q failed, downloading in full: %s", t.Oid, err)
+			if _, err := f.Seek(0, io.SeekStart); err != nil {
+				return err
+			}
+			if err := f.Truncate(0); err != nil {
+				return err
+			}
+		}
+	}
+
 	err = a.download(t, cb, authOkFunc, f, fromByte, hash)
 
-	if err != nil {
+	// A cancelled download is not kept to be resumed, since it may have
+	// been cancelled because it is no longer wanted.
+	if err != nil && a.context().Err() == nil {
 		f.Close()
 		// Rename file so next download can resume from where we stopped.
 		// No error checking here, if rename fails then file will be deleted and there just will be no download resuming
@@ -120,18 +154,12 @@ func (a *basicDownloadAdapter) download(t *Transfer, cb ProgressCallback, authOk
 		return errors.Errorf(tr.Tr.Get("Object %s not found on the server.", t.Oid))
 	}
 
-	req, err := a.newHTTPRequest("GET", rel)
-	if err != nil {
-		return err
-	}
-
-	if fromByte > 0 {
-		// We could just use a start byte, but since we know the length be specific
-		req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", fromByte, t.Size-1))
-	}
-
-	req = a.apiClient.LogRequest(req, "lfs.data.download")
-	res, err := a.makeRequest(t, req)
+	res, err := a.makeMirroredRequest(t, rel, func(req *http.Request) {
+		if fromByte > 0 {
+			// We could just use a start byte, but since we know the length be specific
+			req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", fromByte, t.Size-1))
+		}
+	})
 	if err != nil {
 		if res == nil {
 			// We encountered a network or similar error which caused us
@@ -153,7 +181,7 @@ func (a *basicDownloadAdapter) download(t *Transfer, cb ProgressCallback, authOk
 
 		// Special-cae status code 429 - retry after certain time
 		if res.StatusCode == 429 {
-			retLaterErr := errors.NewRetriableLaterError(err, res.Header["Retry-After"][0])
+			retLaterErr := errors.NewRetriableLaterError(err, res.Header.Get("Retry-After"))
 			if retLaterErr != nil {
 				return retLaterErr
 			}
@@ -164,6 +192,12 @@ func (a *basicDownloadAdapter) download(t *Transfer, cb ProgressCallback, authOk
 
 	defer res.Body.Close()
 
+	// The server has the object, but must restore it from cold storage
+	// before it can be downloaded.
+	if res.StatusCode == http.StatusAccepted {
+		return newRestorePendingError(t.Oid, res)
+	}
+
 	// Range request must return 206 & content range to confirm
 	if fromByte > 0 {
 		rangeRequestOk := false
@@ -216,6 +250,20 @@ func (a *basicDownloadAdapter) download(t *Transfer, cb ProgressCallback, authOk
 		}
 	}
 
+	var writer io.Writer = dlFile
+	if rel.Checkpoints.valid(t.Size) {
+		verifier, err := newCheckpointVerifier(rel.Checkpoints, t.Size, fromByte, dlFile)
+		if err != nil {
+			return err
+		}
+		writer = io.MultiWriter(dlFile, verifier)
+	} else if t.stream != nil {
+		// Data which may yet be discarded as corrupt by a checkpoint
+		// isn't streamed; the queue writes it from the object once
+		// the download is complete instead.
+		writer = io.MultiWriter(dlFile, t.stream.from(fromByte))
+	}
+
 	// Signal auth OK on success response, before starting download to free up
 	// other workers immediately
 	if authOkFunc != nil {
@@ -240,7 +288,16 @@ func (a *basicDownloadAdapter) download(t *Transfer, cb ProgressCallback, authOk
 		}
 		return nil
 	}
-	written, err := tools.CopyWithCallback(dlFile, hasher, res.ContentLength, ccb)
+	written, err := tools.CopyWithCallback(writer, hasher, res.ContentLength, ccb)
+	if cperr, ok := err.(*checkpointError); ok {
+		// Discard the corrupt chunk, so that the retried download
+		// resumes from the end of the last one which was verified.
+		tracerx.Printf("xfer: discarding data of %q from byte %d: %s", t.Oid, cperr.verified, cperr)
+		if err := dlFile.Truncate(cperr.verified); err != nil {
+			return err
+		}
+		return errors.NewRetriableError(cperr)
+	}
 	if err != nil {
 		return errors.Wrapf(err, tr.Tr.Get("cannot write data to temporary file %q", dlfilename))
 	}
@@ -249,16 +306,64 @@ func (a *basicDownloadAdapter) download(t *Transfer, cb ProgressCallback, authOk
 		return errors.New(tr.Tr.Get("expected OID %s, got %s after %d bytes written", t.Oid, actual, written))
 	}
 
+	return a.finishDownload(t, dlFile)
+}
+
+// downloadDelta downloads a delta against the local object at "basePath" from
+// the "download-delta" action "rel", and applies it to reconstruct the object
+// in dlFile, which is expected to be empty.
+func (a *basicDownloadAdapter) downloadDelta(t *Transfer, cb ProgressCallback, authOkFunc func(), dlFile *os.File, rel *Action, basePath string) error {
+	base, err := os.Open(basePath)
+	if err != nil {
+		return err
+	}
+	defer base.Close()
+
+	stat, err := base.Stat()
+	if err != nil {
+		return err
+	}
+
+	req, err := a.newHTTPRequest("GET", rel)
+	if err != nil {
+		return err
+	}
+
+	req = a.apiClient.LogRequest(req, "lfs.data.download")
+	res, err := a.makeRequest(t, req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if authOkFunc != nil {
+		authOkFunc()
+	}
+
+	hash := tools.NewLfsContentHash()
+	if err := applyDelta(base, stat.Size(), res.Body, io.MultiWriter(dlFile, hash)); err != nil {
+		return err
+	}
+
+	if actual := hex.EncodeToString(hash.Sum(nil)); actual != t.Oid {
+		return errors.New(tr.Tr.Get("expected OID %s, got %s after applying delta", t.Oid, actual))
+	}
+
+	tracerx.Printf("xfer: downloaded %q as a delta against %q", t.Oid, filepath.Base(basePath))
+	advanceCallbackProgress(cb, t, t.Size)
+
+	return a.finishDownload(t, dlFile)
+}
+
+// finishDownload closes the completely downloaded dlFile, and moves it into
+// the local object store as the transfer's object.
+func (a *basicDownloadAdapter) finishDownload(t *Transfer, dlFile *os.File) error {
+	dlfilename := dlFile.Name()
 	if err := dlFile.Close(); err != nil {
 		return errors.New(tr.Tr.Get("can't close temporary file %q: %v", dlfilename, err))
 	}
 
-	err = tools.RenameFileCopyPermissions(dlfilename, t.Path)
-	if _, err2 := os.Stat(t.Path); err2 == nil {
-		// Target file already exists, possibly was downloaded by other git-lfs process
-		return nil
-	}
-	return err
+	return a.storeObject(t, dlfilename)
 }
 
 func configureBasicDownloadAdapter(m *concreteManifest) {
@@ -276,6 +381,39 @@ func configureBasicDownloadAdapter(m *concreteManifest) {
 	})
 }
 
+// makeMirroredRequest makes a GET request of the action "rel", after passing it
+// to "prepare". If the server cannot be reached or fails with a server error,
+// the request is made of each of the action's mirrors in turn, and the result
+// of the last is returned. Any other failure, such as a 404 or 403 response,
+// is returned immediately, since a mirror would answer it the same way.
+func (a *basicDownloadAdapter) makeMirroredRequest(t *Transfer, rel *Action, prepare func(*http.Request)) (*http.Response, error) {
+	hrefs := rel.hrefs()
+
+	var res *http.Response
+	for i, href := range hrefs {
+		mirror := *rel
+		mirror.Href = href
+
+		req, err := a.newHTTPRequest("GET", &mirror)
+		if err != nil {
+			return nil, err
+		}
+		prepare(req)
+
+		req = a.apiClient.LogRequest(req, "lfs.data.download")
+		res, err = a.makeRequest(t, req)
+		if err == nil || i == len(hrefs)-1 || !canFailOver(res, err) {
+			return res, err
+		}
+
+		tracerx.Printf("xfer: download of %q from %s failed, trying mirror %s: %s", t.Oid, href, hrefs[i+1], err)
+		if res != nil {
+			res.Body.Close()
+		}
+	}
+	return res, nil
+}
+
 func (a *basicDownloadAdapter) makeRequest(t *Transfer, req *http.Request) (*http.Response, error) {
 	res, err := a.doHTTP(t, req)
 	if errors.IsAuthError(err) && len(req.Header.Get("Authorization")) == 0 {
@@ -0,0 +1,95 @@
+package tq
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/git-lfs/git-lfs/v3/fs"
+	"github.com/git-lfs/git-lfs/v3/lfsapi"
+	"github.com/git-lfs/git-lfs/v3/lfshttp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMirroredRequestFailsOverOnlyOnServerErrors(t *testing.T) {
+	for status, failsOver := range map[int]bool{
+		403: false,
+		404: false,
+		500: true,
+		503: true,
+	} {
+		var mirrored bool
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/mirror" {
+				mirrored = true
+				w.WriteHeader(200)
+				return
+			}
+			w.WriteHeader(status)
+		}))
+
+		c, err := lfsapi.NewClient(lfshttp.NewContext(nil, nil, nil))
+		require.Nil(t, err)
+
+		a := &basicDownloadAdapter{newAdapterBase(nil, BasicAdapterName, Download, nil)}
+		a.apiClient = c
+
+		rel := &Action{Href: srv.URL + "/primary", Mirrors: []string{srv.URL + "/mirror"}}
+		res, err := a.makeMirroredRequest(&Transfer{Oid: "oid", Authenticated: true}, rel, func(*http.Request) {})
+		if res != nil {
+			res.Body.Close()
+		}
+		srv.Close()
+
+		assert.Equal(t, failsOver, mirrored, "status %d", status)
+		if failsOver {
+			assert.Nil(t, err, "status %d", status)
+		} else if assert.NotNil(t, res, "status %d", status) {
+			assert.Equal(t, status, res.StatusCode)
+		}
+	}
+}
+
+func TestCancelledDownloadLeavesNoPartialFile(t *testing.T) {
+	started := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", "5")
+		w.Write([]byte("hel"))
+		w.(http.Flusher).Flush()
+		close(started)
+		<-r.Context().Done()
+	}))
+	defer srv.Close()
+
+	c, err := lfsapi.NewClient(lfshttp.NewContext(nil, nil, nil))
+	require.Nil(t, err)
+	f := fs.New(c.OSEnv(), t.TempDir(), "", "", 0644)
+
+	a := &basicDownloadAdapter{newAdapterBase(f, BasicAdapterName, Download, nil)}
+	a.transferImpl = a
+
+	ctx, cancel := context.WithCancel(context.Background())
+	require.Nil(t, a.Begin(&adapterConfig{apiClient: c, concurrentTransfers: 1, ctx: ctx}, nil))
+
+	results := a.Add(&Transfer{
+		Oid:           "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824",
+		Size:          5,
+		Authenticated: true,
+		Path:          filepath.Join(t.TempDir(), "object"),
+		Actions:       ActionSet{"download": &Action{Href: srv.URL}},
+	})
+	<-started
+	cancel()
+	a.End()
+
+	res := <-results
+	assert.Equal(t, context.Canceled, res.Error)
+
+	entries, err := ioutil.ReadDir(a.tempDir())
+	require.Nil(t, err)
+	assert.Empty(t, entries)
+}
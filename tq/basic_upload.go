@@ -1,9 +1,13 @@
 package tq
 
 import (
+	"bytes"
+	"crypto/md5"
+	"encoding/base64"
 	"io"
 	"io/ioutil"
 	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
 	"strconv"
@@ -14,6 +18,7 @@ import (
 	"github.com/git-lfs/git-lfs/v3/lfsapi"
 	"github.com/git-lfs/git-lfs/v3/tools"
 	"github.com/git-lfs/git-lfs/v3/tr"
+	"github.com/rubyist/tracerx"
 )
 
 const (
@@ -42,6 +47,18 @@ func (a *basicUploadAdapter) WorkerEnding(workerNum int, ctx interface{}) {
 }
 
 func (a *basicUploadAdapter) DoTransfer(ctx interface{}, t *Transfer, cb ProgressCallback, authOkFunc func()) error {
+	if rel, basePath, ok := a.deltaAction(t, "upload-delta"); ok {
+		authOkFunc = onceFunc(authOkFunc)
+		err := a.uploadDelta(t, cb, authOkFunc, rel, basePath)
+		if err == nil {
+			return verifyUpload(a.state, a.apiClient, a.remote, t)
+		}
+
+		// Whatever went wrong, the object can still be uploaded in
+		// full.
+		tracerx.Printf("xfer: delta upload of %q failed, uploading in full: %s", t.Oid, err)
+	}
+
 	rel, err := t.Rel("upload")
 	if err != nil {
 		return err
@@ -50,12 +67,44 @@ func (a *basicUploadAdapter) DoTransfer(ctx interface{}, t *Transfer, cb Progres
 		return errors.Errorf(tr.Tr.Get("No upload action for object: %s", t.Oid))
 	}
 
+	// Both the action and its fallback may begin the upload, but only
+	// the first should signal that authentication succeeded.
+	authOkFunc = onceFunc(authOkFunc)
+
+	routes := a.state.routes()
+	actions := routes.order(rel)
+	for i, action := range actions {
+		unreachable, err := a.upload(t, action, cb, authOkFunc)
+		if err == nil {
+			routes.record(rel, action)
+			return verifyUpload(a.state, a.apiClient, a.remote, t)
+		}
+		if !unreachable || i == len(actions)-1 {
+			return err
+		}
+
+		tracerx.Printf("xfer: unable to reach %s to upload %q, trying %s: %s",
+			action.host(), t.Oid, actions[i+1].host(), err)
+	}
+	return nil
+}
+
+// upload sends the object of "t" with a single PUT request for the action
+// "rel". If no response was received at all, as when a firewall blocks the
+// action's host, "unreachable" is true as well as "err" being set.
+func (a *basicUploadAdapter) upload(t *Transfer, rel *Action, cb ProgressCallback, authOkFunc func()) (unreachable bool, err error) {
 	req, err := a.newHTTPRequest("PUT", rel)
 	if err != nil {
-		return err
+		return false, err
 	}
 
-	if req.Header.Get("Transfer-Encoding") == "chunked" {
+	compat := a.storageCompat(req)
+	if compat {
+		tracerx.Printf("xfer: uploading %q in storage compatibility mode", t.Oid)
+	}
+
+	chunked := a.chunkedUpload(req, compat)
+	if chunked {
 		req.TransferEncoding = []string{"chunked"}
 	} else {
 		req.Header.Set("Content-Length", strconv.FormatInt(t.Size, 10))
@@ -65,25 +114,68 @@ func (a *basicUploadAdapter) DoTransfer(ctx interface{}, t *Transfer, cb Progres
 
 	f, err := os.OpenFile(t.Path, os.O_RDONLY, 0644)
 	if err != nil {
-		return errors.Wrap(err, tr.Tr.Get("basic upload"))
+		return false, errors.Wrap(err, tr.Tr.Get("basic upload"))
 	}
 	defer f.Close()
 
-	if err := a.setContentTypeFor(req, f); err != nil {
-		return err
+	if compat {
+		if err := setStorageCompatHeaders(req, f); err != nil {
+			return false, err
+		}
+	} else if err := a.setContentTypeFor(req, f); err != nil {
+		return false, err
+	}
+
+	body, bodySize := f, t.Size
+	var stream Codec
+	if codec := a.compressUpload(req, compat); codec != nil {
+		if chunked {
+			// Storage which accepts chunked uploads doesn't need
+			// to know the length of the body up front, so compress
+			// the object as it is sent.
+			tracerx.Printf("tq: streaming compressed upload of %d bytes with %s", t.Size, codec.Name())
+			req.ContentLength = -1
+			stream = codec
+		} else {
+			body, bodySize, err = a.compressToTempFile(req, codec, f, t.Size)
+			if err != nil {
+				return false, err
+			}
+		}
+	}
+	if body != f {
+		defer func() {
+			body.Close()
+			os.Remove(body.Name())
+		}()
+
+		req.ContentLength = bodySize
+		req.Header.Set("Content-Length", strconv.FormatInt(bodySize, 10))
 	}
 
 	// Ensure progress callbacks made while uploading
 	// Wrap callback to give name context
+	var reported int64
 	ccb := func(totalSize int64, readSoFar int64, readSinceLast int) error {
-		if cb != nil {
-			return cb(t.Name, totalSize, readSoFar, readSinceLast)
+		if cb == nil {
+			return nil
 		}
-		return nil
+		if bodySize != t.Size && bodySize > 0 {
+			// Report progress in terms of the object's own
+			// size, rather than that of the compressed body.
+			readSoFar = int64(float64(readSoFar) / float64(bodySize) * float64(t.Size))
+			readSinceLast = int(readSoFar - reported)
+			totalSize = t.Size
+		}
+		reported = readSoFar
+		return cb(t.Name, totalSize, readSoFar, readSinceLast)
 	}
 
-	cbr := tools.NewFileBodyWithCallback(f, t.Size, ccb)
+	cbr := tools.NewFileBodyWithCallback(body, bodySize, ccb)
 	var reader lfsapi.ReadSeekCloser = cbr
+	if stream != nil {
+		reader = newCompressReader(reader, stream)
+	}
 
 	// Signal auth was ok on first read; this frees up other workers to start
 	if authOkFunc != nil {
@@ -96,7 +188,7 @@ func (a *basicUploadAdapter) DoTransfer(ctx interface{}, t *Transfer, cb Progres
 	req.Body = reader
 
 	req = a.apiClient.LogRequest(req, "lfs.data.upload")
-	res, err := a.makeRequest(t, req)
+	res, err := a.makeRequest(t, req, body.Name(), bodySize, stream)
 	if err != nil {
 		if errors.IsUnprocessableEntityError(err) {
 			// If we got an HTTP 422, we do _not_ want to retry the
@@ -106,7 +198,7 @@ func (a *basicUploadAdapter) DoTransfer(ctx interface{}, t *Transfer, cb Progres
 			//
 			// Instead, return immediately and wait for the
 			// *tq.TransferQueue to report an error message.
-			return err
+			return false, err
 		}
 
 		// We're about to return a retriable error, meaning that this
@@ -118,31 +210,32 @@ func (a *basicUploadAdapter) DoTransfer(ctx interface{}, t *Transfer, cb Progres
 		if perr := cbr.ResetProgress(); perr != nil {
 			err = errors.Wrap(err, perr.Error())
 		}
+		reported = 0
 
 		if res == nil {
 			// We encountered a network or similar error which caused us
 			// to not receive a response at all.
-			return errors.NewRetriableError(err)
+			return true, errors.NewRetriableError(err)
 		}
 
 		if res.StatusCode == 429 {
-			retLaterErr := errors.NewRetriableLaterError(err, res.Header["Retry-After"][0])
+			retLaterErr := errors.NewRetriableLaterError(err, res.Header.Get("Retry-After"))
 			if retLaterErr != nil {
-				return retLaterErr
+				return false, retLaterErr
 			}
 		}
-		return errors.NewRetriableError(err)
+		return false, errors.NewRetriableError(err)
 	}
 
 	// A status code of 403 likely means that an authentication token for the
 	// upload has expired. This can be safely retried.
 	if res.StatusCode == 403 {
 		err = errors.New(tr.Tr.Get("Received status %d", res.StatusCode))
-		return errors.NewRetriableError(err)
+		return false, errors.NewRetriableError(err)
 	}
 
 	if res.StatusCode > 299 {
-		return errors.Wrapf(nil, tr.Tr.Get("Invalid status for %s %s: %d",
+		return false, errors.Wrapf(nil, tr.Tr.Get("Invalid status for %s %s: %d",
 			req.Method,
 			strings.SplitN(req.URL.String(), "?", 2)[0],
 			res.StatusCode,
@@ -152,10 +245,28 @@ func (a *basicUploadAdapter) DoTransfer(ctx interface{}, t *Transfer, cb Progres
 	io.Copy(ioutil.Discard, res.Body)
 	res.Body.Close()
 
-	return verifyUpload(a.apiClient, a.remote, t)
+	return false, nil
 }
 
 func (a *adapterBase) setContentTypeFor(req *http.Request, r io.ReadSeeker) error {
+	return a.setContentTypeFrom(req, func() ([]byte, error) {
+		buffer := make([]byte, 512)
+		n, err := r.Read(buffer)
+		if err != nil && err != io.EOF {
+			return nil, errors.Wrap(err, tr.Tr.Get("content type detection error"))
+		}
+
+		if _, err := r.Seek(0, io.SeekStart); err != nil {
+			return nil, errors.Wrap(err, tr.Tr.Get("content type rewind failure"))
+		}
+		return buffer[:n], nil
+	})
+}
+
+// setContentTypeFrom sets the Content-Type header of "req", unless the action
+// gave one, to the type detected from the first bytes of the object, which
+// "head" returns.
+func (a *adapterBase) setContentTypeFrom(req *http.Request, head func() ([]byte, error)) error {
 	uc := config.NewURLConfig(a.apiClient.GitEnv())
 	disabled := !uc.Bool("lfs", req.URL.String(), "contenttype", true)
 	if len(req.Header.Get("Content-Type")) != 0 {
@@ -165,16 +276,12 @@ func (a *adapterBase) setContentTypeFor(req *http.Request, r io.ReadSeeker) erro
 	var contentType string
 
 	if !disabled {
-		buffer := make([]byte, 512)
-		n, err := r.Read(buffer)
-		if err != nil && err != io.EOF {
-			return errors.Wrap(err, tr.Tr.Get("content type detection error"))
+		buffer, err := head()
+		if err != nil {
+			return err
 		}
 
-		contentType = http.DetectContentType(buffer[:n])
-		if _, err := r.Seek(0, io.SeekStart); err != nil {
-			return errors.Wrap(err, tr.Tr.Get("content type rewind failure"))
-		}
+		contentType = http.DetectContentType(buffer)
 	}
 
 	if contentType == "" {
@@ -185,6 +292,246 @@ func (a *adapterBase) setContentTypeFor(req *http.Request, r io.ReadSeeker) erro
 	return nil
 }
 
+// uploadDelta uploads a delta of the object against the local object at
+// "basePath" to the "upload-delta" action "rel". It returns an error without
+// uploading anything if the delta would be no smaller than the object itself.
+func (a *basicUploadAdapter) uploadDelta(t *Transfer, cb ProgressCallback, authOkFunc func(), rel *Action, basePath string) error {
+	base, err := os.Open(basePath)
+	if err != nil {
+		return err
+	}
+	defer base.Close()
+
+	stat, err := base.Stat()
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(t.Path, os.O_RDONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	delta, err := tools.TempFile(a.tempDir(), "delta-upload", a.fs)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		delta.Close()
+		os.Remove(delta.Name())
+	}()
+
+	if err := writeDelta(base, stat.Size(), f, delta); err != nil {
+		return err
+	}
+
+	size, err := delta.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+	if size >= t.Size {
+		return errors.New(tr.Tr.Get("delta of %d bytes is no smaller than the object", size))
+	}
+	if _, err := delta.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	req, err := a.newHTTPRequest("PUT", rel)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+	req.Header.Set("Content-Length", strconv.FormatInt(size, 10))
+
+	var body lfsapi.ReadSeekCloser = tools.NewBodyWithCallback(delta, size, nil)
+	if authOkFunc != nil {
+		body = newStartCallbackReader(body, func() error {
+			authOkFunc()
+			return nil
+		})
+	}
+	req.Body = body
+
+	req = a.apiClient.LogRequest(req, "lfs.data.upload")
+	res, err := a.makeRequest(t, req, delta.Name(), size, nil)
+	if err != nil {
+		return err
+	}
+	io.Copy(ioutil.Discard, res.Body)
+	res.Body.Close()
+
+	if res.StatusCode > 299 {
+		return errors.New(tr.Tr.Get("Received status %d", res.StatusCode))
+	}
+
+	tracerx.Printf("xfer: uploaded %q as a delta of %d bytes against %q", t.Oid, size, filepath.Base(basePath))
+	advanceCallbackProgress(cb, t, t.Size)
+	return nil
+}
+
+// storageCompat returns whether uploads to the URL of "req" should be made as
+// storage services such as Amazon S3 and Google Cloud Storage require of
+// presigned URLs, as set by lfs.<url>.storagecompat.
+func (a *basicUploadAdapter) storageCompat(req *http.Request) bool {
+	uc := config.NewURLConfig(a.apiClient.GitEnv())
+	return uc.Bool("lfs", req.URL.String(), "storagecompat", false)
+}
+
+// chunkedUpload returns whether the body of the upload request "req" may be
+// sent with chunked transfer encoding, and so without knowing its length up
+// front, whether or not it is compressed. Uploads in storage compatibility
+// mode, as given by "compat", are never chunked.
+//
+// The upload action may ask for chunked uploads itself. Otherwise, the LFS
+// server's "chunked-upload" capability is only trusted for uploads to the
+// server itself, since an action may send the object to storage elsewhere,
+// such as a presigned URL, which the server can't speak for.
+func (a *basicUploadAdapter) chunkedUpload(req *http.Request, compat bool) bool {
+	if compat {
+		return false
+	}
+	if req.Header.Get("Transfer-Encoding") == "chunked" {
+		return true
+	}
+
+	endpoint := a.apiClient.Endpoints.Endpoint(Upload.String(), a.remote)
+	u, err := url.Parse(endpoint.Url)
+	if err != nil || !strings.EqualFold(u.Scheme, req.URL.Scheme) || !strings.EqualFold(u.Host, req.URL.Host) {
+		return false
+	}
+	return serverCapabilities(a.state, a.apiClient, Upload.String(), a.remote).has(capChunkedUpload)
+}
+
+// setStorageCompatHeaders prepares the headers of the upload request "req",
+// whose body is "r", for storage which checks them against a presigned URL's
+// signature. The Content-Type given by the upload action is sent as-is, or
+// none if it gives none, since a detected type may not match the one which
+// was signed. A Content-MD5 header is computed unless the action gives one,
+// so that the storage can verify the body, and any "Expect: 100-continue"
+// header is removed, since some storage rejects it.
+func setStorageCompatHeaders(req *http.Request, r io.ReadSeeker) error {
+	req.Header.Del("Expect")
+	if len(req.Header.Get("Content-MD5")) > 0 {
+		return nil
+	}
+
+	h := md5.New()
+	if _, err := io.Copy(h, r); err != nil {
+		return errors.Wrap(err, tr.Tr.Get("unable to compute Content-MD5"))
+	}
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return errors.Wrap(err, tr.Tr.Get("unable to compute Content-MD5"))
+	}
+
+	req.Header.Set("Content-MD5", base64.StdEncoding.EncodeToString(h.Sum(nil)))
+	return nil
+}
+
+// compressUpload returns the codec the body of the upload request "req"
+// should be compressed with, or nil if it should be sent as-is. Uploads in
+// storage compatibility mode, as given by "compat", are never compressed, and
+// keep their headers as the server gave them, since they may be covered by
+// the signature of a presigned URL.
+//
+// Storage servers advertise the compressed uploads they accept by including a
+// Content-Encoding header in the upload action, listing one or more encodings
+// in order of preference, such as "Content-Encoding: zstd, gzip". The first
+// which names a registered codec suited to the object's content type is
+// chosen, and the header is set to its name alone. Otherwise, any
+// Content-Encoding header is removed.
+func (a *basicUploadAdapter) compressUpload(req *http.Request, compat bool) Codec {
+	if compat {
+		return nil
+	}
+
+	encodings := req.Header.Get("Content-Encoding")
+	if len(encodings) == 0 {
+		return nil
+	}
+	req.Header.Del("Content-Encoding")
+
+	uc := config.NewURLConfig(a.apiClient.GitEnv())
+	if !uc.Bool("lfs", req.URL.String(), "compressuploads", true) {
+		return nil
+	}
+
+	codec := negotiateCodec(encodings, req.Header.Get("Content-Type"))
+	if codec != nil {
+		req.Header.Set("Content-Encoding", codec.Name())
+	}
+	return codec
+}
+
+// compressToTempFile compresses the object in "f" with "codec" into a
+// temporary file, which is returned along with its size. If compression doesn't
+// make the object any smaller, the Content-Encoding header of "req" is removed
+// and "f" is returned as-is.
+func (a *basicUploadAdapter) compressToTempFile(req *http.Request, codec Codec, f *os.File, size int64) (*os.File, int64, error) {
+	compressed, err := tools.TempFile(a.tempDir(), codec.Name()+"-upload", a.fs)
+	if err != nil {
+		return nil, 0, errors.Wrap(err, tr.Tr.Get("basic upload"))
+	}
+
+	cw, err := codec.NewWriter(compressed)
+	if err == nil {
+		_, err = io.Copy(cw, f)
+		if cerr := cw.Close(); err == nil {
+			err = cerr
+		}
+	}
+	if err == nil {
+		_, err = f.Seek(0, io.SeekStart)
+	}
+	var stat os.FileInfo
+	if err == nil {
+		stat, err = compressed.Stat()
+	}
+	if err == nil {
+		_, err = compressed.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		compressed.Close()
+		os.Remove(compressed.Name())
+		return nil, 0, errors.Wrap(err, tr.Tr.Get("unable to compress upload"))
+	}
+
+	if stat.Size() >= size {
+		// Compression didn't help, so send the object as-is.
+		compressed.Close()
+		os.Remove(compressed.Name())
+		req.Header.Del("Content-Encoding")
+		return f, size, nil
+	}
+
+	tracerx.Printf("tq: compressed upload from %d to %d bytes with %s", size, stat.Size(), codec.Name())
+	return compressed, stat.Size(), nil
+}
+
+// isCompressibleContentType returns whether content of the given media type
+// is likely to benefit from compression.
+func isCompressibleContentType(contentType string) bool {
+	mediaType := strings.ToLower(strings.TrimSpace(strings.SplitN(contentType, ";", 2)[0]))
+
+	switch {
+	case strings.HasPrefix(mediaType, "text/"):
+		return true
+	case strings.HasSuffix(mediaType, "+json"), strings.HasSuffix(mediaType, "+xml"):
+		return true
+	}
+
+	switch mediaType {
+	case "application/json", "application/xml", "application/javascript",
+		"application/x-javascript", "application/ecmascript",
+		"application/x-sh", "application/x-tar", "application/wasm",
+		"application/postscript", "application/rtf", "image/bmp",
+		"image/x-icon", "image/vnd.microsoft.icon", "audio/wave",
+		"audio/wav", "audio/x-wav", "font/ttf", "font/otf":
+		return true
+	}
+	return false
+}
+
 // startCallbackReader is a reader wrapper which calls a function as soon as the
 // first Read() call is made. This callback is only made once
 type startCallbackReader struct {
@@ -209,6 +556,68 @@ func newStartCallbackReader(r lfsapi.ReadSeekCloser, cb func() error) *startCall
 	}
 }
 
+// compressReader is a reader wrapper which compresses the data read from the
+// underlying reader with a codec as it is read, so the length of its output is
+// not known in advance. It may only be rewound to the start, which restarts
+// compression from the start of the underlying reader.
+type compressReader struct {
+	r     lfsapi.ReadSeekCloser
+	codec Codec
+	cw    io.WriteCloser
+	buf   bytes.Buffer
+	chunk []byte
+	eof   bool
+}
+
+func newCompressReader(r lfsapi.ReadSeekCloser, codec Codec) *compressReader {
+	return &compressReader{r: r, codec: codec, chunk: make([]byte, 32*1024)}
+}
+
+func (c *compressReader) Read(p []byte) (int, error) {
+	if c.cw == nil {
+		cw, err := c.codec.NewWriter(&c.buf)
+		if err != nil {
+			return 0, err
+		}
+		c.cw = cw
+	}
+
+	for c.buf.Len() == 0 && !c.eof {
+		n, err := c.r.Read(c.chunk)
+		if n > 0 {
+			if _, werr := c.cw.Write(c.chunk[:n]); werr != nil {
+				return 0, werr
+			}
+		}
+		if err == io.EOF {
+			if cerr := c.cw.Close(); cerr != nil {
+				return 0, cerr
+			}
+			c.eof = true
+		} else if err != nil {
+			return 0, err
+		}
+	}
+	return c.buf.Read(p)
+}
+
+func (c *compressReader) Seek(offset int64, whence int) (int64, error) {
+	if offset != 0 || whence != io.SeekStart {
+		return 0, errors.New(tr.Tr.Get("compressed upload can only be rewound to the start"))
+	}
+	if _, err := c.r.Seek(0, io.SeekStart); err != nil {
+		return 0, err
+	}
+	c.buf.Reset()
+	c.cw = nil
+	c.eof = false
+	return 0, nil
+}
+
+func (c *compressReader) Close() error {
+	return c.r.Close()
+}
+
 func configureBasicUploadAdapter(m *concreteManifest) {
 	m.RegisterNewAdapterFunc(BasicAdapterName, Upload, func(name string, dir Direction) Adapter {
 		switch dir {
@@ -224,7 +633,7 @@ func configureBasicUploadAdapter(m *concreteManifest) {
 	})
 }
 
-func (a *basicUploadAdapter) makeRequest(t *Transfer, req *http.Request) (*http.Response, error) {
+func (a *basicUploadAdapter) makeRequest(t *Transfer, req *http.Request, bodyPath string, bodySize int64, stream Codec) (*http.Response, error) {
 	res, err := a.doHTTP(t, req)
 	if errors.IsAuthError(err) && len(req.Header.Get("Authorization")) == 0 {
 		// Construct a new body with just the raw file and no callbacks. Since
@@ -232,11 +641,15 @@ func (a *basicUploadAdapter) makeRequest(t *Transfer, req *http.Request) (*http.
 		// request body into a new request, we can safely make this request
 		// outside of the flow of the transfer adapter, and if it fails, the
 		// transfer progress will be rewound at the top level
-		f, _ := os.OpenFile(t.Path, os.O_RDONLY, 0644)
+		f, _ := os.OpenFile(bodyPath, os.O_RDONLY, 0644)
 		defer f.Close()
 
-		req.Body = tools.NewBodyWithCallback(f, t.Size, nil)
-		return a.makeRequest(t, req)
+		var body lfsapi.ReadSeekCloser = tools.NewBodyWithCallback(f, bodySize, nil)
+		if stream != nil {
+			body = newCompressReader(body, stream)
+		}
+		req.Body = body
+		return a.makeRequest(t, req, bodyPath, bodySize, stream)
 	}
 
 	return res, err
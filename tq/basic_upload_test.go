@@ -0,0 +1,156 @@
+package tq
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/git-lfs/git-lfs/v3/lfsapi"
+	"github.com/git-lfs/git-lfs/v3/lfshttp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsCompressibleContentType(t *testing.T) {
+	for contentType, expected := range map[string]bool{
+		"text/plain; charset=utf-8": true,
+		"TEXT/HTML":                 true,
+		"application/json":          true,
+		"application/vnd.api+json":  true,
+		"image/svg+xml":             true,
+		"application/octet-stream":  false,
+		"image/png":                 false,
+		"application/zip":           false,
+		"application/x-gzip":        false,
+		"":                          false,
+	} {
+		assert.Equal(t, expected, isCompressibleContentType(contentType), contentType)
+	}
+}
+
+func TestCompressReaderRewind(t *testing.T) {
+	contents := strings.Repeat("compress me, please\n", 10000)
+	r := newCompressReader(lfsapi.NewByteBody([]byte(contents)), gzipCodec{})
+
+	first, err := ioutil.ReadAll(r)
+	require.Nil(t, err)
+
+	_, err = r.Seek(0, io.SeekStart)
+	require.Nil(t, err)
+
+	second, err := ioutil.ReadAll(r)
+	require.Nil(t, err)
+	assert.Equal(t, first, second)
+	assert.True(t, len(first) < len(contents))
+
+	gz, err := gzip.NewReader(bytes.NewReader(first))
+	require.Nil(t, err)
+	decompressed, err := ioutil.ReadAll(gz)
+	require.Nil(t, err)
+	assert.Equal(t, contents, string(decompressed))
+
+	_, err = r.Seek(1, io.SeekStart)
+	assert.NotNil(t, err)
+}
+
+func TestBasicUploadStorageCompat(t *testing.T) {
+	contents := strings.Repeat("upload me, please\n", 1000)
+
+	var received *http.Request
+	var body []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r
+		body, _ = ioutil.ReadAll(r.Body)
+		w.WriteHeader(200)
+	}))
+	defer srv.Close()
+
+	path := filepath.Join(t.TempDir(), "object")
+	require.Nil(t, ioutil.WriteFile(path, []byte(contents), 0644))
+
+	c, err := lfsapi.NewClient(lfshttp.NewContext(nil, nil, map[string]string{
+		"lfs.storagecompat": "true",
+	}))
+	require.Nil(t, err)
+
+	a := &basicUploadAdapter{newAdapterBase(nil, BasicAdapterName, Upload, nil)}
+	a.transferImpl = a
+	require.Nil(t, a.Begin(&adapterConfig{apiClient: c, concurrentTransfers: 1}, nil))
+
+	results := a.Add(&Transfer{
+		Oid:           "oid",
+		Size:          int64(len(contents)),
+		Path:          path,
+		Authenticated: true,
+		Actions: ActionSet{"upload": &Action{
+			Href: srv.URL,
+			Header: map[string]string{
+				"Transfer-Encoding": "chunked",
+				"Content-Encoding":  "gzip",
+				"Expect":            "100-continue",
+			},
+		}},
+	})
+	a.End()
+
+	res := <-results
+	require.Nil(t, res.Error)
+	require.NotNil(t, received)
+
+	assert.Equal(t, contents, string(body))
+	assert.Empty(t, received.TransferEncoding)
+	assert.EqualValues(t, len(contents), received.ContentLength)
+	assert.Equal(t, "gzip", received.Header.Get("Content-Encoding"))
+	assert.Empty(t, received.Header.Get("Content-Type"))
+	assert.Equal(t, "JGjgSVrVrUIciuBUG1cXPQ==", received.Header.Get("Content-MD5"))
+}
+
+func TestSetStorageCompatHeadersKeepsGivenContentMD5(t *testing.T) {
+	req, err := http.NewRequest("PUT", "https://storage.example.com/object", nil)
+	require.Nil(t, err)
+	req.Header.Set("Content-MD5", "given")
+	req.Header.Set("Content-Type", "image/png")
+
+	require.Nil(t, setStorageCompatHeaders(req, strings.NewReader("contents")))
+	assert.Equal(t, "given", req.Header.Get("Content-MD5"))
+	assert.Equal(t, "image/png", req.Header.Get("Content-Type"))
+}
+
+func TestChunkedUploadOnlyTrustsServerForItself(t *testing.T) {
+	var requests uint32
+	srv := newServerConfigServer(t, &requests, `{"capabilities":["chunked-upload"]}`)
+	defer srv.Close()
+
+	c, err := lfsapi.NewClient(lfshttp.NewContext(nil, nil, map[string]string{
+		"lfs.url":                          srv.URL + "/api",
+		"lfs." + srv.URL + ".serverconfig": "true",
+	}))
+	require.Nil(t, err)
+
+	a := &basicUploadAdapter{newAdapterBase(nil, BasicAdapterName, Upload, nil)}
+	a.apiClient = c
+	a.remote = "origin"
+	a.state = newServerState()
+
+	for href, expected := range map[string]bool{
+		srv.URL + "/objects/oid":                                        true,
+		"https://storage.example.com/oid":                               false,
+		strings.Replace(srv.URL, "http:", "https:", 1) + "/objects/oid": false,
+	} {
+		req, err := http.NewRequest("PUT", href, nil)
+		require.Nil(t, err)
+		assert.Equal(t, expected, a.chunkedUpload(req, false), href)
+		assert.False(t, a.chunkedUpload(req, true), href)
+	}
+
+	req, err := http.NewRequest("PUT", "https://storage.example.com/oid", nil)
+	require.Nil(t, err)
+	req.Header.Set("Transfer-Encoding", "chunked")
+	assert.True(t, a.chunkedUpload(req, false))
+}
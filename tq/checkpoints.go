@@ -0,0 +1,117 @@
+package tq
+
+import (
+	"encoding/hex"
+	"hash"
+	"io"
+
+	"github.com/git-lfs/git-lfs/v3/errors"
+	"github.com/git-lfs/git-lfs/v3/tools"
+	"github.com/git-lfs/git-lfs/v3/tr"
+)
+
+// Checkpoints describes the hashes of consecutive chunks of an object, which a
+// server may provide with a download action so that corrupted data is detected
+// as soon as the chunk containing it has arrived, rather than only once the
+// whole object has been downloaded.
+type Checkpoints struct {
+	// Size is the size in bytes of each chunk but the last, which may be
+	// shorter.
+	Size int64 `json:"size"`
+
+	// Hashes holds the hex-encoded SHA-256 hash of each chunk, in order.
+	Hashes []string `json:"hashes"`
+}
+
+// valid returns whether the checkpoints cover an object of the given size.
+func (c *Checkpoints) valid(size int64) bool {
+	if c == nil || c.Size < 1 || size < 1 {
+		return false
+	}
+	return int64(len(c.Hashes)) == (size+c.Size-1)/c.Size
+}
+
+// checkpointVerifier is an io.Writer which checks the data written to it, from
+// "offset" onwards, against an object's checkpoints.
+type checkpointVerifier struct {
+	checkpoints *Checkpoints
+	size        int64
+	hash        hash.Hash
+
+	// offset is the offset within the object of the next byte written.
+	offset int64
+}
+
+// newCheckpointVerifier returns a checkpointVerifier for an object of the given
+// size, of which the first "fromByte" bytes have already been written to
+// "partial". Those bytes of the current chunk are read back from "partial", so
+// that the chunk may be verified once it is complete.
+func newCheckpointVerifier(c *Checkpoints, size, fromByte int64, partial io.ReaderAt) (*checkpointVerifier, error) {
+	v := &checkpointVerifier{
+		checkpoints: c,
+		size:        size,
+		hash:        tools.NewLfsContentHash(),
+		offset:      fromByte - fromByte%c.Size,
+	}
+
+	if v.offset < fromByte {
+		if _, err := io.Copy(v, io.NewSectionReader(partial, v.offset, fromByte-v.offset)); err != nil {
+			return nil, err
+		}
+	}
+	return v, nil
+}
+
+func (v *checkpointVerifier) Write(p []byte) (int, error) {
+	written := 0
+	for len(p) > 0 {
+		n := v.checkpoints.Size - v.offset%v.checkpoints.Size
+		if n > int64(len(p)) {
+			n = int64(len(p))
+		}
+
+		v.hash.Write(p[:n])
+		v.offset += n
+		written += int(n)
+		p = p[n:]
+
+		if v.offset%v.checkpoints.Size == 0 || v.offset == v.size {
+			if err := v.check(); err != nil {
+				return written, err
+			}
+		}
+	}
+	return written, nil
+}
+
+// check compares the hash of the chunk which has just been completed with its
+// checkpoint.
+func (v *checkpointVerifier) check() error {
+	i := (v.offset - 1) / v.checkpoints.Size
+	if i >= int64(len(v.checkpoints.Hashes)) {
+		return nil
+	}
+
+	actual := hex.EncodeToString(v.hash.Sum(nil))
+	v.hash.Reset()
+
+	if actual != v.checkpoints.Hashes[i] {
+		return &checkpointError{
+			verified: i * v.checkpoints.Size,
+			err:      errors.New(tr.Tr.Get("expected chunk %d to have hash %s, got %s", i, v.checkpoints.Hashes[i], actual)),
+		}
+	}
+	return nil
+}
+
+// checkpointError is returned when a chunk of a download does not match its
+// checkpoint. All of the data before it has been verified, so the download may
+// be resumed from there.
+type checkpointError struct {
+	verified int64
+	err      error
+}
+
+func (e *checkpointError) Error() string {
+	return e.err.Error()
+}
@@ -0,0 +1,78 @@
+package tq
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestCheckpoints(data []byte, size int) *Checkpoints {
+	c := &Checkpoints{Size: int64(size)}
+	for i := 0; i < len(data); i += size {
+		end := i + size
+		if end > len(data) {
+			end = len(data)
+		}
+		sum := sha256.Sum256(data[i:end])
+		c.Hashes = append(c.Hashes, hex.EncodeToString(sum[:]))
+	}
+	return c
+}
+
+func TestCheckpointsValid(t *testing.T) {
+	c := newTestCheckpoints([]byte("0123456789"), 4)
+
+	assert.True(t, c.valid(10))
+	assert.True(t, c.valid(9))
+	assert.False(t, c.valid(13))
+	assert.False(t, c.valid(0))
+	assert.False(t, (*Checkpoints)(nil).valid(10))
+}
+
+func TestCheckpointVerifierAcceptsData(t *testing.T) {
+	data := []byte("0123456789")
+	v, err := newCheckpointVerifier(newTestCheckpoints(data, 4), 10, 0, nil)
+	require.Nil(t, err)
+
+	for _, b := range data {
+		_, err := v.Write([]byte{b})
+		require.Nil(t, err)
+	}
+}
+
+func TestCheckpointVerifierDetectsCorruption(t *testing.T) {
+	data := []byte("0123456789")
+	v, err := newCheckpointVerifier(newTestCheckpoints(data, 4), 10, 0, nil)
+	require.Nil(t, err)
+
+	n, err := v.Write([]byte("01234X6789"))
+	assert.Equal(t, 8, n)
+	if assert.IsType(t, &checkpointError{}, err) {
+		assert.EqualValues(t, 4, err.(*checkpointError).verified)
+	}
+}
+
+func TestCheckpointVerifierResumes(t *testing.T) {
+	data := []byte("0123456789")
+	c := newTestCheckpoints(data, 4)
+
+	// Resume from the middle of the second chunk, which must be read back
+	// from the partial download to be verified.
+	v, err := newCheckpointVerifier(c, 10, 6, bytes.NewReader([]byte("0123X5")))
+	require.Nil(t, err)
+
+	_, err = v.Write(data[6:])
+	if assert.IsType(t, &checkpointError{}, err) {
+		assert.EqualValues(t, 4, err.(*checkpointError).verified)
+	}
+
+	v, err = newCheckpointVerifier(c, 10, 6, bytes.NewReader(data[:6]))
+	require.Nil(t, err)
+
+	_, err = v.Write(data[6:])
+	assert.Nil(t, err)
+}
@@ -0,0 +1,131 @@
+package tq
+
+import (
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"strings"
+	"sync"
+)
+
+const (
+	// IdentityCodecName is the name of the codec which leaves content
+	// as-is.
+	IdentityCodecName = "identity"
+
+	// GzipCodecName is the name of the codec which gzip-compresses
+	// content.
+	GzipCodecName = "gzip"
+)
+
+// Codec compresses the contents of objects before they are uploaded. Its name
+// is the HTTP Content-Encoding token which storage servers use to advertise
+// that they accept content compressed with it.
+//
+// The identity and gzip codecs are built in. Others, such as "zstd" or "lz4",
+// may be added with RegisterCodec by programs which embed Git LFS. They are
+// not built in because Go's standard library implements neither, and Git LFS
+// does not take on a third-party compression library for them.
+type Codec interface {
+	// Name returns the Content-Encoding token of the codec, such as
+	// "gzip".
+	Name() string
+
+	// Compresses returns whether content of the given media type is
+	// likely to benefit from being compressed with the codec.
+	Compresses(contentType string) bool
+
+	// NewWriter returns a writer which compresses the data written to it
+	// into "w". Closing it flushes any remaining data, but does not close
+	// "w".
+	NewWriter(w io.Writer) (io.WriteCloser, error)
+
+	// NewReader returns a reader which decompresses the data read from
+	// "r".
+	NewReader(r io.Reader) (io.ReadCloser, error)
+}
+
+var (
+	codecs   = make(map[string]Codec)
+	codecsMu sync.RWMutex
+)
+
+func init() {
+	RegisterCodec(identityCodec{})
+	RegisterCodec(gzipCodec{})
+}
+
+// RegisterCodec makes the given codec available to be negotiated with storage
+// servers, replacing any codec already registered with the same name.
+func RegisterCodec(c Codec) {
+	codecsMu.Lock()
+	defer codecsMu.Unlock()
+
+	codecs[strings.ToLower(c.Name())] = c
+}
+
+// LookupCodec returns the registered codec with the given name, if any.
+func LookupCodec(name string) (Codec, bool) {
+	codecsMu.RLock()
+	defer codecsMu.RUnlock()
+
+	c, ok := codecs[strings.ToLower(strings.TrimSpace(name))]
+	return c, ok
+}
+
+// negotiateCodec returns the codec to compress content of the given media type
+// with, given the Content-Encoding header of an upload action, which lists the
+// encodings the server accepts in order of preference. It returns nil if the
+// content should be sent as-is, because none of the encodings is both
+// registered and suited to the content.
+func negotiateCodec(encodings, contentType string) Codec {
+	for _, name := range strings.Split(encodings, ",") {
+		c, ok := LookupCodec(name)
+		if !ok || !c.Compresses(contentType) {
+			continue
+		}
+		if c.Name() == IdentityCodecName {
+			return nil
+		}
+		return c
+	}
+	return nil
+}
+
+// identityCodec leaves content as-is. A server which lists it before any other
+// encoding prefers that content is not compressed.
+type identityCodec struct{}
+
+func (identityCodec) Name() string { return IdentityCodecName }
+
+func (identityCodec) Compresses(contentType string) bool { return true }
+
+func (identityCodec) NewWriter(w io.Writer) (io.WriteCloser, error) {
+	return nopWriteCloser{w}, nil
+}
+
+func (identityCodec) NewReader(r io.Reader) (io.ReadCloser, error) {
+	return ioutil.NopCloser(r), nil
+}
+
+type gzipCodec struct{}
+
+func (gzipCodec) Name() string { return GzipCodecName }
+
+func (gzipCodec) Compresses(contentType string) bool {
+	return isCompressibleContentType(contentType)
+}
+
+func (gzipCodec) NewWriter(w io.Writer) (io.WriteCloser, error) {
+	return gzip.NewWriter(w), nil
+}
+
+func (gzipCodec) NewReader(r io.Reader) (io.ReadCloser, error) {
+	return gzip.NewReader(r)
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error { return nil }
@@ -0,0 +1,59 @@
+package tq
+
+import (
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testCodec struct {
+	name string
+}
+
+func (c *testCodec) Name() string                       { return c.name }
+func (c *testCodec) Compresses(contentType string) bool { return contentType == "application/x-test" }
+
+func (c *testCodec) NewWriter(w io.Writer) (io.WriteCloser, error) {
+	return nopWriteCloser{w}, nil
+}
+
+func (c *testCodec) NewReader(r io.Reader) (io.ReadCloser, error) {
+	return identityCodec{}.NewReader(r)
+}
+
+func TestNegotiateCodec(t *testing.T) {
+	custom := &testCodec{name: "x-test-codec"}
+	RegisterCodec(custom)
+	defer func() {
+		codecsMu.Lock()
+		delete(codecs, custom.Name())
+		codecsMu.Unlock()
+	}()
+
+	for desc, c := range map[string]struct {
+		Encodings   string
+		ContentType string
+		Expected    Codec
+	}{
+		"gzip":                              {"gzip", "text/plain", gzipCodec{}},
+		"case insensitive":                  {"GZIP", "text/plain", gzipCodec{}},
+		"unknown codec first":               {"zstd, gzip", "text/plain", gzipCodec{}},
+		"only unknown codecs":               {"zstd, lz4", "text/plain", nil},
+		"incompressible type":               {"gzip", "image/png", nil},
+		"identity preferred":                {"identity, gzip", "text/plain", nil},
+		"registered codec":                  {"x-test-codec, gzip", "application/x-test", custom},
+		"registered codec skipped for type": {"x-test-codec, gzip", "text/plain", gzipCodec{}},
+	} {
+		assert.Equal(t, c.Expected, negotiateCodec(c.Encodings, c.ContentType), desc)
+	}
+}
+
+func TestLookupCodec(t *testing.T) {
+	c, ok := LookupCodec(" Gzip ")
+	assert.True(t, ok)
+	assert.Equal(t, GzipCodecName, c.Name())
+
+	_, ok = LookupCodec("no-such-codec")
+	assert.False(t, ok)
+}
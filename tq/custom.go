@@ -69,6 +69,8 @@ type customAdapterInitRequest struct {
 	ConcurrentTransfers int    `json:"concurrenttransfers"`
 }
 
+// NewCustomAdapterInitRequest returns the message which starts a custom
+// transfer agent, as described in docs/custom-transfers.md.
 func NewCustomAdapterInitRequest(
 	op string, remote string, concurrent bool, concurrentTransfers int,
 ) *customAdapterInitRequest {
@@ -84,9 +86,14 @@ type customAdapterTransferRequest struct {
 	Action *Action `json:"action"`
 }
 
+// NewCustomAdapterUploadRequest returns the message which asks a custom
+// transfer agent to upload an object from "path".
 func NewCustomAdapterUploadRequest(oid string, size int64, path string, action *Action) *customAdapterTransferRequest {
 	return &customAdapterTransferRequest{"upload", oid, size, path, action}
 }
+
+// NewCustomAdapterDownloadRequest returns the message which asks a custom
+// transfer agent to download an object.
 func NewCustomAdapterDownloadRequest(oid string, size int64, action *Action) *customAdapterTransferRequest {
 	return &customAdapterTransferRequest{"download", oid, size, "", action}
 }
@@ -95,6 +102,8 @@ type customAdapterTerminateRequest struct {
 	Event string `json:"event"`
 }
 
+// NewCustomAdapterTerminateRequest returns the message which asks a custom
+// transfer agent to exit.
 func NewCustomAdapterTerminateRequest() *customAdapterTerminateRequest {
 	return &customAdapterTerminateRequest{"terminate"}
 }
@@ -315,12 +324,12 @@ func (a *customAdapter) DoTransfer(ctx interface{}, t *Transfer, cb ProgressCall
 				if err = tools.VerifyFileHash(t.Oid, resp.Path); err != nil {
 					return errors.New(tr.Tr.Get("downloaded file failed checks: %v", err))
 				}
-				// Move file to final location
-				if err = tools.RenameFileCopyPermissions(resp.Path, t.Path); err != nil {
+				// Move file into the local object store
+				if err = a.storeObject(t, resp.Path); err != nil {
 					return errors.New(tr.Tr.Get("failed to copy downloaded file: %v", err))
 				}
 			} else if a.direction == Upload {
-				if err = verifyUpload(a.apiClient, a.remote, t); err != nil {
+				if err = verifyUpload(a.state, a.apiClient, a.remote, t); err != nil {
 					return err
 				}
 			}
@@ -0,0 +1,322 @@
+package tq
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+
+	"github.com/git-lfs/git-lfs/v3/config"
+	"github.com/git-lfs/git-lfs/v3/errors"
+	"github.com/git-lfs/git-lfs/v3/tr"
+)
+
+const (
+	// deltaMagic begins every delta, and identifies the version of its
+	// format.
+	deltaMagic = "GLFSDLT1"
+
+	// deltaBaseHeader is the header of a "download-delta" or
+	// "upload-delta" action which names the OID of the object the delta is
+	// made against.
+	deltaBaseHeader = "LFS-Delta-Base"
+
+	// deltaCopy and deltaInsert are the two instructions of a delta. A
+	// copy is followed by the offset and length of a range of the base
+	// object, and an insert by the length of the literal data which
+	// follows it.
+	deltaCopy   = byte(1)
+	deltaInsert = byte(2)
+
+	// minDeltaBlockSize is the smallest run of bytes which is looked up
+	// in the base object when making a delta.
+	minDeltaBlockSize = 4096
+
+	// maxDeltaBlocks limits the number of blocks of the base object which
+	// are indexed, so that the index of a very large object still fits in
+	// memory.
+	maxDeltaBlocks = 1 << 20
+
+	// maxDeltaInsert is the longest insert instruction written, so that
+	// literal data need not be held in memory.
+	maxDeltaInsert = 1 << 20
+)
+
+// deltaAction returns the "download-delta" or "upload-delta" action of the
+// transfer, and the path of the local object it is made against. It returns
+// false if the server did not offer one, delta transfers are disabled for its
+// URL, or the base object is not present locally.
+func (a *adapterBase) deltaAction(t *Transfer, rel string) (*Action, string, bool) {
+	action, err := t.Rel(rel)
+	if err != nil || action == nil {
+		return nil, "", false
+	}
+
+	base := http.Header{}
+	for key, value := range action.Header {
+		base.Set(key, value)
+	}
+	oid := base.Get(deltaBaseHeader)
+	if len(oid) == 0 || oid == t.Oid {
+		return nil, "", false
+	}
+
+	uc := config.NewURLConfig(a.apiClient.GitEnv())
+	if !uc.Bool("lfs", action.Href, "deltatransfers", true) {
+		return nil, "", false
+	}
+
+	// A delta is made against the base's own file, so delta transfers
+	// are only made from stores which keep objects in files.
+	path, err := a.fs.StoredObjectPath(oid)
+	if err != nil {
+		return nil, "", false
+	}
+	if stat, err := os.Stat(path); err != nil || !stat.Mode().IsRegular() {
+		return nil, "", false
+	}
+	return action, path, true
+}
+
+// onceFunc returns a function which calls fn the first time it is called, and
+// does nothing afterwards. This allows authOkFunc to be passed to both a delta
+// transfer and the full transfer it falls back to.
+func onceFunc(fn func()) func() {
+	if fn == nil {
+		return nil
+	}
+
+	var once sync.Once
+	return func() { once.Do(fn) }
+}
+
+// deltaBlockSize returns the size of the blocks of a base object of the given
+// size which are indexed when making a delta.
+func deltaBlockSize(baseSize int64) int {
+	size := int64(minDeltaBlockSize)
+	for baseSize/size > maxDeltaBlocks {
+		size *= 2
+	}
+	return int(size)
+}
+
+// rollingChecksum is the weak checksum used by rsync, which can be updated in
+// constant time as its window slides along by one byte.
+type rollingChecksum struct {
+	a, b uint32
+	n    uint32
+}
+
+func newRollingChecksum(window []byte) *rollingChecksum {
+	c := &rollingChecksum{n: uint32(len(window))}
+	for i, x := range window {
+		c.a += uint32(x)
+		c.b += uint32(len(window)-i) * uint32(x)
+	}
+	return c
+}
+
+func (c *rollingChecksum) roll(out, in byte) {
+	c.a += uint32(in) - uint32(out)
+	c.b += c.a - c.n*uint32(out)
+}
+
+func (c *rollingChecksum) sum() uint32 {
+	return (c.a & 0xffff) | (c.b << 16)
+}
+
+// deltaWriter writes the instructions of a delta, merging adjacent copies.
+type deltaWriter struct {
+	w *bufio.Writer
+
+	copyOffset int64
+	copyLength int64
+}
+
+func (d *deltaWriter) copy(offset, length int64) error {
+	if d.copyLength > 0 && d.copyOffset+d.copyLength == offset {
+		d.copyLength += length
+		return nil
+	}
+	if err := d.flushCopy(); err != nil {
+		return err
+	}
+	d.copyOffset, d.copyLength = offset, length
+	return nil
+}
+
+func (d *deltaWriter) insert(data []byte) error {
+	if len(data) == 0 {
+		return nil
+	}
+	if err := d.flushCopy(); err != nil {
+		return err
+	}
+
+	var hdr [9]byte
+	hdr[0] = deltaInsert
+	binary.BigEndian.PutUint64(hdr[1:], uint64(len(data)))
+	if _, err := d.w.Write(hdr[:]); err != nil {
+		return err
+	}
+	_, err := d.w.Write(data)
+	return err
+}
+
+func (d *deltaWriter) flushCopy() error {
+	if d.copyLength == 0 {
+		return nil
+	}
+
+	var hdr [17]byte
+	hdr[0] = deltaCopy
+	binary.BigEndian.PutUint64(hdr[1:], uint64(d.copyOffset))
+	binary.BigEndian.PutUint64(hdr[9:], uint64(d.copyLength))
+	d.copyLength = 0
+	_, err := d.w.Write(hdr[:])
+	return err
+}
+
+// writeDelta writes a delta to "w" which turns the base object into the
+// contents of "target". Blocks of the target which also appear in the base are
+// found with a rolling checksum, as rsync does, and copied; everything else is
+// inserted literally.
+func writeDelta(base io.ReaderAt, baseSize int64, target io.Reader, w io.Writer) error {
+	blockSize := deltaBlockSize(baseSize)
+
+	// Index the offset of the first block of the base with each checksum.
+	index := make(map[uint32]int64)
+	block := make([]byte, blockSize)
+	for offset := int64(0); offset+int64(blockSize) <= baseSize; offset += int64(blockSize) {
+		if _, err := base.ReadAt(block, offset); err != nil {
+			return err
+		}
+		sum := newRollingChecksum(block).sum()
+		if _, ok := index[sum]; !ok {
+			index[sum] = offset
+		}
+	}
+
+	bw := bufio.NewWriter(w)
+	if _, err := bw.WriteString(deltaMagic); err != nil {
+		return err
+	}
+	d := &deltaWriter{w: bw}
+
+	// "buf" holds the literal data not yet written, the last blockSize
+	// bytes of which are the window whose checksum is "sum".
+	br := bufio.NewReader(target)
+	buf := make([]byte, 0, maxDeltaInsert+blockSize)
+	var sum *rollingChecksum
+	for {
+		c, err := br.ReadByte()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		buf = append(buf, c)
+		if len(buf) < blockSize {
+			continue
+		}
+
+		window := buf[len(buf)-blockSize:]
+		if sum == nil {
+			sum = newRollingChecksum(window)
+		} else {
+			sum.roll(buf[len(buf)-blockSize-1], c)
+		}
+
+		if offset, ok := index[sum.sum()]; ok {
+			if _, err := base.ReadAt(block, offset); err != nil {
+				return err
+			}
+			if bytes.Equal(block, window) {
+				if err := d.insert(buf[:len(buf)-blockSize]); err != nil {
+					return err
+				}
+				if err := d.copy(offset, int64(blockSize)); err != nil {
+					return err
+				}
+				buf, sum = buf[:0], nil
+				continue
+			}
+		}
+
+		if len(buf) == cap(buf) {
+			// Write out the literal data before the window, and
+			// move the window to the start of the buffer.
+			if err := d.insert(buf[:len(buf)-blockSize]); err != nil {
+				return err
+			}
+			buf = append(buf[:0], window...)
+		}
+	}
+
+	if err := d.insert(buf); err != nil {
+		return err
+	}
+	if err := d.flushCopy(); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// applyDelta writes the result of applying the delta read from "delta" to the
+// base object, of the given size, to "w".
+func applyDelta(base io.ReaderAt, baseSize int64, delta io.Reader, w io.Writer) error {
+	br := bufio.NewReader(delta)
+
+	magic := make([]byte, len(deltaMagic))
+	if _, err := io.ReadFull(br, magic); err != nil || string(magic) != deltaMagic {
+		return errors.New(tr.Tr.Get("invalid delta"))
+	}
+
+	for {
+		op, err := br.ReadByte()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		switch op {
+		case deltaCopy:
+			var args [16]byte
+			if _, err := io.ReadFull(br, args[:]); err != nil {
+				return errors.New(tr.Tr.Get("invalid delta"))
+			}
+			offset := int64(binary.BigEndian.Uint64(args[:8]))
+			length := int64(binary.BigEndian.Uint64(args[8:]))
+			if offset < 0 || length < 0 || offset > baseSize || length > baseSize-offset {
+				return errors.New(tr.Tr.Get("invalid delta: copy outside of base object"))
+			}
+			if _, err := io.Copy(w, io.NewSectionReader(base, offset, length)); err != nil {
+				return err
+			}
+		case deltaInsert:
+			var args [8]byte
+			if _, err := io.ReadFull(br, args[:]); err != nil {
+				return errors.New(tr.Tr.Get("invalid delta"))
+			}
+			length := int64(binary.BigEndian.Uint64(args[:]))
+			if length < 0 {
+				return errors.New(tr.Tr.Get("invalid delta"))
+			}
+			if n, err := io.CopyN(w, br, length); err != nil {
+				if n < length && (err == io.EOF || err == io.ErrUnexpectedEOF) {
+					return errors.New(tr.Tr.Get("invalid delta"))
+				}
+				return err
+			}
+		default:
+			return errors.New(tr.Tr.Get("invalid delta: unknown instruction %d", op))
+		}
+	}
+}
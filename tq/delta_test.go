@@ -0,0 +1,59 @@
+package tq
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeltaRoundTrip(t *testing.T) {
+	base := make([]byte, 10*minDeltaBlockSize+123)
+	rand.New(rand.NewSource(1)).Read(base)
+
+	target := make([]byte, 0, len(base)+100)
+	target = append(target, base[:3*minDeltaBlockSize+7]...)
+	target = append(target, []byte("some new data in the middle")...)
+	target = append(target, base[5*minDeltaBlockSize:]...)
+
+	var delta bytes.Buffer
+	require.Nil(t, writeDelta(bytes.NewReader(base), int64(len(base)), bytes.NewReader(target), &delta))
+	assert.True(t, delta.Len() < len(target)/2, "delta of %d bytes is too large", delta.Len())
+
+	var out bytes.Buffer
+	require.Nil(t, applyDelta(bytes.NewReader(base), int64(len(base)), &delta, &out))
+	assert.Equal(t, target, out.Bytes())
+}
+
+func TestDeltaRoundTripSmallBase(t *testing.T) {
+	base := []byte("too small to index")
+	target := []byte("entirely different contents")
+
+	var delta bytes.Buffer
+	require.Nil(t, writeDelta(bytes.NewReader(base), int64(len(base)), bytes.NewReader(target), &delta))
+
+	var out bytes.Buffer
+	require.Nil(t, applyDelta(bytes.NewReader(base), int64(len(base)), &delta, &out))
+	assert.Equal(t, target, out.Bytes())
+}
+
+func TestApplyDeltaRejectsInvalidDeltas(t *testing.T) {
+	base := []byte("base object")
+
+	for desc, delta := range map[string][]byte{
+		"missing magic":       []byte("not a delta"),
+		"unknown instruction": []byte(deltaMagic + "\x03"),
+		"truncated copy":      []byte(deltaMagic + "\x01\x00\x00"),
+		"copy past end": []byte(deltaMagic + "\x01" +
+			"\x00\x00\x00\x00\x00\x00\x00\x05" +
+			"\x00\x00\x00\x00\x00\x00\x00\x10"),
+		"truncated insert": []byte(deltaMagic + "\x02" +
+			"\x00\x00\x00\x00\x00\x00\x00\x10" + "short"),
+	} {
+		var out bytes.Buffer
+		err := applyDelta(bytes.NewReader(base), int64(len(base)), bytes.NewReader(delta), &out)
+		assert.NotNil(t, err, desc)
+	}
+}
@@ -2,6 +2,8 @@ package tq
 
 import "github.com/git-lfs/git-lfs/v3/tr"
 
+// MalformedObjectError is returned for an object which can't be uploaded
+// because it is missing or corrupt locally.
 type MalformedObjectError struct {
 	Name string
 	Oid  string
@@ -17,8 +19,10 @@ func newCorruptObjectError(name, oid string) error {
 	return &MalformedObjectError{Name: name, Oid: oid, missing: false}
 }
 
+// Missing returns whether the object is missing.
 func (e MalformedObjectError) Missing() bool { return e.missing }
 
+// Corrupt returns whether the object is present, but corrupt.
 func (e MalformedObjectError) Corrupt() bool { return !e.Missing() }
 
 func (e MalformedObjectError) Error() string {
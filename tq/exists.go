@@ -0,0 +1,82 @@
+package tq
+
+import (
+	"github.com/git-lfs/git-lfs/v3/errors"
+	"github.com/git-lfs/git-lfs/v3/git"
+	"github.com/git-lfs/git-lfs/v3/tr"
+	"github.com/rubyist/tracerx"
+)
+
+// ObjectExists reports whether the remote already has the object with the
+// given OID and size. See ObjectsExist for details.
+func ObjectExists(m Manifest, remote string, remoteRef *git.Ref, oid string, size int64) (bool, error) {
+	exists, err := ObjectsExist(m, remote, remoteRef, []*Transfer{
+		&Transfer{Oid: oid, Size: size},
+	})
+	if err != nil {
+		return false, err
+	}
+	return exists[oid], nil
+}
+
+// ObjectsExist asks the remote which of the given objects it already has,
+// without opening any local files or starting any transfers. It returns a map
+// from each OID to whether or not the server has that object.
+//
+// The check is performed with an "upload" batch request, since the batch API
+// omits the "upload" action for any object which the server already has.
+// Objects for which the server returns an error are reported as missing.
+//
+// The answer for each object is remembered for as long as "m" is used, until
+// the object is uploaded, so objects which have already been checked, by this
+// or by an upload with the same manifest, are not asked about again.
+func ObjectsExist(m Manifest, remote string, remoteRef *git.Ref, objects []*Transfer) (map[string]bool, error) {
+	exists := make(map[string]bool, len(objects))
+	if len(objects) == 0 {
+		return exists, nil
+	}
+
+	cm := m.Upgrade()
+	endpoint := cm.APIClient().Endpoints.Endpoint(Upload.String(), remote)
+	unknown := make([]*Transfer, 0, len(objects))
+	for _, o := range objects {
+		if known, ok := cm.state.objects().get(endpoint.Url, o.Oid); ok {
+			tracerx.Printf("tq: using cached existence of %s", o.Oid)
+			exists[o.Oid] = known
+			continue
+		}
+		unknown = append(unknown, o)
+	}
+	objects = unknown
+
+	for start := 0; start < len(objects); start += defaultBatchSize {
+		end := start + defaultBatchSize
+		if end > len(objects) {
+			end = len(objects)
+		}
+
+		transfers := make([]*Transfer, 0, end-start)
+		for _, o := range objects[start:end] {
+			exists[o.Oid] = false
+			transfers = append(transfers, &Transfer{Oid: o.Oid, Size: o.Size})
+		}
+
+		bRes, err := Batch(m, Upload, remote, remoteRef, transfers)
+		if err != nil {
+			return nil, errors.Wrap(err, tr.Tr.Get("unable to check for existing objects"))
+		}
+
+		for _, t := range bRes.Objects {
+			if t.Error != nil {
+				tracerx.Printf("tq: existence check for %s failed: %s", t.Oid, t.Error)
+				continue
+			}
+
+			_, hasAction := t.Actions["upload"]
+			_, hasLink := t.Links["upload"]
+			exists[t.Oid] = !hasAction && !hasLink
+		}
+	}
+
+	return exists, nil
+}
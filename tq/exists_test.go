@@ -0,0 +1,213 @@
+package tq
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/git-lfs/git-lfs/v3/lfsapi"
+	"github.com/git-lfs/git-lfs/v3/lfshttp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestObjectsExist(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/objects/batch" {
+			w.WriteHeader(404)
+			return
+		}
+
+		bReq := &batchRequest{}
+		err := json.NewDecoder(r.Body).Decode(bReq)
+		r.Body.Close()
+		assert.Nil(t, err)
+		assert.Equal(t, "upload", bReq.Operation)
+
+		objects := make([]*Transfer, 0, len(bReq.Objects))
+		for _, o := range bReq.Objects {
+			switch o.Oid {
+			case "present":
+				objects = append(objects, &Transfer{Oid: o.Oid, Size: o.Size})
+			case "failed":
+				objects = append(objects, &Transfer{Oid: o.Oid, Size: o.Size,
+					Error: &ObjectError{Code: 422, Message: "invalid"}})
+			default:
+				objects = append(objects, &Transfer{Oid: o.Oid, Size: o.Size,
+					Actions: ActionSet{"upload": &Action{Href: "https://example.com"}}})
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		err = json.NewEncoder(w).Encode(&BatchResponse{Objects: objects})
+		assert.Nil(t, err)
+	}))
+	defer srv.Close()
+
+	c, err := lfsapi.NewClient(lfshttp.NewContext(nil, nil, map[string]string{
+		"lfs.url": srv.URL + "/api",
+	}))
+	require.Nil(t, err)
+
+	m := NewManifest(nil, c, "upload", "origin")
+
+	exists, err := ObjectsExist(m, "origin", nil, []*Transfer{
+		&Transfer{Oid: "present", Size: 1},
+		&Transfer{Oid: "missing", Size: 2},
+		&Transfer{Oid: "failed", Size: 3},
+	})
+	require.Nil(t, err)
+	assert.Equal(t, map[string]bool{
+		"present": true,
+		"missing": false,
+		"failed":  false,
+	}, exists)
+
+	ok, err := ObjectExists(m, "origin", nil, "present", 1)
+	require.Nil(t, err)
+	assert.True(t, ok)
+}
+
+func TestObjectsExistEmpty(t *testing.T) {
+	exists, err := ObjectsExist(NewManifest(nil, nil, "", ""), "origin", nil, nil)
+	require.Nil(t, err)
+	assert.Empty(t, exists)
+}
+
+func TestObjectsExistCached(t *testing.T) {
+	var requests uint32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddUint32(&requests, 1)
+
+		bReq := &batchRequest{}
+		err := json.NewDecoder(r.Body).Decode(bReq)
+		r.Body.Close()
+		assert.Nil(t, err)
+
+		objects := make([]*Transfer, 0, len(bReq.Objects))
+		for _, o := range bReq.Objects {
+			objects = append(objects, &Transfer{Oid: o.Oid, Size: o.Size,
+				Actions: ActionSet{"upload": &Action{Href: "https://example.com"}}})
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		err = json.NewEncoder(w).Encode(&BatchResponse{Objects: objects})
+		assert.Nil(t, err)
+	}))
+	defer srv.Close()
+
+	c, err := lfsapi.NewClient(lfshttp.NewContext(nil, nil, map[string]string{
+		"lfs.url": srv.URL + "/api",
+	}))
+	require.Nil(t, err)
+
+	m := NewManifest(nil, c, "upload", "origin")
+
+	for i := 0; i < 2; i++ {
+		ok, err := ObjectExists(m, "origin", nil, "cached", 1)
+		require.Nil(t, err)
+		assert.False(t, ok)
+	}
+	assert.EqualValues(t, 1, atomic.LoadUint32(&requests))
+
+	m.Upgrade().state.objects().forget("cached")
+
+	ok, err := ObjectExists(m, "origin", nil, "cached", 1)
+	require.Nil(t, err)
+	assert.False(t, ok)
+	assert.EqualValues(t, 2, atomic.LoadUint32(&requests))
+}
+
+func TestTransferQueuePreflight(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		bReq := &batchRequest{}
+		err := json.NewDecoder(r.Body).Decode(bReq)
+		r.Body.Close()
+		assert.Nil(t, err)
+
+		objects := make([]*Transfer, 0, len(bReq.Objects))
+		for _, o := range bReq.Objects {
+			if o.Oid == "present" {
+				objects = append(objects, &Transfer{Oid: o.Oid, Size: o.Size})
+			} else {
+				objects = append(objects, &Transfer{Oid: o.Oid, Size: o.Size,
+					Actions: ActionSet{"upload": &Action{Href: "https://example.com"}}})
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		err = json.NewEncoder(w).Encode(&BatchResponse{Objects: objects})
+		assert.Nil(t, err)
+	}))
+	defer srv.Close()
+
+	c, err := lfsapi.NewClient(lfshttp.NewContext(nil, nil, map[string]string{
+		"lfs.url": srv.URL + "/api",
+	}))
+	require.Nil(t, err)
+
+	q := NewTransferQueue(Upload, NewManifest(nil, c, "upload", "origin"), "origin")
+	defer q.Wait()
+
+	remaining := q.Preflight([]*Transfer{
+		&Transfer{Name: "a.dat", Oid: "present", Size: 1},
+		&Transfer{Name: "b.dat", Oid: "missing", Size: 2},
+	})
+	require.Len(t, remaining, 1)
+	assert.Equal(t, "missing", remaining[0].Oid)
+
+	download := NewTransferQueue(Download, NewManifest(nil, c, "download", "origin"), "origin")
+	defer download.Wait()
+
+	assert.Len(t, download.Preflight([]*Transfer{&Transfer{Oid: "present", Size: 1}}), 1)
+}
+
+func TestTransferQueueSkipsKnownObjects(t *testing.T) {
+	var requests uint32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddUint32(&requests, 1)
+
+		bReq := &batchRequest{}
+		err := json.NewDecoder(r.Body).Decode(bReq)
+		r.Body.Close()
+		assert.Nil(t, err)
+
+		objects := make([]*Transfer, 0, len(bReq.Objects))
+		for _, o := range bReq.Objects {
+			objects = append(objects, &Transfer{Oid: o.Oid, Size: o.Size})
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		err = json.NewEncoder(w).Encode(&BatchResponse{Objects: objects})
+		assert.Nil(t, err)
+	}))
+	defer srv.Close()
+
+	c, err := lfsapi.NewClient(lfshttp.NewContext(nil, nil, map[string]string{
+		"lfs.url": srv.URL + "/api",
+	}))
+	require.Nil(t, err)
+
+	m := NewManifest(nil, c, "upload", "origin")
+	ok, err := ObjectExists(m, "origin", nil, "present", 1)
+	require.Nil(t, err)
+	assert.True(t, ok)
+	assert.EqualValues(t, 1, atomic.LoadUint32(&requests))
+
+	// The server isn't asked about the object again by an upload with
+	// the same manifest.
+	q := NewTransferQueue(Upload, m, "origin")
+	q.Add("a.dat", "a.dat", "present", 1, false, nil)
+	q.Wait()
+	assert.Empty(t, q.Errors())
+	assert.EqualValues(t, 1, atomic.LoadUint32(&requests))
+
+	// But it is by one with another.
+	q = NewTransferQueue(Upload, NewManifest(nil, c, "upload", "origin"), "origin")
+	q.Add("a.dat", "a.dat", "present", 1, false, nil)
+	q.Wait()
+	assert.Empty(t, q.Errors())
+	assert.EqualValues(t, 2, atomic.LoadUint32(&requests))
+}
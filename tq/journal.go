@@ -0,0 +1,127 @@
+package tq
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/git-lfs/git-lfs/v3/fs"
+	"github.com/rubyist/tracerx"
+)
+
+// journalExpiry is how long a journal may go without being written to before
+// the objects it lists are no longer trusted to be transferred, since the
+// server may have since discarded them. It matches the age at which files in
+// the temporary directory are cleaned up.
+var journalExpiry = time.Hour
+
+// journal records the OID of each object which a queue has transferred, or
+// found it did not need to, so that if the queue is interrupted, another run
+// of it may skip those objects. A nil *journal is valid and records nothing.
+type journal struct {
+	path string
+
+	mu   sync.Mutex
+	oids map[string]struct{}
+}
+
+// openJournal returns a journal stored at "path", with the objects recorded in
+// it by an earlier, interrupted run, unless that was too long ago to trust.
+func openJournal(path string) *journal {
+	j := &journal{path: path, oids: make(map[string]struct{})}
+
+	fi, err := os.Stat(path)
+	if err != nil {
+		return j
+	}
+	if time.Since(fi.ModTime()) > journalExpiry {
+		tracerx.Printf("tq: removing stale journal %s", path)
+		os.Remove(path)
+		return j
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return j
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			// A final line without a newline was cut short by
+			// the interruption.
+			break
+		}
+		if oid := strings.TrimSuffix(line, "\n"); fs.ValidateOid(oid) == nil {
+			j.oids[oid] = struct{}{}
+		}
+	}
+
+	if len(j.oids) > 0 {
+		tracerx.Printf("tq: resuming from journal %s with %d object(s)", path, len(j.oids))
+	}
+	return j
+}
+
+// has returns whether the object "oid" has been recorded as transferred.
+func (j *journal) has(oid string) bool {
+	if j == nil {
+		return false
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	_, ok := j.oids[oid]
+	return ok
+}
+
+// record appends the object "oid" to the journal. Each entry is written as
+// soon as it is recorded, so that it survives the process being killed.
+func (j *journal) record(oid string) {
+	if j == nil {
+		return
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if _, ok := j.oids[oid]; ok {
+		return
+	}
+	j.oids[oid] = struct{}{}
+
+	if err := os.MkdirAll(filepath.Dir(j.path), 0755); err != nil {
+		tracerx.Printf("tq: unable to write journal %s: %s", j.path, err)
+		return
+	}
+
+	f, err := os.OpenFile(j.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		tracerx.Printf("tq: unable to write journal %s: %s", j.path, err)
+		return
+	}
+	defer f.Close()
+
+	fmt.Fprintf(f, "%s\n", oid)
+}
+
+// remove deletes the journal, once every object has been transferred, so that
+// later runs ask the server about each object again.
+func (j *journal) remove() {
+	if j == nil {
+		return
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.oids = make(map[string]struct{})
+	os.Remove(j.path)
+}
@@ -0,0 +1,73 @@
+package tq
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	journalOidA = "4d7a214614ab2935c943f9e0ff69d22eadbb8f32b1258daaa5e2ca24d17e2393"
+	journalOidB = "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"
+)
+
+func TestJournalResumes(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tq-journal")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "tmp", "upload.journal")
+	j := openJournal(path)
+	assert.False(t, j.has(journalOidA))
+
+	j.record(journalOidA)
+	j.record(journalOidA)
+	assert.True(t, j.has(journalOidA))
+
+	// A run killed while writing its next entry leaves it incomplete.
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0644)
+	require.Nil(t, err)
+	f.WriteString(journalOidB[:10])
+	f.Close()
+
+	data, err := ioutil.ReadFile(path)
+	require.Nil(t, err)
+	assert.Equal(t, journalOidA+"\n"+journalOidB[:10], string(data))
+
+	resumed := openJournal(path)
+	assert.True(t, resumed.has(journalOidA))
+	assert.False(t, resumed.has(journalOidB))
+
+	resumed.remove()
+	assert.False(t, resumed.has(journalOidA))
+	_, err = os.Stat(path)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestJournalExpires(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tq-journal")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "upload.journal")
+	openJournal(path).record(journalOidA)
+
+	old := time.Now().Add(-2 * journalExpiry)
+	require.Nil(t, os.Chtimes(path, old, old))
+
+	assert.False(t, openJournal(path).has(journalOidA))
+	_, err = os.Stat(path)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestNilJournal(t *testing.T) {
+	var j *journal
+	j.record(journalOidA)
+	assert.False(t, j.has(journalOidA))
+	j.remove()
+}
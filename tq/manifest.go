@@ -3,6 +3,7 @@ package tq
 import (
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/git-lfs/git-lfs/v3/config"
 	"github.com/git-lfs/git-lfs/v3/fs"
@@ -17,6 +18,8 @@ const (
 	defaultConcurrentTransfers = 8
 )
 
+// Manifest holds the transfer adapters available to a queue, and the settings
+// with which it transfers objects.
 type Manifest interface {
 	APIClient() *lfsapi.Client
 	MaxRetries() int
@@ -132,16 +135,20 @@ type concreteManifest struct {
 	// time in seconds to wait between retry attempts when using backoff.
 	maxRetries              int
 	maxRetryDelay           int
+	restoreTimeout          time.Duration
 	concurrentTransfers     int
 	basicTransfersOnly      bool
 	standaloneTransferAgent string
 	tusTransfersAllowed     bool
+	preferredTransfer       string
+	maxFileSize             int64
 	downloadAdapterFuncs    map[string]NewAdapterFunc
 	uploadAdapterFuncs      map[string]NewAdapterFunc
 	fs                      *fs.Filesystem
 	apiClient               *lfsapi.Client
 	sshTransfer             *ssh.SSHTransfer
 	batchClientAdapter      BatchClient
+	state                   *serverState
 	mu                      sync.Mutex
 }
 
@@ -180,6 +187,8 @@ func (m *concreteManifest) Upgraded() bool {
 	return true
 }
 
+// NewManifest returns a Manifest for transfers to and from the remote "remote",
+// which reads its settings only when they are first needed.
 func NewManifest(f *fs.Filesystem, apiClient *lfsapi.Client, operation, remote string) Manifest {
 	return newLazyManifest(f, apiClient, operation, remote)
 }
@@ -200,10 +209,13 @@ func newConcreteManifest(f *fs.Filesystem, apiClient *lfsapi.Client, operation,
 		useSSHMultiplexing = sshTransfer.IsMultiplexingEnabled()
 	}
 
+	state := newServerState()
 	m := &concreteManifest{
+		restoreTimeout:       defaultRestoreTimeout,
 		fs:                   f,
 		apiClient:            apiClient,
-		batchClientAdapter:   &tqClient{Client: apiClient},
+		batchClientAdapter:   &tqClient{Client: apiClient, state: state},
+		state:                state,
 		downloadAdapterFuncs: make(map[string]NewAdapterFunc),
 		uploadAdapterFuncs:   make(map[string]NewAdapterFunc),
 		sshTransfer:          sshTransfer,
@@ -217,6 +229,9 @@ func newConcreteManifest(f *fs.Filesystem, apiClient *lfsapi.Client, operation,
 		if v := git.Int("lfs.transfer.maxretrydelay", -1); v > -1 {
 			m.maxRetryDelay = v
 		}
+		if v := git.Int("lfs.transfer.restoretimeout", -1); v > -1 {
+			m.restoreTimeout = time.Duration(v) * time.Second
+		}
 		if v := git.Int("lfs.concurrenttransfers", 0); v > 0 {
 			m.concurrentTransfers = v
 		}
@@ -226,6 +241,10 @@ func newConcreteManifest(f *fs.Filesystem, apiClient *lfsapi.Client, operation,
 		)
 		tusAllowed = git.Bool("lfs.tustransfers", false)
 		configureCustomAdapters(git, m)
+
+		if sshTransfer == nil && m.standaloneTransferAgent == "" && operation != "" && remote != "" {
+			m.applyServerConfig(fetchServerConfig(state, apiClient, operation, remote), git)
+		}
 	}
 
 	if m.maxRetries < 1 {
@@ -257,6 +276,10 @@ func newConcreteManifest(f *fs.Filesystem, apiClient *lfsapi.Client, operation,
 		configureTusAdapter(m)
 	}
 	configureSSHAdapter(m)
+	if m.standaloneTransferAgent == standaloneMemoryName {
+		// Only offered when in use, since no server could select it.
+		configureMemoryAdapter(m)
+	}
 	return m
 }
 
@@ -264,6 +287,9 @@ func findDefaultStandaloneTransfer(url string) string {
 	if strings.HasPrefix(url, "file://") {
 		return standaloneFileName
 	}
+	if strings.HasPrefix(url, memoryURLPrefix) {
+		return standaloneMemoryName
+	}
 	return ""
 }
 
@@ -316,7 +342,12 @@ func (m *concreteManifest) getAdapterNames(adapters map[string]NewAdapterFunc) [
 
 	ret := make([]string, 0, len(adapters))
 	for n, _ := range adapters {
-		ret = append(ret, n)
+		if n == m.preferredTransfer {
+			// List the adapter the server recommends first.
+			ret = append([]string{n}, ret...)
+		} else {
+			ret = append(ret, n)
+		}
 	}
 	return ret
 }
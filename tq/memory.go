@@ -0,0 +1,154 @@
+package tq
+
+import (
+	"encoding/hex"
+	"io/ioutil"
+	"strings"
+	"sync"
+
+	"github.com/git-lfs/git-lfs/v3/errors"
+	"github.com/git-lfs/git-lfs/v3/tools"
+	"github.com/git-lfs/git-lfs/v3/tr"
+)
+
+const (
+	standaloneMemoryName = "lfs-standalone-memory"
+
+	memoryURLPrefix = "memory://"
+)
+
+var (
+	memoryRemotes   = make(map[string]*MemoryRemote)
+	memoryRemotesMu sync.Mutex
+)
+
+// MemoryRemote holds the contents of pushed objects in memory, standing in for
+// a remote server. Each is named by a "memory://<name>" URL, which may be used
+// as the value of `lfs.url`, and lasts for the lifetime of the process, so that
+// unit tests and programs which embed Git LFS can push and fetch objects
+// without a server.
+//
+// Only the remote is kept in memory. The local repository's copies of its
+// objects are kept in its object store on disk, as they always are, and are
+// read from there when pushed and written there when fetched.
+type MemoryRemote struct {
+	mu      sync.RWMutex
+	objects map[string][]byte
+}
+
+// NewMemoryRemote returns the MemoryRemote named by the given "memory://" URL,
+// creating it if it does not yet exist.
+func NewMemoryRemote(url string) *MemoryRemote {
+	name := strings.TrimSuffix(strings.TrimPrefix(url, memoryURLPrefix), "/")
+
+	memoryRemotesMu.Lock()
+	defer memoryRemotesMu.Unlock()
+
+	s, ok := memoryRemotes[name]
+	if !ok {
+		s = &MemoryRemote{objects: make(map[string][]byte)}
+		memoryRemotes[name] = s
+	}
+	return s
+}
+
+// Get returns the contents of the object with the given OID, if the remote has
+// it.
+func (s *MemoryRemote) Get(oid string) ([]byte, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	data, ok := s.objects[oid]
+	return data, ok
+}
+
+// Put stores "data" as the contents of the object with the given OID.
+func (s *MemoryRemote) Put(oid string, data []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.objects[oid] = data
+}
+
+// memoryAdapter transfers objects between the local object store on disk and a
+// MemoryRemote.
+type memoryAdapter struct {
+	*adapterBase
+	remote *MemoryRemote
+}
+
+func (a *memoryAdapter) Begin(cfg AdapterConfig, cb ProgressCallback) error {
+	endpoint := cfg.APIClient().Endpoints.Endpoint(a.direction.String(), cfg.Remote())
+	a.remote = NewMemoryRemote(endpoint.Url)
+
+	return a.adapterBase.Begin(cfg, cb)
+}
+
+func (a *memoryAdapter) WorkerStarting(workerNum int) (interface{}, error) {
+	return nil, nil
+}
+
+func (a *memoryAdapter) WorkerEnding(workerNum int, ctx interface{}) {
+}
+
+func (a *memoryAdapter) DoTransfer(ctx interface{}, t *Transfer, cb ProgressCallback, authOkFunc func()) error {
+	if authOkFunc != nil {
+		authOkFunc()
+	}
+
+	if a.direction == Upload {
+		data, err := ioutil.ReadFile(t.Path)
+		if err != nil {
+			return err
+		}
+		a.remote.Put(t.Oid, data)
+	} else {
+		data, ok := a.remote.Get(t.Oid)
+		if !ok {
+			return errors.Errorf(tr.Tr.Get("Object %s not found on the server.", t.Oid))
+		}
+		if err := a.writeObject(t, data); err != nil {
+			return err
+		}
+	}
+
+	if cb != nil {
+		cb(t.Name, t.Size, t.Size, int(t.Size))
+	}
+	return nil
+}
+
+// writeObject verifies "data" and moves it into place as the contents of the
+// downloaded object "t".
+func (a *memoryAdapter) writeObject(t *Transfer, data []byte) error {
+	hasher := tools.NewLfsContentHash()
+	hasher.Write(data)
+	if oid := hex.EncodeToString(hasher.Sum(nil)); oid != t.Oid {
+		return errors.New(tr.Tr.Get("expected OID %s, got %s", t.Oid, oid))
+	}
+
+	f, err := tools.TempFile(a.fs.TempDir(), t.Oid, a.fs)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if _, err := f.Write(data); err != nil {
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return tools.RenameFileCopyPermissions(f.Name(), t.Path)
+}
+
+func configureMemoryAdapter(m *concreteManifest) {
+	newfunc := func(name string, dir Direction) Adapter {
+		ma := &memoryAdapter{adapterBase: newAdapterBase(m.fs, name, dir, nil)}
+		// self implements impl
+		ma.transferImpl = ma
+		return ma
+	}
+	m.RegisterNewAdapterFunc(standaloneMemoryName, Download, newfunc)
+	m.RegisterNewAdapterFunc(standaloneMemoryName, Upload, newfunc)
+}
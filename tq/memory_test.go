@@ -0,0 +1,74 @@
+package tq
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/git-lfs/git-lfs/v3/fs"
+	"github.com/git-lfs/git-lfs/v3/lfsapi"
+	"github.com/git-lfs/git-lfs/v3/lfshttp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryRemoteRoundTrip(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lfs-memory-remote")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	cli, err := lfsapi.NewClient(lfshttp.NewContext(nil, nil, map[string]string{
+		"lfs.url": "memory://round-trip",
+	}))
+	require.Nil(t, err)
+
+	f := fs.New(cli.OSEnv(), dir, "", "", 0644)
+
+	// The SHA-256 of "hello".
+	oid := "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"
+	path, err := f.ObjectPath(oid)
+	require.Nil(t, err)
+	require.Nil(t, ioutil.WriteFile(path, []byte("hello"), 0644))
+
+	q := NewTransferQueue(Upload, NewManifest(f, cli, "upload", "origin"), "origin")
+	q.Add("a.dat", path, oid, 5, false, nil)
+	q.Wait()
+	assert.Empty(t, q.Errors())
+
+	data, ok := NewMemoryRemote("memory://round-trip").Get(oid)
+	assert.True(t, ok)
+	assert.Equal(t, "hello", string(data))
+
+	require.Nil(t, os.Remove(path))
+
+	q = NewTransferQueue(Download, NewManifest(f, cli, "download", "origin"), "origin")
+	q.Add("a.dat", path, oid, 5, false, nil)
+	q.Wait()
+	assert.Empty(t, q.Errors())
+
+	data, err = ioutil.ReadFile(path)
+	assert.Nil(t, err)
+	assert.Equal(t, "hello", string(data))
+}
+
+func TestMemoryRemoteMissingObject(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lfs-memory-remote")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	cli, err := lfsapi.NewClient(lfshttp.NewContext(nil, nil, map[string]string{
+		"lfs.url": "memory://missing",
+	}))
+	require.Nil(t, err)
+
+	f := fs.New(cli.OSEnv(), dir, "", "", 0644)
+
+	oid := "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"
+	path, err := f.ObjectPath(oid)
+	require.Nil(t, err)
+
+	q := NewTransferQueue(Download, NewManifest(f, cli, "download", "origin"), "origin")
+	q.Add("a.dat", path, oid, 5, false, nil)
+	q.Wait()
+	assert.Len(t, q.Errors(), 1)
+}
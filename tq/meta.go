@@ -0,0 +1,99 @@
+package tq
+
+import (
+	"github.com/git-lfs/git-lfs/v3/errors"
+	"github.com/git-lfs/git-lfs/v3/git"
+	"github.com/git-lfs/git-lfs/v3/tr"
+)
+
+// ObjectMeta describes an object as its server reports it.
+type ObjectMeta struct {
+	Oid string
+	// Exists is whether the server has the object. If it does not, the
+	// remaining fields are unset.
+	Exists bool
+	// Size is the size of the object in bytes.
+	Size int64
+	// StorageClass is the class of storage the server keeps the object
+	// in, such as "standard" or "archive", if it reports one.
+	StorageClass string
+	// Attributes are any other attributes the server reports for the
+	// object, such as where it came from, as decoded from JSON.
+	Attributes map[string]interface{}
+	// Error is the error the server returned for the object, other than
+	// that it doesn't have it, in which case Exists is false.
+	Error error
+}
+
+// GetObjectMeta asks the remote about the object with the given OID and size,
+// which may be zero if it is not known. Unlike GetObjectsMeta, an error which
+// the server returns for the object is returned as the error. See
+// GetObjectsMeta for details.
+func GetObjectMeta(m Manifest, remote string, remoteRef *git.Ref, oid string, size int64) (*ObjectMeta, error) {
+	meta, err := GetObjectsMeta(m, remote, remoteRef, []*Transfer{
+		&Transfer{Oid: oid, Size: size},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := meta[oid].Error; err != nil {
+		return nil, err
+	}
+	return meta[oid], nil
+}
+
+// GetObjectsMeta asks the remote what it knows about each of the given objects,
+// without downloading their contents. It returns a map from each OID to the
+// object's metadata.
+//
+// The metadata is read from the response to a "download" batch request, which
+// is sent with the size given for each object, or zero where the caller
+// doesn't know it. The size reported is that which the server returns; a
+// server which merely repeats the size it was sent reports what it was given.
+// Objects for which the server returns a 404 error are reported as not
+// existing, and any other error for an object is reported in its metadata, so
+// that one object the server can't describe doesn't hide the others. Only a
+// failure of the batch request itself is returned as an error.
+func GetObjectsMeta(m Manifest, remote string, remoteRef *git.Ref, objects []*Transfer) (map[string]*ObjectMeta, error) {
+	meta := make(map[string]*ObjectMeta, len(objects))
+
+	for start := 0; start < len(objects); start += defaultBatchSize {
+		end := start + defaultBatchSize
+		if end > len(objects) {
+			end = len(objects)
+		}
+
+		transfers := make([]*Transfer, 0, end-start)
+		for _, o := range objects[start:end] {
+			meta[o.Oid] = &ObjectMeta{Oid: o.Oid}
+			transfers = append(transfers, &Transfer{Oid: o.Oid, Size: o.Size})
+		}
+
+		bRes, err := Batch(m, Download, remote, remoteRef, transfers)
+		if err != nil {
+			return nil, errors.Wrap(err, tr.Tr.Get("unable to get object metadata"))
+		}
+
+		for _, t := range bRes.Objects {
+			if t.Error != nil {
+				if t.Error.Code != 404 {
+					meta[t.Oid] = &ObjectMeta{
+						Oid:   t.Oid,
+						Error: errors.Wrap(t.Error, tr.Tr.Get("unable to get metadata of %s", t.Oid)),
+					}
+				}
+				continue
+			}
+
+			meta[t.Oid] = &ObjectMeta{
+				Oid:          t.Oid,
+				Exists:       true,
+				Size:         t.Size,
+				StorageClass: t.StorageClass,
+				Attributes:   t.Attributes,
+			}
+		}
+	}
+
+	return meta, nil
+}
@@ -0,0 +1,118 @@
+package tq
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/git-lfs/git-lfs/v3/lfsapi"
+	"github.com/git-lfs/git-lfs/v3/lfshttp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newObjectMetaServer(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		bReq := &batchRequest{}
+		err := json.NewDecoder(r.Body).Decode(bReq)
+		r.Body.Close()
+		assert.Nil(t, err)
+		assert.Equal(t, "download", bReq.Operation)
+
+		objects := make([]*Transfer, 0, len(bReq.Objects))
+		for _, o := range bReq.Objects {
+			switch o.Oid {
+			case "present":
+				objects = append(objects, &Transfer{Oid: o.Oid, Size: 1234,
+					StorageClass: "archive",
+					Attributes:   map[string]interface{}{"origin": "render-farm"},
+					Actions:      ActionSet{"download": &Action{Href: "https://example.com"}}})
+			case "missing":
+				objects = append(objects, &Transfer{Oid: o.Oid,
+					Error: &ObjectError{Code: 404, Message: "not found"}})
+			default:
+				objects = append(objects, &Transfer{Oid: o.Oid,
+					Error: &ObjectError{Code: 403, Message: "forbidden"}})
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		err = json.NewEncoder(w).Encode(&BatchResponse{Objects: objects})
+		assert.Nil(t, err)
+	}))
+}
+
+func newObjectMetaManifest(t *testing.T, srv *httptest.Server) Manifest {
+	c, err := lfsapi.NewClient(lfshttp.NewContext(nil, nil, map[string]string{
+		"lfs.url": srv.URL + "/api",
+	}))
+	require.Nil(t, err)
+
+	return NewManifest(nil, c, "download", "origin")
+}
+
+func TestGetObjectsMeta(t *testing.T) {
+	srv := newObjectMetaServer(t)
+	defer srv.Close()
+
+	meta, err := GetObjectsMeta(newObjectMetaManifest(t, srv), "origin", nil, []*Transfer{
+		&Transfer{Oid: "present", Size: 1234},
+		&Transfer{Oid: "missing"},
+		&Transfer{Oid: "forbidden"},
+	})
+	require.Nil(t, err)
+
+	require.NotNil(t, meta["forbidden"])
+	if assert.NotNil(t, meta["forbidden"].Error) {
+		assert.Contains(t, meta["forbidden"].Error.Error(), "forbidden")
+	}
+	delete(meta, "forbidden")
+
+	assert.Equal(t, map[string]*ObjectMeta{
+		"present": &ObjectMeta{
+			Oid:          "present",
+			Exists:       true,
+			Size:         1234,
+			StorageClass: "archive",
+			Attributes:   map[string]interface{}{"origin": "render-farm"},
+		},
+		"missing": &ObjectMeta{Oid: "missing"},
+	}, meta)
+}
+
+func TestGetObjectsMetaSendsSizes(t *testing.T) {
+	var sizes map[string]int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		bReq := &batchRequest{}
+		require.Nil(t, json.NewDecoder(r.Body).Decode(bReq))
+		r.Body.Close()
+
+		sizes = make(map[string]int64)
+		for _, o := range bReq.Objects {
+			sizes[o.Oid] = o.Size
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(&BatchResponse{Objects: bReq.Objects})
+	}))
+	defer srv.Close()
+
+	_, err := GetObjectsMeta(newObjectMetaManifest(t, srv), "origin", nil, []*Transfer{
+		&Transfer{Oid: "known", Size: 42},
+		&Transfer{Oid: "unknown"},
+	})
+	require.Nil(t, err)
+	assert.Equal(t, map[string]int64{"known": 42, "unknown": 0}, sizes)
+}
+
+func TestGetObjectMetaObjectError(t *testing.T) {
+	srv := newObjectMetaServer(t)
+	defer srv.Close()
+
+	meta, err := GetObjectMeta(newObjectMetaManifest(t, srv), "origin", nil, "forbidden", 0)
+	assert.Nil(t, meta)
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "forbidden")
+	}
+}
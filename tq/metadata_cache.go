@@ -0,0 +1,75 @@
+package tq
+
+import (
+	"sync"
+)
+
+// metadataCache maps an OID and an endpoint URL to whether the server at that
+// endpoint has the object. A nil *metadataCache remembers nothing.
+type metadataCache struct {
+	mu   sync.Mutex
+	oids map[string]map[string]bool
+}
+
+func newMetadataCache() *metadataCache {
+	return &metadataCache{oids: make(map[string]map[string]bool)}
+}
+
+// get returns whether the server at "endpoint" has the object "oid", and
+// false for "ok" if that isn't known.
+func (c *metadataCache) get(endpoint, oid string) (exists, ok bool) {
+	if c == nil {
+		return false, false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	exists, ok = c.oids[oid][endpoint]
+	return exists, ok
+}
+
+func (c *metadataCache) set(endpoint, oid string, exists bool) {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	endpoints, ok := c.oids[oid]
+	if !ok {
+		endpoints = make(map[string]bool)
+		c.oids[oid] = endpoints
+	}
+	endpoints[endpoint] = exists
+}
+
+// forget discards everything known about the object "oid", at every endpoint.
+// It is called once the object has been uploaded, since the upload may have
+// been redirected to any of them.
+func (c *metadataCache) forget(oid string) {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.oids, oid)
+}
+
+// remember records what the upload batch response "bRes" says about whether
+// its endpoint already has each of its objects. Objects for which the server
+// returned an error are not recorded.
+func (c *metadataCache) remember(bRes *BatchResponse) {
+	for _, t := range bRes.Objects {
+		if t.Error != nil {
+			continue
+		}
+
+		_, hasAction := t.Actions["upload"]
+		_, hasLink := t.Links["upload"]
+		c.set(bRes.endpoint.Url, t.Oid, !hasAction && !hasLink)
+	}
+}
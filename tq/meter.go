@@ -32,6 +32,7 @@ type Meter struct {
 	estimatedFiles    int32
 	paused            uint32
 	fileIndex         map[string]int64 // Maps a file name to its transfer number
+	restoring         map[string]time.Time
 	fileIndexMutex    *sync.Mutex
 	updates           chan *tasklog.Update
 	cfg               *config.Configuration
@@ -45,6 +46,8 @@ type env interface {
 	Get(key string) (val string, ok bool)
 }
 
+// LoggerFromEnv returns a writer for the file named by GIT_LFS_PROGRESS, to
+// which the meter logs the progress of each object, or nil if it is not set.
 func (m *Meter) LoggerFromEnv(os env) *tools.SyncWriter {
 	name, _ := os.Get("GIT_LFS_PROGRESS")
 	if len(name) < 1 {
@@ -53,6 +56,8 @@ func (m *Meter) LoggerFromEnv(os env) *tools.SyncWriter {
 	return m.LoggerToFile(name)
 }
 
+// LoggerToFile returns a writer for the file "name", to which the meter logs
+// the progress of each object.
 func (m *Meter) LoggerToFile(name string) *tools.SyncWriter {
 	printErr := func(err string) {
 		fmt.Fprintln(os.Stderr, tr.Tr.Get("Error creating progress logger: %s", err))
@@ -81,6 +86,7 @@ func (m *Meter) LoggerToFile(name string) *tools.SyncWriter {
 func NewMeter(cfg *config.Configuration) *Meter {
 	m := &Meter{
 		fileIndex:      make(map[string]int64),
+		restoring:      make(map[string]time.Time),
 		fileIndexMutex: &sync.Mutex{},
 		updates:        make(chan *tasklog.Update),
 		cfg:            cfg,
@@ -141,6 +147,25 @@ func (m *Meter) StartTransfer(name string) {
 	idx := atomic.AddInt64(&m.transferringFiles, 1)
 	m.fileIndexMutex.Lock()
 	m.fileIndex[name] = idx
+	delete(m.restoring, name)
+	m.fileIndexMutex.Unlock()
+}
+
+// Restoring tells the progress meter that a file is being restored from cold
+// storage by the server, and will be asked for again at "until". A zero
+// "until" means that the file is no longer being waited for.
+func (m *Meter) Restoring(name string, until time.Time) {
+	if m == nil {
+		return
+	}
+
+	defer m.update(true)
+	m.fileIndexMutex.Lock()
+	if until.IsZero() {
+		delete(m.restoring, name)
+	} else {
+		m.restoring[name] = until
+	}
 	m.fileIndexMutex.Unlock()
 }
 
@@ -203,6 +228,7 @@ func (m *Meter) Finish() {
 	close(m.updates)
 }
 
+// Updates returns the meter's updates, as a tasklog.Task.
 func (m *Meter) Updates() <-chan *tasklog.Update {
 	if m == nil {
 		return nil
@@ -210,6 +236,7 @@ func (m *Meter) Updates() <-chan *tasklog.Update {
 	return m.updates
 }
 
+// Throttled returns true, since the meter updates too often to log each one.
 func (m *Meter) Throttled() bool {
 	return true
 }
@@ -236,12 +263,42 @@ func (m *Meter) str() string {
 	// (Uploading|Downloading) LFS objects: 100% (10/10) 100 MiB | 10 MiB/s
 	percentage := 100 * float64(m.finishedFiles) / float64(m.estimatedFiles)
 
-	return fmt.Sprintf("%s: %3.f%% (%d/%d), %s | %s",
+	str := fmt.Sprintf("%s: %3.f%% (%d/%d), %s | %s",
 		m.Direction.Progress(),
 		percentage,
 		m.finishedFiles, m.estimatedFiles,
 		humanize.FormatBytes(clamp(m.currentBytes)),
 		humanize.FormatByteRate(clampf(m.avgBytes), time.Second))
+
+	if restoring, next := m.restoringFiles(); restoring > 0 {
+		// (Downloading LFS objects: ...), 1 object being restored, retrying in 30s
+		str += ", " + tr.Tr.GetN(
+			"%d object being restored, retrying in %s",
+			"%d objects being restored, retrying in %s",
+			restoring,
+			restoring,
+			next.Round(time.Second))
+	}
+	return str
+}
+
+// restoringFiles returns the number of files being restored from cold storage,
+// and how long it is until the first of them is asked for again.
+func (m *Meter) restoringFiles() (int, time.Duration) {
+	m.fileIndexMutex.Lock()
+	defer m.fileIndexMutex.Unlock()
+
+	var next time.Duration
+	for _, until := range m.restoring {
+		wait := time.Until(until)
+		if wait < 0 {
+			wait = 0
+		}
+		if next == 0 || wait < next {
+			next = wait
+		}
+	}
+	return len(m.restoring), next
 }
 
 // clamp clamps the given "x" within the acceptable domain of the uint64 integer
@@ -0,0 +1,512 @@
+package tq
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/git-lfs/git-lfs/v3/tools"
+)
+
+var (
+	// progressFileInterval is how often a queue's in-flight transfers are
+	// written to its progress file.
+	progressFileInterval = 5 * time.Second
+
+	// progressFileExpiry is how long a progress file may go without being
+	// updated before it is assumed to belong to a process which has
+	// exited without removing it.
+	progressFileExpiry = time.Minute
+)
+
+// TransferProgress is a snapshot of the progress of a single object's
+// transfer, as persisted by a *TransferQueue created with WithProgressDir.
+type TransferProgress struct {
+	Direction string    `json:"direction"`
+	Name      string    `json:"name"`
+	Oid       string    `json:"oid"`
+	Size      int64     `json:"size"`
+	Bytes     int64     `json:"bytes"`
+	Start     time.Time `json:"start"`
+	Updated   time.Time `json:"updated"`
+	// Host is the host to or from which the object is being
+	// transferred, if known.
+	Host string `json:"host,omitempty"`
+}
+
+// Percent returns the percentage of the object which has been transferred.
+func (p *TransferProgress) Percent() int {
+	if p.Size <= 0 {
+		return 100
+	}
+	return int(p.Bytes * 100 / p.Size)
+}
+
+// BytesPerSecond returns the average transfer rate of the object so far.
+func (p *TransferProgress) BytesPerSecond() float64 {
+	elapsed := p.Updated.Sub(p.Start).Seconds()
+	if elapsed <= 0 {
+		return 0
+	}
+	return float64(p.Bytes) / elapsed
+}
+
+// ETA returns the estimated time remaining until the transfer completes, and
+// false if no estimate can be made yet.
+func (p *TransferProgress) ETA() (time.Duration, bool) {
+	rate := p.BytesPerSecond()
+	if rate <= 0 {
+		return 0, false
+	}
+	remaining := float64(p.Size-p.Bytes) / rate
+	return time.Duration(remaining * float64(time.Second)), true
+}
+
+// QueuedObject describes an object which a queue has had to retry, or which
+// it has given up on.
+type QueuedObject struct {
+	Name    string `json:"name"`
+	Oid     string `json:"oid"`
+	Size    int64  `json:"size"`
+	Retries int    `json:"retries,omitempty"`
+	// Error is the most recent error encountered while transferring the
+	// object, if any.
+	Error string `json:"error,omitempty"`
+}
+
+// QueueState is a snapshot of the state of a single *TransferQueue, as
+// persisted by a queue created with WithProgressDir.
+type QueueState struct {
+	Pid       int    `json:"pid"`
+	Direction string `json:"direction"`
+	Remote    string `json:"remote,omitempty"`
+	// Pending is the number of objects which have been added to the
+	// queue, but which are neither being transferred nor done.
+	Pending int                 `json:"pending"`
+	Active  []*TransferProgress `json:"active"`
+	Retried []*QueuedObject     `json:"retried,omitempty"`
+	Failed  []*QueuedObject     `json:"failed,omitempty"`
+	Updated time.Time           `json:"updated"`
+}
+
+// ReadTransferProgress returns the progress of the transfers recorded in all
+// of the progress files in "dir", ignoring any which have not been updated
+// recently enough to still be in progress.
+func ReadTransferProgress(dir string) ([]*TransferProgress, error) {
+	states, err := ReadQueueStates(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var progress []*TransferProgress
+	for _, state := range states {
+		progress = append(progress, state.Active...)
+	}
+
+	sort.Slice(progress, func(i, j int) bool {
+		return progress[i].Start.Before(progress[j].Start)
+	})
+	return progress, nil
+}
+
+// ReadQueueStates returns the state of each of the queues whose progress is
+// recorded in the files in "dir", ignoring any files which have not been
+// updated recently enough for their process to still be running.
+func ReadQueueStates(dir string) ([]*QueueState, error) {
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var states []*QueueState
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		if time.Since(entry.ModTime()) > progressFileExpiry {
+			continue
+		}
+
+		data, err := ioutil.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			// The file may have been removed by its
+			// process finishing.
+			continue
+		}
+
+		var fileStates []*QueueState
+		if err := json.Unmarshal(data, &fileStates); err != nil {
+			continue
+		}
+		states = append(states, fileStates...)
+	}
+
+	sort.SliceStable(states, func(i, j int) bool {
+		return states[i].Pid < states[j].Pid
+	})
+	return states, nil
+}
+
+// removeStaleProgressFiles removes any files in "dir" which have not been
+// updated recently enough for the process which wrote them to still be
+// running.
+func removeStaleProgressFiles(dir string) {
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() || time.Since(entry.ModTime()) <= progressFileExpiry {
+			continue
+		}
+		os.Remove(filepath.Join(dir, entry.Name()))
+	}
+}
+
+// progressFile records the progress of a queue's in-flight transfers, and the
+// state of the rest of its objects, for its process's progressWriter to
+// write. A nil *progressFile is valid and records nothing.
+type progressFile struct {
+	writer    *progressWriter
+	direction Direction
+	remote    string
+
+	mu        sync.Mutex
+	transfers map[string]*TransferProgress
+	retried   map[string]*QueuedObject
+	failed    []*QueuedObject
+	// added and finished count the objects added to the queue, and
+	// those which have since succeeded, been skipped, or failed.
+	added    int
+	finished int
+	dirty    bool
+}
+
+func newProgressFile(dir string, direction Direction, remote string) *progressFile {
+	f := &progressFile{
+		direction: direction,
+		remote:    remote,
+		transfers: make(map[string]*TransferProgress),
+		retried:   make(map[string]*QueuedObject),
+	}
+
+	progressWritersMu.Lock()
+	defer progressWritersMu.Unlock()
+
+	w, ok := progressWriters[dir]
+	if !ok {
+		w = newProgressWriter(dir)
+		progressWriters[dir] = w
+	}
+	w.register(f)
+	f.writer = w
+	return f
+}
+
+// add records that an object has been added to the queue.
+func (f *progressFile) add() {
+	if f == nil {
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.added++
+	f.dirty = true
+}
+
+func (f *progressFile) start(name, oid string, size int64, host string) {
+	if f == nil {
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	now := time.Now()
+	f.transfers[name] = &TransferProgress{
+		Direction: f.direction.String(),
+		Name:      name,
+		Oid:       oid,
+		Size:      size,
+		Start:     now,
+		Updated:   now,
+		Host:      host,
+	}
+	f.dirty = true
+}
+
+func (f *progressFile) update(name string, read int64) {
+	if f == nil {
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if t, ok := f.transfers[name]; ok {
+		t.Bytes = read
+		t.Updated = time.Now()
+		f.dirty = true
+	}
+}
+
+// retry records that the object will be retried, because of "err" if it is
+// not nil, and so is no longer being transferred.
+func (f *progressFile) retry(name, oid string, size int64, err error) {
+	if f == nil {
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	delete(f.transfers, name)
+
+	o, ok := f.retried[oid]
+	if !ok {
+		o = &QueuedObject{Name: name, Oid: oid, Size: size}
+		f.retried[oid] = o
+	}
+	o.Retries++
+	if err != nil {
+		o.Error = err.Error()
+	}
+	f.dirty = true
+}
+
+// finish records that the object has been transferred.
+func (f *progressFile) finish(name string) {
+	if f == nil {
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	delete(f.transfers, name)
+	f.finished++
+	f.dirty = true
+}
+
+// skip records that an object did not need to be transferred.
+func (f *progressFile) skip() {
+	if f == nil {
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.finished++
+	f.dirty = true
+}
+
+// fail records that the object could not be transferred because of "err".
+func (f *progressFile) fail(name, oid string, size int64, err error) {
+	if f == nil {
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	delete(f.transfers, name)
+	f.finished++
+
+	o := &QueuedObject{Name: name, Oid: oid, Size: size}
+	if r, ok := f.retried[oid]; ok {
+		o.Retries = r.Retries
+		delete(f.retried, oid)
+	}
+	if err != nil {
+		o.Error = err.Error()
+	}
+	f.failed = append(f.failed, o)
+	f.dirty = true
+}
+
+// close stops recording the queue's progress. Once every queue in the
+// process which shares its progress directory has closed, the process's
+// progress file is removed.
+func (f *progressFile) close() {
+	if f == nil {
+		return
+	}
+
+	progressWritersMu.Lock()
+	defer progressWritersMu.Unlock()
+
+	if f.writer.unregister(f) {
+		delete(progressWriters, f.writer.dir)
+		f.writer.close()
+	}
+}
+
+var (
+	// progressWriters holds the progressWriter for each progress
+	// directory in use by this process, so that all of its queues share
+	// a single file.
+	progressWriters   = make(map[string]*progressWriter)
+	progressWritersMu sync.Mutex
+)
+
+// progressWriter periodically writes the state of each of a process's queues
+// to a file in a progress directory named after the process's ID, so that
+// concurrent processes never write to the same file.
+type progressWriter struct {
+	dir  string
+	path string
+
+	mu    sync.Mutex
+	files []*progressFile
+	// changed is set when a queue is unregistered, so that its state is
+	// removed from the file.
+	changed bool
+
+	done chan struct{}
+	wg   sync.WaitGroup
+}
+
+func newProgressWriter(dir string) *progressWriter {
+	removeStaleProgressFiles(dir)
+
+	w := &progressWriter{
+		dir:  dir,
+		path: filepath.Join(dir, fmt.Sprintf("%d.json", os.Getpid())),
+		done: make(chan struct{}),
+	}
+
+	w.wg.Add(1)
+	go w.run()
+	return w
+}
+
+func (w *progressWriter) register(f *progressFile) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.files = append(w.files, f)
+}
+
+// unregister stops writing the state of "f", and returns whether there are
+// no other queues left to write.
+func (w *progressWriter) unregister(f *progressFile) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for i, other := range w.files {
+		if other == f {
+			w.files = append(w.files[:i], w.files[i+1:]...)
+			break
+		}
+	}
+	w.changed = true
+	return len(w.files) == 0
+}
+
+// close stops writing the progress file and removes it.
+func (w *progressWriter) close() {
+	close(w.done)
+	w.wg.Wait()
+	os.Remove(w.path)
+}
+
+func (w *progressWriter) run() {
+	defer w.wg.Done()
+
+	ticker := time.NewTicker(progressFileInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.done:
+			return
+		case <-ticker.C:
+			w.write()
+		}
+	}
+}
+
+// write persists the current state of each queue, if any has changed. Even
+// when none has, the file's modification time is refreshed so that readers
+// know this process is still running.
+func (w *progressWriter) write() {
+	w.mu.Lock()
+	dirty := w.changed
+	states := make([]*QueueState, 0, len(w.files))
+	for _, f := range w.files {
+		f.mu.Lock()
+		dirty = dirty || f.dirty
+		states = append(states, f.state())
+		f.dirty = false
+		f.mu.Unlock()
+	}
+	w.changed = false
+	w.mu.Unlock()
+
+	if !dirty {
+		now := time.Now()
+		os.Chtimes(w.path, now, now)
+		return
+	}
+
+	data, err := json.Marshal(states)
+	if err != nil {
+		return
+	}
+
+	if err := os.MkdirAll(w.dir, 0755); err != nil {
+		return
+	}
+
+	// Write to a temporary file first so that readers never see a
+	// partially written file.
+	tmp := w.path + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0644); err != nil {
+		return
+	}
+	tools.RobustRename(tmp, w.path)
+}
+
+// state returns a snapshot of the queue's state. The caller must hold f.mu.
+func (f *progressFile) state() *QueueState {
+	state := &QueueState{
+		Pid:       os.Getpid(),
+		Direction: f.direction.String(),
+		Remote:    f.remote,
+		Active:    make([]*TransferProgress, 0, len(f.transfers)),
+		Failed:    f.failed,
+		Updated:   time.Now(),
+	}
+
+	for _, t := range f.transfers {
+		state.Active = append(state.Active, t)
+	}
+	sort.Slice(state.Active, func(i, j int) bool {
+		return state.Active[i].Start.Before(state.Active[j].Start)
+	})
+
+	for _, o := range f.retried {
+		state.Retried = append(state.Retried, o)
+	}
+	sort.Slice(state.Retried, func(i, j int) bool {
+		return state.Retried[i].Name < state.Retried[j].Name
+	})
+
+	if pending := f.added - f.finished - len(state.Active); pending > 0 {
+		state.Pending = pending
+	}
+	return state
+}
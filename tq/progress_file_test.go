@@ -0,0 +1,185 @@
+package tq
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProgressFileRoundTrip(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tq-progress")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	defer func(interval time.Duration) {
+		progressFileInterval = interval
+	}(progressFileInterval)
+	progressFileInterval = 10 * time.Millisecond
+
+	f := newProgressFile(dir, Upload, "origin")
+	f.start("dataset.bin", "oid1", 100, "lfs.example.com")
+	f.start("other.bin", "oid2", 10, "lfs.example.com")
+	f.update("dataset.bin", 42)
+	f.finish("other.bin")
+
+	var progress []*TransferProgress
+	require.Eventually(t, func() bool {
+		progress, err = ReadTransferProgress(dir)
+		return err == nil && len(progress) == 1 && progress[0].Bytes == 42
+	}, time.Second, 10*time.Millisecond)
+
+	assert.Equal(t, "upload", progress[0].Direction)
+	assert.Equal(t, "dataset.bin", progress[0].Name)
+	assert.Equal(t, "oid1", progress[0].Oid)
+	assert.Equal(t, "lfs.example.com", progress[0].Host)
+	assert.Equal(t, 42, progress[0].Percent())
+
+	f.close()
+	_, err = os.Stat(filepath.Join(dir, fmt.Sprintf("%d.json", os.Getpid())))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestProgressFileQueueState(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tq-progress")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	defer func(interval time.Duration) {
+		progressFileInterval = interval
+	}(progressFileInterval)
+	progressFileInterval = 10 * time.Millisecond
+
+	f := newProgressFile(dir, Upload, "origin")
+	defer f.close()
+
+	for i := 0; i < 5; i++ {
+		f.add()
+	}
+	f.start("a.bin", "oid1", 10, "lfs.example.com")
+	f.start("b.bin", "oid2", 10, "lfs.example.com")
+	f.start("c.bin", "oid3", 10, "cdn.example.com")
+	f.retry("b.bin", "oid2", 10, errors.New("connection reset"))
+	f.fail("c.bin", "oid3", 10, errors.New("forbidden"))
+	f.skip()
+
+	var states []*QueueState
+	require.Eventually(t, func() bool {
+		states, err = ReadQueueStates(dir)
+		return err == nil && len(states) == 1 && len(states[0].Failed) == 1 && states[0].Pending == 2
+	}, time.Second, 10*time.Millisecond)
+
+	state := states[0]
+	assert.Equal(t, os.Getpid(), state.Pid)
+	assert.Equal(t, "upload", state.Direction)
+	assert.Equal(t, "origin", state.Remote)
+
+	require.Len(t, state.Active, 1)
+	assert.Equal(t, "a.bin", state.Active[0].Name)
+
+	require.Len(t, state.Retried, 1)
+	assert.Equal(t, "b.bin", state.Retried[0].Name)
+	assert.Equal(t, 1, state.Retried[0].Retries)
+	assert.Equal(t, "connection reset", state.Retried[0].Error)
+
+	assert.Equal(t, "c.bin", state.Failed[0].Name)
+	assert.Equal(t, "forbidden", state.Failed[0].Error)
+}
+
+func TestReadTransferProgressIgnoresStaleFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tq-progress")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "1.json")
+	require.Nil(t, ioutil.WriteFile(path, []byte(`[{"pid":1,"direction":"upload","active":[{"name":"a.dat","size":10,"bytes":5}]}]`), 0644))
+
+	progress, err := ReadTransferProgress(dir)
+	require.Nil(t, err)
+	assert.Len(t, progress, 1)
+
+	old := time.Now().Add(-2 * progressFileExpiry)
+	require.Nil(t, os.Chtimes(path, old, old))
+
+	progress, err = ReadTransferProgress(dir)
+	require.Nil(t, err)
+	assert.Empty(t, progress)
+}
+
+func TestProgressFilesShareOneFilePerProcess(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tq-progress")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	defer func(interval time.Duration) {
+		progressFileInterval = interval
+	}(progressFileInterval)
+	progressFileInterval = 10 * time.Millisecond
+
+	// Another process, which has since exited, left its file behind.
+	stale := filepath.Join(dir, "1.json")
+	require.Nil(t, ioutil.WriteFile(stale, []byte(`[{"pid":1,"direction":"upload"}]`), 0644))
+	old := time.Now().Add(-2 * progressFileExpiry)
+	require.Nil(t, os.Chtimes(stale, old, old))
+
+	upload := newProgressFile(dir, Upload, "origin")
+	download := newProgressFile(dir, Download, "origin")
+	upload.start("a.bin", "oid1", 10, "lfs.example.com")
+	download.start("b.bin", "oid2", 10, "lfs.example.com")
+
+	var states []*QueueState
+	require.Eventually(t, func() bool {
+		states, err = ReadQueueStates(dir)
+		return err == nil && len(states) == 2
+	}, time.Second, 10*time.Millisecond)
+
+	assert.Equal(t, "upload", states[0].Direction)
+	assert.Equal(t, "download", states[1].Direction)
+
+	entries, err := ioutil.ReadDir(dir)
+	require.Nil(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, fmt.Sprintf("%d.json", os.Getpid()), entries[0].Name())
+
+	upload.close()
+	require.Eventually(t, func() bool {
+		states, err = ReadQueueStates(dir)
+		return err == nil && len(states) == 1 && states[0].Direction == "download"
+	}, time.Second, 10*time.Millisecond)
+
+	download.close()
+	entries, err = ioutil.ReadDir(dir)
+	require.Nil(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestReadTransferProgressMissingDir(t *testing.T) {
+	progress, err := ReadTransferProgress(filepath.Join(os.TempDir(), "tq-progress-missing"))
+	assert.Nil(t, err)
+	assert.Empty(t, progress)
+}
+
+func TestTransferProgressETA(t *testing.T) {
+	start := time.Now()
+	p := &TransferProgress{
+		Size:    1000,
+		Bytes:   250,
+		Start:   start,
+		Updated: start.Add(10 * time.Second),
+	}
+
+	eta, ok := p.ETA()
+	require.True(t, ok)
+	assert.Equal(t, 30*time.Second, eta)
+	assert.Equal(t, 25, p.Percent())
+
+	p.Updated = p.Start
+	_, ok = p.ETA()
+	assert.False(t, ok)
+}
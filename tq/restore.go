@@ -0,0 +1,122 @@
+package tq
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/git-lfs/git-lfs/v3/errors"
+	"github.com/git-lfs/git-lfs/v3/tr"
+)
+
+const (
+	// defaultRestoreTimeout is how long to poll for an object which is
+	// being restored from cold storage before giving up on it.
+	defaultRestoreTimeout = 10 * time.Minute
+
+	// defaultRestorePollInterval is how long to wait before asking again
+	// for an object which is being restored, if the server doesn't say.
+	defaultRestorePollInterval = 30 * time.Second
+)
+
+// restorePendingError is returned when a download of an object is answered
+// with "202 Accepted", meaning that the server has the object, but must first
+// restore it from cold storage before it can be downloaded.
+type restorePendingError struct {
+	oid   string
+	until time.Time
+}
+
+// newRestorePendingError returns an error for the object "oid" whose download
+// was answered by "res", which may say when to try again either with a
+// Retry-After header or with a "retry_after" number of seconds in a JSON body.
+func newRestorePendingError(oid string, res *http.Response) error {
+	until, ok := errors.ParseRetryAfter(res.Header.Get("Retry-After"))
+	if !ok {
+		var body struct {
+			RetryAfter int `json:"retry_after"`
+		}
+		err := json.NewDecoder(io.LimitReader(res.Body, 4096)).Decode(&body)
+		if err == nil && body.RetryAfter > 0 {
+			until, ok = time.Now().Add(time.Duration(body.RetryAfter)*time.Second), true
+		}
+	}
+	if !ok {
+		until = time.Now().Add(defaultRestorePollInterval)
+	}
+
+	io.Copy(ioutil.Discard, res.Body)
+	return &restorePendingError{oid: oid, until: until}
+}
+
+func (e *restorePendingError) Error() string {
+	return tr.Tr.Get("Object %s is being restored from cold storage; try again later", e.oid)
+}
+
+// isRestorePendingError returns the *restorePendingError which caused "err",
+// if any.
+func isRestorePendingError(err error) (*restorePendingError, bool) {
+	e, ok := errors.Cause(err).(*restorePendingError)
+	return e, ok
+}
+
+// restoreTracker records when each object was first found to be being
+// restored from cold storage, so that the queue polls for it for no longer than
+// its timeout. Polls don't count against an object's retries, since a restore
+// may well take longer than they would last.
+type restoreTracker struct {
+	timeout time.Duration
+
+	mu      sync.Mutex
+	started map[string]time.Time
+	polling map[string]bool
+}
+
+func newRestoreTracker(timeout time.Duration) *restoreTracker {
+	return &restoreTracker{
+		timeout: timeout,
+		started: make(map[string]time.Time),
+		polling: make(map[string]bool),
+	}
+}
+
+// poll returns whether the object "oid" should be asked for again once it has
+// been restored, and marks it as being polled for if so.
+func (r *restoreTracker) poll(oid string) bool {
+	if r == nil || r.timeout <= 0 {
+		return false
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	started, ok := r.started[oid]
+	if !ok {
+		started = time.Now()
+		r.started[oid] = started
+	}
+	if time.Since(started) > r.timeout {
+		return false
+	}
+
+	r.polling[oid] = true
+	return true
+}
+
+// polled returns whether the object "oid" is being polled for, which it no
+// longer is once it has been asked for again.
+func (r *restoreTracker) polled(oid string) bool {
+	if r == nil {
+		return false
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	polling := r.polling[oid]
+	delete(r.polling, oid)
+	return polling
+}
@@ -0,0 +1,59 @@
+package tq
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/git-lfs/git-lfs/v3/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRestorePendingErrorRetryAfter(t *testing.T) {
+	for desc, res := range map[string]*http.Response{
+		"header": {
+			Header: http.Header{"Retry-After": []string{"120"}},
+			Body:   ioutil.NopCloser(strings.NewReader("")),
+		},
+		"body": {
+			Header: http.Header{},
+			Body:   ioutil.NopCloser(strings.NewReader(`{"retry_after": 120}`)),
+		},
+	} {
+		err := newRestorePendingError("oid", res)
+		restore, ok := isRestorePendingError(errors.Wrap(err, "download"))
+		require.True(t, ok, desc)
+		assert.WithinDuration(t, time.Now().Add(120*time.Second), restore.until, 5*time.Second, desc)
+	}
+
+	err := newRestorePendingError("oid", &http.Response{
+		Header: http.Header{},
+		Body:   ioutil.NopCloser(strings.NewReader("not json")),
+	})
+	restore, ok := isRestorePendingError(err)
+	require.True(t, ok)
+	assert.WithinDuration(t, time.Now().Add(defaultRestorePollInterval), restore.until, 5*time.Second)
+	assert.False(t, errors.IsRetriableError(err))
+}
+
+func TestRestoreTrackerPoll(t *testing.T) {
+	r := newRestoreTracker(time.Hour)
+	assert.False(t, r.polled("oid"))
+
+	assert.True(t, r.poll("oid"))
+	assert.True(t, r.polled("oid"))
+	assert.False(t, r.polled("oid"))
+
+	r.started["oid"] = time.Now().Add(-2 * time.Hour)
+	assert.False(t, r.poll("oid"))
+	assert.False(t, r.polled("oid"))
+
+	assert.False(t, newRestoreTracker(0).poll("oid"))
+
+	var nilTracker *restoreTracker
+	assert.False(t, nilTracker.poll("oid"))
+	assert.False(t, nilTracker.polled("oid"))
+}
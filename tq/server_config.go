@@ -0,0 +1,149 @@
+package tq
+
+import (
+	"github.com/git-lfs/git-lfs/v3/config"
+	"github.com/git-lfs/git-lfs/v3/lfsapi"
+	"github.com/git-lfs/git-lfs/v3/lfshttp"
+	"github.com/rubyist/tracerx"
+)
+
+// serverConfig is the configuration document which a server may advertise at
+// "<endpoint>/config", to tune how clients transfer objects to and from it.
+// See docs/api/server-config.md.
+type serverConfig struct {
+	// ConcurrentTransfers is the number of transfers which should be made
+	// at once.
+	ConcurrentTransfers int `json:"concurrent_transfers,omitempty"`
+
+	// Transfer is the name of the transfer adapter which the server
+	// recommends.
+	Transfer string `json:"transfer,omitempty"`
+
+	// MaxFileSize is the size in bytes of the largest object which the
+	// server accepts.
+	MaxFileSize int64 `json:"max_file_size,omitempty"`
+
+	// Capabilities are the names of the optional features which the
+	// server supports, or nil if it doesn't say.
+	Capabilities capabilities `json:"capabilities,omitempty"`
+}
+
+const (
+	// capBatch is the capability of serving the batch API.
+	capBatch = "batch"
+	// capChunkedUpload is the capability of accepting uploads with
+	// chunked transfer encoding.
+	capChunkedUpload = "chunked-upload"
+	// capRangeDownload is the capability of serving part of an object
+	// in response to a request with a Range header.
+	capRangeDownload = "range-download"
+	// capVerifyRequired means that every upload must be verified, so the
+	// server always gives a "verify" action.
+	capVerifyRequired = "verify-required"
+)
+
+// capabilities are the optional features which a server advertises in its
+// configuration document. A nil set is unknown, and has no capabilities.
+type capabilities []string
+
+// known returns whether the server advertised its capabilities. Features
+// which it does not advertise may still be tried when they are not known.
+func (c capabilities) known() bool {
+	return c != nil
+}
+
+// has returns whether the server advertised the capability "name".
+func (c capabilities) has(name string) bool {
+	for _, n := range c {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// lacks returns whether the server advertised its capabilities without
+// "name", and so is known not to support it.
+func (c capabilities) lacks(name string) bool {
+	return c.known() && !c.has(name)
+}
+
+// serverCapabilities returns the capabilities advertised by the endpoint for
+// "operation" on "remote", which are fetched once per endpoint and server state
+// "s" along with the rest of its configuration document.
+func serverCapabilities(s *serverState, c *lfsapi.Client, operation, remote string) capabilities {
+	if c == nil {
+		return nil
+	}
+	if sc := fetchServerConfig(s, c, operation, remote); sc != nil {
+		return sc.Capabilities
+	}
+	return nil
+}
+
+// fetchServerConfig returns the configuration document advertised by the
+// endpoint for "operation" on "remote", or nil if there is none, or fetching
+// it is not enabled with `lfs.serverconfig`. The document is remembered by the
+// server state "s", so that it is only asked for once.
+func fetchServerConfig(s *serverState, c *lfsapi.Client, operation, remote string) *serverConfig {
+	e := c.Endpoints.Endpoint(operation, remote)
+	if len(e.Url) == 0 || !config.NewURLConfig(c.GitEnv()).Bool("lfs", e.Url, "serverconfig", false) {
+		return nil
+	}
+
+	if sc, ok := s.serverConfig(e.Url); ok {
+		return sc
+	}
+
+	sc, err := requestServerConfig(c, e, remote)
+	if err != nil {
+		tracerx.Printf("api: unable to fetch server config from %s: %s", e.Url, err)
+		sc = nil
+	}
+
+	s.setServerConfig(e.Url, sc)
+	return sc
+}
+
+func requestServerConfig(c *lfsapi.Client, e lfshttp.Endpoint, remote string) (*serverConfig, error) {
+	req, err := c.NewRequest("GET", e, "config", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := c.DoAPIRequestWithAuth(remote, c.LogRequest(req, "lfs.config"))
+	if err != nil {
+		return nil, err
+	}
+
+	sc := &serverConfig{}
+	if err := lfshttp.DecodeJSON(res, sc); err != nil {
+		return nil, err
+	}
+
+	tracerx.Printf("api: server config from %s: %+v", e.Url, *sc)
+	return sc, nil
+}
+
+// applyServerConfig adjusts the settings of "m" as advised by "sc", within the
+// bounds of the user's own configuration.
+func (m *concreteManifest) applyServerConfig(sc *serverConfig, git Env) {
+	if sc == nil {
+		return
+	}
+
+	if n := sc.ConcurrentTransfers; n > 0 {
+		// The user's setting, or the default where there is none, is
+		// an upper bound, which the server may only lower.
+		max := git.Int("lfs.concurrenttransfers", 0)
+		if max < 1 {
+			max = defaultConcurrentTransfers
+		}
+		if n < max {
+			m.concurrentTransfers = n
+		}
+	}
+
+	m.preferredTransfer = sc.Transfer
+	m.maxFileSize = sc.MaxFileSize
+}
@@ -0,0 +1,160 @@
+package tq
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/git-lfs/git-lfs/v3/lfsapi"
+	"github.com/git-lfs/git-lfs/v3/lfshttp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newServerConfigServer(t *testing.T, requests *uint32, doc string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/config" {
+			w.WriteHeader(404)
+			return
+		}
+
+		atomic.AddUint32(requests, 1)
+		w.Header().Set("Content-Type", "application/vnd.git-lfs+json")
+		w.Write([]byte(doc))
+	}))
+}
+
+// serverConfigDoc is the configuration document served by the servers which
+// newServerConfigServer returns in most tests.
+const serverConfigDoc = `{"concurrent_transfers":4,"transfer":"basic","max_file_size":10}`
+
+func TestServerConfigApplied(t *testing.T) {
+	var requests uint32
+	srv := newServerConfigServer(t, &requests, serverConfigDoc)
+	defer srv.Close()
+
+	cli, err := lfsapi.NewClient(lfshttp.NewContext(nil, nil, map[string]string{
+		"lfs.url":                          srv.URL + "/api",
+		"lfs." + srv.URL + ".serverconfig": "true",
+	}))
+	require.Nil(t, err)
+
+	m := NewManifest(nil, cli, "upload", "origin")
+	cm := m.Upgrade()
+	assert.Equal(t, 4, cm.ConcurrentTransfers())
+	assert.EqualValues(t, 10, cm.maxFileSize)
+	assert.Equal(t, "basic", cm.GetUploadAdapterNames()[0])
+
+	q := NewTransferQueue(Upload, m, "origin")
+	q.Add("big.dat", "big.dat", "oid", 11, false, nil)
+	q.Wait()
+	if assert.Len(t, q.Errors(), 1) {
+		assert.Contains(t, q.Errors()[0].Error(), "big.dat")
+	}
+
+	// The document is only fetched once for each manifest.
+	assert.EqualValues(t, 1, atomic.LoadUint32(&requests))
+
+	NewManifest(nil, cli, "upload", "origin").Upgrade()
+	assert.EqualValues(t, 2, atomic.LoadUint32(&requests))
+}
+
+func TestServerConfigBoundedByUser(t *testing.T) {
+	var requests uint32
+	srv := newServerConfigServer(t, &requests, serverConfigDoc)
+	defer srv.Close()
+
+	cli, err := lfsapi.NewClient(lfshttp.NewContext(nil, nil, map[string]string{
+		"lfs.url":                          srv.URL + "/api",
+		"lfs." + srv.URL + ".serverconfig": "true",
+		"lfs.concurrenttransfers":          "2",
+	}))
+	require.Nil(t, err)
+
+	m := NewManifest(nil, cli, "download", "origin").Upgrade()
+	assert.Equal(t, 2, m.ConcurrentTransfers())
+}
+
+func TestServerConfigBoundedByDefault(t *testing.T) {
+	var requests uint32
+	srv := newServerConfigServer(t, &requests, `{"concurrent_transfers":1000}`)
+	defer srv.Close()
+
+	config := map[string]string{
+		"lfs.url":                          srv.URL + "/api",
+		"lfs." + srv.URL + ".serverconfig": "true",
+	}
+	cli, err := lfsapi.NewClient(lfshttp.NewContext(nil, nil, config))
+	require.Nil(t, err)
+
+	m := NewManifest(nil, cli, "download", "origin").Upgrade()
+	assert.Equal(t, defaultConcurrentTransfers, m.ConcurrentTransfers())
+
+	// Where the user has set a higher number, that is the bound instead.
+	config["lfs.concurrenttransfers"] = "16"
+	cli, err = lfsapi.NewClient(lfshttp.NewContext(nil, nil, config))
+	require.Nil(t, err)
+
+	m = NewManifest(nil, cli, "download", "origin").Upgrade()
+	assert.Equal(t, 16, m.ConcurrentTransfers())
+}
+
+func TestServerConfigDisabledByDefault(t *testing.T) {
+	var requests uint32
+	srv := newServerConfigServer(t, &requests, serverConfigDoc)
+	defer srv.Close()
+
+	cli, err := lfsapi.NewClient(lfshttp.NewContext(nil, nil, map[string]string{
+		"lfs.url": srv.URL + "/api",
+	}))
+	require.Nil(t, err)
+
+	m := NewManifest(nil, cli, "download", "origin").Upgrade()
+	assert.Equal(t, defaultConcurrentTransfers, m.ConcurrentTransfers())
+	assert.EqualValues(t, 0, atomic.LoadUint32(&requests))
+}
+
+func TestCapabilities(t *testing.T) {
+	var unknown capabilities
+	assert.False(t, unknown.known())
+	assert.False(t, unknown.has(capBatch))
+	assert.False(t, unknown.lacks(capBatch))
+
+	caps := capabilities{capBatch}
+	assert.True(t, caps.known())
+	assert.True(t, caps.has(capBatch))
+	assert.False(t, caps.lacks(capBatch))
+	assert.True(t, caps.lacks(capRangeDownload))
+}
+
+func TestServerConfigWithoutBatchCapability(t *testing.T) {
+	var batches uint32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/config":
+			w.Header().Set("Content-Type", "application/vnd.git-lfs+json")
+			w.Write([]byte(`{"capabilities":["range-download"]}`))
+		case "/api/objects/batch":
+			atomic.AddUint32(&batches, 1)
+			w.WriteHeader(404)
+		default:
+			w.WriteHeader(404)
+		}
+	}))
+	defer srv.Close()
+
+	cli, err := lfsapi.NewClient(lfshttp.NewContext(nil, nil, map[string]string{
+		"lfs.url":                          srv.URL + "/api",
+		"lfs." + srv.URL + ".serverconfig": "true",
+	}))
+	require.Nil(t, err)
+
+	_, err = Batch(NewManifest(nil, cli, "download", "origin"), Download, "origin", nil, []*Transfer{
+		&Transfer{Oid: "a", Size: 1},
+	})
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "does not support the Git LFS batch API")
+	}
+	assert.EqualValues(t, 0, atomic.LoadUint32(&batches))
+}
@@ -0,0 +1,101 @@
+package tq
+
+import (
+	"sync"
+)
+
+// serverState remembers what servers have said about themselves, so that they
+// need not be asked again for as long as the manifest which holds it is used.
+// A nil serverState remembers nothing.
+type serverState struct {
+	// unsupportedBatchEndpoints holds the error returned by each endpoint
+	// whose batch API responded with HTTP 404 or 406, so that later
+	// batches fail immediately rather than asking the server again.
+	//
+	// Since the legacy per-object API is no longer supported, there is no
+	// other protocol to fall back to.
+	unsupportedBatchEndpoints sync.Map
+
+	// knownObjects records whether each endpoint's batch API has
+	// reported that it already has a given object, so that existence
+	// checks and uploads made over the course of a single command, such
+	// as a push of several refs, need not ask the server again.
+	knownObjects *metadataCache
+
+	// serverConfigs holds the configuration document fetched from each
+	// endpoint, or nil if it didn't provide one, so that it is only
+	// asked once.
+	serverConfigs sync.Map
+
+	// uploadRoutes records whether uploads to each host named by an
+	// upload action's href could only be made through the action's
+	// fallback, so that later uploads try the route which worked first
+	// rather than waiting on a host which can't be reached, as when a
+	// firewall blocks a storage service's domain.
+	uploadRoutes *routeCache
+}
+
+func newServerState() *serverState {
+	return &serverState{
+		knownObjects: newMetadataCache(),
+		uploadRoutes: newRouteCache(),
+	}
+}
+
+// objects returns what is known about which objects each endpoint has.
+func (s *serverState) objects() *metadataCache {
+	if s == nil {
+		return nil
+	}
+	return s.knownObjects
+}
+
+// routes returns which route worked for uploads to each host.
+func (s *serverState) routes() *routeCache {
+	if s == nil {
+		return nil
+	}
+	return s.uploadRoutes
+}
+
+// batchUnsupported returns the error with which the batch API at the endpoint
+// "url" failed before, or nil if it has not.
+func (s *serverState) batchUnsupported(url string) error {
+	if s == nil {
+		return nil
+	}
+	if err, ok := s.unsupportedBatchEndpoints.Load(url); ok {
+		return err.(error)
+	}
+	return nil
+}
+
+// setBatchUnsupported records that the batch API at the endpoint "url" failed
+// with "err".
+func (s *serverState) setBatchUnsupported(url string, err error) {
+	if s != nil {
+		s.unsupportedBatchEndpoints.Store(url, err)
+	}
+}
+
+// serverConfig returns the configuration document fetched before from the
+// endpoint "url", which is nil if it provided none, and whether it has been
+// fetched at all.
+func (s *serverState) serverConfig(url string) (*serverConfig, bool) {
+	if s == nil {
+		return nil, false
+	}
+	sc, ok := s.serverConfigs.Load(url)
+	if !ok {
+		return nil, false
+	}
+	return sc.(*serverConfig), true
+}
+
+// setServerConfig records the configuration document "sc" fetched from the
+// endpoint "url".
+func (s *serverState) setServerConfig(url string, sc *serverConfig) {
+	if s != nil {
+		s.serverConfigs.Store(url, sc)
+	}
+}
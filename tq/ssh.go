@@ -20,6 +20,7 @@ import (
 	"github.com/rubyist/tracerx"
 )
 
+// SSHBatchClient sends batch requests over an SSH connection.
 type SSHBatchClient struct {
 	maxRetries int
 	transfer   *ssh.SSHTransfer
@@ -44,6 +45,7 @@ func (a *SSHBatchClient) batchInternal(args []string, batchLines []string) (int,
 	return status, args, lines, err
 }
 
+// Batch sends the batch request "bReq".
 func (a *SSHBatchClient) Batch(remote string, bReq *batchRequest) (*BatchResponse, error) {
 	bRes := &BatchResponse{TransferAdapterName: "ssh"}
 	if len(bReq.Objects) == 0 {
@@ -141,14 +143,18 @@ func (a *SSHBatchClient) Batch(remote string, bReq *batchRequest) (*BatchRespons
 	return bRes, nil
 }
 
+// MaxRetries returns the number of times a request is retried.
 func (a *SSHBatchClient) MaxRetries() int {
 	return a.maxRetries
 }
 
+// SetMaxRetries sets the number of times a request is retried.
 func (a *SSHBatchClient) SetMaxRetries(n int) {
 	a.maxRetries = n
 }
 
+// SSHAdapter transfers objects over an SSH connection, with the
+// git-lfs-transfer protocol.
 type SSHAdapter struct {
 	*adapterBase
 	ctx      lfshttp.Context
@@ -278,12 +284,7 @@ func (a *SSHAdapter) doDownload(t *Transfer, conn *ssh.PktlineConnection, f *os.
 		return errors.New(tr.Tr.Get("can't close temporary file %q: %v", dlfilename, err))
 	}
 
-	err = tools.RenameFileCopyPermissions(dlfilename, t.Path)
-	if _, err2 := os.Stat(t.Path); err2 == nil {
-		// Target file already exists, possibly was downloaded by other git-lfs process
-		return nil
-	}
-	return err
+	return a.storeObject(t, dlfilename)
 }
 
 func (a *SSHAdapter) verifyUpload(t *Transfer, conn *ssh.PktlineConnection) error {
@@ -400,6 +401,7 @@ func (a *SSHAdapter) Begin(cfg AdapterConfig, cb ProgressCallback) error {
 	return nil
 }
 
+// Trace logs a message if GIT_TRANSFER_TRACE is set.
 func (a *SSHAdapter) Trace(format string, args ...interface{}) {
 	if !a.adapterBase.debugging {
 		return
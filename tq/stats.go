@@ -0,0 +1,219 @@
+package tq
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+)
+
+// ObjectStatus is the final outcome of an object's transfer.
+type ObjectStatus string
+
+const (
+	// StatusInProgress indicates that the object's transfer has not yet
+	// completed.
+	StatusInProgress ObjectStatus = "in-progress"
+	// StatusSucceeded indicates that the object was transferred.
+	StatusSucceeded ObjectStatus = "succeeded"
+	// StatusSkipped indicates that the object did not need to be
+	// transferred, for instance because the server already had it.
+	StatusSkipped ObjectStatus = "skipped"
+	// StatusFailed indicates that the object could not be transferred.
+	StatusFailed ObjectStatus = "failed"
+)
+
+// ObjectStats describes the transfer of a single object.
+type ObjectStats struct {
+	Oid  string `json:"oid"`
+	Name string `json:"name"`
+	Size int64  `json:"size"`
+	// Bytes is the number of bytes transferred, including those sent or
+	// received during attempts which were later retried.
+	Bytes   int64        `json:"bytes"`
+	Retries int          `json:"retries"`
+	Status  ObjectStatus `json:"status"`
+	Error   string       `json:"error,omitempty"`
+	Start   time.Time    `json:"start"`
+	End     time.Time    `json:"end"`
+}
+
+// Duration returns the time between the start of the object's first transfer
+// attempt and the end of its last, or zero if it has not completed.
+func (o *ObjectStats) Duration() time.Duration {
+	if o.Start.IsZero() || o.End.IsZero() {
+		return 0
+	}
+	return o.End.Sub(o.Start)
+}
+
+// StatsSummary holds aggregate numbers for all of the objects recorded by a
+// *Stats.
+type StatsSummary struct {
+	Objects   int   `json:"objects"`
+	Succeeded int   `json:"succeeded"`
+	Skipped   int   `json:"skipped"`
+	Failed    int   `json:"failed"`
+	Bytes     int64 `json:"bytes"`
+	Retries   int   `json:"retries"`
+	// Duration is the time between the start of the first transfer and
+	// the end of the last.
+	Duration time.Duration `json:"duration_ns"`
+	// BytesPerSecond is the average rate over Duration.
+	BytesPerSecond float64 `json:"bytes_per_second"`
+}
+
+// Stats collects statistics about the objects transferred by one or more
+// *TransferQueues. A nil *Stats is valid and records nothing.
+type Stats struct {
+	mu      sync.Mutex
+	objects map[string]*ObjectStats
+	names   map[string]*ObjectStats
+	order   []*ObjectStats
+}
+
+// NewStats returns a new, empty *Stats.
+func NewStats() *Stats {
+	return &Stats{
+		objects: make(map[string]*ObjectStats),
+		names:   make(map[string]*ObjectStats),
+	}
+}
+
+// Objects returns a copy of the statistics for each recorded object, in the
+// order in which they were first seen.
+func (s *Stats) Objects() []ObjectStats {
+	if s == nil {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	objects := make([]ObjectStats, 0, len(s.order))
+	for _, o := range s.order {
+		objects = append(objects, *o)
+	}
+	return objects
+}
+
+// Summary returns aggregate numbers for all of the recorded objects.
+func (s *Stats) Summary() StatsSummary {
+	var summary StatsSummary
+	var start, end time.Time
+
+	for _, o := range s.Objects() {
+		summary.Objects++
+		summary.Bytes += o.Bytes
+		summary.Retries += o.Retries
+
+		switch o.Status {
+		case StatusSucceeded:
+			summary.Succeeded++
+		case StatusSkipped:
+			summary.Skipped++
+		case StatusFailed:
+			summary.Failed++
+		}
+
+		if !o.Start.IsZero() && (start.IsZero() || o.Start.Before(start)) {
+			start = o.Start
+		}
+		if o.End.After(end) {
+			end = o.End
+		}
+	}
+
+	if !start.IsZero() && end.After(start) {
+		summary.Duration = end.Sub(start)
+		summary.BytesPerSecond = float64(summary.Bytes) / summary.Duration.Seconds()
+	}
+	return summary
+}
+
+// WriteJSON writes a report of the summary and each recorded object to "w" as
+// a single JSON object.
+func (s *Stats) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(struct {
+		Summary StatsSummary  `json:"summary"`
+		Objects []ObjectStats `json:"objects"`
+	}{s.Summary(), s.Objects()})
+}
+
+// object returns the statistics for the given OID, creating them if needed.
+// The caller must hold s.mu.
+func (s *Stats) object(oid, name string, size int64) *ObjectStats {
+	o, ok := s.objects[oid]
+	if !ok {
+		o = &ObjectStats{Oid: oid, Name: name, Size: size, Status: StatusInProgress}
+		s.objects[oid] = o
+		s.order = append(s.order, o)
+	}
+	if len(name) > 0 {
+		s.names[name] = o
+		if len(o.Name) == 0 {
+			o.Name = name
+		}
+	}
+	return o
+}
+
+func (s *Stats) start(oid, name string, size int64) {
+	if s == nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if o := s.object(oid, name, size); o.Start.IsZero() {
+		o.Start = time.Now()
+	}
+}
+
+// transferBytes records "n" bytes transferred for the object which is being
+// transferred under the given name, as reported by the adapter callback.
+func (s *Stats) transferBytes(name string, n int) {
+	if s == nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if o, ok := s.names[name]; ok {
+		o.Bytes += int64(n)
+	}
+}
+
+func (s *Stats) retry(oid string) {
+	if s == nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.object(oid, "", 0).Retries++
+}
+
+func (s *Stats) finish(oid, name string, size int64, status ObjectStatus, err error) {
+	if s == nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	o := s.object(oid, name, size)
+	o.End = time.Now()
+	if o.Start.IsZero() {
+		o.Start = o.End
+	}
+	o.Status = status
+	if err != nil {
+		o.Error = err.Error()
+	}
+}
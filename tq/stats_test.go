@@ -0,0 +1,82 @@
+package tq
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatsRecordsObjects(t *testing.T) {
+	s := NewStats()
+
+	s.start("oid1", "a.dat", 10)
+	s.transferBytes("a.dat", 4)
+	s.retry("oid1")
+	s.transferBytes("a.dat", 10)
+	s.finish("oid1", "a.dat", 10, StatusSucceeded, nil)
+
+	s.start("oid2", "b.dat", 20)
+	s.finish("oid2", "b.dat", 20, StatusFailed, errors.New("boom"))
+
+	s.finish("oid3", "c.dat", 30, StatusSkipped, nil)
+
+	objects := s.Objects()
+	require.Len(t, objects, 3)
+
+	assert.Equal(t, "oid1", objects[0].Oid)
+	assert.Equal(t, "a.dat", objects[0].Name)
+	assert.EqualValues(t, 14, objects[0].Bytes)
+	assert.Equal(t, 1, objects[0].Retries)
+	assert.Equal(t, StatusSucceeded, objects[0].Status)
+	assert.False(t, objects[0].End.Before(objects[0].Start))
+
+	assert.Equal(t, StatusFailed, objects[1].Status)
+	assert.Equal(t, "boom", objects[1].Error)
+
+	assert.Equal(t, StatusSkipped, objects[2].Status)
+	assert.Zero(t, objects[2].Duration())
+
+	summary := s.Summary()
+	assert.Equal(t, 3, summary.Objects)
+	assert.Equal(t, 1, summary.Succeeded)
+	assert.Equal(t, 1, summary.Failed)
+	assert.Equal(t, 1, summary.Skipped)
+	assert.EqualValues(t, 14, summary.Bytes)
+	assert.Equal(t, 1, summary.Retries)
+}
+
+func TestStatsWriteJSON(t *testing.T) {
+	s := NewStats()
+	s.start("oid1", "a.dat", 10)
+	s.transferBytes("a.dat", 10)
+	s.finish("oid1", "a.dat", 10, StatusSucceeded, nil)
+
+	var buf bytes.Buffer
+	require.Nil(t, s.WriteJSON(&buf))
+
+	var report struct {
+		Summary StatsSummary
+		Objects []ObjectStats
+	}
+	require.Nil(t, json.Unmarshal(buf.Bytes(), &report))
+	assert.Equal(t, 1, report.Summary.Succeeded)
+	require.Len(t, report.Objects, 1)
+	assert.Equal(t, "oid1", report.Objects[0].Oid)
+	assert.Equal(t, StatusSucceeded, report.Objects[0].Status)
+}
+
+func TestNilStats(t *testing.T) {
+	var s *Stats
+
+	s.start("oid1", "a.dat", 10)
+	s.transferBytes("a.dat", 10)
+	s.retry("oid1")
+	s.finish("oid1", "a.dat", 10, StatusSucceeded, nil)
+
+	assert.Empty(t, s.Objects())
+	assert.Equal(t, StatsSummary{}, s.Summary())
+}
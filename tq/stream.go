@@ -0,0 +1,125 @@
+package tq
+
+import (
+	"encoding/hex"
+	"hash"
+	"io"
+	"io/ioutil"
+	"sync"
+
+	"github.com/git-lfs/git-lfs/v3/errors"
+	"github.com/git-lfs/git-lfs/v3/tools"
+	"github.com/git-lfs/git-lfs/v3/tr"
+)
+
+// streamWriter writes an object to a writer as it is downloaded, so that the
+// object need not be read back from the local object store afterwards. Each
+// byte of the object is written once, in order, however many times its
+// download is retried or resumed.
+//
+// Since bytes are written before the download which gave them is verified, a
+// download which failed partway may have written some which are not the
+// object's. What was written is hashed as it is written, and checked against
+// the object's OID once the object is complete.
+type streamWriter struct {
+	w   io.Writer
+	oid string
+	h   hash.Hash
+
+	// written is the number of bytes of the object written to "w", and
+	// err the error, if any, with which writing to it failed.
+	written int64
+	err     error
+
+	mu sync.Mutex
+}
+
+func newStreamWriter(w io.Writer, oid string) *streamWriter {
+	return &streamWriter{w: w, oid: oid, h: tools.NewLfsContentHash()}
+}
+
+// write writes "p" to the stream, hashing what was written.
+func (s *streamWriter) write(p []byte) (int64, error) {
+	n, err := s.w.Write(p)
+	s.h.Write(p[:n])
+	s.written += int64(n)
+	return int64(n), err
+}
+
+// writeAt writes those of the bytes "p", which are found at "offset" in the
+// object, which have not been written yet. Bytes which would leave a gap after
+// those written so far are not written; finish writes them instead.
+func (s *streamWriter) writeAt(offset int64, p []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	end := offset + int64(len(p))
+	if s.err != nil || offset > s.written || end <= s.written {
+		return
+	}
+
+	_, s.err = s.write(p[s.written-offset:])
+}
+
+// from returns a writer which writes the bytes written to it with writeAt,
+// beginning at "offset" in the object. Its writes never fail, so that a
+// failure to write the object to the stream does not stop it from being
+// downloaded into the local object store.
+func (s *streamWriter) from(offset int64) io.Writer {
+	return &streamTee{s: s, offset: offset}
+}
+
+// finish writes the rest of the object from "r", which reads the complete,
+// verified copy of it, and returns the number of bytes of it written in all.
+// It returns an error if the bytes written, including any written by downloads
+// which failed, are not those of the object.
+func (s *streamWriter) finish(r io.Reader) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.err != nil {
+		return s.written, s.err
+	}
+
+	if seeker, ok := r.(io.Seeker); ok {
+		if _, err := seeker.Seek(s.written, io.SeekStart); err != nil {
+			return s.written, err
+		}
+	} else if _, err := io.CopyN(ioutil.Discard, r, s.written); err != nil {
+		return s.written, err
+	}
+
+	buf := make([]byte, 32*1024)
+	for {
+		n, rerr := r.Read(buf)
+		if n > 0 {
+			if _, s.err = s.write(buf[:n]); s.err != nil {
+				return s.written, s.err
+			}
+		}
+		if rerr == io.EOF {
+			break
+		} else if rerr != nil {
+			s.err = rerr
+			return s.written, s.err
+		}
+	}
+
+	if actual := hex.EncodeToString(s.h.Sum(nil)); actual != s.oid {
+		s.err = errors.New(tr.Tr.Get("streamed contents of %s hash to %s", s.oid, actual))
+	}
+	return s.written, s.err
+}
+
+// streamTee writes the bytes written to it to a streamWriter, keeping track
+// of their offset in the object.
+type streamTee struct {
+	s      *streamWriter
+	offset int64
+}
+
+func (t *streamTee) Write(p []byte) (int, error) {
+	t.s.writeAt(t.offset, p)
+	t.offset += int64(len(p))
+	return len(p), nil
+}
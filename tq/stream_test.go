@@ -0,0 +1,87 @@
+package tq
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type failingWriter struct{}
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("write failed")
+}
+
+// streamTestOid is the OID of the object written in these tests.
+var streamTestOid = func() string {
+	sum := sha256.Sum256([]byte("0123456789"))
+	return hex.EncodeToString(sum[:])
+}()
+
+func TestStreamWriterWritesRetriedBytesOnce(t *testing.T) {
+	var buf bytes.Buffer
+	s := newStreamWriter(&buf, streamTestOid)
+
+	w := s.from(0)
+	w.Write([]byte("0123"))
+	w.Write([]byte("45"))
+
+	// A retry downloads the object from the start again.
+	w = s.from(0)
+	w.Write([]byte("0123"))
+	w.Write([]byte("4567"))
+	assert.Equal(t, "01234567", buf.String())
+
+	n, err := s.finish(bytes.NewReader([]byte("0123456789")))
+	require.Nil(t, err)
+	assert.EqualValues(t, 10, n)
+	assert.Equal(t, "0123456789", buf.String())
+}
+
+func TestStreamWriterSkipsGaps(t *testing.T) {
+	var buf bytes.Buffer
+	s := newStreamWriter(&buf, streamTestOid)
+
+	// A resumed download begins after the bytes written so far.
+	n, err := s.from(6).Write([]byte("6789"))
+	assert.Nil(t, err)
+	assert.Equal(t, 4, n)
+	assert.Empty(t, buf.String())
+
+	written, err := s.finish(bytes.NewReader([]byte("0123456789")))
+	require.Nil(t, err)
+	assert.EqualValues(t, 10, written)
+	assert.Equal(t, "0123456789", buf.String())
+}
+
+func TestStreamWriterReportsWriteErrors(t *testing.T) {
+	s := newStreamWriter(&failingWriter{}, streamTestOid)
+
+	n, err := s.from(0).Write([]byte("0123"))
+	assert.Nil(t, err)
+	assert.Equal(t, 4, n)
+
+	_, err = s.finish(bytes.NewReader([]byte("0123456789")))
+	assert.EqualError(t, err, "write failed")
+}
+
+func TestStreamWriterReportsWrongBytes(t *testing.T) {
+	var buf bytes.Buffer
+	s := newStreamWriter(&buf, streamTestOid)
+
+	// A download which fails partway may have written bytes which are not
+	// the object's, and which its retry doesn't write again.
+	s.from(0).Write([]byte("01X3"))
+	s.from(0).Write([]byte("0123456789"))
+
+	n, err := s.finish(bytes.NewReader([]byte("0123456789")))
+	assert.EqualValues(t, 10, n)
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), streamTestOid)
+	}
+}
@@ -1,9 +1,16 @@
-// Package transfer collects together adapters for uploading and downloading LFS content
-// NOTE: Subject to change, do not rely on this package from outside git-lfs source
+// Package tq transfers the contents of Git LFS objects to and from a remote,
+// asking its server how with batch requests, and then using transfer adapters.
+//
+// NewTransferQueue, NewManifest, the Option functions, and the Batch,
+// ObjectsExist, and GetObjectsMeta requests are part of the stable Go API of
+// Git LFS described in docs/library.md. Transfer adapters may be added with
+// RegisterNewAdapterFunc.
 package tq
 
 import (
+	"context"
 	"fmt"
+	"net/url"
 	"time"
 
 	"github.com/git-lfs/git-lfs/v3/errors"
@@ -12,6 +19,7 @@ import (
 	"github.com/git-lfs/git-lfs/v3/tr"
 )
 
+// Direction is the direction in which a queue transfers objects.
 type Direction int
 
 const (
@@ -34,6 +42,7 @@ func (d Direction) Progress() string {
 	}
 }
 
+// String returns the name of the direction as used in batch requests.
 func (d Direction) String() string {
 	switch d {
 	case Checkout:
@@ -47,6 +56,8 @@ func (d Direction) String() string {
 	}
 }
 
+// Transfer is a single object to be transferred, as sent in a batch request,
+// with the actions the server answered it with.
 type Transfer struct {
 	Name          string       `json:"name,omitempty"`
 	Oid           string       `json:"oid,omitempty"`
@@ -57,8 +68,23 @@ type Transfer struct {
 	Error         *ObjectError `json:"error,omitempty"`
 	Path          string       `json:"path,omitempty"`
 	Missing       bool         `json:"-"`
+
+	// StorageClass and Attributes are metadata which the server may
+	// report about the object; see GetObjectMeta.
+	StorageClass string                 `json:"storage_class,omitempty"`
+	Attributes   map[string]interface{} `json:"attributes,omitempty"`
+
+	// ctx, if set, governs the requests made to transfer the object,
+	// while an adapter is doing so within lfs.transfer.timeout.
+	ctx context.Context
+
+	// stream, if set, is the writer to which the object is written as it
+	// is downloaded, as well as to Path.
+	stream *streamWriter
 }
 
+// Rel returns the action "name" with which the object may be transferred,
+// or nil if the server gave none, such as when it already has the object.
 func (t *Transfer) Rel(name string) (*Action, error) {
 	a, err := t.Actions.Get(name)
 	if a != nil || err != nil {
@@ -75,6 +101,7 @@ func (t *Transfer) Rel(name string) (*Action, error) {
 	return nil, nil
 }
 
+// ObjectError is an error returned by the server for a single object.
 type ObjectError struct {
 	Code    int    `json:"code"`
 	Message string `json:"message"`
@@ -105,11 +132,14 @@ func newTransfer(tr *Transfer, name string, path string) *Transfer {
 
 	for rel, action := range tr.Actions {
 		t.Actions[rel] = &Action{
-			Href:      action.Href,
-			Header:    action.Header,
-			ExpiresAt: action.ExpiresAt,
-			ExpiresIn: action.ExpiresIn,
-			createdAt: action.createdAt,
+			Href:        action.Href,
+			Mirrors:     action.Mirrors,
+			Header:      action.Header,
+			Checkpoints: action.Checkpoints,
+			ExpiresAt:   action.ExpiresAt,
+			ExpiresIn:   action.ExpiresIn,
+			Fallback:    action.fallback(),
+			createdAt:   action.createdAt,
 		}
 	}
 
@@ -118,11 +148,14 @@ func newTransfer(tr *Transfer, name string, path string) *Transfer {
 
 		for rel, link := range tr.Links {
 			t.Links[rel] = &Action{
-				Href:      link.Href,
-				Header:    link.Header,
-				ExpiresAt: link.ExpiresAt,
-				ExpiresIn: link.ExpiresIn,
-				createdAt: link.createdAt,
+				Href:        link.Href,
+				Mirrors:     link.Mirrors,
+				Header:      link.Header,
+				Checkpoints: link.Checkpoints,
+				ExpiresAt:   link.ExpiresAt,
+				ExpiresIn:   link.ExpiresIn,
+				Fallback:    link.fallback(),
+				createdAt:   link.createdAt,
 			}
 		}
 	}
@@ -130,21 +163,62 @@ func newTransfer(tr *Transfer, name string, path string) *Transfer {
 	return t
 }
 
+// Action is how to perform one step of an object's transfer, such as the URL
+// to which to upload it.
 type Action struct {
-	Href      string            `json:"href"`
-	Header    map[string]string `json:"header,omitempty"`
-	ExpiresAt time.Time         `json:"expires_at,omitempty"`
-	ExpiresIn int               `json:"expires_in,omitempty"`
-	Id        string            `json:"-"`
-	Token     string            `json:"-"`
+	Href        string            `json:"href"`
+	Mirrors     []string          `json:"mirrors,omitempty"`
+	Header      map[string]string `json:"header,omitempty"`
+	Checkpoints *Checkpoints      `json:"checkpoints,omitempty"`
+	ExpiresAt   time.Time         `json:"expires_at,omitempty"`
+	ExpiresIn   int               `json:"expires_in,omitempty"`
+	Fallback    *Action           `json:"fallback,omitempty"`
+	Id          string            `json:"-"`
+	Token       string            `json:"-"`
 
 	createdAt time.Time
 }
 
+// hrefs returns the action's Href followed by its Mirrors, which serve the same
+// content with the same headers, in the order they should be tried.
+func (a *Action) hrefs() []string {
+	return append([]string{a.Href}, a.Mirrors...)
+}
+
+// fallback returns a copy of the action's Fallback, if it has one, which was
+// created along with the action. A Fallback is an alternative to the action,
+// such as one which proxies the transfer through the API server, to be used
+// when its Href can't be reached.
+func (a *Action) fallback() *Action {
+	if a.Fallback == nil {
+		return nil
+	}
+
+	f := *a.Fallback
+	f.Fallback = nil
+	f.createdAt = a.createdAt
+	return &f
+}
+
+// host returns the host named by the action's Href, or blank if it has none.
+func (a *Action) host() string {
+	if a == nil {
+		return ""
+	}
+	u, err := url.Parse(a.Href)
+	if err != nil {
+		return ""
+	}
+	return u.Host
+}
+
+// IsExpiredWithin returns when the action expires, and whether it does so
+// within "d" from now.
 func (a *Action) IsExpiredWithin(d time.Duration) (time.Time, bool) {
 	return tools.IsExpiredAtOrIn(a.createdAt, d, a.ExpiresAt, time.Duration(a.ExpiresIn)*time.Second)
 }
 
+// ActionSet maps the names of actions, such as "upload", to the actions.
 type ActionSet map[string]*Action
 
 const (
@@ -154,6 +228,8 @@ const (
 	objectExpirationToTransfer = 5 * time.Second
 )
 
+// Get returns the action "rel", or nil if there is none, and a retriable
+// ActionExpiredErr if it has expired or is about to.
 func (as ActionSet) Get(rel string) (*Action, error) {
 	a, ok := as[rel]
 	if !ok {
@@ -167,6 +243,7 @@ func (as ActionSet) Get(rel string) (*Action, error) {
 	return a, nil
 }
 
+// ActionExpiredErr is returned for an action which has expired.
 type ActionExpiredErr struct {
 	Rel string
 	At  time.Time
@@ -177,8 +254,10 @@ func (e ActionExpiredErr) Error() string {
 		e.Rel, e.At.In(time.Local).Format(time.RFC822))
 }
 
+// IsActionExpiredError returns whether "err" was caused by an action having
+// expired, including when it is wrapped as retriable by ActionSet.Get.
 func IsActionExpiredError(err error) bool {
-	if _, ok := err.(*ActionExpiredErr); ok {
+	if _, ok := errors.Cause(err).(*ActionExpiredErr); ok {
 		return true
 	}
 	return false
@@ -190,18 +269,26 @@ func IsActionExpiredError(err error) bool {
 // name and dir are to provide context if one func implements many instances
 type NewAdapterFunc func(name string, dir Direction) Adapter
 
+// ProgressCallback is called as the contents of the object "name" are
+// transferred.
 type ProgressCallback func(name string, totalSize, readSoFar int64, readSinceLast int) error
 
+// AdapterConfig is the configuration with which an Adapter begins.
 type AdapterConfig interface {
 	APIClient() *lfsapi.Client
 	ConcurrentTransfers() int
 	Remote() string
+	// Context returns the context governing all transfers made by the
+	// adapter. Once it is done, in-flight transfers should be abandoned.
+	Context() context.Context
 }
 
 type adapterConfig struct {
 	apiClient           *lfsapi.Client
 	concurrentTransfers int
 	remote              string
+	ctx                 context.Context
+	state               *serverState
 }
 
 func (c *adapterConfig) ConcurrentTransfers() int {
@@ -216,6 +303,13 @@ func (c *adapterConfig) Remote() string {
 	return c.remote
 }
 
+func (c *adapterConfig) Context() context.Context {
+	if c.ctx == nil {
+		return context.Background()
+	}
+	return c.ctx
+}
+
 // Adapter is implemented by types which can upload and/or download LFS
 // file content to a remote store. Each Adapter accepts one or more requests
 // which it may schedule and parallelise in whatever way it chooses, clients of
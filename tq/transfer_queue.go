@@ -1,16 +1,21 @@
 package tq
 
 import (
+	"bytes"
+	"context"
 	"fmt"
+	"io"
 	"os"
 	"sort"
 	"sync"
 	"time"
 
 	"github.com/git-lfs/git-lfs/v3/errors"
+	"github.com/git-lfs/git-lfs/v3/fs"
 	"github.com/git-lfs/git-lfs/v3/git"
 	"github.com/git-lfs/git-lfs/v3/lfshttp"
 	"github.com/git-lfs/git-lfs/v3/tools"
+	"github.com/git-lfs/git-lfs/v3/tools/humanize"
 	"github.com/git-lfs/git-lfs/v3/tr"
 	"github.com/rubyist/tracerx"
 )
@@ -24,10 +29,16 @@ type retryCounter struct {
 	MaxRetries    int
 	MaxRetryDelay int
 
-	// cmu guards count
+	// cmu guards count, refreshes and refreshing
 	cmu sync.Mutex
 	// count maps OIDs to number of retry attempts
 	count map[string]int
+	// refreshes maps OIDs to the number of times their expired actions
+	// have been asked for again
+	refreshes map[string]int
+	// refreshing holds the OIDs of objects waiting to have their expired
+	// actions asked for again
+	refreshing map[string]bool
 }
 
 // newRetryCounter instantiates a new *retryCounter.
@@ -36,9 +47,39 @@ func newRetryCounter() *retryCounter {
 		MaxRetries:    defaultMaxRetries,
 		MaxRetryDelay: defaultMaxRetryDelay,
 		count:         make(map[string]int),
+		refreshes:     make(map[string]int),
+		refreshing:    make(map[string]bool),
 	}
 }
 
+// Refresh returns whether the object "oid", whose actions expired before it
+// could be transferred, may be asked for again without using up one of its
+// retries, and marks it as waiting to be if so. An object may be refreshed as
+// many times as it may be retried, so that a server which only hands out
+// actions which expire too soon to be used is not asked forever.
+func (r *retryCounter) Refresh(oid string) bool {
+	r.cmu.Lock()
+	defer r.cmu.Unlock()
+
+	if r.refreshes[oid] >= r.MaxRetries {
+		return false
+	}
+	r.refreshes[oid]++
+	r.refreshing[oid] = true
+	return true
+}
+
+// Refreshing returns whether the object "oid" is waiting to have its expired
+// actions asked for again, which it no longer is once it has been.
+func (r *retryCounter) Refreshing(oid string) bool {
+	r.cmu.Lock()
+	defer r.cmu.Unlock()
+
+	refreshing := r.refreshing[oid]
+	delete(r.refreshing, oid)
+	return refreshing
+}
+
 // Increment increments the number of retries for a given OID and returns the
 // new value. It is safe to call across multiple goroutines.
 func (r *retryCounter) Increment(oid string) int {
@@ -82,17 +123,19 @@ func (r *retryCounter) ReadyTime(oid string) time.Time {
 }
 
 // batch implements the sort.Interface interface and enables sorting on a slice
-// of `*Transfer`s by object size.
+// of `*Transfer`s by priority, and then by object size.
 //
-// This interface is implemented here so that the largest objects can be
-// processed first. Since adding a new batch is unable to occur until the
-// current batch has finished processing, this enables us to reduce the risk of
-// a single worker getting tied up on a large item at the end of a batch while
-// all other workers are sitting idle.
+// This interface is implemented here so that, among objects of the same
+// priority, the largest objects can be processed first. Since adding a new
+// batch is unable to occur until the current batch has finished processing,
+// this enables us to reduce the risk of a single worker getting tied up on a
+// large item at the end of a batch while all other workers are sitting idle.
 type batch []*objectTuple
 
 // Concat concatenates two batches together, returning a single, clamped batch as
-// "left", and the remainder of elements as "right". If the union of the
+// "left", and the remainder of elements as "right". Objects of higher priority
+// are placed in "left" before those of lower priority, but otherwise the order
+// of the receiver and then "other" is kept. If the union of the
 // receiver and "other" has cardinality less than "size", "right" will be
 // returned as nil. Any object tuple that is not currently able to be retried
 // (ie Retry-After response), will also go into the right batch. Also, when object(s)
@@ -100,6 +143,9 @@ type batch []*objectTuple
 // a object is ready.
 func (b batch) Concat(other batch, size int) (left, right batch, minWait time.Duration) {
 	u := batch(append(b, other...))
+	sort.SliceStable(u, func(i, j int) bool {
+		return u[i].Priority > u[j].Priority
+	})
 	for _, ot := range u {
 		if time.Now().After(ot.ReadyTime) {
 			// The current time is past the time the object should
@@ -134,9 +180,15 @@ func (b batch) ToTransfers() []*Transfer {
 	return transfers
 }
 
-func (b batch) Len() int           { return len(b) }
-func (b batch) Less(i, j int) bool { return b[i].Size < b[j].Size }
-func (b batch) Swap(i, j int)      { b[i], b[j] = b[j], b[i] }
+func (b batch) Len() int      { return len(b) }
+func (b batch) Swap(i, j int) { b[i], b[j] = b[j], b[i] }
+
+func (b batch) Less(i, j int) bool {
+	if b[i].Priority != b[j].Priority {
+		return b[i].Priority < b[j].Priority
+	}
+	return b[i].Size < b[j].Size
+}
 
 type abortableWaitGroup struct {
 	wq      sync.WaitGroup
@@ -189,12 +241,18 @@ type TransferQueue struct {
 	client            *tqClient
 	remote            string
 	ref               *git.Ref
+	ctx               context.Context
 	adapter           Adapter
 	adapterInProgress bool
 	adapterInitMutex  sync.Mutex
 	dryRun            bool
 	cb                tools.CopyCallback
 	meter             *Meter
+	stats             *Stats
+	progressDir       string
+	progress          *progressFile
+	journalPath       string
+	journal           *journal
 	errors            []error
 	transfers         map[string]*objects
 	batchSize         int
@@ -211,11 +269,27 @@ type TransferQueue struct {
 	wait     *abortableWaitGroup
 	manifest Manifest
 	rc       *retryCounter
+	restores *restoreTracker
 
 	// unsupportedContentType indicates whether the transfer queue ever saw
 	// an HTTP 422 response indicating that their upload destination does
 	// not support Content-Type detection.
 	unsupportedContentType bool
+
+	// priorities maps the OIDs of objects given a priority with
+	// SetPriority to it, and is guarded by priorityMu.
+	priorities map[string]int
+	priorityMu sync.Mutex
+
+	// routePaths maps the OIDs of objects given a path with SetRoutePath
+	// to it, and is guarded by routeMu.
+	routePaths map[string]string
+	routeMu    sync.Mutex
+
+	// streams maps the OIDs of objects added with AddStream to the
+	// writers to which they are streamed, and is guarded by streamMu.
+	streams  map[string]*streamWriter
+	streamMu sync.Mutex
 }
 
 // objects holds a set of objects.
@@ -251,6 +325,10 @@ type objectTuple struct {
 	Size            int64
 	Missing         bool
 	ReadyTime       time.Time
+
+	// Priority is the priority of the object, as of the last time its
+	// batch was sorted.
+	Priority int
 }
 
 func (o *objectTuple) ToTransfer() *Transfer {
@@ -263,57 +341,110 @@ func (o *objectTuple) ToTransfer() *Transfer {
 	}
 }
 
+// Option configures a TransferQueue.
 type Option func(*TransferQueue)
 
+// DryRun makes the queue ask the server about each object as it otherwise
+// would, but then report each one which it would have transferred as having
+// been transferred, without reading or writing any object's contents.
 func DryRun(dryRun bool) Option {
 	return func(tq *TransferQueue) {
 		tq.dryRun = dryRun
 	}
 }
 
+// WithProgress reports the progress of the queue's transfers to "m".
 func WithProgress(m *Meter) Option {
 	return func(tq *TransferQueue) {
 		tq.meter = m
 	}
 }
 
+// WithStats records statistics about each object's transfer in "s".
+func WithStats(s *Stats) Option {
+	return func(tq *TransferQueue) {
+		tq.stats = s
+	}
+}
+
+// WithProgressDir periodically writes the progress of the queue's in-flight
+// transfers to a file in "dir" named after the ID of the current process,
+// which it shares with the process's other queues, so that it can be read by
+// other processes using ReadTransferProgress. The file is removed when the
+// last of those queues finishes.
+func WithProgressDir(dir string) Option {
+	return func(tq *TransferQueue) {
+		tq.progressDir = dir
+	}
+}
+
+// WithJournal records each object which the queue transfers, or finds it need
+// not, in a journal at "path". If the queue is interrupted, another queue with
+// the same journal skips those objects, rather than starting over. The journal
+// is removed once a queue finishes without errors.
+func WithJournal(path string) Option {
+	return func(tq *TransferQueue) {
+		tq.journalPath = path
+	}
+}
+
+// RemoteRef sends "ref" with the queue's batch requests, as the ref being
+// pushed to or fetched from.
 func RemoteRef(ref *git.Ref) Option {
 	return func(tq *TransferQueue) {
 		tq.ref = ref
 	}
 }
 
+// WithProgressCallback calls "cb" as the contents of each object are
+// transferred.
 func WithProgressCallback(cb tools.CopyCallback) Option {
 	return func(tq *TransferQueue) {
 		tq.cb = cb
 	}
 }
 
+// WithBatchSize sets the number of objects sent in each batch request.
 func WithBatchSize(size int) Option {
 	return func(tq *TransferQueue) { tq.batchSize = size }
 }
 
+// WithBufferDepth sets the number of objects which may be added to the queue
+// before Add blocks.
 func WithBufferDepth(depth int) Option {
 	return func(tq *TransferQueue) { tq.bufferDepth = depth }
 }
 
+// WithContext sets the context governing the queue's transfers. Once the
+// context is done, no further batches are requested, in-flight transfers are
+// abandoned, and every remaining object fails with the context's error.
+func WithContext(ctx context.Context) Option {
+	return func(tq *TransferQueue) { tq.ctx = ctx }
+}
+
 // NewTransferQueue builds a TransferQueue, direction and underlying mechanism determined by adapter
 func NewTransferQueue(dir Direction, manifest Manifest, remote string, options ...Option) *TransferQueue {
 	q := &TransferQueue{
-		direction: dir,
-		remote:    remote,
-		errorc:    make(chan error),
-		transfers: make(map[string]*objects),
-		trMutex:   &sync.Mutex{},
-		manifest:  manifest,
-		rc:        newRetryCounter(),
-		wait:      newAbortableWaitGroup(),
+		direction:  dir,
+		remote:     remote,
+		errorc:     make(chan error),
+		transfers:  make(map[string]*objects),
+		priorities: make(map[string]int),
+		routePaths: make(map[string]string),
+		streams:    make(map[string]*streamWriter),
+		trMutex:    &sync.Mutex{},
+		manifest:   manifest,
+		rc:         newRetryCounter(),
+		wait:       newAbortableWaitGroup(),
 	}
 
 	for _, opt := range options {
 		opt(q)
 	}
 
+	if q.ctx == nil {
+		q.ctx = context.Background()
+	}
 	if q.batchSize <= 0 {
 		q.batchSize = defaultBatchSize
 	}
@@ -323,6 +454,12 @@ func NewTransferQueue(dir Direction, manifest Manifest, remote string, options .
 	if q.meter != nil {
 		q.meter.Direction = q.direction
 	}
+	if len(q.progressDir) > 0 && !q.dryRun {
+		q.progress = newProgressFile(q.progressDir, q.direction, q.remote)
+	}
+	if len(q.journalPath) > 0 && !q.dryRun {
+		q.journal = openJournal(q.journalPath)
+	}
 
 	q.incoming = make(chan *objectTuple, q.bufferDepth)
 	q.collectorWait.Add(1)
@@ -337,9 +474,10 @@ func NewTransferQueue(dir Direction, manifest Manifest, remote string, options .
 func (q *TransferQueue) Upgrade() {
 	if q.client == nil {
 		manifest := q.manifest.Upgrade()
-		q.client = &tqClient{Client: manifest.APIClient()}
+		q.client = &tqClient{Client: manifest.APIClient(), state: manifest.state}
 		q.rc.MaxRetries = manifest.maxRetries
 		q.rc.MaxRetryDelay = manifest.maxRetryDelay
+		q.restores = newRestoreTracker(manifest.restoreTimeout)
 		q.client.SetMaxRetries(manifest.maxRetries)
 	}
 }
@@ -361,6 +499,12 @@ func (q *TransferQueue) Add(name, path, oid string, size int64, missing bool, er
 		return
 	}
 
+	if max := q.manifest.Upgrade().maxFileSize; q.direction == Upload && max > 0 && size > max {
+		q.errorc <- errors.New(tr.Tr.Get("%s is %s, which is larger than the server's limit of %s",
+			name, humanize.FormatBytes(uint64(size)), humanize.FormatBytes(uint64(max))))
+		return
+	}
+
 	t := &objectTuple{
 		Name:    name,
 		Path:    path,
@@ -369,6 +513,25 @@ func (q *TransferQueue) Add(name, path, oid string, size int64, missing bool, er
 		Missing: missing,
 	}
 
+	if size == 0 {
+		// An empty object has no contents to transfer, so it is
+		// already done, and need not be sent to the server.
+		tracerx.Printf("tq: skipping empty object %q", t.Oid)
+		if err := q.storeEmpty(t.Oid); err != nil {
+			q.errorc <- err
+			return
+		}
+
+		q.progress.add()
+		q.stats.finish(t.Oid, t.Name, t.Size, StatusSkipped, nil)
+		q.progress.skip()
+		q.Skip(0)
+		for _, w := range q.watchers {
+			w <- t.ToTransfer()
+		}
+		return
+	}
+
 	if objs := q.remember(t); len(objs.objects) > 1 {
 		if objs.completed {
 			// If there is already a completed transfer chain for
@@ -385,9 +548,232 @@ func (q *TransferQueue) Add(name, path, oid string, size int64, missing bool, er
 		return
 	}
 
+	q.progress.add()
+
+	if q.journal.has(t.Oid) {
+		// An earlier run was interrupted after transferring this
+		// object.
+		tracerx.Printf("tq: skipping %q, already transferred according to journal", t.Oid)
+		q.stats.finish(t.Oid, t.Name, t.Size, StatusSkipped, nil)
+		q.progress.skip()
+		q.Skip(t.Size)
+		q.wait.Done()
+		return
+	}
+
 	q.incoming <- t
 }
 
+// storeEmpty writes the empty object "oid" to the local store when it is
+// downloaded, so that, like any other downloaded object, it can be found there
+// afterwards.
+func (q *TransferQueue) storeEmpty(oid string) error {
+	f := q.manifest.Upgrade().fs
+	if q.direction != Download || q.dryRun || f == nil {
+		return nil
+	}
+
+	store, err := f.Store()
+	if err != nil {
+		return err
+	}
+	if store.Exists(oid, 0) {
+		return nil
+	}
+	return store.Put(oid, bytes.NewReader(nil))
+}
+
+// localStore returns the local object store which the queue's objects are
+// read from and written to, or nil if the queue has none, in which case they
+// are read from and written to the files at their transfers' paths.
+func (q *TransferQueue) localStore() (fs.LocalStore, error) {
+	f := q.manifest.Upgrade().fs
+	if f == nil {
+		return nil, nil
+	}
+	return f.Store()
+}
+
+// objectSize returns the size of the object which "t" uploads.
+func (q *TransferQueue) objectSize(t *Transfer) (int64, error) {
+	store, err := q.localStore()
+	if err != nil {
+		return 0, err
+	}
+	if _, ok := store.(fs.FileBacked); ok || store == nil {
+		fi, err := os.Stat(t.Path)
+		if err != nil {
+			return 0, err
+		}
+		return fi.Size(), nil
+	}
+	return store.Size(t.Oid)
+}
+
+// finishStream writes the rest of the object which "t" downloaded to the
+// stream "s".
+func (q *TransferQueue) finishStream(s *streamWriter, t *Transfer) error {
+	store, err := q.localStore()
+	if err != nil {
+		return err
+	}
+
+	var r io.ReadCloser
+	if _, ok := store.(fs.FileBacked); ok || store == nil {
+		r, err = os.Open(t.Path)
+	} else {
+		r, err = store.Get(t.Oid)
+	}
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	_, err = s.finish(r)
+	return err
+}
+
+// SetPriority sets the priority of the object "oid", which is zero unless it is
+// set. Objects of higher priority are sent to the server, and then transferred,
+// before those of lower priority which are waiting with them, so that, for
+// instance, files which are about to be checked out may be downloaded first.
+//
+// SetPriority may be called before the object is added to the queue, or
+// afterwards to reorder the objects which are still waiting to be sent to the
+// server or transferred. It has no effect on objects which are already being
+// transferred.
+func (q *TransferQueue) SetPriority(oid string, priority int) {
+	q.priorityMu.Lock()
+	defer q.priorityMu.Unlock()
+
+	q.priorities[oid] = priority
+}
+
+// SetRoutePath sets the path within the repository by which the object "oid"
+// is routed to an endpoint with `lfs.route.<path>.url`, for callers which name
+// the object differently, such as by its base name alone. Objects without one
+// are routed by their name.
+func (q *TransferQueue) SetRoutePath(oid, path string) {
+	q.routeMu.Lock()
+	defer q.routeMu.Unlock()
+
+	q.routePaths[oid] = path
+}
+
+// routePath returns the path by which the object "t" is routed.
+func (q *TransferQueue) routePath(t *objectTuple) string {
+	q.routeMu.Lock()
+	defer q.routeMu.Unlock()
+
+	if path, ok := q.routePaths[t.Oid]; ok {
+		return path
+	}
+	return t.Name
+}
+
+// AddStream adds a download to the queue, as Add does, and writes the object
+// to "w" as well as to "path" as it is downloaded, so that callers which need
+// its contents, such as the smudge filter, need not read them back from the
+// local object store. Each byte is written once, in order, even if the
+// download is retried or resumed; any which could not be written as they were
+// downloaded are written from "path" once the download has succeeded, before
+// the queue reports it as done.
+//
+// Bytes are written before the object's hash is verified, so if the download
+// fails, some of the object may already have been written to "w", and a
+// download which failed partway may have written bytes which are not the
+// object's. Once the object is complete, everything written to "w" is checked
+// against its OID, and a mismatch is reported as an error of the queue, as is
+// an error writing to "w", which does not stop the object from being
+// downloaded. Callers must discard what was written to "w" if the queue
+// reports any error.
+func (q *TransferQueue) AddStream(name, path, oid string, size int64, w io.Writer) {
+	q.streamMu.Lock()
+	q.streams[oid] = newStreamWriter(w, oid)
+	q.streamMu.Unlock()
+
+	q.Add(name, path, oid, size, false, nil)
+}
+
+// stream returns the writer to which the object "oid" is streamed, or nil if
+// it was not added with AddStream.
+func (q *TransferQueue) stream(oid string) *streamWriter {
+	q.streamMu.Lock()
+	defer q.streamMu.Unlock()
+
+	return q.streams[oid]
+}
+
+// priority returns the priority of the object "oid".
+func (q *TransferQueue) priority(oid string) int {
+	q.priorityMu.Lock()
+	defer q.priorityMu.Unlock()
+
+	return q.priorities[oid]
+}
+
+// prioritize updates the priority of each object in "b" to its current value.
+func (q *TransferQueue) prioritize(b batch) {
+	for _, ot := range b {
+		ot.Priority = q.priority(ot.Oid)
+	}
+}
+
+// Preflight asks the server which of the given objects it already has,
+// before they are added to the queue, and returns the rest. Callers need not
+// then open or prepare the files of objects which the server already has.
+// Objects in the queue's journal are removed without asking the server.
+// Objects which are removed are recorded as skipped in the queue's statistics,
+// but callers which have added them to the progress meter must Skip() them.
+//
+// Preflight only checks uploads; objects routed to another endpoint are always
+// returned, as are all of the objects if the check fails.
+func (q *TransferQueue) Preflight(objects []*Transfer) []*Transfer {
+	q.Upgrade()
+
+	if q.direction != Upload || q.dryRun || len(objects) == 0 ||
+		q.manifest.Upgrade().standaloneTransferAgent != "" {
+		return objects
+	}
+
+	check := make([]*Transfer, 0, len(objects))
+	for _, t := range objects {
+		if !q.routed(t) && !q.journal.has(t.Oid) {
+			check = append(check, t)
+		}
+	}
+
+	exists, err := ObjectsExist(q.manifest, q.remote, q.ref, check)
+	if err != nil {
+		tracerx.Printf("tq: unable to check for existing objects before upload: %s", err)
+		return objects
+	}
+
+	remaining := make([]*Transfer, 0, len(objects))
+	for _, t := range objects {
+		if q.journal.has(t.Oid) {
+			tracerx.Printf("tq: skipping %q, already transferred according to journal", t.Oid)
+			q.stats.finish(t.Oid, t.Name, t.Size, StatusSkipped, nil)
+			continue
+		}
+		if !q.routed(t) && exists[t.Oid] {
+			q.stats.finish(t.Oid, t.Name, t.Size, StatusSkipped, nil)
+			continue
+		}
+		remaining = append(remaining, t)
+	}
+
+	tracerx.Printf("tq: server already has %d of %d object(s)", len(objects)-len(remaining), len(objects))
+	return remaining
+}
+
+// routed returns whether the object "t" is routed by its path to an endpoint
+// other than the remote's.
+func (q *TransferQueue) routed(t *Transfer) bool {
+	_, ok := q.routeFor(&objectTuple{Name: t.Name})
+	return ok
+}
+
 // remember remembers the *Transfer "t" if the *TransferQueue doesn't already
 // know about a Transfer with the same OID.
 //
@@ -451,8 +837,9 @@ func (q *TransferQueue) collectBatches() {
 			next = append(next, t)
 		}
 
-		// Before enqueuing the next batch, sort by descending object
-		// size.
+		// Before enqueuing the next batch, sort by descending
+		// priority and then object size.
+		q.prioritize(next)
 		sort.Sort(sort.Reverse(next))
 
 		done := make(chan struct{})
@@ -491,7 +878,10 @@ func (q *TransferQueue) collectBatches() {
 		// - new additions that were enqueued behind retries, &
 		// - items collected while the batch was processing.
 		var minWaitTime time.Duration
-		next, pending, minWaitTime = retries.Concat(append(pending, collected...), q.batchSize)
+		pending = append(pending, collected...)
+		q.prioritize(retries)
+		q.prioritize(pending)
+		next, pending, minWaitTime = retries.Concat(pending, q.batchSize)
 		if len(next) == 0 && len(pending) != 0 {
 			// There are some pending that could not be queued.
 			// Wait the requested time before resuming loop.
@@ -545,6 +935,12 @@ func (q *TransferQueue) enqueueAndCollectRetriesFor(batch batch) (batch, error)
 
 	enqueueRetry := func(t *objectTuple, err error, readyTime *time.Time) {
 		count := q.rc.Increment(t.Oid)
+		q.stats.retry(t.Oid)
+		if err != nil {
+			// Retries of transfers which failed in the adapter
+			// are recorded with their error as they are handled.
+			q.progress.retry(t.Name, t.Oid, t.Size, err)
+		}
 
 		if readyTime == nil {
 			t.ReadyTime = q.rc.ReadyTime(t.Oid)
@@ -561,9 +957,43 @@ func (q *TransferQueue) enqueueAndCollectRetriesFor(batch batch) (batch, error)
 		next = append(next, t)
 	}
 
+	// If the queue has been cancelled, give up on every object in the
+	// batch without asking the server about them.
+	if err := q.ctx.Err(); err != nil {
+		for _, t := range batch {
+			q.stats.finish(t.Oid, t.Name, t.Size, StatusFailed, err)
+			q.progress.fail(t.Name, t.Oid, t.Size, err)
+			q.Skip(t.Size)
+			q.wait.Done()
+		}
+		return next, err
+	}
+
+	manifest := q.manifest.Upgrade()
+	if manifest.standaloneTransferAgent == "" {
+		// Objects routed to different endpoints by their paths
+		// must be sent in separate batch requests.
+		if routes := q.partitionRoutes(batch); len(routes) > 1 {
+			var err error
+			for _, routed := range routes {
+				retries, rerr := q.enqueueAndCollectRetriesFor(routed)
+				next = append(next, retries...)
+				if rerr != nil && (err == nil || errors.IsRetriableError(err)) {
+					err = rerr
+				}
+			}
+			return next, err
+		}
+	}
+
+	if q.direction == Upload && manifest.standaloneTransferAgent == "" {
+		if batch = q.skipKnownObjects(manifest, batch); len(batch) == 0 {
+			return next, nil
+		}
+	}
+
 	q.meter.Pause()
 	var bRes *BatchResponse
-	manifest := q.manifest.Upgrade()
 	if manifest.standaloneTransferAgent != "" {
 		// Trust the external transfer agent can do everything by itself.
 		objects := make([]*Transfer, 0, len(batch))
@@ -578,7 +1008,12 @@ func (q *TransferQueue) enqueueAndCollectRetriesFor(batch batch) (batch, error)
 		// Query the Git LFS server for what transfer method to use and
 		// details such as URLs, authentication, etc.
 		var err error
-		bRes, err = Batch(q.manifest, q.direction, q.remote, q.ref, batch.ToTransfers())
+		if e, ok := q.routeForBatch(batch); ok {
+			tracerx.Printf("tq: routing batch of size %d to %s", len(batch), e.Url)
+			bRes, err = batchToEndpointWithContext(q.ctx, q.manifest, e, q.direction, q.remote, q.ref, batch.ToTransfers())
+		} else {
+			bRes, err = batchWithContext(q.ctx, q.manifest, q.direction, q.remote, q.ref, batch.ToTransfers())
+		}
 		if err != nil {
 			var hasNonScheduledErrors = false
 			// If there was an error making the batch API call, mark all of
@@ -593,6 +1028,8 @@ func (q *TransferQueue) enqueueAndCollectRetriesFor(batch batch) (batch, error)
 					enqueueRetry(t, err, &readyTime)
 				} else {
 					hasNonScheduledErrors = true
+					q.stats.finish(t.Oid, t.Name, t.Size, StatusFailed, err)
+					q.progress.fail(t.Name, t.Oid, t.Size, err)
 					q.wait.Done()
 				}
 			}
@@ -634,7 +1071,15 @@ func (q *TransferQueue) enqueueAndCollectRetriesFor(batch batch) (batch, error)
 
 	for _, o := range bRes.Objects {
 		if o.Error != nil {
-			q.errorc <- errors.Wrapf(o.Error, "[%v] %v", o.Oid, o.Error.Message)
+			var err error = o.Error
+			if o.Error.Code == 423 {
+				// The object is for a file which another
+				// user has locked.
+				err = errors.NewLockConflictError(o.Error)
+			}
+			q.errorc <- errors.Wrapf(err, "[%v] %v", o.Oid, o.Error.Message)
+			q.stats.finish(o.Oid, "", o.Size, StatusFailed, o.Error)
+			q.progress.fail("", o.Oid, o.Size, o.Error)
 			q.Skip(o.Size)
 			q.wait.Done()
 
@@ -651,12 +1096,14 @@ func (q *TransferQueue) enqueueAndCollectRetriesFor(batch batch) (batch, error)
 			// skip the number of bytes in "o".
 			q.errorc <- errors.Errorf(tr.Tr.Get("[%v] The server returned an unknown OID.", o.Oid))
 
+			q.progress.skip()
 			q.Skip(o.Size)
 			q.wait.Done()
 		} else {
 			// Pick t[0], since it will cover all transfers with the
 			// same OID.
 			tr := newTransfer(o, objects.First().Name, objects.First().Path)
+			tr.stream = q.stream(tr.Oid)
 
 			if a, err := tr.Rel(q.direction.String()); err != nil {
 				if q.canRetryObject(tr.Oid, err) {
@@ -664,27 +1111,119 @@ func (q *TransferQueue) enqueueAndCollectRetriesFor(batch batch) (batch, error)
 				} else {
 					q.errorc <- errors.Errorf("[%v] %v", tr.Name, err)
 
+					q.stats.finish(tr.Oid, tr.Name, o.Size, StatusFailed, err)
+					q.progress.fail(tr.Name, tr.Oid, o.Size, err)
 					q.Skip(o.Size)
 					q.wait.Done()
 				}
 			} else if a == nil && manifest.standaloneTransferAgent == "" {
+				q.journal.record(tr.Oid)
+				q.stats.finish(tr.Oid, tr.Name, o.Size, StatusSkipped, nil)
+				q.progress.skip()
 				q.Skip(o.Size)
 				q.wait.Done()
 			} else {
 				q.meter.StartTransfer(objects.First().Name)
+				q.stats.start(tr.Oid, tr.Name, o.Size)
+				q.progress.start(tr.Name, tr.Oid, o.Size, a.host())
 				toTransfer = append(toTransfer, tr)
 			}
 		}
 	}
 
+	// The server need not respond with the objects in the order in which
+	// they were requested, and their priorities may have changed since.
+	sort.SliceStable(toTransfer, func(i, j int) bool {
+		return q.priority(toTransfer[i].Oid) > q.priority(toTransfer[j].Oid)
+	})
+
 	retries := q.addToAdapter(bRes.endpoint, toTransfer)
 	for t := range retries {
+		if q.restores.polled(t.Oid) {
+			// Asking again for an object which is being restored
+			// from cold storage doesn't use up its retries.
+			next = append(next, t)
+			continue
+		}
+		if q.rc.Refreshing(t.Oid) {
+			// Nor does asking again for the actions of an object
+			// which expired before it could be transferred, which
+			// may be done at once.
+			next = append(next, t)
+			continue
+		}
 		enqueueRetry(t, nil, nil)
 	}
 
 	return next, nil
 }
 
+// routeFor returns the endpoint to which the object "t" is routed by its path,
+// and false if it should be sent to the remote's endpoint.
+func (q *TransferQueue) routeFor(t *objectTuple) (lfshttp.Endpoint, bool) {
+	path := q.routePath(t)
+	if len(path) == 0 || q.client.Client == nil {
+		return lfshttp.Endpoint{}, false
+	}
+	return q.client.Endpoints.RouteEndpoint(q.direction.String(), path)
+}
+
+// routeForBatch returns the endpoint to which the objects in the batch are
+// routed, which must all be routed alike.
+func (q *TransferQueue) routeForBatch(b batch) (lfshttp.Endpoint, bool) {
+	if len(b) == 0 {
+		return lfshttp.Endpoint{}, false
+	}
+	return q.routeFor(b[0])
+}
+
+// skipKnownObjects finishes each object in the upload batch "b" which the
+// server it is sent to has already said it has, in answer to an earlier batch
+// request with the same manifest, and returns the rest.
+func (q *TransferQueue) skipKnownObjects(m *concreteManifest, b batch) batch {
+	e, ok := q.routeForBatch(b)
+	if !ok {
+		e = m.APIClient().Endpoints.Endpoint(Upload.String(), q.remote)
+	}
+
+	unknown := make(batch, 0, len(b))
+	for _, t := range b {
+		if exists, ok := m.state.objects().get(e.Url, t.Oid); ok && exists {
+			tracerx.Printf("tq: server already has %s", t.Oid)
+			q.journal.record(t.Oid)
+			q.stats.finish(t.Oid, t.Name, t.Size, StatusSkipped, nil)
+			q.progress.skip()
+			q.Skip(t.Size)
+			q.wait.Done()
+			continue
+		}
+		unknown = append(unknown, t)
+	}
+	return unknown
+}
+
+// partitionRoutes splits the batch into one batch per endpoint to which its
+// objects are routed, preserving their order.
+func (q *TransferQueue) partitionRoutes(b batch) []batch {
+	var routes []batch
+	index := make(map[string]int)
+	for _, t := range b {
+		var url string
+		if e, ok := q.routeFor(t); ok {
+			url = e.Url
+		}
+
+		i, ok := index[url]
+		if !ok {
+			i = len(routes)
+			index[url] = i
+			routes = append(routes, q.makeBatch())
+		}
+		routes[i] = append(routes[i], t)
+	}
+	return routes
+}
+
 // makeBatch returns a new, empty batch, with a capacity equal to the maximum
 // batch size designated by the `*TransferQueue`.
 func (q *TransferQueue) makeBatch() batch { return make(batch, 0, q.batchSize) }
@@ -705,6 +1244,8 @@ func (q *TransferQueue) addToAdapter(e lfshttp.Endpoint, pending []*Transfer) <-
 
 		q.errorc <- err
 		for _, t := range pending {
+			q.stats.finish(t.Oid, t.Name, t.Size, StatusFailed, err)
+			q.progress.fail(t.Name, t.Oid, t.Size, err)
 			q.Skip(t.Size)
 			q.wait.Done()
 		}
@@ -738,7 +1279,9 @@ func (q *TransferQueue) addToAdapter(e lfshttp.Endpoint, pending []*Transfer) <-
 func (q *TransferQueue) partitionTransfers(transfers []*Transfer) (present []*Transfer, results []TransferResult) {
 	q.Upgrade()
 
-	if q.direction != Upload {
+	// A dry run does not read the objects' files, so they need not
+	// exist.
+	if q.direction != Upload || q.dryRun {
 		return transfers, nil
 	}
 
@@ -751,14 +1294,14 @@ func (q *TransferQueue) partitionTransfers(transfers []*Transfer) (present []*Tr
 		if t.Size < 0 {
 			err = errors.Errorf(tr.Tr.Get("object %q has invalid size (got: %d)", t.Oid, t.Size))
 		} else {
-			fd, serr := os.Stat(t.Path)
+			size, serr := q.objectSize(t)
 			if serr != nil {
 				if os.IsNotExist(serr) {
 					err = newObjectMissingError(t.Name, t.Oid)
 				} else {
 					err = serr
 				}
-			} else if t.Size != fd.Size() {
+			} else if t.Size != size {
 				err = newCorruptObjectError(t.Name, t.Oid)
 			}
 		}
@@ -799,11 +1342,43 @@ func (q *TransferQueue) handleTransferResult(
 	if res.Error != nil {
 		// If there was an error encountered when processing the
 		// transfer (res.Transfer), handle the error as is appropriate:
-		if readyTime, canRetry := q.canRetryObjectLater(oid, res.Error); canRetry {
+		if restore, ok := isRestorePendingError(res.Error); ok && q.restores.poll(oid) {
+			// If the object is being restored from cold storage,
+			// ask for it again once it's likely to be ready.
+			tracerx.Printf("tq: object %s is being restored, retrying after %.2f seconds", oid, time.Until(restore.until).Seconds())
+			q.progress.retry(res.Transfer.Name, oid, res.Transfer.Size, res.Error)
+			q.meter.Restoring(res.Transfer.Name, restore.until)
+			q.trMutex.Lock()
+			objects, ok := q.transfers[oid]
+			q.trMutex.Unlock()
+
+			if ok {
+				t := objects.First()
+				t.ReadyTime = restore.until
+				retries <- t
+			} else {
+				q.errorc <- res.Error
+			}
+		} else if IsActionExpiredError(res.Error) && q.rc.Refresh(oid) {
+			// If the object's actions expired while it was waiting
+			// to be transferred, ask the server for new ones.
+			tracerx.Printf("tq: actions for object %s expired, asking for new ones", oid)
+			q.progress.retry(res.Transfer.Name, oid, res.Transfer.Size, res.Error)
+			q.trMutex.Lock()
+			objects, ok := q.transfers[oid]
+			q.trMutex.Unlock()
+
+			if ok {
+				retries <- objects.First()
+			} else {
+				q.errorc <- res.Error
+			}
+		} else if readyTime, canRetry := q.canRetryObjectLater(oid, res.Error); canRetry {
 			// If the object can't be retried now, but can be
 			// after a certain period of time, send it to
 			// the retry channel with a time when it's ready.
 			tracerx.Printf("tq: retrying object %s after %s seconds.", oid, time.Until(readyTime).Seconds())
+			q.progress.retry(res.Transfer.Name, oid, res.Transfer.Size, res.Error)
 			q.trMutex.Lock()
 			objects, ok := q.transfers[oid]
 			q.trMutex.Unlock()
@@ -820,6 +1395,7 @@ func (q *TransferQueue) handleTransferResult(
 			// channel, where it will be read at the call-site and
 			// its retry count will be incremented.
 			tracerx.Printf("tq: retrying object %s: %s", oid, res.Error)
+			q.progress.retry(res.Transfer.Name, oid, res.Transfer.Size, res.Error)
 
 			q.trMutex.Lock()
 			objects, ok := q.transfers[oid]
@@ -841,9 +1417,20 @@ func (q *TransferQueue) handleTransferResult(
 			} else {
 				q.errorc <- res.Error
 			}
+			if _, ok := isRestorePendingError(res.Error); ok {
+				q.meter.Restoring(res.Transfer.Name, time.Time{})
+			}
+			q.stats.finish(oid, res.Transfer.Name, res.Transfer.Size, StatusFailed, res.Error)
+			q.progress.fail(res.Transfer.Name, oid, res.Transfer.Size, res.Error)
 			q.wait.Done()
 		}
 	} else {
+		if s := q.stream(oid); s != nil {
+			if err := q.finishStream(s, res.Transfer); err != nil {
+				q.errorc <- errors.Wrapf(err, tr.Tr.Get("Error writing %s", res.Transfer.Name))
+			}
+		}
+
 		q.trMutex.Lock()
 		objects := q.transfers[oid]
 		objects.completed = true
@@ -865,7 +1452,14 @@ func (q *TransferQueue) handleTransferResult(
 
 		q.trMutex.Unlock()
 
+		if q.direction == Upload {
+			q.manifest.Upgrade().state.objects().forget(oid)
+		}
+		q.journal.record(oid)
+
 		q.meter.FinishTransfer(res.Transfer.Name)
+		q.stats.finish(oid, res.Transfer.Name, res.Transfer.Size, StatusSucceeded, nil)
+		q.progress.finish(res.Transfer.Name)
 		q.wait.Done()
 	}
 }
@@ -902,6 +1496,7 @@ func (q *TransferQueue) BatchSize() int {
 	return q.batchSize
 }
 
+// Skip records that an object of the given size was not transferred.
 func (q *TransferQueue) Skip(size int64) {
 	q.meter.Skip(size)
 }
@@ -919,6 +1514,8 @@ func (q *TransferQueue) ensureAdapterBegun(e lfshttp.Endpoint) error {
 	// Progress callback - receives byte updates
 	cb := func(name string, total, read int64, current int) error {
 		q.meter.TransferBytes(q.direction.String(), name, read, total, current)
+		q.stats.transferBytes(name, current)
+		q.progress.update(name, read)
 		if q.cb != nil {
 			// NOTE: this is the mechanism by which the logpath
 			// specified by GIT_LFS_PROGRESS is written to.
@@ -947,6 +1544,8 @@ func (q *TransferQueue) toAdapterCfg(e lfshttp.Endpoint) AdapterConfig {
 		concurrentTransfers: concurrency,
 		apiClient:           apiClient,
 		remote:              q.remote,
+		ctx:                 q.ctx,
+		state:               q.manifest.Upgrade().state,
 	}
 }
 
@@ -967,8 +1566,13 @@ func (q *TransferQueue) Wait() {
 	}
 
 	q.meter.Flush()
+	q.progress.close()
 	q.errorwait.Wait()
 
+	if len(q.errors) == 0 {
+		q.journal.remove()
+	}
+
 	if q.manifest.Upgraded() {
 		manifest := q.manifest.Upgrade()
 		if manifest.sshTransfer != nil {
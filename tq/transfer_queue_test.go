@@ -1,10 +1,24 @@
 package tq
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sort"
 	"testing"
 	"time"
 
+	"github.com/git-lfs/git-lfs/v3/errors"
+	"github.com/git-lfs/git-lfs/v3/fs"
+	"github.com/git-lfs/git-lfs/v3/lfsapi"
+	"github.com/git-lfs/git-lfs/v3/lfshttp"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestManifestDefaultsToFixedRetries(t *testing.T) {
@@ -80,3 +94,230 @@ func TestBatchSizeReturnsBatchSize(t *testing.T) {
 
 	assert.Equal(t, 3, q.BatchSize())
 }
+
+func TestCancelledQueueFailsWithoutTransferring(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	q := NewTransferQueue(
+		Download, NewManifest(nil, nil, "", ""), "origin", WithContext(ctx))
+	q.Add("a.dat", "a.dat", "oid", 1, false, nil)
+	q.Wait()
+
+	if assert.Len(t, q.Errors(), 1) {
+		assert.Equal(t, context.Canceled, q.Errors()[0])
+	}
+}
+
+func TestCancellingQueueAbandonsBatchRequest(t *testing.T) {
+	received := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ioutil.ReadAll(r.Body)
+		close(received)
+		<-r.Context().Done()
+	}))
+	defer srv.Close()
+
+	cli, err := lfsapi.NewClient(lfshttp.NewContext(nil, nil, map[string]string{
+		"lfs.url": srv.URL + "/api",
+	}))
+	require.Nil(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	q := NewTransferQueue(Download, NewManifest(nil, cli, "download", "origin"), "origin", WithContext(ctx))
+	q.Add("a.dat", "a.dat", "4d7a214614ab2935c943f9e0ff69d22eadbb8f32b1258daaa5e2ca24d17e2393", 12, false, nil)
+	go func() {
+		<-received
+		cancel()
+	}()
+	q.Wait()
+
+	if assert.Len(t, q.Errors(), 1) {
+		assert.Contains(t, q.Errors()[0].Error(), context.Canceled.Error())
+	}
+}
+
+func TestAdapterConfigDefaultsToBackgroundContext(t *testing.T) {
+	cfg := &adapterConfig{}
+	assert.Equal(t, context.Background(), cfg.Context())
+}
+
+func TestBatchSortsByPriorityThenSize(t *testing.T) {
+	b := batch{
+		{Oid: "small", Size: 1},
+		{Oid: "urgent", Size: 1, Priority: 1},
+		{Oid: "large", Size: 10},
+		{Oid: "deferred", Size: 100, Priority: -1},
+	}
+	sort.Sort(sort.Reverse(b))
+
+	var oids []string
+	for _, ot := range b {
+		oids = append(oids, ot.Oid)
+	}
+	assert.Equal(t, []string{"urgent", "large", "small", "deferred"}, oids)
+}
+
+func TestBatchConcatPlacesHigherPriorityFirst(t *testing.T) {
+	retries := batch{{Oid: "retry"}}
+	pending := batch{{Oid: "a"}, {Oid: "b", Priority: 1}, {Oid: "c"}}
+
+	left, right, _ := retries.Concat(pending, 2)
+	if assert.Len(t, left, 2) {
+		assert.Equal(t, "b", left[0].Oid)
+		assert.Equal(t, "retry", left[1].Oid)
+	}
+	if assert.Len(t, right, 2) {
+		assert.Equal(t, "a", right[0].Oid)
+		assert.Equal(t, "c", right[1].Oid)
+	}
+}
+
+func TestDownloadsInPriorityOrder(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lfs-priority")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	cli, err := lfsapi.NewClient(lfshttp.NewContext(nil, nil, map[string]string{
+		"lfs.url":                 "memory://priority",
+		"lfs.concurrenttransfers": "1",
+	}))
+	require.Nil(t, err)
+	f := fs.New(cli.OSEnv(), dir, "", "", 0644)
+
+	remote := NewMemoryRemote("memory://priority")
+	oids := make(map[string]string)
+	for _, name := range []string{"a.dat", "b.dat", "c.dat"} {
+		oid := fmt.Sprintf("%x", sha256.Sum256([]byte(name)))
+		remote.Put(oid, []byte(name))
+		oids[name] = oid
+	}
+
+	q := NewTransferQueue(Download, NewManifest(f, cli, "download", "origin"), "origin")
+	watch := q.Watch()
+
+	q.SetPriority(oids["c.dat"], 2)
+	for _, name := range []string{"a.dat", "b.dat", "c.dat"} {
+		path, err := f.ObjectPath(oids[name])
+		require.Nil(t, err)
+		q.Add(name, path, oids[name], int64(len(name)), false, nil)
+	}
+	// Reprioritize an object which is waiting to be transferred.
+	q.SetPriority(oids["b.dat"], 1)
+	q.Wait()
+	assert.Empty(t, q.Errors())
+
+	var names []string
+	for len(watch) > 0 {
+		names = append(names, (<-watch).Name)
+	}
+	assert.Equal(t, []string{"c.dat", "b.dat", "a.dat"}, names)
+}
+
+func TestSetRoutePathRoutesObjectByPath(t *testing.T) {
+	cli, err := lfsapi.NewClient(lfshttp.NewContext(nil, nil, map[string]string{
+		"lfs.url":                    "https://example.com/repo.git/info/lfs",
+		"lfs.route.assets/video.url": "https://video.example.com/lfs",
+	}))
+	require.Nil(t, err)
+
+	q := NewTransferQueue(Download, NewManifest(nil, cli, "download", "origin"), "origin")
+	q.Upgrade()
+
+	clip := &objectTuple{Name: "clip.dat", Oid: "a"}
+	_, ok := q.routeFor(clip)
+	assert.False(t, ok)
+
+	q.SetRoutePath("a", "assets/video/clip.dat")
+	e, ok := q.routeFor(clip)
+	if assert.True(t, ok) {
+		assert.Equal(t, "https://video.example.com/lfs", e.Url)
+	}
+}
+
+func TestEmptyObjectIsSkippedAndStored(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lfs-empty")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	cli, err := lfsapi.NewClient(lfshttp.NewContext(nil, nil, map[string]string{
+		"lfs.url": "https://example.com/repo.git/info/lfs",
+	}))
+	require.Nil(t, err)
+	f := fs.New(cli.OSEnv(), dir, "", "", 0644)
+
+	oid := fmt.Sprintf("%x", sha256.Sum256(nil))
+	stats := NewStats()
+	q := NewTransferQueue(Download, NewManifest(f, cli, "download", "origin"), "origin", WithStats(stats))
+	watch := q.Watch()
+
+	path, err := f.ObjectPath(oid)
+	require.Nil(t, err)
+	q.Add("empty.dat", path, oid, 0, false, nil)
+	q.Wait()
+
+	assert.Empty(t, q.Errors())
+	if assert.Len(t, watch, 1) {
+		assert.Equal(t, oid, (<-watch).Oid)
+	}
+	if objects := stats.Objects(); assert.Len(t, objects, 1) {
+		assert.Equal(t, StatusSkipped, objects[0].Status)
+	}
+	assert.True(t, f.ObjectExists(oid, 0))
+}
+
+func TestExpiredActionIsRefreshedWithoutUsingARetry(t *testing.T) {
+	q := NewTransferQueue(Download, NewManifest(nil, nil, "", ""), "origin")
+	q.rc.MaxRetries = 1
+	ot := &objectTuple{Name: "a.dat", Oid: "oid", Size: 1}
+	q.transfers["oid"] = &objects{objects: []*objectTuple{ot}}
+
+	_, err := ActionSet{"download": &Action{ExpiresAt: time.Now()}}.Get("download")
+	res := TransferResult{Transfer: &Transfer{Name: "a.dat", Oid: "oid", Size: 1}, Error: err}
+
+	retries := make(chan *objectTuple, 1)
+	q.handleTransferResult(res, retries)
+	if assert.Len(t, retries, 1) {
+		assert.Equal(t, ot, <-retries)
+	}
+	assert.True(t, q.rc.Refreshing("oid"))
+	assert.Equal(t, 0, q.rc.CountFor("oid"))
+
+	// An object may be refreshed only as many times as it may be retried.
+	q.handleTransferResult(res, retries)
+	assert.False(t, q.rc.Refreshing("oid"))
+}
+
+func TestUploadRejectedForLockIsLockConflict(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		bReq := &batchRequest{}
+		err := json.NewDecoder(r.Body).Decode(bReq)
+		r.Body.Close()
+		assert.Nil(t, err)
+
+		objects := make([]*Transfer, 0, len(bReq.Objects))
+		for _, o := range bReq.Objects {
+			objects = append(objects, &Transfer{Oid: o.Oid, Size: o.Size,
+				Error: &ObjectError{Code: 423, Message: "a.psd is locked by Alice"}})
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		err = json.NewEncoder(w).Encode(&BatchResponse{Objects: objects})
+		assert.Nil(t, err)
+	}))
+	defer srv.Close()
+
+	cli, err := lfsapi.NewClient(lfshttp.NewContext(nil, nil, map[string]string{
+		"lfs.url": srv.URL + "/api",
+	}))
+	require.Nil(t, err)
+
+	q := NewTransferQueue(Upload, NewManifest(nil, cli, "upload", "origin"), "origin")
+	q.Add("a.psd", "a.psd", "4d7a214614ab2935c943f9e0ff69d22eadbb8f32b1258daaa5e2ca24d17e2393", 12, false, nil)
+	q.Wait()
+
+	if assert.Len(t, q.Errors(), 1) {
+		assert.True(t, errors.IsLockConflictError(q.Errors()[0]))
+		assert.Contains(t, q.Errors()[0].Error(), "a.psd is locked by Alice")
+	}
+}
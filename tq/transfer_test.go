@@ -2,7 +2,9 @@ package tq
 
 import (
 	"testing"
+	"time"
 
+	"github.com/git-lfs/git-lfs/v3/errors"
 	"github.com/git-lfs/git-lfs/v3/lfsapi"
 	"github.com/git-lfs/git-lfs/v3/lfshttp"
 	"github.com/stretchr/testify/assert"
@@ -136,3 +138,14 @@ func testAdapterRegButBasicOnly(t *testing.T) {
 	lu := m.GetUploadAdapterNames()
 	assert.Equal([]string{BasicAdapterName}, lu)
 }
+
+func TestActionSetGetExpired(t *testing.T) {
+	as := ActionSet{
+		"upload": &Action{Href: "https://example.com", ExpiresAt: time.Now().Add(-time.Minute)},
+	}
+
+	a, err := as.Get("upload")
+	assert.Nil(t, a)
+	assert.True(t, errors.IsRetriableError(err))
+	assert.True(t, IsActionExpiredError(err))
+}
@@ -153,7 +153,7 @@ func (a *tusUploadAdapter) DoTransfer(ctx interface{}, t *Transfer, cb ProgressC
 	io.Copy(ioutil.Discard, res.Body)
 	res.Body.Close()
 
-	return verifyUpload(a.apiClient, a.remote, t)
+	return verifyUpload(a.state, a.apiClient, a.remote, t)
 }
 
 func configureTusAdapter(m *concreteManifest) {
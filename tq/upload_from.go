@@ -0,0 +1,232 @@
+package tq
+
+import (
+	"bufio"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/git-lfs/git-lfs/v3/errors"
+	"github.com/git-lfs/git-lfs/v3/fs"
+	"github.com/git-lfs/git-lfs/v3/tools"
+	"github.com/git-lfs/git-lfs/v3/tr"
+	"github.com/rubyist/tracerx"
+)
+
+// UploadFrom uploads the object "oid" of "size" bytes to the remote, reading
+// its contents from "r", which need not be a file nor seekable, such as the
+// output of another process. Nothing is read from "r" if the server already
+// has the object.
+//
+// If the server asks for the object to be uploaded with the basic adapter, to
+// an action without a fallback and which doesn't need a checksum of the
+// object up front, as storage in compatibility mode does, the object is sent
+// as it is read, in a single request. Such an upload can't be retried, and
+// any failure is returned. Otherwise, "r" is first copied to a temporary file,
+// which is then uploaded as any other object is, retries and all.
+//
+// Contents which don't hash to "oid", or aren't "size" bytes long, are
+// reported as an error. When the object is sent as it is read, the request
+// fails as the end of the contents is read, before it completes, so that the
+// server is never sent the whole of the wrong contents.
+//
+// "cb", if not nil, is called as "r" is read.
+func UploadFrom(m Manifest, remote string, r io.Reader, oid string, size int64, cb tools.CopyCallback) error {
+	if err := fs.ValidateOid(oid); err != nil {
+		return err
+	}
+
+	cm := m.Upgrade()
+	bRes, err := Batch(m, Upload, remote, nil, []*Transfer{&Transfer{Oid: oid, Size: size}})
+	if err != nil {
+		return err
+	}
+
+	var t *Transfer
+	for _, o := range bRes.Objects {
+		if o.Oid == oid {
+			t = o
+		}
+	}
+	if t == nil {
+		return errors.New(tr.Tr.Get("server did not respond with object %s", oid))
+	}
+	if t.Error != nil {
+		return errors.Wrap(t.Error, tr.Tr.Get("unable to upload %s", oid))
+	}
+
+	rel, err := t.Rel("upload")
+	if err != nil {
+		return err
+	}
+	if rel == nil {
+		tracerx.Printf("tq: server already has %s", oid)
+		return nil
+	}
+	t.Name = oid
+	t.Size = size
+
+	if name := bRes.TransferAdapterName; (len(name) == 0 || name == BasicAdapterName) && rel.Fallback == nil {
+		a := &basicUploadAdapter{newAdapterBase(cm.fs, BasicAdapterName, Upload, nil)}
+		a.apiClient = cm.APIClient()
+		a.remote = remote
+		a.state = cm.state
+
+		req, err := a.newHTTPRequest("PUT", rel)
+		if err != nil {
+			return err
+		}
+		if !a.storageCompat(req) {
+			return a.uploadFrom(t, req, r, cb)
+		}
+	}
+
+	return uploadFromTempFile(cm, remote, r, oid, size, cb)
+}
+
+// uploadFrom sends the object of "t" with the PUT request "req", reading it
+// from "r" as it is sent.
+func (a *basicUploadAdapter) uploadFrom(t *Transfer, req *http.Request, r io.Reader, cb tools.CopyCallback) error {
+	tracerx.Printf("tq: streaming upload of %q", t.Oid)
+
+	// Compression is only ever offered, and an object can't be compressed
+	// without knowing whether that makes it any smaller. Storage in
+	// compatibility mode keeps its headers, as compressUpload leaves them.
+	if !a.storageCompat(req) {
+		req.Header.Del("Content-Encoding")
+	}
+
+	br := bufio.NewReader(r)
+	err := a.setContentTypeFrom(req, func() ([]byte, error) {
+		head, err := br.Peek(512)
+		if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+			return nil, errors.Wrap(err, tr.Tr.Get("content type detection error"))
+		}
+		return head, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	vr := newVerifyingReader(br, t.Oid, t.Size)
+	body := &tools.CallbackReader{C: cb, TotalSize: t.Size, Reader: vr}
+
+	if a.chunkedUpload(req, false) {
+		// The length of "r" is only known from what the caller
+		// says it is, so don't promise it to storage which doesn't
+		// need to know it.
+		req.TransferEncoding = []string{"chunked"}
+		req.ContentLength = -1
+	} else {
+		req.ContentLength = t.Size
+		req.Header.Set("Content-Length", strconv.FormatInt(t.Size, 10))
+	}
+	req.Body = ioutil.NopCloser(body)
+
+	req = a.apiClient.LogRequest(req, "lfs.data.upload")
+	res, err := a.doHTTP(t, req)
+	if err != nil {
+		return errors.Wrap(err, tr.Tr.Get("unable to upload %s", t.Oid))
+	}
+	io.Copy(ioutil.Discard, res.Body)
+	res.Body.Close()
+
+	if res.StatusCode > 299 {
+		return errors.Wrapf(nil, tr.Tr.Get("Invalid status for %s %s: %d",
+			req.Method,
+			strings.SplitN(req.URL.String(), "?", 2)[0],
+			res.StatusCode,
+		))
+	}
+
+	// The reader fails the request before it completes if the contents
+	// are wrong, but check again in case the server responded without
+	// reading the whole body.
+	if err := vr.verify(); err != nil {
+		return err
+	}
+
+	return verifyUpload(a.state, a.apiClient, a.remote, t)
+}
+
+// verifyingReader reads the contents of the object "oid" of "size" bytes, and
+// fails as soon as they turn out to be of the wrong size or not to hash to
+// "oid". The read which would complete the wrong contents returns none of
+// them, so that a request whose body it is fails before the server is sent
+// the whole of the wrong contents.
+type verifyingReader struct {
+	hr   *tools.HashingReader
+	oid  string
+	size int64
+	n    int64
+}
+
+func newVerifyingReader(r io.Reader, oid string, size int64) *verifyingReader {
+	return &verifyingReader{hr: tools.NewHashingReader(r), oid: oid, size: size}
+}
+
+func (r *verifyingReader) Read(b []byte) (int, error) {
+	n, err := r.hr.Read(b)
+	r.n += int64(n)
+
+	if r.n >= r.size || err == io.EOF {
+		if verr := r.verify(); verr != nil {
+			return 0, verr
+		}
+	}
+	return n, err
+}
+
+// verify returns an error if the contents read so far are not those of the
+// object.
+func (r *verifyingReader) verify() error {
+	if r.n != r.size {
+		return errors.New(tr.Tr.Get("expected %d bytes of %s, read %d", r.size, r.oid, r.n))
+	}
+	if actual := r.hr.Hash(); actual != r.oid {
+		return errors.New(tr.Tr.Get("expected OID %s, got %s", r.oid, actual))
+	}
+	return nil
+}
+
+// uploadFromTempFile copies "r" to a temporary file, checking that it hashes
+// to "oid", and uploads it with a TransferQueue.
+func uploadFromTempFile(m *concreteManifest, remote string, r io.Reader, oid string, size int64, cb tools.CopyCallback) error {
+	tracerx.Printf("tq: buffering upload of %q", oid)
+
+	var tmp *os.File
+	var err error
+	if m.fs != nil {
+		tmp, err = tools.TempFile(m.fs.TempDir(), oid, m.fs)
+	} else {
+		tmp, err = ioutil.TempFile("", oid)
+	}
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	hr := tools.NewHashingReader(r)
+	n, err := tools.CopyWithCallback(tmp, hr, size, cb)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	if n != size {
+		return errors.New(tr.Tr.Get("expected %d bytes of %s, read %d", size, oid, n))
+	}
+	if actual := hr.Hash(); actual != oid {
+		return errors.New(tr.Tr.Get("expected OID %s, got %s", oid, actual))
+	}
+
+	q := NewTransferQueue(Upload, m, remote)
+	q.Add(oid, tmp.Name(), oid, size, false, nil)
+	q.Wait()
+
+	return errors.Combine(q.Errors())
+}
@@ -0,0 +1,195 @@
+package tq
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/git-lfs/git-lfs/v3/lfsapi"
+	"github.com/git-lfs/git-lfs/v3/lfshttp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// helloOid is the OID of the contents "hello".
+const helloOid = "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"
+
+// uploadFromServer is a server which accepts uploads of objects it doesn't
+// have with the basic adapter.
+type uploadFromServer struct {
+	*httptest.Server
+
+	// actionHeader is sent in each upload action.
+	actionHeader map[string]string
+
+	mu      sync.Mutex
+	objects map[string][]byte
+	headers map[string]http.Header
+	// lengths holds the Content-Length of each upload, or -1 if it was
+	// sent without one.
+	lengths map[string]int64
+}
+
+func newUploadFromServer(t *testing.T) *uploadFromServer {
+	s := &uploadFromServer{
+		objects: make(map[string][]byte),
+		headers: make(map[string]http.Header),
+		lengths: make(map[string]int64),
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		if r.Method == "PUT" {
+			oid := strings.TrimPrefix(r.URL.Path, "/objects/")
+			data, err := ioutil.ReadAll(r.Body)
+			if err != nil {
+				// The client gave up on the upload.
+				w.WriteHeader(400)
+				return
+			}
+			s.objects[oid] = data
+			s.headers[oid] = r.Header
+			s.lengths[oid] = r.ContentLength
+			return
+		}
+
+		bReq := &batchRequest{}
+		err := json.NewDecoder(r.Body).Decode(bReq)
+		r.Body.Close()
+		assert.Nil(t, err)
+		assert.Equal(t, "upload", bReq.Operation)
+
+		objects := make([]*Transfer, 0, len(bReq.Objects))
+		for _, o := range bReq.Objects {
+			obj := &Transfer{Oid: o.Oid, Size: o.Size, Authenticated: true}
+			if _, ok := s.objects[o.Oid]; !ok {
+				obj.Actions = ActionSet{"upload": &Action{Href: s.URL + "/objects/" + o.Oid, Header: s.actionHeader}}
+			}
+			objects = append(objects, obj)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		err = json.NewEncoder(w).Encode(&BatchResponse{Objects: objects})
+		assert.Nil(t, err)
+	}))
+	return s
+}
+
+func (s *uploadFromServer) manifest(t *testing.T, config map[string]string) Manifest {
+	gitConfig := map[string]string{"lfs.url": s.URL + "/api"}
+	for k, v := range config {
+		gitConfig[k] = v
+	}
+
+	c, err := lfsapi.NewClient(lfshttp.NewContext(nil, nil, gitConfig))
+	require.Nil(t, err)
+
+	return NewManifest(nil, c, "upload", "origin")
+}
+
+// unreadable fails the test if it is read from.
+type unreadable struct {
+	t *testing.T
+}
+
+func (r *unreadable) Read(p []byte) (int, error) {
+	r.t.Error("unexpected read")
+	return 0, io.EOF
+}
+
+func TestUploadFromStreamsObject(t *testing.T) {
+	srv := newUploadFromServer(t)
+	defer srv.Close()
+
+	// A pipe can't be rewound, as the output of a process can't.
+	pr, pw := io.Pipe()
+	go func() {
+		pw.Write([]byte("hel"))
+		pw.Write([]byte("lo"))
+		pw.Close()
+	}()
+
+	var progress int64
+	err := UploadFrom(srv.manifest(t, nil), "origin", pr, helloOid, 5, func(total, read int64, current int) error {
+		progress = read
+		return nil
+	})
+	require.Nil(t, err)
+	assert.EqualValues(t, 5, progress)
+
+	assert.Equal(t, "hello", string(srv.objects[helloOid]))
+	assert.Equal(t, "text/plain; charset=utf-8", srv.headers[helloOid].Get("Content-Type"))
+	assert.Empty(t, srv.headers[helloOid].Get("Content-MD5"))
+	assert.EqualValues(t, 5, srv.lengths[helloOid])
+}
+
+func TestUploadFromStreamsChunkedObject(t *testing.T) {
+	srv := newUploadFromServer(t)
+	srv.actionHeader = map[string]string{"Transfer-Encoding": "chunked"}
+	defer srv.Close()
+
+	require.Nil(t, UploadFrom(srv.manifest(t, nil), "origin", strings.NewReader("hello"), helloOid, 5, nil))
+
+	assert.Equal(t, "hello", string(srv.objects[helloOid]))
+	assert.EqualValues(t, -1, srv.lengths[helloOid])
+}
+
+func TestUploadFromBuffersForStorageCompat(t *testing.T) {
+	srv := newUploadFromServer(t)
+	defer srv.Close()
+
+	m := srv.manifest(t, map[string]string{
+		"lfs." + srv.URL + "/objects/.storagecompat": "true",
+	})
+	require.Nil(t, UploadFrom(m, "origin", strings.NewReader("hello"), helloOid, 5, nil))
+
+	assert.Equal(t, "hello", string(srv.objects[helloOid]))
+	assert.Equal(t, "XUFAKrxLKna5cZ2REBfFkg==", srv.headers[helloOid].Get("Content-MD5"))
+}
+
+func TestUploadFromSkipsObjectOnServer(t *testing.T) {
+	srv := newUploadFromServer(t)
+	defer srv.Close()
+	srv.objects[helloOid] = []byte("hello")
+
+	require.Nil(t, UploadFrom(srv.manifest(t, nil), "origin", &unreadable{t}, helloOid, 5, nil))
+}
+
+func TestUploadFromRejectsWrongContents(t *testing.T) {
+	srv := newUploadFromServer(t)
+	defer srv.Close()
+
+	err := UploadFrom(srv.manifest(t, nil), "origin", strings.NewReader("jello"), helloOid, 5, nil)
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "expected OID "+helloOid)
+	}
+	assert.Empty(t, srv.objects)
+
+	// Nor are contents which turn out to be too long, when sent without
+	// a length.
+	srv.actionHeader = map[string]string{"Transfer-Encoding": "chunked"}
+	err = UploadFrom(srv.manifest(t, nil), "origin", strings.NewReader("hello!"), helloOid, 5, nil)
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "expected 5 bytes of "+helloOid)
+	}
+	assert.Empty(t, srv.objects)
+
+	// Contents which are buffered first are not sent at all.
+	buffered := newUploadFromServer(t)
+	defer buffered.Close()
+
+	m := buffered.manifest(t, map[string]string{
+		"lfs." + buffered.URL + "/objects/.storagecompat": "true",
+	})
+	err = UploadFrom(m, "origin", strings.NewReader("jello"), helloOid, 5, nil)
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "expected OID "+helloOid)
+	}
+	assert.Empty(t, buffered.objects)
+}
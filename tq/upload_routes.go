@@ -0,0 +1,48 @@
+package tq
+
+import (
+	"sync"
+)
+
+// routeCache maps the host of an action's href to whether its fallback should
+// be tried first. A nil routeCache tries each action before its fallback.
+type routeCache struct {
+	mu       sync.Mutex
+	fallback map[string]bool
+}
+
+func newRouteCache() *routeCache {
+	return &routeCache{fallback: make(map[string]bool)}
+}
+
+// order returns the actions to try in turn for "rel": "rel" itself and then its
+// fallback, if it has one, unless the fallback worked in its place before.
+func (c *routeCache) order(rel *Action) []*Action {
+	if rel.Fallback == nil {
+		return []*Action{rel}
+	}
+	if c == nil {
+		return []*Action{rel, rel.Fallback}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.fallback[rel.host()] {
+		return []*Action{rel.Fallback, rel}
+	}
+	return []*Action{rel, rel.Fallback}
+}
+
+// record notes that "used", which is either "rel" or its fallback, worked in
+// place of "rel".
+func (c *routeCache) record(rel, used *Action) {
+	if c == nil || rel.Fallback == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.fallback[rel.host()] = used != rel
+}
@@ -0,0 +1,64 @@
+package tq
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRouteCacheOrder(t *testing.T) {
+	c := newRouteCache()
+
+	direct := &Action{Href: "https://s3.example.com/oid"}
+	assert.Equal(t, []*Action{direct}, c.order(direct))
+
+	fallback := &Action{Href: "https://lfs.example.com/objects/oid"}
+	direct.Fallback = fallback
+	assert.Equal(t, []*Action{direct, fallback}, c.order(direct))
+
+	c.record(direct, fallback)
+	assert.Equal(t, []*Action{fallback, direct}, c.order(direct))
+
+	other := &Action{
+		Href:     "https://s3.example.com/other",
+		Fallback: &Action{Href: "https://lfs.example.com/objects/other"},
+	}
+	assert.Equal(t, []*Action{other.Fallback, other}, c.order(other))
+
+	c.record(direct, direct)
+	assert.Equal(t, []*Action{direct, fallback}, c.order(direct))
+}
+
+func TestRouteCacheNil(t *testing.T) {
+	var c *routeCache
+
+	direct := &Action{
+		Href:     "https://s3.example.com/oid",
+		Fallback: &Action{Href: "https://lfs.example.com/objects/oid"},
+	}
+	c.record(direct, direct.Fallback)
+	assert.Equal(t, []*Action{direct, direct.Fallback}, c.order(direct))
+}
+
+func TestNewTransferCopiesFallback(t *testing.T) {
+	createdAt := time.Now()
+	tr := newTransfer(&Transfer{
+		Oid:  "oid",
+		Size: 1,
+		Actions: ActionSet{
+			"upload": &Action{
+				Href:      "https://s3.example.com/oid",
+				Fallback:  &Action{Href: "https://lfs.example.com/objects/oid"},
+				createdAt: createdAt,
+			},
+		},
+	}, "a.dat", "path/to/a.dat")
+
+	rel, err := tr.Rel("upload")
+	require.Nil(t, err)
+	require.NotNil(t, rel.Fallback)
+	assert.Equal(t, "https://lfs.example.com/objects/oid", rel.Fallback.Href)
+	assert.Equal(t, createdAt, rel.Fallback.createdAt)
+}
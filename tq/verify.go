@@ -2,9 +2,13 @@ package tq
 
 import (
 	"net/http"
+	"time"
 
+	"github.com/git-lfs/git-lfs/v3/errors"
 	"github.com/git-lfs/git-lfs/v3/lfsapi"
+	"github.com/git-lfs/git-lfs/v3/lfshttp"
 	"github.com/git-lfs/git-lfs/v3/tools"
+	"github.com/git-lfs/git-lfs/v3/tr"
 	"github.com/rubyist/tracerx"
 )
 
@@ -13,12 +17,19 @@ const (
 	defaultMaxVerifyAttempts = 3
 )
 
-func verifyUpload(c *lfsapi.Client, remote string, t *Transfer) error {
+// verifyRetryDelay is the time to wait before the second attempt to verify an
+// upload, which doubles after each further failed attempt.
+var verifyRetryDelay = 250 * time.Millisecond
+
+func verifyUpload(s *serverState, c *lfsapi.Client, remote string, t *Transfer) error {
 	action, err := t.Actions.Get("verify")
 	if err != nil {
 		return err
 	}
 	if action == nil {
+		if serverCapabilities(s, c, Upload.String(), remote).has(capVerifyRequired) {
+			return errors.New(tr.Tr.Get("server requires uploads to be verified, but gave no verify action for %s", t.Oid))
+		}
 		return nil
 	}
 
@@ -45,7 +56,13 @@ func verifyUpload(c *lfsapi.Client, remote string, t *Transfer) error {
 	mv = tools.MaxInt(defaultMaxVerifyAttempts, mv)
 	req = c.LogRequest(req, "lfs.verify")
 
+	delay := verifyRetryDelay
 	for i := 1; i <= mv; i++ {
+		if i > 1 {
+			time.Sleep(delay)
+			delay *= 2
+		}
+
 		tracerx.Printf("tq: verify %s attempt #%d (max: %d)", t.Oid[:7], i, mv)
 
 		var res *http.Response
@@ -55,12 +72,27 @@ func verifyUpload(c *lfsapi.Client, remote string, t *Transfer) error {
 			res, err = c.DoWithAuth(remote, c.Endpoints.AccessFor(action.Href), req)
 		}
 
-		if err != nil {
-			tracerx.Printf("tq: verify err: %+v", err.Error())
-		} else {
-			err = res.Body.Close()
-			break
+		if err == nil {
+			return res.Body.Close()
 		}
+
+		tracerx.Printf("tq: verify err: %+v", err.Error())
+		if isVerifyMismatch(err) {
+			// The server does not have the object, or has one of
+			// a different size, so verifying again will not help.
+			// Instead, retry the transfer to upload it again.
+			return errors.NewRetriableError(errors.Wrap(err, tr.Tr.Get("server does not have a matching object for %s", t.Oid)))
+		}
+	}
+	return errors.Wrap(err, tr.Tr.Get("unable to verify upload of %s", t.Oid))
+}
+
+// isVerifyMismatch returns whether the given error from a verify request
+// indicates that the server has no object with the uploaded OID and size.
+func isVerifyMismatch(err error) bool {
+	if errors.IsUnprocessableEntityError(err) {
+		return true
 	}
-	return err
+	res, ok := lfshttp.IsHTTP(err)
+	return ok && res.StatusCode == http.StatusNotFound
 }
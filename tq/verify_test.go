@@ -6,7 +6,9 @@ import (
 	"net/http/httptest"
 	"sync/atomic"
 	"testing"
+	"time"
 
+	"github.com/git-lfs/git-lfs/v3/errors"
 	"github.com/git-lfs/git-lfs/v3/lfsapi"
 	"github.com/git-lfs/git-lfs/v3/lfshttp"
 	"github.com/stretchr/testify/assert"
@@ -20,7 +22,7 @@ func TestVerifyWithoutAction(t *testing.T) {
 		Size: 123,
 	}
 
-	assert.Nil(t, verifyUpload(c, "origin", tr))
+	assert.Nil(t, verifyUpload(nil, c, "origin", tr))
 }
 
 func TestVerifySuccess(t *testing.T) {
@@ -67,6 +69,58 @@ func TestVerifySuccess(t *testing.T) {
 		},
 	}
 
-	assert.Nil(t, verifyUpload(c, "origin", tr))
+	assert.Nil(t, verifyUpload(nil, c, "origin", tr))
 	assert.EqualValues(t, 1, called)
 }
+
+func TestVerifyMismatchIsRetriable(t *testing.T) {
+	var called uint32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddUint32(&called, 1)
+		w.WriteHeader(http.StatusUnprocessableEntity)
+	}))
+	defer srv.Close()
+
+	c, err := lfsapi.NewClient(lfshttp.NewContext(nil, nil, map[string]string{
+		"lfs." + srv.URL + "/verify.access": "None",
+	}))
+	require.Nil(t, err)
+	tr := &Transfer{
+		Oid:     "abcd1234",
+		Size:    123,
+		Actions: map[string]*Action{"verify": &Action{Href: srv.URL + "/verify"}},
+	}
+
+	err = verifyUpload(nil, c, "origin", tr)
+	require.NotNil(t, err)
+	assert.True(t, errors.IsRetriableError(err))
+	assert.EqualValues(t, 1, called)
+}
+
+func TestVerifyFailureIsRetriedWithBackoff(t *testing.T) {
+	defer func(d time.Duration) { verifyRetryDelay = d }(verifyRetryDelay)
+	verifyRetryDelay = time.Millisecond
+
+	var called uint32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddUint32(&called, 1)
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer srv.Close()
+
+	c, err := lfsapi.NewClient(lfshttp.NewContext(nil, nil, map[string]string{
+		"lfs." + srv.URL + "/verify.access": "None",
+	}))
+	require.Nil(t, err)
+	tr := &Transfer{
+		Oid:     "abcd1234",
+		Size:    123,
+		Actions: map[string]*Action{"verify": &Action{Href: srv.URL + "/verify"}},
+	}
+
+	err = verifyUpload(nil, c, "origin", tr)
+	require.NotNil(t, err)
+	assert.False(t, errors.IsRetriableError(err))
+	assert.Contains(t, err.Error(), "unable to verify upload of abcd1234")
+	assert.EqualValues(t, defaultMaxVerifyAttempts, called)
+}